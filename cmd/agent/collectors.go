@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/collectors/cgroup"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/collectors/goruntime"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/collectors/logtail"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/collectors/plugin"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/collectors/probe"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/collectors/procfs"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"go.uber.org/zap"
+)
+
+// collectorInfo describes whether the collector is enabled. Err is set when the configured collector failed to start,
+// unavailable on the host collectors have only the reason.
+type collectorInfo struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	Reason  string `json:"reason,omitempty"`
+	Err     error  `json:"-"`
+}
+
+// builtinCollectors are metrics collected by the agent itself
+func builtinCollectors(cfg *config.Config) []collectorInfo {
+	memstats := collectorInfo{Name: "memstats", Enabled: true}
+	if cfg.RuntimeCollector == config.RuntimeCollectorMetrics {
+		memstats = collectorInfo{Name: "memstats", Reason: "replaced by runtime_metrics"}
+	}
+
+	return []collectorInfo{
+		memstats,
+		{Name: "custom", Enabled: true},
+		{Name: "virtual_memory", Enabled: true},
+		{Name: "cpu", Enabled: true},
+	}
+}
+
+// newCollectors creates collectors available on the host, unavailable ones are skipped.
+// Collectors listed in disabled_collectors are created, so the remote config is able to enable them,
+// but they are reported as disabled.
+func newCollectors(ctx context.Context, cfg *config.Config, lg *logging.ZapLogger) ([]agent.Collector, []collectorInfo) {
+	var (
+		collectors []agent.Collector
+		infos      = builtinCollectors(cfg)
+	)
+
+	enable := func(c agent.Collector) {
+		collectors = append(collectors, c)
+		infos = append(infos, collectorInfo{Name: c.Name(), Enabled: true})
+	}
+	disable := func(name, reason string) {
+		infos = append(infos, collectorInfo{Name: name, Reason: reason})
+	}
+	fail := func(name string, err error) {
+		infos = append(infos, collectorInfo{Name: name, Reason: err.Error(), Err: err})
+	}
+
+	if cfg.CgroupRoot == "" {
+		disable("cgroup", "cgroup_root is empty")
+	} else {
+		c, err := cgroup.NewCollector(cfg.CgroupRoot, "/proc/self/cgroup")
+		switch {
+		case errors.Is(err, cgroup.ErrNotFound):
+			disable("cgroup", fmt.Sprintf("cgroup filesystem not found at %s", cfg.CgroupRoot))
+		case err != nil:
+			fail("cgroup", err)
+		default:
+			enable(c)
+		}
+	}
+
+	if cfg.ProcRoot == "" {
+		disable("procfs", "proc_root is empty")
+	} else {
+		c, err := procfs.NewCollector(cfg.ProcRoot)
+		switch {
+		case errors.Is(err, procfs.ErrNotFound):
+			disable("procfs", fmt.Sprintf("proc filesystem not found at %s", cfg.ProcRoot))
+		case err != nil:
+			fail("procfs", err)
+		default:
+			enable(c)
+		}
+	}
+
+	if len(cfg.LogTail) == 0 {
+		disable("logtail", "no log files configured")
+	} else if c, err := logtail.NewCollector(cfg.LogTail, cfg.LogTailCheckpoint, lg); err != nil {
+		fail("logtail", err)
+	} else {
+		enable(c)
+	}
+
+	if len(cfg.Probes) == 0 {
+		disable("probe", "no probes configured")
+	} else if c, err := probe.NewCollector(cfg.Probes, lg); err != nil {
+		fail("probe", err)
+	} else {
+		enable(c)
+	}
+
+	for _, p := range cfg.Plugins {
+		c, err := plugin.NewCollector(ctx, plugin.DefaultEngine, p, lg)
+		if err != nil {
+			fail("plugin:"+p.Name, err)
+			continue
+		}
+
+		enable(c)
+	}
+
+	if cfg.RuntimeCollector == config.RuntimeCollectorMetrics {
+		enable(goruntime.NewCollector())
+	} else {
+		disable("goruntime", "runtime_collector is not runtime_metrics")
+	}
+
+	for i, c := range infos {
+		if c.Enabled && slices.Contains(cfg.DisabledCollectors, c.Name) {
+			infos[i] = collectorInfo{Name: c.Name, Reason: "disabled by config"}
+		}
+	}
+
+	return collectors, infos
+}
+
+func logCollectors(ctx context.Context, lg *logging.ZapLogger, infos []collectorInfo) {
+	for _, c := range infos {
+		switch {
+		case c.Err != nil:
+			lg.ErrorCtx(ctx, "Failed to create collector", zap.String("collector", c.Name), zap.Error(c.Err))
+		case c.Enabled:
+			lg.InfoCtx(ctx, "collector enabled", zap.String("collector", c.Name))
+		default:
+			lg.InfoCtx(ctx, "collector disabled", zap.String("collector", c.Name), zap.String("reason", c.Reason))
+		}
+	}
+}
+
+// startCollectors runs background work of the collectors until ctx is done
+func startCollectors(ctx context.Context, cfg *config.Config, collectors []agent.Collector) {
+	for _, collector := range collectors {
+		if c, ok := collector.(*probe.Collector); ok {
+			interval := cfg.ProbeInterval
+			if interval <= 0 {
+				interval = cfg.PollInterval
+			}
+
+			go c.Start(ctx, interval)
+		}
+	}
+}
+
+func closeCollectors(ctx context.Context, lg *logging.ZapLogger, collectors []agent.Collector) {
+	for _, collector := range collectors {
+		if c, ok := collector.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				lg.ErrorCtx(ctx, "Failed to close collector", zap.String("collector", collector.Name()), zap.Error(err))
+			}
+		}
+	}
+}
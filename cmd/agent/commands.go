@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/collectors/probe"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/storage"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+)
+
+// Output formats of the commands
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+// statusTimeout limits the request to the control endpoint of the running agent
+const statusTimeout = 5 * time.Second
+
+// once polls all collectors once and prints the metrics without sending them
+func once(ctx context.Context, w io.Writer, cfg *config.Config, lg *logging.ZapLogger, format string) error {
+	// offsets of the tailed logs belong to the running agent
+	onceCfg := *cfg
+	onceCfg.LogTailCheckpoint = ""
+
+	relabeler, err := agent.NewRelabeler(onceCfg.Relabel, onceCfg.RelabelDryRun, lg)
+	if err != nil {
+		return err
+	}
+
+	collectors, _ := newCollectors(ctx, &onceCfg, lg)
+	defer closeCollectors(ctx, lg, collectors)
+
+	for _, collector := range collectors {
+		if c, ok := collector.(*probe.Collector); ok {
+			c.Run(ctx)
+		}
+	}
+
+	rep := agent.NewMetricsRepository(storage.NewMemoryStorage(lg))
+	metrics, pollErr := agent.NewAgent(lg, onceCfg, rep, nil, relabeler, collectors...).Once(ctx)
+	if pollErr != nil {
+		pollErr = fmt.Errorf("poll metrics error %w", pollErr)
+	}
+
+	slices.SortFunc(metrics, func(a, b models.Metric) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	switch format {
+	case formatJSON:
+		type record struct {
+			Type  string `json:"type"`
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		}
+
+		records := make([]record, 0, len(metrics))
+		for _, m := range metrics {
+			records = append(records, record{Type: m.Type, Name: m.Name, Value: metricValue(m)})
+		}
+
+		return errors.Join(writeJSON(w, records), pollErr)
+	case formatTable:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tTYPE\tVALUE")
+		for _, m := range metrics {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", m.Name, m.Type, metricValue(m))
+		}
+
+		return errors.Join(tw.Flush(), pollErr)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// listCollectors prints collectors and whether they are enabled on the host
+func listCollectors(ctx context.Context, w io.Writer, cfg *config.Config, lg *logging.ZapLogger, format string) error {
+	collectors, infos := newCollectors(ctx, cfg, lg)
+	closeCollectors(ctx, lg, collectors)
+
+	switch format {
+	case formatJSON:
+		return writeJSON(w, infos)
+	case formatTable:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tENABLED\tREASON")
+		for _, c := range infos {
+			fmt.Fprintf(tw, "%s\t%t\t%s\n", c.Name, c.Enabled, c.Reason)
+		}
+
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// checkConfig prints the merged flags, env and file config with secrets redacted
+// and validates the parts checked when the agent starts
+func checkConfig(ctx context.Context, w io.Writer, cfg *config.Config, lg *logging.ZapLogger) error {
+	if err := writeJSON(w, cfg.Redacted()); err != nil {
+		return err
+	}
+
+	var errs []error
+	if _, err := agent.NewRelabeler(cfg.Relabel, cfg.RelabelDryRun, lg); err != nil {
+		errs = append(errs, err)
+	}
+
+	collectors, infos := newCollectors(ctx, cfg, lg)
+	closeCollectors(ctx, lg, collectors)
+
+	for _, c := range infos {
+		if c.Err != nil {
+			errs = append(errs, fmt.Errorf("collector %s: %w", c.Name, c.Err))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("config is invalid:\n%w", err)
+	}
+
+	_, err := fmt.Fprintln(w, "config is valid")
+	return err
+}
+
+// status prints the state of the running agent served by its control endpoint
+func status(ctx context.Context, w io.Writer, cfg *config.Config, format string) error {
+	if cfg.ControlAddress == "" {
+		return errors.New("control address is empty")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, statusTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+cfg.ControlAddress+"/status", http.NoBody)
+	if err != nil {
+		return fmt.Errorf("build status request error %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("agent is not running or control endpoint is not reachable at %s: %w", cfg.ControlAddress, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status response %s", resp.Status)
+	}
+
+	var st agent.Status
+	if err := json.NewDecoder(resp.Body).Decode(&st); err != nil {
+		return fmt.Errorf("decode status error %w", err)
+	}
+
+	switch format {
+	case formatJSON:
+		return writeJSON(w, st)
+	case formatTable:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintf(tw, "started at\t%s\n", formatTime(st.StartedAt))
+		fmt.Fprintf(tw, "last poll at\t%s\n", formatTime(st.LastPollAt))
+		fmt.Fprintf(tw, "last report at\t%s\n", formatTime(st.LastReportAt))
+		fmt.Fprintf(tw, "errors\t%d\n", st.Errors)
+		fmt.Fprintf(tw, "last error\t%s\n", st.LastError)
+		fmt.Fprintf(tw, "last error at\t%s\n", formatTime(st.LastErrorAt))
+		fmt.Fprintf(tw, "pending reports\t%d\n", st.PendingReports)
+		fmt.Fprintf(tw, "pending metrics\t%d\n", st.PendingMetrics)
+
+		if len(st.Collectors) > 0 {
+			fmt.Fprintln(tw, "\nCOLLECTOR\tHEALTHY\tFAILURES\tLAST SUCCESS\tLAST ERROR")
+			for _, c := range st.Collectors {
+				fmt.Fprintf(tw, "%s\t%t\t%d\t%s\t%s\n", c.Name, c.Healthy, c.ConsecutiveFailures, formatTime(c.LastSuccessAt), c.LastError)
+			}
+		}
+
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+func metricValue(m models.Metric) string {
+	if m.Type == models.CounterType {
+		return strconv.FormatInt(m.Delta, 10)
+	}
+
+	return strconv.FormatFloat(m.Value, 'f', -1, 64)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(v)
+}
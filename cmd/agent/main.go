@@ -2,16 +2,29 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 
 	"github.com/vysogota0399/mem_stats_monitoring/internal/agent"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/clients"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/clients/file"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/clients/grpc"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/clients/nats"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/clients/remotewrite"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/failover"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/remoteconfig"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/storage"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/capabilities"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/crypto"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/systemd"
 	"go.uber.org/zap"
 )
 
@@ -21,6 +34,15 @@ var (
 	BuildCommit  string = "N/A"
 )
 
+// Commands, the agent is started when the command is omitted
+const (
+	commandRun         = "run"
+	commandOnce        = "once"
+	commandCollectors  = "collectors"
+	commandCheckConfig = "check-config"
+	commandStatus      = "status"
+)
+
 func main() {
 	ctx, stop := signal.NotifyContext(
 		context.Background(),
@@ -29,6 +51,11 @@ func main() {
 	)
 	defer stop()
 
+	command, args := parseCommand(os.Args[1:])
+	os.Args = append(os.Args[:1], args...)
+
+	format := flag.String("format", formatTable, "output format of the once, collectors and status commands: table or json")
+
 	cfg, err := config.NewConfig(config.NewFileConfig())
 	if err != nil {
 		log.Fatal(err)
@@ -39,23 +66,137 @@ func main() {
 		log.Fatal(err)
 	}
 
+	switch command {
+	case commandRun:
+		err = run(ctx, &cfg, lg)
+	case commandOnce:
+		err = once(ctx, os.Stdout, &cfg, lg, *format)
+	case commandCollectors:
+		err = listCollectors(ctx, os.Stdout, &cfg, lg, *format)
+	case commandCheckConfig:
+		err = checkConfig(ctx, os.Stdout, &cfg, lg)
+	case commandStatus:
+		err = status(ctx, os.Stdout, &cfg, *format)
+	default:
+		err = fmt.Errorf("unknown command %q, expected one of: %s, %s, %s, %s, %s",
+			command, commandRun, commandOnce, commandCollectors, commandCheckConfig, commandStatus)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parseCommand takes the command from the first argument, flags may follow it
+func parseCommand(args []string) (string, []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return commandRun, args
+	}
+
+	return args[0], args[1:]
+}
+
+// run starts the agent and blocks until ctx is done
+func run(ctx context.Context, cfg *config.Config, lg *logging.ZapLogger) error {
 	info(lg)
 
 	rep := agent.NewMetricsRepository(storage.NewMemoryStorage(lg))
 
-	adapter, err := NewAdapter(ctx, &cfg, lg, rep)
+	adapter, err := NewAdapter(ctx, cfg, lg)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+
+	if err := negotiate(ctx, lg, adapter); err != nil {
+		return err
+	}
+
+	relabeler, err := agent.NewRelabeler(cfg.Relabel, cfg.RelabelDryRun, lg)
+	if err != nil {
+		return err
 	}
 
+	collectors, infos := newCollectors(ctx, cfg, lg)
+	logCollectors(ctx, lg, infos)
+	startCollectors(ctx, cfg, collectors)
+
 	agent := agent.NewAgent(
 		lg,
-		cfg,
+		*cfg,
 		rep,
 		adapter,
+		relabeler,
+		collectors...,
 	)
 
+	if n := systemd.NewNotifier(lg); n.Enabled() {
+		agent.WithNotifier(n)
+	}
+
+	startRemoteConfig(ctx, cfg, lg, adapter, agent)
 	agent.Start(ctx)
+
+	closeCollectors(ctx, lg, collectors)
+
+	if c, ok := adapter.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			lg.ErrorCtx(ctx, "Failed to close adapter", zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// negotiator adapts reporting to the capabilities of the server
+type negotiator interface {
+	Negotiate(ctx context.Context) error
+}
+
+// negotiate fetches capabilities of the server on startup. The agent doesn't start when the server is
+// incompatible, unreachable server is not an error: legacy protocol is used and the negotiation is retried
+// by the reporter before the reports, it also renegotiates when the agent fails over to another server.
+func negotiate(ctx context.Context, lg *logging.ZapLogger, adapter agent.Adapter) error {
+	n, ok := adapter.(negotiator)
+	if !ok {
+		return nil
+	}
+
+	err := n.Negotiate(ctx)
+	if errors.Is(err, capabilities.ErrIncompatible) {
+		return err
+	}
+
+	if err != nil {
+		lg.WarnCtx(ctx, "failed to negotiate capabilities, legacy protocol is used", zap.Error(err))
+	}
+
+	return nil
+}
+
+// startRemoteConfig syncs the agent config with the profile assigned on the server when it is enabled
+// and the adapter is able to fetch it
+func startRemoteConfig(ctx context.Context, cfg *config.Config, lg *logging.ZapLogger, adapter agent.Adapter, a *agent.Agent) {
+	if cfg.RemoteConfigInterval <= 0 {
+		return
+	}
+
+	fetcher, ok := adapter.(remoteconfig.Fetcher)
+	if !ok {
+		lg.WarnCtx(ctx, "remote config is not supported by the output", zap.String("output", cfg.Output))
+		return
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		lg.WarnCtx(ctx, "failed to get hostname", zap.Error(err))
+	}
+
+	local := *cfg
+	if local.AgentID == "" {
+		local.AgentID = host
+	}
+
+	go remoteconfig.NewManager(lg, local, host, fetcher, a).Start(ctx, cfg.RemoteConfigInterval)
 }
 
 func info(lg *logging.ZapLogger) {
@@ -66,9 +207,45 @@ func info(lg *logging.ZapLogger) {
 	)
 }
 
-func NewAdapter(ctx context.Context, cfg *config.Config, lg *logging.ZapLogger, rep *agent.MetricsRepository) (agent.Adapter, error) {
-	if cfg.GRPCPort != "" {
-		rep, err := grpc.NewReporter(ctx, cfg, rep, lg)
+func NewAdapter(ctx context.Context, cfg *config.Config, lg *logging.ZapLogger) (agent.Adapter, error) {
+	switch cfg.Output {
+	case config.OutputStdout:
+		return file.NewStdoutReporter(lg), nil
+	case config.OutputFile:
+		rep, err := file.NewReporter(cfg, lg)
+		if err != nil {
+			lg.ErrorCtx(ctx, "Failed to create file reporter", zap.Error(err))
+			return nil, err
+		}
+		return rep, nil
+	case config.OutputRemoteWrite:
+		client, err := newHTTPClient(cfg)
+		if err != nil {
+			lg.ErrorCtx(ctx, "Failed to create http client", zap.Error(err))
+			return nil, err
+		}
+
+		rep, err := remotewrite.NewReporter(cfg, lg, client)
+		if err != nil {
+			lg.ErrorCtx(ctx, "Failed to create remote write reporter", zap.Error(err))
+			return nil, err
+		}
+		return rep, nil
+	case config.OutputNATS:
+		rep, err := nats.NewReporter(ctx, cfg, lg)
+		if err != nil {
+			lg.ErrorCtx(ctx, "Failed to create nats reporter", zap.Error(err))
+			return nil, err
+		}
+		return rep, nil
+	case "":
+		// reports are sent to the server
+	default:
+		return nil, fmt.Errorf("unknown output %s", cfg.Output)
+	}
+
+	if cfg.GRPCPort != "" || len(cfg.GRPCAddresses) > 0 {
+		rep, err := grpc.NewReporter(ctx, cfg, lg)
 		if err != nil {
 			lg.ErrorCtx(ctx, "Failed to create grpc reporter", zap.Error(err))
 			return nil, err
@@ -76,5 +253,27 @@ func NewAdapter(ctx context.Context, cfg *config.Config, lg *logging.ZapLogger,
 		return rep, nil
 	}
 
-	return clients.NewCompReporter(cfg.ServerURL, lg, cfg, clients.NewDefaulut(), clients.NewIpSetter(lg), rep), nil
+	client, err := newHTTPClient(cfg)
+	if err != nil {
+		lg.ErrorCtx(ctx, "Failed to create http client", zap.Error(err))
+		return nil, err
+	}
+
+	servers := failover.NewPool(cfg.ServerURLs, cfg.FailoverThreshold, lg)
+	go servers.Start(ctx, clients.PingProber(client), cfg.FailbackInterval)
+
+	return clients.NewCompReporter(cfg.ServerURL, lg, cfg, client, clients.NewIpSetter(lg)).WithServers(servers), nil
+}
+
+func newHTTPClient(cfg *config.Config) (*clients.Default, error) {
+	if !cfg.IsTLSEnabled() {
+		return clients.NewDefaulut(), nil
+	}
+
+	tlsCfg, err := crypto.NewClientTLSConfig(cfg.TLSCert, cfg.TLSKey, cfg.TLSCA)
+	if err != nil {
+		return nil, err
+	}
+
+	return clients.NewTLSClient(tlsCfg), nil
 }
@@ -2,15 +2,18 @@ package main
 
 import (
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/alerting"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/grpc"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/handlers"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/nats"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/repositories"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/service"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/storages"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/storages/dump"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/crypto"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/systemd"
 	"github.com/vysogota0399/mem_stats_monitoring/pkg/gen/services/metrics"
 	"go.uber.org/fx"
 )
@@ -30,6 +33,8 @@ func CreateApp() fx.Option {
 		fx.Provide(
 			config.NewConfig,
 			logging.NewZapLogger,
+			systemd.NewNotifier,
+			systemd.NewSockets,
 
 			server.NewHTTPServer,
 			grpc.NewServer,
@@ -38,6 +43,11 @@ func CreateApp() fx.Option {
 			grpc.NewShowHandler,
 			grpc.NewUpdateBatchHandler,
 			grpc.NewUpdateHandler,
+			grpc.NewAgentConfigHandler,
+			grpc.NewCapabilitiesHandler,
+			grpc.NewHistoryHandler,
+			nats.NewConsumer,
+			service.NewCapabilities,
 
 			fx.Annotate(crypto.NewDecryptor, fx.As(new(server.Decrypter))),
 			fx.Annotate(config.NewFileConfig, fx.As(new(config.FileConfigurer))),
@@ -58,6 +68,7 @@ func CreateApp() fx.Option {
 				fx.As(new(service.CntrRep)),
 				fx.As(new(grpc.IShowMetricCounterRepository)),
 				fx.As(new(grpc.IMetricsCounterRepository)),
+				fx.As(new(alerting.CounterRepository)),
 			),
 			fx.Annotate(repositories.NewGaugeRepository,
 				fx.As(new(handlers.RootGaugeRepository)),
@@ -67,6 +78,24 @@ func CreateApp() fx.Option {
 				fx.As(new(service.GGRep)),
 				fx.As(new(grpc.IShowMetricGaugeRepository)),
 				fx.As(new(grpc.IMetricsGaugeRepository)),
+				fx.As(new(alerting.GaugeRepository)),
+			),
+			fx.Annotate(storages.NewHistory, fx.As(fx.Self()), fx.As(new(service.HistorySource)), fx.As(new(alerting.HistorySource))),
+			storages.NewRetention,
+			storages.NewCompactor,
+			fx.Annotate(service.NewHistoryService,
+				fx.As(new(handlers.IHistoryService)),
+				fx.As(new(grpc.IHistoryService)),
+			),
+			fx.Annotate(alerting.NewRules, fx.As(fx.Self()), fx.As(new(handlers.IAlertRulesService))),
+			fx.Annotate(alerting.NewAlerts, fx.As(fx.Self()), fx.As(new(handlers.IAlertsService))),
+			fx.Annotate(alerting.NewDispatcher, fx.As(new(alerting.Notifier))),
+			alerting.NewEvaluator,
+			fx.Annotate(service.NewIdempotencyKeys, fx.As(new(service.IdempotencyStore))),
+			fx.Annotate(service.NewAgentProfiles,
+				fx.As(new(handlers.IAgentConfigService)),
+				fx.As(new(handlers.IAgentProfilesService)),
+				fx.As(new(grpc.IAgentConfigService)),
 			),
 			fx.Annotate(service.NewUpdateMetricService,
 				fx.As(new(handlers.IUpdateMetricService)),
@@ -76,6 +105,7 @@ func CreateApp() fx.Option {
 			fx.Annotate(service.NewUpdateMetricsService,
 				fx.As(new(handlers.IUpdateMetricsService)),
 				fx.As(new(grpc.IUpdateMetricsService)),
+				fx.As(new(nats.IUpdateMetricsService)),
 			),
 
 			AsHandlers(handlers.NewPingHandler),
@@ -85,16 +115,38 @@ func CreateApp() fx.Option {
 			AsHandlers(handlers.NewUpdateMetricHandler),
 			AsHandlers(handlers.NewUpdateRestMetricHandler),
 			AsHandlers(handlers.NewUpdatesRestMetricsHandler),
+			AsHandlers(handlers.NewAgentConfigHandler),
+			AsHandlers(handlers.NewListAgentProfilesHandler),
+			AsHandlers(handlers.NewPutAgentProfileHandler),
+			AsHandlers(handlers.NewDeleteAgentProfileHandler),
+			AsHandlers(handlers.NewCapabilitiesHandler),
+			AsHandlers(handlers.NewHistoryHandler),
+			AsHandlers(handlers.NewListAlertRulesHandler),
+			AsHandlers(handlers.NewPutAlertRuleHandler),
+			AsHandlers(handlers.NewDeleteAlertRuleHandler),
+			AsHandlers(handlers.NewListAlertsHandler),
+			AsHandlers(handlers.NewAlertsPageHandler),
 
 			fx.Annotate(grpc.NewHandler, fx.As(new(metrics.MetricsServiceServer))),
 		),
 		fx.Invoke(startHTTPServer),
 		fx.Invoke(startGRPCServer),
+		fx.Invoke(startNATSConsumer),
+		fx.Invoke(startCompactor),
+		fx.Invoke(startAlertEvaluator),
+		fx.Invoke(notifySystemd),
 	)
 }
 
-func startHTTPServer(*server.HTTPServer) {}
-func startGRPCServer(*grpc.Server)       {}
+func startHTTPServer(*server.HTTPServer)      {}
+func startGRPCServer(*grpc.Server)            {}
+func startNATSConsumer(*nats.Consumer)        {}
+func startCompactor(*storages.Compactor)      {}
+func startAlertEvaluator(*alerting.Evaluator) {}
+
+func notifySystemd(lc fx.Lifecycle, cfg *config.Config, n *systemd.Notifier, lg *logging.ZapLogger, h *server.HTTPServer, g *grpc.Server) {
+	server.NotifySystemd(lc, cfg, n, lg, h, g)
+}
 
 func AsHandlers(f any, ants ...fx.Annotation) any {
 	ants = append(ants, fx.ResultTags(`group:"handlers"`))
@@ -2,7 +2,7 @@ package agent
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"net/http"
 	"sync"
 	"time"
@@ -17,29 +17,48 @@ import (
 
 // Adapter defines the interface for making integration with server
 type Adapter interface {
-	UpdateMetric(ctx context.Context, mType, mName, value string) error
+	UpdateMetric(ctx context.Context, m models.Metric) error
 	UpdateMetrics(ctx context.Context, data []*models.Metric) error
 }
 
-type resetMetric func(ctx context.Context, a *Agent) error
+// Collector gathers a group of metrics on every poll, counters must be reported as deltas since the previous poll
+type Collector interface {
+	Name() string
+	Collect(ctx context.Context) ([]models.Metric, error)
+}
 
 // Agent handles the collection and reporting of system metrics
 type Agent struct {
 	lg                   *logging.ZapLogger
+	mu                   sync.RWMutex // guards cfg, batchReport and relabeler replaced by ApplyConfig
 	cfg                  config.Config
 	reporter             Adapter
 	runtimeMetrics       []RuntimeMetric
 	customMetrics        []*CustomMetric
 	virtualMemoryMetrics []VirtualMemoryMetric
 	cpuMetrics           []CPUMetric
-	resetMetrics         []resetMetric
-	reporterPipeLock     sync.Mutex
 	repository           *MetricsRepository
 	batchReport          bool
+	pending              pendingReports
+	relabeler            *Relabeler
+	collectors           []Collector
+	status               statusTracker
+	health               collectorsHealth
+	notifier             Notifier
+	ready                sync.Once
+	reporterPipeLock     sync.Mutex // serializes reporter runs of the reporter and the poller on shutdown
 }
 
 // NewAgent creates a new Agent instance with the specified configuration
-func NewAgent(lg *logging.ZapLogger, cfg config.Config, rep *MetricsRepository, adaper Adapter) *Agent {
+// collectors are polled in addition to the built-in runtime, memory and cpu metrics
+func NewAgent(
+	lg *logging.ZapLogger,
+	cfg config.Config,
+	rep *MetricsRepository,
+	adaper Adapter,
+	relabeler *Relabeler,
+	collectors ...Collector,
+) *Agent {
 	agent := &Agent{
 		lg:                   lg,
 		cfg:                  cfg,
@@ -48,15 +67,61 @@ func NewAgent(lg *logging.ZapLogger, cfg config.Config, rep *MetricsRepository,
 		customMetrics:        customMetricsDefinition,
 		virtualMemoryMetrics: virtualMemoryMetricsDefinition,
 		cpuMetrics:           cpuMetricsDefinition,
-		reporterPipeLock:     sync.Mutex{},
 		repository:           rep,
 		batchReport:          cfg.BatchReport,
+		relabeler:            relabeler,
+		collectors:           collectors,
+		health:               collectorsHealth{budget: cfg.CollectorErrorBudget},
+	}
+
+	// runtime metrics are reported by the runtime/metrics collector instead of runtime.ReadMemStats
+	if cfg.RuntimeCollector == config.RuntimeCollectorMetrics {
+		agent.runtimeMetrics = nil
 	}
-	initResetMetrics(agent)
 
 	return agent
 }
 
+// ApplyConfig replaces intervals, batching, collectors timeout, error budget, disabled collectors and relabel rules
+// of the running agent. The config is validated first, the running config is kept when it is invalid.
+func (a *Agent) ApplyConfig(cfg config.Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	if cfg.PollInterval <= 0 || cfg.ReportInterval <= 0 {
+		return errors.New("config: poll and report intervals must be positive")
+	}
+
+	if cfg.CollectorErrorBudget < 0 {
+		return errors.New("config: collector error budget must not be negative")
+	}
+
+	relabeler, err := NewRelabeler(cfg.Relabel, cfg.RelabelDryRun, a.lg)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.cfg = cfg
+	a.batchReport = cfg.BatchReport
+	a.relabeler = relabeler
+	a.health.setBudget(cfg.CollectorErrorBudget)
+	a.health.forget(cfg.DisabledCollectors...)
+
+	return nil
+}
+
+// config returns the running config
+func (a *Agent) config() config.Config {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.cfg
+}
+
 // Start launches multiple goroutines:
 // - startPoller: collects metrics
 // - startReporter: sends metrics to the server
@@ -64,13 +129,27 @@ func (a *Agent) Start(ctx context.Context) {
 	wg := sync.WaitGroup{}
 
 	ctx = a.lg.WithContextFields(ctx, zap.String("name", "agent"))
-	a.lg.InfoCtx(ctx, "init", zap.Any("config", a.cfg))
+	a.lg.InfoCtx(ctx, "init", zap.Any("config", a.cfg.Redacted()))
+	a.status.started(time.Now())
 	a.startProfiler()
+	a.startControl(ctx)
+	a.startNotifier(ctx)
 	a.startPoller(ctx, &wg)
 	a.startReporter(ctx, &wg)
 	wg.Wait()
 }
 
+// Once polls the collectors once and returns the collected metrics without reporting them,
+// metrics of the healthy collectors are returned along with the poll error
+func (a *Agent) Once(ctx context.Context) ([]models.Metric, error) {
+	err := a.runPollerPipe(ctx)
+
+	snapshot := a.repository.Snapshot()
+	defer a.repository.Release(snapshot...)
+
+	return a.read(snapshot), err
+}
+
 // startProfiler starts the HTTP profiler server if configured
 func (a *Agent) startProfiler() {
 	if a.cfg.ProfileAddress == "" {
@@ -103,12 +182,15 @@ func (a *Agent) startPoller(ctx context.Context, wg *sync.WaitGroup) {
 				return
 			default:
 				a.lg.DebugCtx(ctx, "poller operation started")
-				if err := a.runPollerPipe(pollerCtx); err != nil {
+				err := a.runPollerPipe(pollerCtx)
+				if err != nil {
 					a.lg.ErrorCtx(pollerCtx, "error in poller pipe", zap.Error(err))
 				}
+				a.status.polled(time.Now(), err)
+				a.notifyPolled(pollerCtx, err)
 
 				a.lg.DebugCtx(ctx, "poller operation started")
-				time.Sleep(a.cfg.PollInterval)
+				time.Sleep(a.config().PollInterval)
 			}
 		}
 	}()
@@ -131,27 +213,11 @@ func (a *Agent) startReporter(ctx context.Context, wg *sync.WaitGroup) {
 			case <-reporterCtx.Done():
 				a.lg.InfoCtx(reporterCtx, "reporter done with context cancellation")
 				return
-			case <-time.NewTicker(a.cfg.ReportInterval).C:
+			case <-time.NewTicker(a.config().ReportInterval).C:
 				a.lg.DebugCtx(reporterCtx, "reporter start")
 				a.runReporterPipe(reporterCtx)
-				a.lg.DebugCtx(reporterCtx, "sleep", zap.Duration("dur", a.cfg.ReportInterval))
+				a.lg.DebugCtx(reporterCtx, "sleep", zap.Duration("dur", a.config().ReportInterval))
 			}
 		}
 	}()
 }
-
-// convertToStr converts various numeric types to their string representation
-func convertToStr(val any) (string, error) {
-	switch val2 := val.(type) {
-	case uint32:
-		return fmt.Sprintf("%d", val2), nil
-	case int32:
-		return fmt.Sprintf("%d", val2), nil
-	case uint64:
-		return fmt.Sprintf("%d", val2), nil
-	case float64:
-		return fmt.Sprintf("%.2f", val2), nil
-	default:
-		return "", fmt.Errorf("internal/agent: value %v underfined type - %T error", val, val)
-	}
-}
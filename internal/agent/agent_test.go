@@ -2,17 +2,24 @@ package agent
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/collectors/goruntime"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/storage"
 	mocks "github.com/vysogota0399/mem_stats_monitoring/internal/mocks/agent"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/idempotency"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/systemd"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -25,13 +32,43 @@ func TestNewAgent(t *testing.T) {
 	cfg, err := config.NewConfig(nil)
 	assert.NoError(t, err)
 
-	agent := NewAgent(logger, cfg, rep, nil)
+	agent := NewAgent(logger, cfg, rep, nil, nil)
 	assert.NotNil(t, agent)
 	assert.Equal(t, logger, agent.lg)
 	assert.Equal(t, rep, agent.repository)
 	assert.Equal(t, cfg, agent.cfg)
 }
 
+func TestNewAgent_runtimeCollector(t *testing.T) {
+	logger, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+	require.NoError(t, err)
+
+	rep := NewMetricsRepository(storage.NewMemoryStorage(logger))
+	agent := NewAgent(logger, config.Config{RuntimeCollector: config.RuntimeCollectorMetrics}, rep, nil, nil, goruntime.NewCollector())
+	assert.Empty(t, agent.runtimeMetrics, "memstats are not read")
+
+	require.NoError(t, agent.runPollerPipe(context.Background()))
+
+	saved := make(map[string]bool)
+	snapshot := rep.Snapshot()
+	for _, m := range snapshot {
+		saved[m.Name] = true
+	}
+	rep.Release(snapshot...)
+
+	assert.True(t, saved["HeapAlloc"], "memstats name is reported as alias")
+	assert.True(t, saved["go_memory_classes_total_bytes"])
+	assert.False(t, saved["Lookups"])
+}
+
+func Test_runtimeMetricsDefinition(t *testing.T) {
+	names := make(map[string]bool, len(runtimeMetricsDefinition))
+	for _, m := range runtimeMetricsDefinition {
+		assert.False(t, names[m.Name], "metric %s is defined twice", m.Name)
+		names[m.Name] = true
+	}
+}
+
 // TestRunPollerPipe проверяет функцию runPollerPipe.
 func TestRunPollerPipe(t *testing.T) {
 	t.Parallel()
@@ -47,13 +84,264 @@ func TestRunPollerPipe(t *testing.T) {
 	}
 	assert.NoError(t, err)
 
-	agent := NewAgent(logger, cfg, rep, nil)
+	agent := NewAgent(logger, cfg, rep, nil, nil)
 	ctx := context.Background()
 
 	err = agent.runPollerPipe(ctx)
 	require.NoError(t, err)
 }
 
+type stubCollector struct {
+	metrics []models.Metric
+	err     error
+}
+
+func (c stubCollector) Name() string { return "stub" }
+
+func (c stubCollector) Collect(context.Context) ([]models.Metric, error) {
+	return c.metrics, c.err
+}
+
+func TestRunPollerPipe_collectors(t *testing.T) {
+	logger, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		collector stubCollector
+		wantErr   bool
+	}{
+		{
+			name:      "collected metrics are saved",
+			collector: stubCollector{metrics: []models.Metric{models.NewGauge("CgroupMemoryUsage", 1024), models.NewCounter("CgroupOOMKills", 1)}},
+		},
+		{
+			name:      "collector error fails the poll",
+			collector: stubCollector{err: errors.New("read error")},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rep := NewMetricsRepository(storage.NewMemoryStorage(logger))
+			agent := NewAgent(logger, config.Config{}, rep, nil, nil, tt.collector)
+
+			err := agent.runPollerPipe(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			saved := make(map[string]models.Metric)
+			snapshot := rep.Snapshot()
+			for _, m := range snapshot {
+				saved[m.Name] = *m
+			}
+			rep.Release(snapshot...)
+
+			for _, m := range tt.collector.metrics {
+				assert.Equal(t, m, saved[m.Name])
+			}
+		})
+	}
+}
+
+func TestAgent_Once(t *testing.T) {
+	logger, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+	require.NoError(t, err)
+
+	collector := stubCollector{metrics: []models.Metric{models.NewGauge("CgroupMemoryUsage", 1024)}}
+	rep := NewMetricsRepository(storage.NewMemoryStorage(logger))
+	agent := NewAgent(logger, config.Config{}, rep, nil, nil, collector)
+
+	got, err := agent.Once(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, got, models.NewGauge("CgroupMemoryUsage", 1024))
+
+	_, err = NewAgent(logger, config.Config{}, rep, nil, nil, stubCollector{err: errors.New("read error")}).Once(context.Background())
+	assert.Error(t, err)
+}
+
+func TestAgent_ApplyConfig(t *testing.T) {
+	logger, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+	require.NoError(t, err)
+
+	failing := namedCollector{name: "failing", collect: func(context.Context) ([]models.Metric, error) {
+		return nil, errors.New("read error")
+	}}
+	healthy := stubCollector{metrics: []models.Metric{models.NewGauge("QueueLength", 3)}}
+
+	local := config.Config{PollInterval: time.Second, ReportInterval: time.Second}
+	rep := NewMetricsRepository(storage.NewMemoryStorage(logger))
+	agent := NewAgent(logger, local, rep, nil, nil, failing, healthy)
+
+	_, err = agent.Once(context.Background())
+	require.Error(t, err)
+
+	invalid := local
+	invalid.Relabel = []config.RelabelRule{{Action: "unknown"}}
+	assert.Error(t, agent.ApplyConfig(invalid))
+
+	invalid = local
+	invalid.PollInterval = 0
+	assert.Error(t, agent.ApplyConfig(invalid))
+	assert.Equal(t, local, agent.config(), "invalid config is not applied")
+
+	remote := local
+	remote.PollInterval = 5 * time.Second
+	remote.BatchReport = true
+	remote.DisabledCollectors = []string{"failing"}
+	remote.Relabel = []config.RelabelRule{{Action: config.RelabelPrefix, Regex: "QueueLength", Prefix: "app_"}}
+	require.NoError(t, agent.ApplyConfig(remote))
+	assert.Equal(t, remote, agent.config())
+	assert.True(t, agent.batchReport)
+
+	got, err := agent.Once(context.Background())
+	require.NoError(t, err, "disabled collector is not polled")
+	assert.Contains(t, got, models.NewGauge("app_QueueLength", 3))
+
+	for _, h := range agent.CollectorsHealth() {
+		assert.NotEqual(t, "failing", h.Name)
+	}
+}
+
+func TestAgent_Status(t *testing.T) {
+	logger, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+	require.NoError(t, err)
+
+	agent := NewAgent(logger, config.Config{}, NewMetricsRepository(storage.NewMemoryStorage(logger)), nil, nil)
+	started := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	agent.status.started(started)
+	agent.status.polled(started.Add(time.Second), nil)
+	agent.status.reported(started.Add(2*time.Second), errors.New("connection refused"))
+	agent.pending.Add("key", []models.Metric{models.NewCounter("PollCount", 1), models.NewGauge("Alloc", 1)})
+
+	assert.Equal(t, Status{
+		StartedAt:      started,
+		LastPollAt:     started.Add(time.Second),
+		LastError:      "connection refused",
+		LastErrorAt:    started.Add(2 * time.Second),
+		Errors:         1,
+		PendingReports: 1,
+		PendingMetrics: 1,
+	}, agent.Status())
+}
+
+func TestAgent_alive(t *testing.T) {
+	logger, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+	require.NoError(t, err)
+
+	started := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := config.Config{PollInterval: 2 * time.Second, ReportInterval: 10 * time.Second, CollectorTimeout: time.Second}
+
+	tests := []struct {
+		name     string
+		polled   time.Duration // since start, zero when there was no poll
+		reported time.Duration
+		now      time.Duration
+		want     bool
+	}{
+		{name: "when agent just started", now: time.Second, want: true},
+		{name: "when loops are running", polled: 28 * time.Second, reported: 20 * time.Second, now: 30 * time.Second, want: true},
+		{name: "when poll loop is hung", polled: 20 * time.Second, reported: 30 * time.Second, now: 30 * time.Second, want: false},
+		{name: "when report loop is hung", polled: 30 * time.Second, reported: 10 * time.Second, now: 45 * time.Second, want: false},
+		{name: "when first poll is hung", reported: 10 * time.Second, now: 10 * time.Second, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent := NewAgent(logger, cfg, NewMetricsRepository(storage.NewMemoryStorage(logger)), nil, nil)
+			agent.status.started(started)
+			if tt.polled > 0 {
+				agent.status.polled(started.Add(tt.polled), nil)
+			}
+			if tt.reported > 0 {
+				agent.status.reported(started.Add(tt.reported), errors.New("connection refused"))
+			}
+
+			assert.Equal(t, tt.want, agent.alive(started.Add(tt.now)))
+		})
+	}
+}
+
+// TestAgent_alive_slowReporter проверяет, что долгая повторная отправка отчетов недоступному серверу не считается зависанием.
+func TestAgent_alive_slowReporter(t *testing.T) {
+	logger, err := logging.MustZapLogger(&config.Config{LogLevel: 2})
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := mocks.NewMockHttpClient(ctrl)
+	client.EXPECT().UpdateMetrics(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(func(context.Context, []*models.Metric) error {
+		time.Sleep(30 * time.Millisecond)
+		return errors.New("connection refused")
+	})
+
+	cfg := config.Config{BatchReport: true, PollInterval: time.Minute, ReportInterval: 20 * time.Millisecond}
+	agent := NewAgent(logger, cfg, NewMetricsRepository(storage.NewMemoryStorage(logger)), client, nil)
+	agent.status.started(time.Now())
+	for i := range 10 {
+		agent.pending.Add(fmt.Sprintf("key-%d", i), []models.Metric{models.NewCounter("PollCount", 1)})
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		agent.runReporterPipe(context.Background())
+	}()
+
+	ticker := time.NewTicker(5 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			assert.True(t, agent.alive(time.Now()))
+			return
+		case now := <-ticker.C:
+			require.True(t, agent.alive(now), "report loop is considered hung while it sends requests")
+		}
+	}
+}
+
+func TestAgent_notify(t *testing.T) {
+	logger, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+	require.NoError(t, err)
+
+	sock := systemd.NewFakeNotifySocket(t, 0)
+
+	cfg := config.Config{PollInterval: 2 * time.Second, ReportInterval: 10 * time.Second}
+	agent := NewAgent(logger, cfg, NewMetricsRepository(storage.NewMemoryStorage(logger)), nil, nil).
+		WithNotifier(systemd.NewNotifier(logger))
+
+	ctx := context.Background()
+
+	agent.notifyPolled(ctx, errors.New("collector ran out of error budget"))
+	_, ok := sock.Next(50 * time.Millisecond)
+	assert.False(t, ok, "agent is not ready until the poll succeeded")
+
+	agent.notifyPolled(ctx, nil)
+	states, ok := sock.Next(time.Second)
+	require.True(t, ok)
+	assert.Equal(t, []string{systemd.StateReady, "STATUS=polling every 2s, reporting every 10s"}, states)
+
+	agent.notifyPolled(ctx, nil)
+	_, ok = sock.Next(50 * time.Millisecond)
+	assert.False(t, ok, "readiness is reported once")
+
+	at := time.Date(2024, 1, 1, 12, 30, 0, 0, time.UTC)
+	agent.pending.Add("key", []models.Metric{models.NewCounter("PollCount", 1)})
+	agent.notifyReported(ctx, at, errors.New("connection refused"))
+	states, ok = sock.Next(time.Second)
+	require.True(t, ok)
+	assert.Equal(t, []string{"STATUS=last report failed at 12:30:00: connection refused, pending reports 1"}, states)
+}
+
 // TestGenMetrics tests metrics generation by verifying that the genMetrics function
 // produces valid metrics with expected fields and types through the returned channel.
 func TestGenMetrics(t *testing.T) {
@@ -68,7 +356,7 @@ func TestGenMetrics(t *testing.T) {
 	cfg, err := config.NewConfig(nil)
 	assert.NoError(t, err)
 
-	agent := NewAgent(logger, cfg, rep, nil)
+	agent := NewAgent(logger, cfg, rep, nil, nil)
 	ctx := context.Background()
 
 	metricsChan := agent.genMetrics(ctx, &errgroup.Group{})
@@ -102,16 +390,16 @@ func TestSaveMetrics(t *testing.T) {
 	cfg := config.Config{}
 	assert.NoError(t, err)
 
-	agent := NewAgent(logger, cfg, rep, nil)
+	agent := NewAgent(logger, cfg, rep, nil, nil)
 
 	metricsChan := make(chan *models.Metric, 10)
 	errg, ctx := errgroup.WithContext(context.Background())
 
 	// Send test metrics
 	testMetrics := []*models.Metric{
-		{Name: "TestMetric1", Type: "gauge", Value: "123.45"},
-		{Name: "TestMetric2", Type: "counter", Value: "100"},
-		{Name: "TestMetric3", Type: "gauge", Value: "67.89"},
+		{Name: "TestMetric1", Type: "gauge", Value: 123.45},
+		{Name: "TestMetric2", Type: "counter", Delta: 100},
+		{Name: "TestMetric3", Type: "gauge", Value: 67.89},
 	}
 
 	for _, m := range testMetrics {
@@ -126,29 +414,21 @@ func TestSaveMetrics(t *testing.T) {
 	assert.NoError(t, err)
 }
 
-// TestConvertToStr проверяет конвертацию значений в строку.
-func TestConvertToStr(t *testing.T) {
+// TestNewMetric проверяет создание метрики из собранного значения.
+func TestNewMetric(t *testing.T) {
 	testCases := []struct {
 		name     string
-		input    any
-		expected string
+		mType    string
+		input    float64
+		expected models.Metric
 	}{
-		{"int32", int32(42), "42"},
-		{"float64", float64(3.14), "3.14"},
-		{"uint32", uint32(100), "100"},
-		{"invalid", complex128(1 + 2i), ""}, // Ожидается ошибка
+		{"gauge", models.GaugeType, 3.14, models.Metric{Name: "m", Type: models.GaugeType, Value: 3.14}},
+		{"counter", models.CounterType, 42, models.Metric{Name: "m", Type: models.CounterType, Delta: 42}},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			result, err := convertToStr(tc.input)
-			if err != nil {
-				assert.Empty(t, result)
-				assert.Error(t, err)
-			} else {
-				assert.Equal(t, tc.expected, result)
-				assert.NoError(t, err)
-			}
+			assert.Equal(t, tc.expected, newMetric("m", tc.mType, tc.input))
 		})
 	}
 }
@@ -162,7 +442,7 @@ func TestAgent_Start(t *testing.T) {
 		{
 			name: "graceful shutdown",
 			setup: func(client *mocks.MockHttpClient) (context.Context, context.CancelFunc) {
-				client.EXPECT().UpdateMetric(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+				client.EXPECT().UpdateMetric(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
 				client.EXPECT().UpdateMetrics(gomock.Any(), gomock.Any()).AnyTimes().AnyTimes().Return(nil)
 				return context.WithTimeout(context.Background(), 1000*time.Millisecond)
 			},
@@ -173,7 +453,7 @@ func TestAgent_Start(t *testing.T) {
 		{
 			name: "context cancellation",
 			setup: func(client *mocks.MockHttpClient) (context.Context, context.CancelFunc) {
-				client.EXPECT().UpdateMetric(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+				client.EXPECT().UpdateMetric(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
 				client.EXPECT().UpdateMetrics(gomock.Any(), gomock.Any()).AnyTimes().AnyTimes().Return(nil)
 				ctx, cancel := context.WithCancel(context.Background())
 				go func(cancel context.CancelFunc) {
@@ -226,3 +506,316 @@ func TestAgent_Start(t *testing.T) {
 		})
 	}
 }
+
+// benchCollector returns a single gauge named after the collector
+type benchCollector struct {
+	name string
+}
+
+func (c benchCollector) Name() string { return c.name }
+
+func (c benchCollector) Collect(context.Context) ([]models.Metric, error) {
+	return []models.Metric{models.NewGauge(c.name, 1)}, nil
+}
+
+// BenchmarkAgent_runPollerPipe измеряет время опроса при параллельной медленной отправке метрик в зависимости от числа
+// коллекторов. Вариант shared_lock воспроизводит прежнее поведение, когда опрос ждал блокировку отправки.
+func BenchmarkAgent_runPollerPipe(b *testing.B) {
+	logger, err := logging.MustZapLogger(&config.Config{LogLevel: 2})
+	require.NoError(b, err)
+
+	for _, n := range []int{10, 100, 1000} {
+		for _, sharedLock := range []bool{false, true} {
+			name := fmt.Sprintf("collectors=%d/decoupled", n)
+			if sharedLock {
+				name = fmt.Sprintf("collectors=%d/shared_lock", n)
+			}
+
+			b.Run(name, func(b *testing.B) {
+				ctrl := gomock.NewController(b)
+				defer ctrl.Finish()
+
+				client := mocks.NewMockHttpClient(ctrl)
+				client.EXPECT().UpdateMetrics(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+					func(context.Context, []*models.Metric) error {
+						time.Sleep(10 * time.Millisecond)
+						return nil
+					},
+				)
+
+				collectors := make([]Collector, 0, n)
+				for i := range n {
+					collectors = append(collectors, benchCollector{name: fmt.Sprintf("Metric%d", i)})
+				}
+
+				rep := NewMetricsRepository(storage.NewMemoryStorage(logger))
+				agent := NewAgent(logger, config.Config{BatchReport: true}, rep, client, nil, collectors...)
+
+				ctx, cancel := context.WithCancel(context.Background())
+				require.NoError(b, agent.runPollerPipe(ctx))
+
+				done := make(chan struct{})
+				go func() {
+					defer close(done)
+					for ctx.Err() == nil {
+						agent.runReporterPipe(ctx)
+					}
+				}()
+
+				b.ReportAllocs()
+				for b.Loop() {
+					if sharedLock {
+						agent.reporterPipeLock.Lock()
+					}
+
+					err := agent.runPollerPipe(ctx)
+
+					if sharedLock {
+						agent.reporterPipeLock.Unlock()
+					}
+					require.NoError(b, err)
+				}
+
+				cancel()
+				<-done
+			})
+		}
+	}
+}
+
+// TestRunReporterPipe_pending проверяет, что счетчики неподтвержденного отчета отправляются повторно с тем же ключом и без разбиения.
+func TestRunReporterPipe_pending(t *testing.T) {
+	logger, err := logging.MustZapLogger(&config.Config{LogLevel: 2})
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	type call struct {
+		key     string
+		unsplit bool
+		metrics []models.Metric
+	}
+
+	var calls []call
+	client := mocks.NewMockHttpClient(ctrl)
+	record := func(ctx context.Context, data []*models.Metric) {
+		c := call{key: idempotency.KeyFromContext(ctx), unsplit: ctx.Value(withoutSplitKey) != nil}
+		for _, m := range data {
+			c.metrics = append(c.metrics, *m)
+		}
+		calls = append(calls, c)
+	}
+
+	gomock.InOrder(
+		client.EXPECT().UpdateMetrics(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data []*models.Metric) error {
+			record(ctx, data)
+			return errors.New("connection refused")
+		}),
+		client.EXPECT().UpdateMetrics(gomock.Any(), gomock.Any()).Times(2).DoAndReturn(func(ctx context.Context, data []*models.Metric) error {
+			record(ctx, data)
+			return nil
+		}),
+	)
+
+	rep := NewMetricsRepository(storage.NewMemoryStorage(logger))
+	agent := NewAgent(logger, config.Config{BatchReport: true}, rep, client, nil)
+	ctx := context.Background()
+
+	require.NoError(t, rep.SaveAndRelease(ctx, rep.New(models.NewCounter("PollCount", 3))))
+	require.NoError(t, rep.SaveAndRelease(ctx, rep.New(models.NewGauge("Alloc", 1.5))))
+	agent.runReporterPipe(ctx)
+	assert.Equal(t, 1, agent.pending.Len())
+
+	require.NoError(t, rep.SaveAndRelease(ctx, rep.New(models.NewCounter("PollCount", 2))))
+	agent.runReporterPipe(ctx)
+	assert.Equal(t, 0, agent.pending.Len())
+
+	require.Len(t, calls, 3)
+	assert.NotEmpty(t, calls[0].key)
+	assert.False(t, calls[0].unsplit)
+	assert.Equal(t, call{key: calls[0].key, unsplit: true, metrics: []models.Metric{models.NewCounter("PollCount", 3)}}, calls[1])
+	assert.NotEqual(t, calls[0].key, calls[2].key)
+	assert.False(t, calls[2].unsplit)
+	assert.ElementsMatch(t, []models.Metric{models.NewGauge("Alloc", 1.5), models.NewCounter("PollCount", 2)}, calls[2].metrics,
+		"gauge which was not polled again is reported with the last value")
+}
+
+// TestRunReporterPipe_serialized проверяет, что отчеты репортера и завершающего поллера не отправляются одновременно.
+func TestRunReporterPipe_serialized(t *testing.T) {
+	logger, err := logging.MustZapLogger(&config.Config{LogLevel: 2})
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var inflight, maxInflight atomic.Int32
+	client := mocks.NewMockHttpClient(ctrl)
+	client.EXPECT().UpdateMetrics(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(func(context.Context, []*models.Metric) error {
+		n := inflight.Add(1)
+		defer inflight.Add(-1)
+
+		if n > maxInflight.Load() {
+			maxInflight.Store(n)
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		return errors.New("connection refused")
+	})
+
+	rep := NewMetricsRepository(storage.NewMemoryStorage(logger))
+	agent := NewAgent(logger, config.Config{BatchReport: true}, rep, client, nil)
+	ctx := context.Background()
+
+	require.NoError(t, rep.SaveAndRelease(ctx, rep.New(models.NewCounter("PollCount", 1))))
+	require.NoError(t, rep.SaveAndRelease(ctx, rep.New(models.NewGauge("Alloc", 1.5))))
+	agent.runReporterPipe(ctx)
+
+	var g errgroup.Group
+	for range 4 {
+		g.Go(func() error {
+			agent.runReporterPipe(ctx)
+			return nil
+		})
+	}
+	require.NoError(t, g.Wait())
+
+	assert.Equal(t, int32(1), maxInflight.Load())
+	assert.Equal(t, 1, agent.pending.Len(), "pending report is requeued once")
+}
+
+// limitedAdapter splits batches by maxItems like the reporters do and records keys of the sent chunks
+type limitedAdapter struct {
+	mu       sync.Mutex
+	maxItems int
+	fail     bool
+	keys     []string
+}
+
+func (a *limitedAdapter) UpdateMetric(context.Context, models.Metric) error {
+	return errors.New("not implemented")
+}
+
+func (a *limitedAdapter) UpdateMetrics(ctx context.Context, data []*models.Metric) error {
+	a.mu.Lock()
+	maxItems, fail := a.maxItems, a.fail
+	a.mu.Unlock()
+
+	chunks := SplitBatch(ctx, data, maxItems, 0, 0, func(int) int { return 1 })
+	return SendChunks(ctx, chunks, func(ctx context.Context, ch Chunk) error {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+
+		if len(ch.Metrics) > maxItems && maxItems > 0 {
+			return errors.New("too many metrics")
+		}
+
+		a.keys = append(a.keys, idempotency.KeyFromContext(ctx))
+		if fail {
+			return errors.New("connection refused")
+		}
+
+		return nil
+	})
+}
+
+// TestRunReporterPipe_pendingShrunkLimits проверяет, что неподтвержденный отчет разбивается, если лимиты уменьшились.
+func TestRunReporterPipe_pendingShrunkLimits(t *testing.T) {
+	logger, err := logging.MustZapLogger(&config.Config{LogLevel: 2})
+	require.NoError(t, err)
+
+	client := &limitedAdapter{fail: true}
+	rep := NewMetricsRepository(storage.NewMemoryStorage(logger))
+	agent := NewAgent(logger, config.Config{BatchReport: true}, rep, client, nil)
+	ctx := context.Background()
+
+	require.NoError(t, rep.SaveAndRelease(ctx, rep.New(models.NewCounter("PollCount", 1))))
+	require.NoError(t, rep.SaveAndRelease(ctx, rep.New(models.NewCounter("Errors", 2))))
+	agent.runReporterPipe(ctx)
+
+	pending := agent.pending.Take()
+	require.Len(t, pending, 1)
+	key := pending[0].key
+	agent.pending.Add(key, pending[0].metrics)
+
+	// server negotiated smaller batches before the resend
+	client.mu.Lock()
+	client.maxItems, client.fail, client.keys = 1, false, nil
+	client.mu.Unlock()
+
+	agent.runReporterPipe(ctx)
+	assert.Equal(t, 0, agent.pending.Len())
+	assert.ElementsMatch(t, []string{key + "/PollCount", key + "/Errors"}, client.keys)
+}
+
+// dedupAdapter applies counters once per idempotency key like the server does. Batches are sent one by one
+// like the reporters do when the server doesn't accept them, the second request fails.
+type dedupAdapter struct {
+	mu      sync.Mutex
+	applied map[string]bool
+	totals  map[string]int64
+	calls   int
+}
+
+func (a *dedupAdapter) UpdateMetric(ctx context.Context, m models.Metric) error {
+	return a.apply(idempotency.KeyFromContext(ctx), m)
+}
+
+func (a *dedupAdapter) UpdateMetrics(ctx context.Context, data []*models.Metric) error {
+	key := idempotency.KeyFromContext(ctx)
+	for _, m := range data {
+		if err := a.apply(idempotency.MetricKey(key, m.Name, len(data)), *m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *dedupAdapter) apply(key string, m models.Metric) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.calls++
+	if a.calls == 2 {
+		return errors.New("connection refused")
+	}
+
+	if a.applied[key] {
+		return nil
+	}
+
+	a.applied[key] = true
+	a.totals[m.Name] += m.Delta
+
+	return nil
+}
+
+// TestRunReporterPipe_pendingBatchToggled проверяет, что счетчики повторно отправляются с теми же ключами,
+// если режим отправки пачками изменился между отправками.
+func TestRunReporterPipe_pendingBatchToggled(t *testing.T) {
+	logger, err := logging.MustZapLogger(&config.Config{LogLevel: 2})
+	require.NoError(t, err)
+
+	for _, batch := range []bool{true, false} {
+		t.Run(fmt.Sprintf("batch %t", batch), func(t *testing.T) {
+			client := &dedupAdapter{applied: map[string]bool{}, totals: map[string]int64{}}
+			cfg := config.Config{PollInterval: time.Second, ReportInterval: time.Second, BatchReport: batch}
+			rep := NewMetricsRepository(storage.NewMemoryStorage(logger))
+			agent := NewAgent(logger, cfg, rep, client, nil)
+			ctx := context.Background()
+
+			require.NoError(t, rep.SaveAndRelease(ctx, rep.New(models.NewCounter("PollCount", 1))))
+			require.NoError(t, rep.SaveAndRelease(ctx, rep.New(models.NewCounter("Errors", 2))))
+			agent.runReporterPipe(ctx)
+			require.Equal(t, 1, agent.pending.Len())
+
+			cfg.BatchReport = !batch
+			require.NoError(t, agent.ApplyConfig(cfg))
+			agent.runReporterPipe(ctx)
+
+			assert.Equal(t, 0, agent.pending.Len())
+			assert.Equal(t, map[string]int64{"PollCount": 1, "Errors": 2}, client.totals)
+		})
+	}
+}
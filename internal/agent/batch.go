@@ -0,0 +1,172 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/idempotency"
+)
+
+// Chunk is a part of the report batch which is sent to the server within a single request
+type Chunk struct {
+	From    int    // index of the first metric of the chunk in the original batch
+	To      int    // index next to the last metric of the chunk in the original batch
+	Key     string // idempotency key of the chunk request
+	Metrics []*models.Metric
+}
+
+// ChunkFailure describes a chunk which was not delivered to the server
+type ChunkFailure struct {
+	Chunk
+	Err error
+}
+
+// ChunksError is returned by Adapter.UpdateMetrics when some of the batch chunks were not delivered.
+// Delivered chunks are not included, so the caller is able to retry only the failed ones.
+type ChunksError struct {
+	Failed []ChunkFailure
+	Total  int
+}
+
+func (e *ChunksError) Error() string {
+	msgs := make([]string, 0, len(e.Failed))
+	for _, f := range e.Failed {
+		msgs = append(msgs, fmt.Sprintf("chunk [%d:%d] error %s", f.From, f.To, f.Err))
+	}
+
+	return fmt.Sprintf("batch: %d of %d chunks failed: %s", len(e.Failed), e.Total, strings.Join(msgs, "; "))
+}
+
+func (e *ChunksError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed))
+	for _, f := range e.Failed {
+		errs = append(errs, f.Err)
+	}
+
+	return errs
+}
+
+// FailedMetrics returns metrics of all the failed chunks
+func (e *ChunksError) FailedMetrics() []*models.Metric {
+	res := make([]*models.Metric, 0)
+	for _, f := range e.Failed {
+		res = append(res, f.Metrics...)
+	}
+
+	return res
+}
+
+type withoutSplit string
+
+const withoutSplitKey withoutSplit = "withoutSplit"
+
+// WithoutSplit marks the batch which must be sent in a single request under the key of ctx while it fits
+// the limits, e.g. a pending report which was sent with the limits negotiated before
+func WithoutSplit(ctx context.Context) context.Context {
+	return context.WithValue(ctx, withoutSplitKey, struct{}{})
+}
+
+// SplitBatch splits the batch into chunks limited by maxItems metrics and maxBytes encoded bytes.
+// size returns encoded size of the i-th metric including its separator, overhead is the size
+// of the request envelope. Zero or negative limits are treated as unlimited.
+// A metric that does not fit into maxBytes by itself is sent in a dedicated chunk.
+// The batch marked with WithoutSplit is returned as a single chunk unless it exceeds the limits,
+// e.g. when they shrank since the batch was sent, then it is split and chunks get keys derived from its key.
+func SplitBatch(ctx context.Context, data []*models.Metric, maxItems, maxBytes, overhead int, size func(i int) int) []Chunk {
+	if len(data) == 0 || ctx.Value(withoutSplitKey) != nil && fits(len(data), maxItems, maxBytes, overhead, size) {
+		return []Chunk{{To: len(data), Metrics: data}}
+	}
+
+	chunks := make([]Chunk, 0, 1)
+	from, bytes := 0, overhead
+
+	for i := range data {
+		itemSize := size(i)
+		count := i - from
+
+		overflowItems := maxItems > 0 && count >= maxItems
+		overflowBytes := maxBytes > 0 && count > 0 && bytes+itemSize > maxBytes
+
+		if overflowItems || overflowBytes {
+			chunks = append(chunks, Chunk{From: from, To: i, Metrics: data[from:i]})
+			from, bytes = i, overhead
+		}
+
+		bytes += itemSize
+	}
+
+	return append(chunks, Chunk{From: from, To: len(data), Metrics: data[from:]})
+}
+
+// fits reports whether the batch of n metrics fits into a single chunk
+func fits(n, maxItems, maxBytes, overhead int, size func(i int) int) bool {
+	if maxItems > 0 && n > maxItems {
+		return false
+	}
+
+	if maxBytes <= 0 || n == 1 {
+		return true
+	}
+
+	bytes := overhead
+	for i := range n {
+		bytes += size(i)
+	}
+
+	return bytes <= maxBytes
+}
+
+// SendChunks sends every chunk in a separate goroutine and waits for all of them.
+// Concurrency is expected to be limited by send itself, e.g. with reporter rate limit semaphore.
+// Every chunk is sent with its own idempotency key derived from the key of ctx.
+func SendChunks(ctx context.Context, chunks []Chunk, send func(context.Context, Chunk) error) error {
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed []ChunkFailure
+	)
+
+	key := idempotency.KeyFromContext(ctx)
+	for i := range chunks {
+		chunks[i].Key = ChunkKey(key, chunks[i], len(chunks))
+	}
+
+	for _, ch := range chunks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := send(idempotency.WithKey(ctx, ch.Key), ch); err != nil {
+				mu.Lock()
+				failed = append(failed, ChunkFailure{Chunk: ch, Err: err})
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	return &ChunksError{Failed: failed, Total: len(chunks)}
+}
+
+// ChunkKey derives idempotency key of the chunk from the report key.
+// The report key is kept as is when the report is not split, so a resent chunk keeps its key.
+// A chunk of a single metric gets the key of the metric, like the metric sent one by one.
+func ChunkKey(key string, ch Chunk, total int) string {
+	if key == "" || total <= 1 {
+		return key
+	}
+
+	if len(ch.Metrics) == 1 {
+		return idempotency.MetricKey(key, ch.Metrics[0].Name, total)
+	}
+
+	return fmt.Sprintf("%s-%d", key, ch.From)
+}
@@ -0,0 +1,234 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/idempotency"
+)
+
+func TestSplitBatch(t *testing.T) {
+	batch := make([]*models.Metric, 5)
+	for i := range batch {
+		batch[i] = &models.Metric{Name: "m", Type: models.GaugeType, Value: 1}
+	}
+
+	type args struct {
+		data     []*models.Metric
+		maxItems int
+		maxBytes int
+		overhead int
+		size     func(int) int
+	}
+	tests := []struct {
+		name string
+		args args
+		want [][2]int
+	}{
+		{
+			name: "empty batch produces single empty chunk",
+			args: args{data: []*models.Metric{}, size: func(int) int { return 10 }},
+			want: [][2]int{{0, 0}},
+		},
+		{
+			name: "no limits",
+			args: args{data: batch, size: func(int) int { return 10 }},
+			want: [][2]int{{0, 5}},
+		},
+		{
+			name: "limited by items",
+			args: args{data: batch, maxItems: 2, size: func(int) int { return 10 }},
+			want: [][2]int{{0, 2}, {2, 4}, {4, 5}},
+		},
+		{
+			name: "limited by bytes with overhead",
+			args: args{data: batch, maxBytes: 32, overhead: 2, size: func(int) int { return 10 }},
+			want: [][2]int{{0, 3}, {3, 5}},
+		},
+		{
+			name: "limited by items and bytes",
+			args: args{data: batch, maxItems: 2, maxBytes: 15, size: func(int) int { return 10 }},
+			want: [][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 4}, {4, 5}},
+		},
+		{
+			name: "oversized item goes to dedicated chunk",
+			args: args{data: batch, maxBytes: 25, size: func(i int) int {
+				if i == 2 {
+					return 100
+				}
+				return 10
+			}},
+			want: [][2]int{{0, 2}, {2, 3}, {3, 5}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := SplitBatch(context.Background(), tt.args.data, tt.args.maxItems, tt.args.maxBytes, tt.args.overhead, tt.args.size)
+
+			got := make([][2]int, 0, len(chunks))
+			for _, ch := range chunks {
+				assert.Equal(t, ch.To-ch.From, len(ch.Metrics))
+				got = append(got, [2]int{ch.From, ch.To})
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSplitBatch_withoutSplit(t *testing.T) {
+	batch := make([]*models.Metric, 4)
+	for i := range batch {
+		batch[i] = &models.Metric{Name: "m", Type: models.CounterType, Delta: 1}
+	}
+
+	ctx := WithoutSplit(idempotency.WithKey(context.Background(), "report-2"))
+	chunks := SplitBatch(ctx, batch, 4, 40, 0, func(int) int { return 10 })
+	require.Len(t, chunks, 1)
+	assert.Equal(t, 0, chunks[0].From)
+	assert.Equal(t, 4, chunks[0].To)
+	assert.Equal(t, batch, chunks[0].Metrics)
+
+	// resent pending chunk keeps its key
+	err := SendChunks(ctx, chunks, func(ctx context.Context, ch Chunk) error {
+		assert.Equal(t, "report-2", idempotency.KeyFromContext(ctx))
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestSplitBatch_withoutSplitExceedsLimits(t *testing.T) {
+	batch := make([]*models.Metric, 4)
+	for i := range batch {
+		batch[i] = &models.Metric{Name: "m", Type: models.CounterType, Delta: 1}
+	}
+
+	tests := []struct {
+		name     string
+		maxItems int
+		maxBytes int
+		want     []string
+	}{
+		{
+			name:     "max items",
+			maxItems: 3,
+			want:     []string{"report-2-0", "report-2/m"},
+		},
+		{
+			name:     "max bytes",
+			maxBytes: 25,
+			want:     []string{"report-2-0", "report-2-2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := WithoutSplit(idempotency.WithKey(context.Background(), "report-2"))
+			chunks := SplitBatch(ctx, batch, tt.maxItems, tt.maxBytes, 0, func(int) int { return 10 })
+
+			var (
+				mu   sync.Mutex
+				keys []string
+			)
+			err := SendChunks(ctx, chunks, func(ctx context.Context, ch Chunk) error {
+				mu.Lock()
+				defer mu.Unlock()
+
+				keys = append(keys, idempotency.KeyFromContext(ctx))
+				return nil
+			})
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tt.want, keys)
+		})
+	}
+}
+
+func TestSendChunks(t *testing.T) {
+	batch := make([]*models.Metric, 6)
+	for i := range batch {
+		batch[i] = &models.Metric{Name: "m", Type: models.GaugeType, Value: 1}
+	}
+	chunks := SplitBatch(context.Background(), batch, 2, 0, 0, func(int) int { return 1 })
+	sendErr := errors.New("send failed")
+
+	t.Run("all chunks delivered", func(t *testing.T) {
+		var sent atomic.Int32
+		err := SendChunks(context.Background(), chunks, func(context.Context, Chunk) error {
+			sent.Add(1)
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int32(3), sent.Load())
+	})
+
+	t.Run("only failed chunks are reported", func(t *testing.T) {
+		err := SendChunks(context.Background(), chunks, func(_ context.Context, ch Chunk) error {
+			if ch.From == 2 {
+				return sendErr
+			}
+			return nil
+		})
+
+		var chErr *ChunksError
+		require.ErrorAs(t, err, &chErr)
+		assert.ErrorIs(t, err, sendErr)
+		assert.Equal(t, 3, chErr.Total)
+		require.Len(t, chErr.Failed, 1)
+		assert.Equal(t, 2, chErr.Failed[0].From)
+		assert.Equal(t, batch[2:4], chErr.FailedMetrics())
+	})
+}
+
+func TestSendChunks_keys(t *testing.T) {
+	batch := make([]*models.Metric, 4)
+	for i := range batch {
+		batch[i] = &models.Metric{Name: "m", Type: models.CounterType, Delta: 1}
+	}
+
+	tests := []struct {
+		name     string
+		maxItems int
+		want     []string
+	}{
+		{
+			name:     "when batch is not split",
+			maxItems: 0,
+			want:     []string{"report"},
+		},
+		{
+			name:     "when batch is split",
+			maxItems: 2,
+			want:     []string{"report-0", "report-2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu   sync.Mutex
+				keys []string
+			)
+
+			chunks := SplitBatch(context.Background(), batch, tt.maxItems, 0, 0, func(int) int { return 1 })
+			err := SendChunks(idempotency.WithKey(context.Background(), "report"), chunks, func(ctx context.Context, ch Chunk) error {
+				assert.Equal(t, ch.Key, idempotency.KeyFromContext(ctx))
+
+				mu.Lock()
+				keys = append(keys, ch.Key)
+				mu.Unlock()
+
+				return nil
+			})
+
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, tt.want, keys)
+		})
+	}
+}
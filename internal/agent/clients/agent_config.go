@@ -0,0 +1,80 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/remoteconfig"
+	"go.uber.org/zap"
+)
+
+var _ remoteconfig.Fetcher = (*Reporter)(nil)
+
+type agentConfigBody struct {
+	AgentID        string `json:"agent_id"`
+	Host           string `json:"host"`
+	RunningVersion int64  `json:"running_version"`
+	ApplyError     string `json:"apply_error,omitempty"`
+}
+
+type agentConfigResponse struct {
+	Profile string          `json:"profile"`
+	Version int64           `json:"version"`
+	Config  json.RawMessage `json:"config"`
+}
+
+// FetchAgentConfig fetches the config profile assigned to the agent, remoteconfig.ErrNoProfile is returned
+// when the server has no profile for the agent. The request is not retried, the next sync repeats it.
+func (c *Reporter) FetchAgentConfig(ctx context.Context, params remoteconfig.Request) (remoteconfig.Profile, error) {
+	reqCtx := c.lg.WithContextFields(ctx, zap.String("name", "http"))
+
+	b, err := json.Marshal(agentConfigBody(params))
+	if err != nil {
+		return remoteconfig.Profile{}, fmt.Errorf("internal/agent/clients/agent_config: encode request error %w", err)
+	}
+
+	addr := c.serverAddress()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, addr+"/agent/config", bytes.NewReader(b))
+	if err != nil {
+		return remoteconfig.Profile{}, fmt.Errorf("internal/agent/clients/agent_config: create request error %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Request-ID", uuid.NewV4().String())
+
+	if _, err := c.prepareRequest(reqCtx, req); err != nil {
+		return remoteconfig.Profile{}, err
+	}
+
+	resp, err := c.client.Request(req)
+	c.trackResult(addr, resp, err)
+	if err != nil {
+		return remoteconfig.Profile{}, fmt.Errorf("internal/agent/clients/agent_config: send request error %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			c.lg.ErrorCtx(reqCtx, "close body erorr", zap.Error(closeErr))
+		}
+	}()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return remoteconfig.Profile{}, remoteconfig.ErrNoProfile
+	default:
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return remoteconfig.Profile{}, fmt.Errorf("internal/agent/clients/agent_config: %w status %s", ErrUnsuccessfulResponse, resp.Status)
+	}
+
+	var body agentConfigResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return remoteconfig.Profile{}, fmt.Errorf("internal/agent/clients/agent_config: decode response error %w", err)
+	}
+
+	return remoteconfig.Profile{Name: body.Profile, Version: body.Version, Config: body.Config}, nil
+}
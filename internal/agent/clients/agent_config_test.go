@@ -0,0 +1,101 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/remoteconfig"
+	mocks "github.com/vysogota0399/mem_stats_monitoring/internal/mocks/agent/clients"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+)
+
+func TestReporter_FetchAgentConfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: 1})
+	require.NoError(t, err)
+
+	errConnRefused := errors.New("connection refused")
+
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		reqErr  error
+		want    remoteconfig.Profile
+		wantErr error
+	}{
+		{
+			name:   "when profile is assigned",
+			status: http.StatusOK,
+			body:   `{"profile":"db","version":3,"config":{"poll_interval":5}}`,
+			want:   remoteconfig.Profile{Name: "db", Version: 3, Config: []byte(`{"poll_interval":5}`)},
+		},
+		{
+			name:    "when no profile is assigned",
+			status:  http.StatusNotFound,
+			wantErr: remoteconfig.ErrNoProfile,
+		},
+		{
+			name:    "when server failed",
+			status:  http.StatusInternalServerError,
+			wantErr: ErrUnsuccessfulResponse,
+		},
+		{
+			name:    "when server is unreachable",
+			reqErr:  errConnRefused,
+			wantErr: errConnRefused,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewMockRequester(ctrl)
+			ips := mocks.NewMockIRealIPHeaderSetter(ctrl)
+			ips.EXPECT().Call(gomock.Any()).Return(nil)
+
+			client.EXPECT().Request(gomock.Any()).DoAndReturn(func(r *http.Request) (*http.Response, error) {
+				assert.Equal(t, "http://test-server/agent/config", r.URL.String())
+				assert.NotEmpty(t, r.Header.Get(signHeaderKey))
+
+				b, err := io.ReadAll(r.Body)
+				assert.NoError(t, err)
+				assert.JSONEq(t, `{"agent_id":"db-1","host":"host-1","running_version":2}`, string(b))
+
+				if tt.reqErr != nil {
+					return nil, tt.reqErr
+				}
+
+				return &http.Response{StatusCode: tt.status, Body: io.NopCloser(bytes.NewBufferString(tt.body))}, nil
+			})
+
+			c := NewCompReporter(
+				"http://test-server",
+				lg,
+				&config.Config{RateLimit: 1, MaxAttempts: 1, Key: "secret"},
+				client,
+				ips,
+			)
+
+			got, err := c.FetchAgentConfig(context.Background(), remoteconfig.Request{AgentID: "db-1", Host: "host-1", RunningVersion: 2})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want.Name, got.Name)
+			assert.Equal(t, tt.want.Version, got.Version)
+			assert.JSONEq(t, string(tt.want.Config), string(got.Config))
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/capabilities"
+	"go.uber.org/zap"
+)
+
+// Negotiate fetches capabilities of the current server and adapts reporting to them, servers which don't
+// serve /capabilities are treated as legacy ones. capabilities.ErrIncompatible is returned when the server
+// can't accept reports of the agent. Legacy capabilities are used when the negotiation fails, it is retried
+// before the reports and when the agent fails over to another server.
+func (c *Reporter) Negotiate(ctx context.Context) error {
+	addr := c.serverAddress()
+
+	err := c.negotiate(ctx, addr)
+	c.negotiation.Negotiated(addr, time.Now(), err)
+	if err != nil {
+		c.mu.Lock()
+		c.caps, c.noBatch, c.noCompress = nil, false, false
+		c.mu.Unlock()
+	}
+
+	return err
+}
+
+// renegotiate negotiates capabilities again when the agent failed over to another server or the previous
+// negotiation failed
+func (c *Reporter) renegotiate(ctx context.Context) {
+	if !c.negotiation.Due(c.serverAddress(), time.Now()) {
+		return
+	}
+
+	if err := c.Negotiate(ctx); err != nil {
+		c.lg.WarnCtx(ctx, "failed to negotiate capabilities, legacy protocol is used", zap.Error(err))
+	}
+}
+
+func (c *Reporter) negotiate(ctx context.Context, addr string) error {
+	reqCtx := c.lg.WithContextFields(ctx, zap.String("name", "http"))
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, addr+"/capabilities", &bytes.Buffer{})
+	if err != nil {
+		return fmt.Errorf("internal/agent/clients/capabilities: create request error %w", err)
+	}
+
+	req.Header.Add("X-Request-ID", uuid.NewV4().String())
+
+	if _, err := c.prepareRequest(reqCtx, req); err != nil {
+		return err
+	}
+
+	resp, err := c.client.Request(req)
+	c.trackResult(addr, resp, err)
+	if err != nil {
+		return fmt.Errorf("internal/agent/clients/capabilities: send request error %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			c.lg.ErrorCtx(reqCtx, "close body erorr", zap.Error(closeErr))
+		}
+	}()
+
+	var caps capabilities.Capabilities
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if err := json.NewDecoder(resp.Body).Decode(&caps); err != nil {
+			return fmt.Errorf("internal/agent/clients/capabilities: decode response error %w", err)
+		}
+	case http.StatusNotFound:
+		caps = capabilities.Legacy()
+	default:
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return fmt.Errorf("internal/agent/clients/capabilities: %w status %s", ErrUnsuccessfulResponse, resp.Status)
+	}
+
+	if err := caps.Compatible(c.encryptor != nil); err != nil {
+		return err
+	}
+
+	if len(c.secretKey) > 0 && !slices.Contains(caps.Signatures, capabilities.SignatureHMACSHA256) {
+		c.lg.WarnCtx(reqCtx, "server does not verify signatures, reports are signed anyway")
+	}
+
+	c.mu.Lock()
+	c.caps, c.noBatch, c.noCompress = &caps, false, false
+	c.mu.Unlock()
+
+	c.lg.InfoCtx(reqCtx, "capabilities negotiated",
+		zap.String("addr", addr),
+		zap.Bool("batch", c.batchEnabled()),
+		zap.Bool("compression", c.compressionEnabled()),
+		zap.Int("max_batch_size", caps.MaxBatchSize),
+		zap.Int64("max_body_bytes", caps.MaxBodyBytes),
+	)
+
+	return nil
+}
+
+// serverCaps returns capabilities of the server, legacy ones until negotiated
+func (c *Reporter) serverCaps() capabilities.Capabilities {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.caps == nil {
+		return capabilities.Legacy()
+	}
+
+	return *c.caps
+}
+
+// batchEnabled reports whether metrics can be sent with /updates/
+func (c *Reporter) batchEnabled() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return !c.noBatch && (c.caps == nil || c.caps.Has(capabilities.FeatureBatch))
+}
+
+// compressionEnabled reports whether request bodies are compressed, only servers advertising gzip get them
+func (c *Reporter) compressionEnabled() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.compressor != nil && !c.noCompress && c.caps != nil && c.caps.Accepts(capabilities.CompressionGzip)
+}
+
+func (c *Reporter) disableBatch() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.noBatch = true
+}
+
+func (c *Reporter) disableCompression() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.noCompress = true
+}
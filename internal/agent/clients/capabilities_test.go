@@ -0,0 +1,287 @@
+package clients
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/failover"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+	mocks "github.com/vysogota0399/mem_stats_monitoring/internal/mocks/agent/clients"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/capabilities"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/idempotency"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+)
+
+func TestReporter_Negotiate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: 1})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		status          int
+		body            string
+		encrypted       bool
+		wantErr         error
+		wantBatch       bool
+		wantCompression bool
+	}{
+		{
+			name:            "when server advertises capabilities",
+			status:          http.StatusOK,
+			body:            `{"api_versions":["v1"],"features":["batch"],"compression":["gzip"],"max_batch_size":10}`,
+			wantBatch:       true,
+			wantCompression: true,
+		},
+		{
+			name:   "when server does not accept batches",
+			status: http.StatusOK,
+			body:   `{"api_versions":["v1"],"features":[]}`,
+		},
+		{
+			name:      "when server does not advertise capabilities",
+			status:    http.StatusNotFound,
+			wantBatch: true,
+		},
+		{
+			name:    "when api version is not supported",
+			status:  http.StatusOK,
+			body:    `{"api_versions":["v2"],"features":["batch"]}`,
+			wantErr: capabilities.ErrIncompatible,
+		},
+		{
+			name:      "when server does not decrypt bodies",
+			status:    http.StatusOK,
+			body:      `{"api_versions":["v1"],"features":["batch"],"encryption":[]}`,
+			encrypted: true,
+			wantErr:   capabilities.ErrIncompatible,
+		},
+		{
+			name:    "when server failed",
+			status:  http.StatusInternalServerError,
+			wantErr: ErrUnsuccessfulResponse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewMockRequester(ctrl)
+			ips := mocks.NewMockIRealIPHeaderSetter(ctrl)
+			ips.EXPECT().Call(gomock.Any()).Return(nil)
+
+			client.EXPECT().Request(gomock.Any()).DoAndReturn(func(r *http.Request) (*http.Response, error) {
+				assert.Equal(t, http.MethodGet, r.Method)
+				assert.Equal(t, "http://test-server/capabilities", r.URL.String())
+
+				return &http.Response{StatusCode: tt.status, Body: io.NopCloser(bytes.NewBufferString(tt.body))}, nil
+			})
+
+			c := NewCompReporter(
+				"http://test-server",
+				lg,
+				&config.Config{RateLimit: 1, MaxAttempts: 1},
+				client,
+				ips,
+			)
+
+			if tt.encrypted {
+				enc := mocks.NewMockEncryptor(ctrl)
+				enc.EXPECT().Encrypt(gomock.Any()).Return("encrypted", nil)
+				c.encryptor = enc
+			}
+
+			err := c.Negotiate(context.Background())
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantBatch, c.batchEnabled())
+			assert.Equal(t, tt.wantCompression, c.compressionEnabled())
+		})
+	}
+}
+
+func TestReporter_UpdateMetrics_fallback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: 1})
+	require.NoError(t, err)
+
+	type request struct {
+		path     string
+		encoding string
+		key      string
+		body     string
+	}
+
+	var got []request
+	responses := []int{
+		http.StatusUnsupportedMediaType, // compressed batch
+		http.StatusNotFound,             // uncompressed batch
+		http.StatusOK,                   // gauge1
+		http.StatusOK,                   // counter1
+	}
+
+	client := NewMockRequester(ctrl)
+	ips := mocks.NewMockIRealIPHeaderSetter(ctrl)
+	ips.EXPECT().Call(gomock.Any()).Times(len(responses)).Return(nil)
+
+	client.EXPECT().Request(gomock.Any()).Times(len(responses)).DoAndReturn(func(r *http.Request) (*http.Response, error) {
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			require.NoError(t, err)
+			body = zr
+		}
+
+		b, err := io.ReadAll(body)
+		require.NoError(t, err)
+
+		got = append(got, request{
+			path:     r.URL.Path,
+			encoding: r.Header.Get("Content-Encoding"),
+			key:      r.Header.Get(idempotency.Header),
+			body:     string(b),
+		})
+
+		return &http.Response{StatusCode: responses[len(got)-1], Body: io.NopCloser(bytes.NewBuffer(nil))}, nil
+	})
+
+	c := NewCompReporter(
+		"http://test-server",
+		lg,
+		&config.Config{RateLimit: 1, MaxAttempts: 1},
+		client,
+		ips,
+	)
+	c.caps = &capabilities.Capabilities{
+		APIVersions: []string{capabilities.APIVersion},
+		Features:    []string{capabilities.FeatureBatch},
+		Compression: []string{capabilities.CompressionGzip},
+	}
+
+	err = c.UpdateMetrics(idempotency.WithKey(context.Background(), "report"), []*models.Metric{
+		{Name: "gauge1", Type: models.GaugeType, Value: 1.5},
+		{Name: "counter1", Type: models.CounterType, Delta: 4},
+	})
+	require.NoError(t, err)
+
+	batch := `[{"id":"gauge1","type":"gauge","value":1.5},{"id":"counter1","type":"counter","delta":4}]` + "\n"
+	assert.Equal(t, []request{
+		{path: "/updates/", encoding: "gzip", key: "report", body: batch},
+		{path: "/updates/", key: "report", body: batch},
+		{path: "/update/", key: "report/gauge1", body: `{"id":"gauge1","type":"gauge","value":1.5}`},
+		{path: "/update/", key: "report/counter1", body: `{"id":"counter1","type":"counter","delta":4}`},
+	}, got)
+
+	assert.False(t, c.batchEnabled())
+	assert.False(t, c.compressionEnabled())
+}
+
+func TestReporter_renegotiate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: 1})
+	require.NoError(t, err)
+
+	advertised := `{"api_versions":["v1"],"features":["batch"],"compression":["gzip"],"max_batch_size":10}`
+
+	tests := []struct {
+		name      string
+		setup     func(c *Reporter)
+		responses map[string]int // by host and path, 200 when missing
+		sends     int
+		want      []string
+		wantCaps  capabilities.Capabilities
+	}{
+		{
+			name:      "when failed over to another server",
+			setup:     func(c *Reporter) { require.NoError(t, c.Negotiate(context.Background())) },
+			responses: map[string]int{"primary/update/": http.StatusInternalServerError, "secondary/capabilities": http.StatusNotFound},
+			sends:     2,
+			want:      []string{"primary/capabilities", "primary/update/", "secondary/capabilities", "secondary/update/"},
+			wantCaps:  capabilities.Legacy(),
+		},
+		{
+			name: "when startup negotiation failed",
+			setup: func(c *Reporter) {
+				c.negotiation.Negotiated("http://primary", time.Now().Add(-capabilities.RetryInterval), errors.New("timeout"))
+			},
+			sends: 1,
+			want:  []string{"primary/capabilities", "primary/update/"},
+			wantCaps: capabilities.Capabilities{
+				APIVersions:  []string{capabilities.APIVersion},
+				Features:     []string{capabilities.FeatureBatch},
+				Compression:  []string{capabilities.CompressionGzip},
+				MaxBatchSize: 10,
+			},
+		},
+		{
+			name: "when negotiation failed recently",
+			setup: func(c *Reporter) {
+				c.negotiation.Negotiated("http://primary", time.Now(), errors.New("timeout"))
+			},
+			sends:    1,
+			want:     []string{"primary/update/"},
+			wantCaps: capabilities.Legacy(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			client := NewMockRequester(ctrl)
+			client.EXPECT().Request(gomock.Any()).AnyTimes().DoAndReturn(func(r *http.Request) (*http.Response, error) {
+				req := r.URL.Host + r.URL.Path
+				got = append(got, req)
+
+				status, ok := tt.responses[req]
+				if !ok {
+					status = http.StatusOK
+				}
+
+				body := ""
+				if r.URL.Path == "/capabilities" && status == http.StatusOK {
+					body = advertised
+				}
+
+				return &http.Response{StatusCode: status, Body: io.NopCloser(bytes.NewBufferString(body))}, nil
+			})
+
+			ips := mocks.NewMockIRealIPHeaderSetter(ctrl)
+			ips.EXPECT().Call(gomock.Any()).AnyTimes().Return(nil)
+
+			c := NewCompReporter(
+				"http://primary",
+				lg,
+				&config.Config{RateLimit: 1, MaxAttempts: 1},
+				client,
+				ips,
+			).WithServers(failover.NewPool([]string{"http://primary", "http://secondary"}, 1, lg))
+			tt.setup(c)
+
+			for range tt.sends {
+				_ = c.UpdateMetric(context.Background(), models.NewGauge("Alloc", 1))
+			}
+
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantCaps, c.serverCaps())
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package clients
 
-import "net/http"
+import (
+	"crypto/tls"
+	"net/http"
+)
 
 type Default struct {
 	client http.Client
@@ -12,6 +15,16 @@ func NewDefaulut() *Default {
 	}}
 }
 
+// NewTLSClient creates a client which verifies the server and presents the agent certificate from tlsCfg
+func NewTLSClient(tlsCfg *tls.Config) *Default {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsCfg
+
+	return &Default{client: http.Client{
+		Transport: transport,
+	}}
+}
+
 func (http *Default) Request(r *http.Request) (*http.Response, error) {
 	return http.client.Do(r)
 }
@@ -0,0 +1,131 @@
+// Package file implements agent adapters for hosts which can't reach the server.
+// Reports are written as NDJSON records in the format of the server file storage,
+// so the output can be carried over and restored by the server with FILE_STORAGE_PATH.
+package file
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"go.uber.org/zap"
+)
+
+// record is a line of the server file storage
+type record struct {
+	MType  string `json:"type"`
+	MName  string `json:"name"`
+	MValue any    `json:"value"`
+}
+
+// Reporter writes metrics to w. The server restorer keeps the last value of each metric,
+// so counters are written as running totals of the reported deltas, not the deltas themselves.
+// Totals live in memory and start from zero after the agent restart.
+type Reporter struct {
+	mu       sync.Mutex
+	lg       *logging.ZapLogger
+	w        io.Writer
+	counters map[string]int64
+}
+
+// NewReporter creates reporter writing to the rotating file configured by OutputPath
+func NewReporter(cfg *config.Config, lg *logging.ZapLogger) (*Reporter, error) {
+	if cfg.OutputPath == "" {
+		return nil, fmt.Errorf("internal/agent/clients/file/reporter: output path is empty")
+	}
+
+	f, err := NewRotatingFile(cfg.OutputPath, cfg.OutputMaxBytes, cfg.OutputRotateInterval, cfg.OutputGzip, lg)
+	if err != nil {
+		return nil, err
+	}
+
+	return newReporter(f, lg), nil
+}
+
+// NewStdoutReporter creates reporter writing to stdout
+func NewStdoutReporter(lg *logging.ZapLogger) *Reporter {
+	return newReporter(os.Stdout, lg)
+}
+
+func newReporter(w io.Writer, lg *logging.ZapLogger) *Reporter {
+	return &Reporter{
+		lg:       lg,
+		w:        w,
+		counters: make(map[string]int64),
+	}
+}
+
+func (r *Reporter) UpdateMetric(ctx context.Context, m models.Metric) error {
+	return r.write(ctx, []models.Metric{m})
+}
+
+// UpdateMetrics writes the whole batch with a single write call
+func (r *Reporter) UpdateMetrics(ctx context.Context, data []*models.Metric) error {
+	metrics := make([]models.Metric, 0, len(data))
+	for _, m := range data {
+		metrics = append(metrics, *m)
+	}
+
+	return r.write(ctx, metrics)
+}
+
+// Close closes the underlying writer unless it is stdout
+func (r *Reporter) Close() error {
+	if c, ok := r.w.(io.Closer); ok && r.w != os.Stdout {
+		return c.Close()
+	}
+
+	return nil
+}
+
+func (r *Reporter) write(ctx context.Context, metrics []models.Metric) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// totals are committed only after the successful write, failed counters are resent by the agent
+	totals := make(map[string]int64)
+	buff := bytes.Buffer{}
+	enc := json.NewEncoder(&buff)
+
+	for _, m := range metrics {
+		rec := record{MType: m.Type, MName: m.Name}
+
+		switch m.Type {
+		case models.CounterType:
+			total, ok := totals[m.Name]
+			if !ok {
+				total = r.counters[m.Name]
+			}
+			total += m.Delta
+			totals[m.Name] = total
+			rec.MValue = total
+		case models.GaugeType:
+			rec.MValue = m.Value
+		default:
+			return fmt.Errorf("internal/agent/clients/file/reporter: unknown metric type %s", m.Type)
+		}
+
+		if err := enc.Encode(rec); err != nil {
+			return fmt.Errorf("internal/agent/clients/file/reporter: encode record error %w", err)
+		}
+	}
+
+	if _, err := r.w.Write(buff.Bytes()); err != nil {
+		return fmt.Errorf("internal/agent/clients/file/reporter: write records error %w", err)
+	}
+
+	for name, total := range totals {
+		r.counters[name] = total
+	}
+
+	r.lg.DebugCtx(ctx, "metrics written", zap.Int("count", len(metrics)))
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk is full")
+}
+
+func TestReporter_UpdateMetrics(t *testing.T) {
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+	require.NoError(t, err)
+
+	buff := &bytes.Buffer{}
+	r := newReporter(buff, lg)
+	ctx := context.Background()
+
+	pollCount := models.NewCounter("PollCount", 2)
+	alloc := models.NewGauge("Alloc", 1.5)
+	require.NoError(t, r.UpdateMetrics(ctx, []*models.Metric{&pollCount, &alloc}))
+	require.NoError(t, r.UpdateMetric(ctx, models.NewCounter("PollCount", 3)))
+
+	// failed write must not change counter totals, the agent resends the delta
+	r.w = failingWriter{}
+	assert.Error(t, r.UpdateMetric(ctx, models.NewCounter("PollCount", 100)))
+	r.w = buff
+
+	require.NoError(t, r.UpdateMetric(ctx, models.NewCounter("PollCount", 100)))
+
+	assert.Equal(t, []map[string]any{
+		{"type": "counter", "name": "PollCount", "value": float64(2)},
+		{"type": "gauge", "name": "Alloc", "value": 1.5},
+		{"type": "counter", "name": "PollCount", "value": float64(5)},
+		{"type": "counter", "name": "PollCount", "value": float64(105)},
+	}, readRecords(t, buff))
+}
+
+func TestReporter_UpdateMetric_unknownType(t *testing.T) {
+	r := newReporter(&bytes.Buffer{}, nil)
+
+	assert.Error(t, r.UpdateMetric(context.Background(), models.Metric{Name: "m", Type: "histogram"}))
+}
+
+func TestNewReporter(t *testing.T) {
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+	require.NoError(t, err)
+
+	_, err = NewReporter(&config.Config{}, lg)
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "metrics.ndjson")
+	r, err := NewReporter(&config.Config{OutputPath: path}, lg)
+	require.NoError(t, err)
+
+	require.NoError(t, r.UpdateMetric(context.Background(), models.NewGauge("Alloc", 1)))
+	require.NoError(t, r.Close())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]any{
+		{"type": "gauge", "name": "Alloc", "value": float64(1)},
+	}, readRecords(t, bytes.NewBuffer(content)))
+}
+
+func readRecords(t *testing.T, buff *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var records []map[string]any
+	scanner := bufio.NewScanner(buff)
+	for scanner.Scan() {
+		var rec map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &rec))
+		records = append(records, rec)
+	}
+
+	return records
+}
@@ -0,0 +1,189 @@
+package file
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"go.uber.org/zap"
+)
+
+const (
+	rwfmode = 0644
+
+	// rotatedSuffixLayout is appended to the path of rotated files
+	rotatedSuffixLayout = "20060102T150405.000000000"
+)
+
+// RotatingFile is an append-only file which is rotated when it grows over max bytes or gets older than interval.
+// Rotated files are renamed to <path>.<timestamp> and optionally compressed to <path>.<timestamp>.gz.
+// Failed rotation is logged and writing continues to <path>, the rotated file is kept uncompressed when gzip fails.
+type RotatingFile struct {
+	mu       sync.Mutex
+	lg       *logging.ZapLogger
+	path     string
+	maxBytes int64
+	interval time.Duration
+	compress bool
+	now      func() time.Time
+
+	f        *os.File
+	closed   bool
+	size     int64
+	openedAt time.Time
+}
+
+// NewRotatingFile opens or creates file for appending. Zero maxBytes or interval disables the corresponding rotation.
+func NewRotatingFile(path string, maxBytes int64, interval time.Duration, compress bool, lg *logging.ZapLogger) (*RotatingFile, error) {
+	rf := &RotatingFile{
+		lg:       lg,
+		path:     path,
+		maxBytes: maxBytes,
+		interval: interval,
+		compress: compress,
+		now:      time.Now,
+	}
+
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+
+	return rf, nil
+}
+
+// Write appends p to the current file, the file is rotated before the write if needed.
+// p is never split between files. The file which failed to reopen after the rotation is reopened on the next write.
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.closed {
+		return 0, os.ErrClosed
+	}
+
+	if rf.f != nil && rf.shouldRotate(int64(len(p))) {
+		rf.rotate()
+	}
+
+	if rf.f == nil {
+		if err := rf.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := rf.f.Write(p)
+	rf.size += int64(n)
+	if err != nil {
+		return n, fmt.Errorf("internal/agent/clients/file/rotating: write error %w", err)
+	}
+
+	return n, nil
+}
+
+// Close closes the current file
+func (rf *RotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	rf.closed = true
+	if rf.f == nil {
+		return nil
+	}
+
+	err := rf.f.Close()
+	rf.f = nil
+
+	return err
+}
+
+func (rf *RotatingFile) shouldRotate(n int64) bool {
+	if rf.size == 0 {
+		return false
+	}
+
+	if rf.maxBytes > 0 && rf.size+n > rf.maxBytes {
+		return true
+	}
+
+	return rf.interval > 0 && rf.now().Sub(rf.openedAt) >= rf.interval
+}
+
+func (rf *RotatingFile) open() error {
+	f, err := os.OpenFile(rf.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, rwfmode)
+	if err != nil {
+		return fmt.Errorf("internal/agent/clients/file/rotating: open file error %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("internal/agent/clients/file/rotating: stat file error %w", err)
+	}
+
+	rf.f = f
+	rf.size = info.Size()
+	rf.openedAt = rf.now()
+
+	return nil
+}
+
+// rotate renames the current file and reopens the path. Errors are logged, since the path is reopened anyway:
+// the current file keeps growing when it can't be renamed, the rotated file is kept as is when it can't be compressed.
+func (rf *RotatingFile) rotate() {
+	if err := rf.f.Close(); err != nil {
+		rf.lg.ErrorCtx(context.Background(), "failed to close file before rotation", zap.String("path", rf.path), zap.Error(err))
+	}
+	rf.f = nil
+
+	rotated := rf.path + "." + rf.now().UTC().Format(rotatedSuffixLayout)
+	if err := os.Rename(rf.path, rotated); err != nil {
+		rf.lg.ErrorCtx(context.Background(), "failed to rotate file", zap.String("path", rf.path), zap.Error(err))
+	} else if rf.compress {
+		if err := compressFile(rotated); err != nil {
+			rf.lg.ErrorCtx(context.Background(), "failed to compress rotated file", zap.String("path", rotated), zap.Error(err))
+		}
+	}
+
+	if err := rf.open(); err != nil {
+		rf.lg.ErrorCtx(context.Background(), "failed to reopen file after rotation", zap.String("path", rf.path), zap.Error(err))
+	}
+}
+
+func compressFile(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("internal/agent/clients/file/rotating: open rotated file error %w", err)
+	}
+	defer func() {
+		if closeErr := src.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, rwfmode)
+	if err != nil {
+		return fmt.Errorf("internal/agent/clients/file/rotating: create gzip file error %w", err)
+	}
+
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		_ = dst.Close()
+		return fmt.Errorf("internal/agent/clients/file/rotating: compress file error %w", err)
+	}
+
+	if err := zw.Close(); err != nil {
+		_ = dst.Close()
+		return fmt.Errorf("internal/agent/clients/file/rotating: compress file error %w", err)
+	}
+
+	if err := dst.Close(); err != nil {
+		return fmt.Errorf("internal/agent/clients/file/rotating: close gzip file error %w", err)
+	}
+
+	return os.Remove(path)
+}
@@ -0,0 +1,188 @@
+package file
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+)
+
+func TestRotatingFile_Write(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxBytes    int64
+		interval    time.Duration
+		compress    bool
+		advance     time.Duration
+		writes      []string
+		wantCurrent string
+		wantRotated []string
+	}{
+		{
+			name:        "no rotation",
+			writes:      []string{"a\n", "b\n"},
+			wantCurrent: "a\nb\n",
+		},
+		{
+			name:        "rotate by size, record is not split",
+			maxBytes:    4,
+			writes:      []string{"a\n", "bb\n", "c\n"},
+			wantCurrent: "c\n",
+			wantRotated: []string{"a\n", "bb\n"},
+		},
+		{
+			name:        "rotate by time",
+			interval:    time.Minute,
+			advance:     time.Minute,
+			writes:      []string{"a\n", "b\n"},
+			wantCurrent: "b\n",
+			wantRotated: []string{"a\n"},
+		},
+		{
+			name:        "gzip rotated",
+			maxBytes:    2,
+			compress:    true,
+			writes:      []string{"a\n", "b\n"},
+			wantCurrent: "b\n",
+			wantRotated: []string{"a\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lg, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+			require.NoError(t, err)
+
+			path := filepath.Join(t.TempDir(), "metrics.ndjson")
+			rf, err := NewRotatingFile(path, tt.maxBytes, tt.interval, tt.compress, lg)
+			require.NoError(t, err)
+
+			now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+			rf.now = func() time.Time { return now }
+			rf.openedAt = now
+
+			for _, w := range tt.writes {
+				_, err := rf.Write([]byte(w))
+				require.NoError(t, err)
+				now = now.Add(tt.advance + time.Nanosecond)
+			}
+			require.NoError(t, rf.Close())
+
+			current, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCurrent, string(current))
+
+			rotated, err := filepath.Glob(path + ".*")
+			require.NoError(t, err)
+			require.Len(t, rotated, len(tt.wantRotated))
+
+			for i, p := range rotated {
+				assert.Equal(t, tt.compress, filepath.Ext(p) == ".gz")
+				assert.Equal(t, tt.wantRotated[i], readRotated(t, p))
+			}
+		})
+	}
+}
+
+func TestRotatingFile_appendsExisting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.ndjson")
+	require.NoError(t, os.WriteFile(path, []byte("a\n"), rwfmode))
+
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+	require.NoError(t, err)
+
+	rf, err := NewRotatingFile(path, 3, 0, false, lg)
+	require.NoError(t, err)
+
+	_, err = rf.Write([]byte("b\n"))
+	require.NoError(t, err)
+	require.NoError(t, rf.Close())
+
+	rotated, err := filepath.Glob(path + ".*")
+	require.NoError(t, err)
+	assert.Len(t, rotated, 1)
+
+	_, err = rf.Write([]byte("c\n"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+}
+
+func TestRotatingFile_rotationFails(t *testing.T) {
+	tests := []struct {
+		name        string
+		compress    bool
+		occupy      string
+		wantCurrent string
+		wantRotated string
+	}{
+		{
+			name:        "rename fails, current file keeps growing",
+			wantCurrent: "a\nb\n",
+		},
+		{
+			name:        "gzip fails, rotated file is kept uncompressed",
+			compress:    true,
+			occupy:      ".gz",
+			wantCurrent: "b\n",
+			wantRotated: "a\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lg, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+			require.NoError(t, err)
+
+			path := filepath.Join(t.TempDir(), "metrics.ndjson")
+			rf, err := NewRotatingFile(path, 2, 0, tt.compress, lg)
+			require.NoError(t, err)
+
+			now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+			rf.now = func() time.Time { return now }
+
+			// the rotated path (or its .gz) is taken by a non-empty directory
+			rotated := path + "." + now.Format(rotatedSuffixLayout)
+			require.NoError(t, os.MkdirAll(filepath.Join(rotated+tt.occupy, "busy"), 0755))
+
+			for _, w := range []string{"a\n", "b\n"} {
+				_, err := rf.Write([]byte(w))
+				require.NoError(t, err)
+			}
+			require.NoError(t, rf.Close())
+
+			current, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCurrent, string(current))
+
+			if tt.wantRotated != "" {
+				assert.Equal(t, tt.wantRotated, readRotated(t, rotated))
+			}
+		})
+	}
+}
+
+func readRotated(t *testing.T, path string) string {
+	t.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	var r io.Reader = f
+	if filepath.Ext(path) == ".gz" {
+		zr, err := gzip.NewReader(f)
+		require.NoError(t, err)
+		r = zr
+	}
+
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+
+	return string(b)
+}
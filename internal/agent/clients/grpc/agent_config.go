@@ -0,0 +1,37 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/remoteconfig"
+	"github.com/vysogota0399/mem_stats_monitoring/pkg/gen/services/metrics"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ remoteconfig.Fetcher = (*Reporter)(nil)
+
+// FetchAgentConfig fetches the config profile assigned to the agent, remoteconfig.ErrNoProfile is returned
+// when the server has no profile for the agent
+func (r *Reporter) FetchAgentConfig(ctx context.Context, req remoteconfig.Request) (remoteconfig.Profile, error) {
+	resp, err := r.client.AgentConfig(ctx, &metrics.AgentConfigParams{
+		AgentId:        req.AgentID,
+		Host:           req.Host,
+		RunningVersion: req.RunningVersion,
+		ApplyError:     req.ApplyError,
+	})
+	if status.Code(err) == codes.NotFound {
+		return remoteconfig.Profile{}, remoteconfig.ErrNoProfile
+	}
+
+	if err != nil {
+		return remoteconfig.Profile{}, fmt.Errorf("internal/agent/clients/grpc: fetch agent config error %w", err)
+	}
+
+	return remoteconfig.Profile{
+		Name:    resp.GetProfile(),
+		Version: resp.GetVersion(),
+		Config:  resp.GetConfig(),
+	}, nil
+}
@@ -0,0 +1,120 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/capabilities"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Negotiate fetches capabilities of the server and adapts reporting to them, servers which don't implement
+// Capabilities rpc are treated as legacy ones. capabilities.ErrIncompatible is returned when the server
+// can't accept reports of the agent. Legacy capabilities are used when the negotiation fails, it is retried
+// before the reports and when the agent fails over to another server.
+func (r *Reporter) Negotiate(ctx context.Context) error {
+	addr := r.serverAddress()
+
+	err := r.negotiate(ctx, addr)
+	r.negotiation.Negotiated(addr, time.Now(), err)
+	if err != nil {
+		r.mu.Lock()
+		r.caps, r.noBatch = nil, false
+		r.mu.Unlock()
+	}
+
+	return err
+}
+
+// renegotiate negotiates capabilities again when the agent failed over to another server or the previous
+// negotiation failed
+func (r *Reporter) renegotiate(ctx context.Context) {
+	if !r.negotiation.Due(r.serverAddress(), time.Now()) {
+		return
+	}
+
+	if err := r.Negotiate(ctx); err != nil {
+		r.lg.WarnCtx(ctx, "failed to negotiate capabilities, legacy protocol is used", zap.Error(err))
+	}
+}
+
+// serverAddress returns address of the current server, empty without the servers pool
+func (r *Reporter) serverAddress() string {
+	if r.servers == nil {
+		return ""
+	}
+
+	return r.servers.Current()
+}
+
+func (r *Reporter) negotiate(ctx context.Context, addr string) error {
+	var caps capabilities.Capabilities
+
+	resp, err := r.client.Capabilities(ctx, &emptypb.Empty{})
+	switch status.Code(err) {
+	case codes.OK:
+		caps = capabilities.Capabilities{
+			APIVersions:  resp.GetApiVersions(),
+			Features:     resp.GetFeatures(),
+			Encodings:    resp.GetEncodings(),
+			Compression:  resp.GetCompression(),
+			Encryption:   resp.GetEncryption(),
+			Signatures:   resp.GetSignatures(),
+			MaxBatchSize: int(resp.GetMaxBatchSize()),
+			MaxBodyBytes: resp.GetMaxBodyBytes(),
+			GRPCPort:     resp.GetGrpcPort(),
+		}
+	case codes.Unimplemented:
+		caps = capabilities.Legacy()
+	default:
+		return fmt.Errorf("internal/agent/clients/grpc: fetch capabilities error %w", err)
+	}
+
+	if err := caps.Compatible(false); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.caps, r.noBatch = &caps, false
+	r.mu.Unlock()
+
+	r.lg.InfoCtx(ctx, "capabilities negotiated",
+		zap.String("addr", addr),
+		zap.Bool("batch", r.batchEnabled()),
+		zap.Int("max_batch_size", caps.MaxBatchSize),
+		zap.Int64("max_body_bytes", caps.MaxBodyBytes),
+	)
+
+	return nil
+}
+
+// serverCaps returns capabilities of the server, legacy ones until negotiated
+func (r *Reporter) serverCaps() capabilities.Capabilities {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.caps == nil {
+		return capabilities.Legacy()
+	}
+
+	return *r.caps
+}
+
+// batchEnabled reports whether metrics can be sent with UpdateBatch rpc
+func (r *Reporter) batchEnabled() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return !r.noBatch && (r.caps == nil || r.caps.Has(capabilities.FeatureBatch))
+}
+
+func (r *Reporter) disableBatch() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.noBatch = true
+}
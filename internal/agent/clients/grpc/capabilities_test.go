@@ -0,0 +1,142 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/capabilities"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/idempotency"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"github.com/vysogota0399/mem_stats_monitoring/pkg/gen/services/metrics"
+	"github.com/vysogota0399/mem_stats_monitoring/pkg/gen/services/metrics/mocks"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestReporter_Negotiate(t *testing.T) {
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: -1})
+	require.NoError(t, err)
+
+	cntr := gomock.NewController(t)
+	defer cntr.Finish()
+
+	tests := []struct {
+		name         string
+		resp         *metrics.CapabilitiesResponse
+		err          error
+		wantErr      error
+		wantBatch    bool
+		wantMaxBatch int
+	}{
+		{
+			name:         "when server advertises capabilities",
+			resp:         &metrics.CapabilitiesResponse{ApiVersions: []string{"v1"}, Features: []string{"batch"}, MaxBatchSize: 10},
+			wantBatch:    true,
+			wantMaxBatch: 10,
+		},
+		{
+			name: "when server does not accept batches",
+			resp: &metrics.CapabilitiesResponse{ApiVersions: []string{"v1"}},
+		},
+		{
+			name:      "when server does not implement capabilities",
+			err:       status.Error(codes.Unimplemented, "unknown method"),
+			wantBatch: true,
+		},
+		{
+			name:    "when api version is not supported",
+			resp:    &metrics.CapabilitiesResponse{ApiVersions: []string{"v2"}},
+			wantErr: capabilities.ErrIncompatible,
+		},
+		{
+			name:    "when server is unavailable",
+			err:     status.Error(codes.Unavailable, "connection refused"),
+			wantErr: status.Error(codes.Unavailable, "connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := mocks.NewMockMetricsServiceClient(cntr)
+			client.EXPECT().Capabilities(gomock.Any(), gomock.Any()).Return(tt.resp, tt.err)
+
+			r := &Reporter{client: client, lg: lg}
+
+			err := r.Negotiate(context.Background())
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantBatch, r.batchEnabled())
+			assert.Equal(t, tt.wantMaxBatch, r.serverCaps().MaxBatchSize)
+		})
+	}
+}
+
+func TestReporter_UpdateMetrics_fallback(t *testing.T) {
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: -1})
+	require.NoError(t, err)
+
+	cntr := gomock.NewController(t)
+	defer cntr.Finish()
+
+	var keys []string
+
+	client := mocks.NewMockMetricsServiceClient(cntr)
+	client.EXPECT().UpdateBatch(gomock.Any(), gomock.Any()).Return(nil, status.Error(codes.Unimplemented, "unknown method"))
+	client.EXPECT().Update(gomock.Any(), gomock.Any()).Times(2).DoAndReturn(
+		func(ctx context.Context, _ *metrics.UpdateMetricParams, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+			md, _ := metadata.FromOutgoingContext(ctx)
+			keys = append(keys, md.Get(idempotency.MetadataKey)...)
+
+			return nil, nil
+		},
+	)
+
+	r := &Reporter{
+		client: client,
+		lg:     lg,
+	}
+
+	err = r.UpdateMetrics(idempotency.WithKey(context.Background(), "report"), []*models.Metric{
+		{Name: "test1", Type: models.CounterType, Delta: 1},
+		{Name: "test2", Type: models.GaugeType, Value: 2},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"report/test1", "report/test2"}, keys)
+	assert.False(t, r.batchEnabled())
+}
+
+func TestReporter_renegotiate(t *testing.T) {
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: -1})
+	require.NoError(t, err)
+
+	cntr := gomock.NewController(t)
+	defer cntr.Finish()
+
+	client := mocks.NewMockMetricsServiceClient(cntr)
+	gomock.InOrder(
+		client.EXPECT().Capabilities(gomock.Any(), gomock.Any()).Return(
+			&metrics.CapabilitiesResponse{ApiVersions: []string{"v1"}, Features: []string{"batch"}, MaxBatchSize: 10}, nil,
+		),
+		client.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil, nil),
+	)
+
+	r := &Reporter{client: client, lg: lg}
+	r.negotiation.Negotiated("", time.Now().Add(-capabilities.RetryInterval), status.Error(codes.Unavailable, "connection refused"))
+
+	require.NoError(t, r.UpdateMetric(context.Background(), models.NewGauge("Alloc", 1)))
+	assert.Equal(t, 10, r.serverCaps().MaxBatchSize, "failed startup negotiation is retried")
+}
@@ -0,0 +1,183 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/failover"
+	"github.com/vysogota0399/mem_stats_monitoring/pkg/gen/services/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/attributes"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	// failoverScheme is the scheme of the target resolved to the servers of the pool
+	failoverScheme = "failover"
+	// failoverBalancer picks the first healthy ready server of the pool
+	failoverBalancer = "failover_priority"
+
+	failoverTarget        = failoverScheme + ":///metrics"
+	failoverServiceConfig = `{"loadBalancingConfig":[{"` + failoverBalancer + `":{}}]}`
+)
+
+func init() {
+	balancer.Register(base.NewBalancerBuilder(failoverBalancer, failoverPickerBuilder{}, base.Config{}))
+}
+
+type poolKey struct{}
+
+// failoverResolver resolves the target to all servers of the pool, the pool is passed to the balancer
+// with balancer attributes of the addresses
+type failoverResolver struct {
+	servers *failover.Pool
+}
+
+var _ resolver.Builder = (*failoverResolver)(nil)
+
+func newFailoverResolver(servers *failover.Pool) *failoverResolver {
+	return &failoverResolver{servers: servers}
+}
+
+func (r *failoverResolver) Build(_ resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	addrs := make([]resolver.Address, 0, len(r.servers.Addresses()))
+	for _, addr := range r.servers.Addresses() {
+		addrs = append(addrs, resolver.Address{
+			Addr:               addr,
+			BalancerAttributes: attributes.New(poolKey{}, r.servers),
+		})
+	}
+
+	if err := cc.UpdateState(resolver.State{Addresses: addrs}); err != nil {
+		return nil, fmt.Errorf("internal/agent/clients/grpc/failover: update resolver state error %w", err)
+	}
+
+	return staticResolver{}, nil
+}
+
+func (r *failoverResolver) Scheme() string {
+	return failoverScheme
+}
+
+// staticResolver has nothing to resolve again, the list of servers comes from config
+type staticResolver struct{}
+
+func (staticResolver) ResolveNow(resolver.ResolveNowOptions) {}
+func (staticResolver) Close()                                {}
+
+type failoverPickerBuilder struct{}
+
+func (failoverPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+
+	p := &failoverPicker{subConns: make(map[string]balancer.SubConn, len(info.ReadySCs))}
+	for sc, sci := range info.ReadySCs {
+		p.subConns[sci.Address.Addr] = sc
+		if servers, ok := sci.Address.BalancerAttributes.Value(poolKey{}).(*failover.Pool); ok {
+			p.servers = servers
+		}
+	}
+
+	return p
+}
+
+// failoverPicker picks ready connection to the first healthy server in priority order,
+// results of the calls are reported to the pool
+type failoverPicker struct {
+	servers  *failover.Pool
+	subConns map[string]balancer.SubConn
+}
+
+func (p *failoverPicker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	addr, sc := p.pick()
+	if sc == nil {
+		return balancer.PickResult{}, balancer.ErrNoSubConnAvailable
+	}
+
+	return balancer.PickResult{
+		SubConn: sc,
+		Done: func(di balancer.DoneInfo) {
+			if p.servers == nil {
+				return
+			}
+
+			if isServerFailure(di.Err) {
+				p.servers.ReportFailure(addr)
+				return
+			}
+
+			p.servers.ReportSuccess(addr)
+		},
+	}, nil
+}
+
+func (p *failoverPicker) pick() (string, balancer.SubConn) {
+	if p.servers == nil {
+		for addr, sc := range p.subConns {
+			return addr, sc
+		}
+	}
+
+	var (
+		fallbackAddr string
+		fallback     balancer.SubConn
+	)
+
+	for _, addr := range p.servers.Addresses() {
+		sc, ok := p.subConns[addr]
+		if !ok {
+			continue
+		}
+
+		if p.servers.Healthy(addr) {
+			return addr, sc
+		}
+
+		if fallback == nil {
+			fallbackAddr, fallback = addr, sc
+		}
+	}
+
+	return fallbackAddr, fallback
+}
+
+// isServerFailure reports whether err is caused by the server state, not by the request
+func isServerFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Unknown:
+		return true
+	default:
+		return false
+	}
+}
+
+// pingProber checks the server with Ping rpc over a dedicated connection
+func pingProber(ctx context.Context, addr string) (err error) {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return fmt.Errorf("internal/agent/clients/grpc/failover: create probe client error %w", err)
+	}
+	defer func() {
+		if closeErr := conn.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	if _, err := metrics.NewMetricsServiceClient(conn).Ping(ctx, &emptypb.Empty{}); err != nil {
+		return fmt.Errorf("internal/agent/clients/grpc/failover: ping error %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"github.com/vysogota0399/mem_stats_monitoring/pkg/gen/services/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type countingServer struct {
+	metrics.UnimplementedMetricsServiceServer
+	updates atomic.Int64
+}
+
+func (s *countingServer) Update(context.Context, *metrics.UpdateMetricParams) (*emptypb.Empty, error) {
+	s.updates.Add(1)
+	return &emptypb.Empty{}, nil
+}
+
+func (s *countingServer) Ping(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, nil
+}
+
+// serve starts grpc server on addr, empty addr means any free port
+func serve(t *testing.T, addr string, h metrics.MetricsServiceServer) (*grpc.Server, string) {
+	t.Helper()
+
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	require.NoError(t, err)
+
+	srv := grpc.NewServer()
+	metrics.RegisterMetricsServiceServer(srv, h)
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(srv.Stop)
+
+	return srv, ln.Addr().String()
+}
+
+func TestReporter_failover(t *testing.T) {
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+	require.NoError(t, err)
+
+	primary, secondary := &countingServer{}, &countingServer{}
+	primarySrv, primaryAddr := serve(t, "", primary)
+	_, secondaryAddr := serve(t, "", secondary)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, err := NewReporter(ctx, &config.Config{
+		GRPCAddresses:     []string{primaryAddr, secondaryAddr},
+		FailoverThreshold: 1,
+		MaxAttempts:       3,
+	}, lg)
+	require.NoError(t, err)
+
+	update := func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		return r.UpdateMetric(ctx, models.NewGauge("Alloc", 1))
+	}
+
+	assert.Eventually(t, func() bool {
+		return update() == nil && primary.updates.Load() > 0
+	}, 5*time.Second, 10*time.Millisecond, "reports go to the primary server")
+
+	primarySrv.Stop()
+	assert.Eventually(t, func() bool {
+		return update() == nil && secondary.updates.Load() > 0
+	}, 5*time.Second, 10*time.Millisecond, "reports fail over to the secondary server")
+
+	serve(t, primaryAddr, primary)
+	r.servers.Probe(ctx, pingProber)
+	require.True(t, r.servers.Healthy(primaryAddr))
+
+	before := primary.updates.Load()
+	assert.Eventually(t, func() bool {
+		return update() == nil && primary.updates.Load() > before
+	}, 10*time.Second, 50*time.Millisecond, "reports fail back to the primary server")
+}
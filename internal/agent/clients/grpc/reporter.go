@@ -3,31 +3,72 @@ package grpc
 import (
 	"context"
 	"fmt"
-	"strconv"
+	"sync"
+	"time"
 
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/retry"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/agent"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/failover"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/capabilities"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/idempotency"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
 	"github.com/vysogota0399/mem_stats_monitoring/pkg/gen/entities"
 	"github.com/vysogota0399/mem_stats_monitoring/pkg/gen/services/metrics"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
 )
 
 type Reporter struct {
-	client metrics.MetricsServiceClient
-	lg     *logging.ZapLogger
-	rep    *agent.MetricsRepository
+	client        metrics.MetricsServiceClient
+	lg            *logging.ZapLogger
+	semaphore     chan struct{}
+	maxBatchSize  int
+	maxBatchBytes int
+	servers       *failover.Pool
+	negotiation   capabilities.Session
+
+	mu      sync.RWMutex
+	caps    *capabilities.Capabilities // advertised by the server, nil until negotiated
+	noBatch bool                       // the server doesn't implement UpdateBatch rpc
 }
 
-func NewReporter(ctx context.Context, cfg *config.Config, rep *agent.MetricsRepository, lg *logging.ZapLogger) (*Reporter, error) {
-	conn, err := grpc.NewClient(":"+cfg.GRPCPort, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// retryBackoff is a delay between attempts to send a chunk
+const retryBackoff = 500 * time.Millisecond
+
+func NewReporter(ctx context.Context, cfg *config.Config, lg *logging.ZapLogger) (*Reporter, error) {
+	var retries uint
+	if cfg.MaxAttempts > 1 {
+		retries = uint(cfg.MaxAttempts - 1)
+	}
+
+	servers := failover.NewPool(grpcAddresses(cfg), cfg.FailoverThreshold, lg)
+
+	conn, err := grpc.NewClient(
+		failoverTarget,
+		grpc.WithResolvers(newFailoverResolver(servers)),
+		grpc.WithDefaultServiceConfig(failoverServiceConfig),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(
+			retry.UnaryClientInterceptor(
+				retry.WithMax(retries),
+				retry.WithBackoff(retry.BackoffExponential(retryBackoff)),
+			),
+		),
+	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to server %s: %w", cfg.GRPCPort, err)
+		return nil, fmt.Errorf("failed to connect to servers %v: %w", servers.Addresses(), err)
 	}
 
+	go servers.Start(ctx, pingProber, cfg.FailbackInterval)
+
 	go func() {
 		<-ctx.Done()
 		lg.InfoCtx(ctx, "GC grpc client")
@@ -36,15 +77,25 @@ func NewReporter(ctx context.Context, cfg *config.Config, rep *agent.MetricsRepo
 		}
 	}()
 
-	return &Reporter{
-		client: metrics.NewMetricsServiceClient(conn),
-		lg:     lg,
-		rep:    rep,
-	}, nil
+	reporter := &Reporter{
+		client:        metrics.NewMetricsServiceClient(conn),
+		lg:            lg,
+		maxBatchSize:  cfg.MaxBatchSize,
+		maxBatchBytes: cfg.MaxBatchBytes,
+		servers:       servers,
+	}
+
+	if cfg.RateLimit > 0 {
+		reporter.semaphore = make(chan struct{}, cfg.RateLimit)
+	}
+
+	return reporter, nil
 }
 
-func (r *Reporter) UpdateMetric(ctx context.Context, mType, mName, value string) error {
-	item, err := metricToItem(mType, mName, value)
+func (r *Reporter) UpdateMetric(ctx context.Context, m models.Metric) error {
+	r.renegotiate(ctx)
+
+	item, err := MetricToItem(m)
 	if err != nil {
 		return fmt.Errorf("failed to convert metric to item: %w", err)
 	}
@@ -53,7 +104,7 @@ func (r *Reporter) UpdateMetric(ctx context.Context, mType, mName, value string)
 		Item: item,
 	}
 
-	_, err = r.client.Update(ctx, in)
+	_, err = r.client.Update(withIdempotencyKey(ctx), in)
 	if err != nil {
 		return fmt.Errorf("failed to update metric: %w", err)
 	}
@@ -61,23 +112,73 @@ func (r *Reporter) UpdateMetric(ctx context.Context, mType, mName, value string)
 	return nil
 }
 
+// UpdateMetrics sends a batch of metric updates to the server.
+// The batch is split into chunks limited by max batch size and max message size, chunks are sent concurrently
+// within the rate limit. If some of the chunks fail, *agent.ChunksError with failed chunks only is returned.
+// When the server doesn't accept batches, metrics are sent one by one.
 func (r *Reporter) UpdateMetrics(ctx context.Context, data []*models.Metric) error {
-	metricsBody := make([]*metrics.Item, 0, len(data))
+	r.renegotiate(ctx)
+
+	items := make([]*metrics.Item, 0, len(data))
 	for _, m := range data {
-		name, mType, value := r.rep.SafeRead(m)
-		item, err := metricToItem(mType, name, value)
+		item, err := MetricToItem(*m)
 		if err != nil {
 			return fmt.Errorf("failed to convert metric to item: %w", err)
 		}
 
-		metricsBody = append(metricsBody, item)
+		items = append(items, item)
+	}
+
+	caps := r.serverCaps()
+	batch := r.batchEnabled()
+	maxSize := capabilities.Limit(r.maxBatchSize, caps.MaxBatchSize)
+	if !batch {
+		maxSize = 1
+	}
+
+	chunks := agent.SplitBatch(ctx, data, maxSize, capabilities.Limit(r.maxBatchBytes, int(caps.MaxBodyBytes)), 0, func(i int) int {
+		return protowire.SizeTag(itemFieldNumber) + protowire.SizeBytes(proto.Size(items[i]))
+	})
+
+	return agent.SendChunks(ctx, chunks, func(ctx context.Context, ch agent.Chunk) error {
+		if !batch {
+			return r.sendItems(ctx, items[ch.From:ch.To])
+		}
+
+		return r.sendBatch(ctx, items[ch.From:ch.To])
+	})
+}
+
+// grpcAddresses returns grpc servers in priority order, local server on GRPCPort when the list is not configured
+func grpcAddresses(cfg *config.Config) []string {
+	if len(cfg.GRPCAddresses) > 0 {
+		return cfg.GRPCAddresses
+	}
+
+	return []string{":" + cfg.GRPCPort}
+}
+
+// itemFieldNumber is the number of the item field in UpdateMetricsBatchParams message
+const itemFieldNumber = 1
+
+func (r *Reporter) sendBatch(ctx context.Context, items []*metrics.Item) error {
+	if r.semaphore != nil {
+		r.semaphore <- struct{}{}
+		defer func() { <-r.semaphore }()
 	}
 
 	in := &metrics.UpdateMetricsBatchParams{
-		Item: metricsBody,
+		Item: items,
+	}
+
+	_, err := r.client.UpdateBatch(withIdempotencyKey(ctx), in)
+	if status.Code(err) == codes.Unimplemented {
+		r.lg.InfoCtx(ctx, "server does not accept batches, metrics are sent one by one")
+		r.disableBatch()
+
+		return r.sendItems(ctx, items)
 	}
 
-	_, err := r.client.UpdateBatch(ctx, in)
 	if err != nil {
 		return fmt.Errorf("failed to update metrics: %w", err)
 	}
@@ -85,40 +186,65 @@ func (r *Reporter) UpdateMetrics(ctx context.Context, data []*models.Metric) err
 	return nil
 }
 
-func metricToItem(mType, mName, value string) (*metrics.Item, error) {
-	var item *metrics.Item
-	switch mType {
-	case models.GaugeType:
-		gauge, err := strconv.ParseFloat(value, 64)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse gauge: %w", err)
+// sendItems sends metrics one by one, idempotency keys of the metrics are derived from the chunk key
+func (r *Reporter) sendItems(ctx context.Context, items []*metrics.Item) error {
+	key := idempotency.KeyFromContext(ctx)
+
+	for _, item := range items {
+		itemCtx := idempotency.WithKey(ctx, idempotency.MetricKey(key, itemName(item), len(items)))
+
+		if _, err := r.client.Update(withIdempotencyKey(itemCtx), &metrics.UpdateMetricParams{Item: item}); err != nil {
+			return fmt.Errorf("failed to update metric: %w", err)
 		}
+	}
+
+	return nil
+}
+
+// itemName returns name of the gauge or counter item
+func itemName(item *metrics.Item) string {
+	if c := item.GetCounter(); c != nil {
+		return c.GetName()
+	}
+
+	return item.GetGauge().GetName()
+}
 
+// MetricToItem converts the agent metric to the protobuf message item
+func MetricToItem(m models.Metric) (*metrics.Item, error) {
+	var item *metrics.Item
+	switch m.Type {
+	case models.GaugeType:
 		item = &metrics.Item{
 			Metric: &metrics.Item_Gauge{
 				Gauge: &entities.Gauge{
-					Value: gauge,
-					Name:  mName,
+					Value: m.Value,
+					Name:  m.Name,
 				},
 			},
 		}
 	case models.CounterType:
-		counter, err := strconv.ParseInt(value, 10, 64)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse counter: %w", err)
-		}
-
 		item = &metrics.Item{
 			Metric: &metrics.Item_Counter{
 				Counter: &entities.Counter{
-					Value: counter,
-					Name:  mName,
+					Value: m.Delta,
+					Name:  m.Name,
 				},
 			},
 		}
 	default:
-		return nil, fmt.Errorf("unknown metric type: %s", mType)
+		return nil, fmt.Errorf("unknown metric type: %s", m.Type)
 	}
 
 	return item, nil
 }
+
+// withIdempotencyKey passes idempotency key of the context to the server with outgoing metadata
+func withIdempotencyKey(ctx context.Context) context.Context {
+	key := idempotency.KeyFromContext(ctx)
+	if key == "" {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, idempotency.MetadataKey, key)
+}
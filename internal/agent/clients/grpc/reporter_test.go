@@ -3,6 +3,7 @@ package grpc
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -10,16 +11,17 @@ import (
 	"github.com/vysogota0399/mem_stats_monitoring/internal/agent"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
-	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/storage"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"github.com/vysogota0399/mem_stats_monitoring/pkg/gen/services/metrics"
 	"github.com/vysogota0399/mem_stats_monitoring/pkg/gen/services/metrics/mocks"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 func TestNewReporter(t *testing.T) {
 	type args struct {
 		ctx context.Context
 		cfg *config.Config
-		rep *agent.MetricsRepository
 	}
 	tests := []struct {
 		name    string
@@ -34,7 +36,6 @@ func TestNewReporter(t *testing.T) {
 				cfg: &config.Config{
 					GRPCPort: "3200",
 				},
-				rep: agent.NewMetricsRepository(storage.NewMemoryStorage(nil)),
 			},
 			wantErr: false,
 		},
@@ -47,7 +48,7 @@ func TestNewReporter(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
-			got, err := NewReporter(ctx, tt.args.cfg, tt.args.rep, lg)
+			got, err := NewReporter(ctx, tt.args.cfg, lg)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("NewReporter() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -58,7 +59,6 @@ func TestNewReporter(t *testing.T) {
 				assert.NotNil(t, got)
 				assert.NotNil(t, got.client)
 				assert.Equal(t, lg, got.lg)
-				assert.Equal(t, tt.args.rep, got.rep)
 			}
 		})
 	}
@@ -69,10 +69,8 @@ func TestReporter_UpdateMetric(t *testing.T) {
 		client *mocks.MockMetricsServiceClient
 	}
 	type args struct {
-		ctx   context.Context
-		mType string
-		mName string
-		value string
+		ctx context.Context
+		m   models.Metric
 	}
 	tests := []struct {
 		name    string
@@ -85,10 +83,8 @@ func TestReporter_UpdateMetric(t *testing.T) {
 			name:   "successful update counter",
 			fields: fields{},
 			args: args{
-				ctx:   context.Background(),
-				mType: models.CounterType,
-				mName: "test",
-				value: "1",
+				ctx: context.Background(),
+				m:   models.Metric{Name: "test", Type: models.CounterType, Delta: 1},
 			},
 			prepare: func(f *fields) {
 				f.client.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil, nil)
@@ -99,10 +95,8 @@ func TestReporter_UpdateMetric(t *testing.T) {
 			name:   "successful update gauge",
 			fields: fields{},
 			args: args{
-				ctx:   context.Background(),
-				mType: models.GaugeType,
-				mName: "test",
-				value: "1",
+				ctx: context.Background(),
+				m:   models.Metric{Name: "test", Type: models.GaugeType, Value: 1},
 			},
 			prepare: func(f *fields) {
 				f.client.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil, nil)
@@ -113,10 +107,8 @@ func TestReporter_UpdateMetric(t *testing.T) {
 			name:   "failed update metric",
 			fields: fields{},
 			args: args{
-				ctx:   context.Background(),
-				mType: models.CounterType,
-				mName: "test",
-				value: "1",
+				ctx: context.Background(),
+				m:   models.Metric{Name: "test", Type: models.CounterType, Delta: 1},
 			},
 			prepare: func(f *fields) {
 				f.client.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil, errors.New("error"))
@@ -137,10 +129,9 @@ func TestReporter_UpdateMetric(t *testing.T) {
 			r := &Reporter{
 				client: tt.fields.client,
 				lg:     lg,
-				rep:    agent.NewMetricsRepository(storage.NewMemoryStorage(nil)),
 			}
 
-			err := r.UpdateMetric(tt.args.ctx, tt.args.mType, tt.args.mName, tt.args.value)
+			err := r.UpdateMetric(tt.args.ctx, tt.args.m)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -172,12 +163,12 @@ func TestReporter_UpdateMetrics(t *testing.T) {
 					{
 						Name:  "test",
 						Type:  models.CounterType,
-						Value: "1",
+						Delta: 1,
 					},
 					{
 						Name:  "test2",
 						Type:  models.GaugeType,
-						Value: "2",
+						Value: 2,
 					},
 				},
 			},
@@ -194,7 +185,7 @@ func TestReporter_UpdateMetrics(t *testing.T) {
 					{
 						Name:  "test",
 						Type:  models.CounterType,
-						Value: "1",
+						Delta: 1,
 					},
 				},
 			},
@@ -218,7 +209,6 @@ func TestReporter_UpdateMetrics(t *testing.T) {
 			r := &Reporter{
 				client: tt.fields.client,
 				lg:     lg,
-				rep:    agent.NewMetricsRepository(storage.NewMemoryStorage(nil)),
 			}
 			err := r.UpdateMetrics(context.Background(), tt.args.data)
 			if tt.wantErr {
@@ -229,3 +219,73 @@ func TestReporter_UpdateMetrics(t *testing.T) {
 		})
 	}
 }
+
+func TestReporter_UpdateMetrics_chunks(t *testing.T) {
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: -1})
+	assert.NoError(t, err)
+
+	cntr := gomock.NewController(t)
+	defer cntr.Finish()
+
+	data := []*models.Metric{
+		{Name: "test1", Type: models.CounterType, Delta: 1},
+		{Name: "test2", Type: models.GaugeType, Value: 2},
+		{Name: "test3", Type: models.GaugeType, Value: 3},
+	}
+
+	client := mocks.NewMockMetricsServiceClient(cntr)
+	client.EXPECT().UpdateBatch(gomock.Any(), gomock.Any()).Times(2).DoAndReturn(
+		func(_ context.Context, in *metrics.UpdateMetricsBatchParams, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+			assert.LessOrEqual(t, len(in.Item), 2)
+			if in.Item[0].GetGauge().GetName() == "test3" {
+				return nil, errors.New("error")
+			}
+
+			return nil, nil
+		},
+	)
+
+	r := &Reporter{
+		client:       client,
+		lg:           lg,
+		semaphore:    make(chan struct{}, 1),
+		maxBatchSize: 2,
+	}
+
+	err = r.UpdateMetrics(context.Background(), data)
+
+	var chErr *agent.ChunksError
+	assert.ErrorAs(t, err, &chErr)
+	assert.Equal(t, 2, chErr.Total)
+	assert.Equal(t, data[2:], chErr.FailedMetrics())
+}
+
+func BenchmarkReporter_UpdateMetrics(b *testing.B) {
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: 2})
+	assert.NoError(b, err)
+
+	cntr := gomock.NewController(b)
+	defer cntr.Finish()
+
+	client := mocks.NewMockMetricsServiceClient(cntr)
+	client.EXPECT().UpdateBatch(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+
+	r := &Reporter{
+		client: client,
+		lg:     lg,
+	}
+
+	data := make([]*models.Metric, 1000)
+	for i := range data {
+		if i%2 == 0 {
+			data[i] = &models.Metric{Name: fmt.Sprintf("gauge%d", i), Type: models.GaugeType, Value: 123.456}
+		} else {
+			data[i] = &models.Metric{Name: fmt.Sprintf("counter%d", i), Type: models.CounterType, Delta: 42}
+		}
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		assert.NoError(b, r.UpdateMetrics(context.Background(), data))
+	}
+}
@@ -0,0 +1,154 @@
+// Package nats implements agent adapter publishing metric batches to the nats JetStream stream.
+// The stream keeps batches until a server applies them, so reports don't depend on server availability,
+// but they do depend on nats: a batch is reported only when the stream acknowledges it.
+package nats
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/clients/grpc"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/capabilities"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/idempotency"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"github.com/vysogota0399/mem_stats_monitoring/pkg/gen/services/metrics"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// Reporter publishes metrics as protobuf UpdateMetricsBatchParams messages and waits for the stream acknowledgement.
+// The connection is re-established in background, publishing fails while nats is unreachable or the stream
+// doesn't exist yet, so the agent keeps counters of the batch until it is acknowledged.
+type Reporter struct {
+	conn          *nats.Conn
+	js            jetstream.JetStream
+	subject       string
+	lg            *logging.ZapLogger
+	maxBatchSize  int
+	maxBatchBytes int
+}
+
+const (
+	// itemFieldNumber is the number of the item field in UpdateMetricsBatchParams message
+	itemFieldNumber = 1
+
+	// chunkKeySuffix reserves space for the index suffix of chunk idempotency keys
+	chunkKeySuffix = "-9223372036854775807"
+
+	// ackTimeout limits waiting for the stream acknowledgement of a single message
+	ackTimeout = 5 * time.Second
+)
+
+func NewReporter(ctx context.Context, cfg *config.Config, lg *logging.ZapLogger) (*Reporter, error) {
+	conn, err := nats.Connect(
+		cfg.NATSURL,
+		nats.Name("mem_stats_monitoring-agent"),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			lg.WarnCtx(ctx, "nats disconnected", zap.Error(err))
+		}),
+		nats.ReconnectHandler(func(c *nats.Conn) {
+			lg.InfoCtx(ctx, "nats reconnected", zap.String("url", c.ConnectedUrlRedacted()))
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("internal/agent/clients/nats/reporter: connect error %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("internal/agent/clients/nats/reporter: jetstream error %w", err)
+	}
+
+	return &Reporter{
+		conn:          conn,
+		js:            js,
+		subject:       cfg.NATSSubject,
+		lg:            lg,
+		maxBatchSize:  cfg.MaxBatchSize,
+		maxBatchBytes: cfg.MaxBatchBytes,
+	}, nil
+}
+
+func (r *Reporter) UpdateMetric(ctx context.Context, m models.Metric) error {
+	item, err := grpc.MetricToItem(m)
+	if err != nil {
+		return fmt.Errorf("internal/agent/clients/nats/reporter: convert metric error %w", err)
+	}
+
+	return r.publish(ctx, []*metrics.Item{item})
+}
+
+// UpdateMetrics publishes a batch of metric updates. The batch is split into chunks limited by max batch size
+// and max payload of the nats server. If some of the chunks fail, *agent.ChunksError with failed chunks only is returned.
+func (r *Reporter) UpdateMetrics(ctx context.Context, data []*models.Metric) error {
+	items := make([]*metrics.Item, 0, len(data))
+	for _, m := range data {
+		item, err := grpc.MetricToItem(*m)
+		if err != nil {
+			return fmt.Errorf("internal/agent/clients/nats/reporter: convert metric error %w", err)
+		}
+
+		items = append(items, item)
+	}
+
+	// max payload is known once connected, it is 0 and ignored until then
+	maxBytes := capabilities.Limit(r.maxBatchBytes, int(r.conn.MaxPayload()))
+
+	chunks := agent.SplitBatch(ctx, data, r.maxBatchSize, maxBytes, headerSize(idempotency.KeyFromContext(ctx)), func(i int) int {
+		return protowire.SizeTag(itemFieldNumber) + protowire.SizeBytes(proto.Size(items[i]))
+	})
+
+	return agent.SendChunks(ctx, chunks, func(ctx context.Context, ch agent.Chunk) error {
+		return r.publish(ctx, items[ch.From:ch.To])
+	})
+}
+
+// headerSize estimates size of the message header, nats counts it in the max payload.
+// Chunk keys are derived from the report key with the index suffix, it is reserved as well.
+func headerSize(key string) int {
+	if key == "" {
+		return 0
+	}
+
+	return len("NATS/1.0\r\n") + len(idempotency.Header) + len(": ") + len(key) + len(chunkKeySuffix) + len("\r\n\r\n")
+}
+
+// publish sends the items with the idempotency key of the context in the message header and waits for the ack
+func (r *Reporter) publish(ctx context.Context, items []*metrics.Item) error {
+	data, err := proto.Marshal(&metrics.UpdateMetricsBatchParams{Item: items})
+	if err != nil {
+		return fmt.Errorf("internal/agent/clients/nats/reporter: marshal error %w", err)
+	}
+
+	msg := nats.NewMsg(r.subject)
+	msg.Data = data
+	if key := idempotency.KeyFromContext(ctx); key != "" {
+		msg.Header.Set(idempotency.Header, key)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ackTimeout)
+	defer cancel()
+
+	if _, err := r.js.PublishMsg(ctx, msg); err != nil {
+		return fmt.Errorf("internal/agent/clients/nats/reporter: publish error %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the connection, every reported batch is already acknowledged by the stream
+func (r *Reporter) Close() error {
+	r.conn.Close()
+
+	return nil
+}
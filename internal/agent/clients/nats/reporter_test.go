@@ -0,0 +1,245 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	natstest "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/idempotency"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"github.com/vysogota0399/mem_stats_monitoring/pkg/gen/services/metrics"
+	"google.golang.org/protobuf/proto"
+)
+
+const subject = "metrics.updates"
+
+// runServer starts embedded nats server with jetstream on a random port, the stream of the subject is created
+// unless noStream
+func runServer(t *testing.T, maxPayload int32, noStream bool) *server.Server {
+	t.Helper()
+
+	opts := natstest.DefaultTestOptions
+	opts.Port = -1
+	opts.MaxPayload = maxPayload
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+
+	srv := natstest.RunServer(&opts)
+	t.Cleanup(srv.Shutdown)
+
+	if noStream {
+		return srv
+	}
+
+	conn, err := nats.Connect(srv.ClientURL())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	js, err := jetstream.New(conn)
+	require.NoError(t, err)
+
+	_, err = js.CreateStream(context.Background(), jetstream.StreamConfig{Name: "metrics", Subjects: []string{subject}})
+	require.NoError(t, err)
+
+	return srv
+}
+
+// subscribe collects messages published to the subject
+func subscribe(t *testing.T, srv *server.Server) chan *nats.Msg {
+	t.Helper()
+
+	conn, err := nats.Connect(srv.ClientURL())
+	require.NoError(t, err)
+	t.Cleanup(conn.Close)
+
+	msgs := make(chan *nats.Msg, 64)
+	_, err = conn.ChanSubscribe(subject, msgs)
+	require.NoError(t, err)
+	require.NoError(t, conn.Flush())
+
+	return msgs
+}
+
+func newReporter(t *testing.T, srv *server.Server, cfg config.Config) *Reporter {
+	t.Helper()
+
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+	require.NoError(t, err)
+
+	cfg.NATSURL = srv.ClientURL()
+	cfg.NATSSubject = subject
+
+	r, err := NewReporter(context.Background(), &cfg, lg)
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, r.Close()) })
+
+	require.Eventually(t, r.conn.IsConnected, time.Second, 10*time.Millisecond)
+
+	return r
+}
+
+func receive(t *testing.T, msgs chan *nats.Msg) (*metrics.UpdateMetricsBatchParams, nats.Header) {
+	t.Helper()
+
+	select {
+	case msg := <-msgs:
+		params := &metrics.UpdateMetricsBatchParams{}
+		require.NoError(t, proto.Unmarshal(msg.Data, params))
+		return params, msg.Header
+	case <-time.After(time.Second):
+		t.Fatal("message is not published")
+		return nil, nil
+	}
+}
+
+func TestReporter_UpdateMetric(t *testing.T) {
+	tests := []struct {
+		name    string
+		metric  models.Metric
+		key     string
+		wantErr bool
+	}{
+		{
+			name:   "gauge",
+			metric: models.NewGauge("alloc", 1.5),
+			key:    "report-1",
+		},
+		{
+			name:   "counter without idempotency key",
+			metric: models.Metric{Name: "poll_count", Type: models.CounterType, Delta: 3},
+		},
+		{
+			name:    "unknown type",
+			metric:  models.Metric{Name: "x", Type: "unknown"},
+			wantErr: true,
+		},
+	}
+
+	srv := runServer(t, 0, false)
+	msgs := subscribe(t, srv)
+	r := newReporter(t, srv, config.Config{})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.key != "" {
+				ctx = idempotency.WithKey(ctx, tt.key)
+			}
+
+			err := r.UpdateMetric(ctx, tt.metric)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			params, header := receive(t, msgs)
+			require.Len(t, params.Item, 1)
+			assert.Equal(t, tt.key, header.Get(idempotency.Header))
+
+			switch tt.metric.Type {
+			case models.GaugeType:
+				assert.Equal(t, tt.metric.Name, params.Item[0].GetGauge().GetName())
+				assert.Equal(t, tt.metric.Value, params.Item[0].GetGauge().GetValue())
+			case models.CounterType:
+				assert.Equal(t, tt.metric.Name, params.Item[0].GetCounter().GetName())
+				assert.Equal(t, tt.metric.Delta, params.Item[0].GetCounter().GetValue())
+			}
+		})
+	}
+}
+
+func TestReporter_UpdateMetrics(t *testing.T) {
+	batch := func(n int) []*models.Metric {
+		data := make([]*models.Metric, n)
+		for i := range data {
+			m := models.NewGauge(fmt.Sprintf("gauge_%d", i), float64(i))
+			data[i] = &m
+		}
+		return data
+	}
+
+	tests := []struct {
+		name       string
+		cfg        config.Config
+		maxPayload int32
+		data       []*models.Metric
+		wantSizes  []int
+	}{
+		{
+			name:      "single message",
+			data:      batch(5),
+			wantSizes: []int{5},
+		},
+		{
+			name:      "split by max batch size",
+			cfg:       config.Config{MaxBatchSize: 2},
+			data:      batch(5),
+			wantSizes: []int{2, 2, 1},
+		},
+		{
+			name:       "split by max payload of the server",
+			maxPayload: 512,
+			data:       batch(30),
+			wantSizes:  []int{20, 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := runServer(t, tt.maxPayload, false)
+			msgs := subscribe(t, srv)
+			r := newReporter(t, srv, tt.cfg)
+
+			ctx := idempotency.WithKey(context.Background(), "report")
+			require.NoError(t, r.UpdateMetrics(ctx, tt.data))
+
+			sizes := make([]int, 0, len(tt.wantSizes))
+			keys := make(map[string]struct{})
+			for range tt.wantSizes {
+				params, header := receive(t, msgs)
+				sizes = append(sizes, len(params.Item))
+				keys[header.Get(idempotency.Header)] = struct{}{}
+			}
+
+			assert.ElementsMatch(t, tt.wantSizes, sizes)
+			assert.Len(t, keys, len(tt.wantSizes), "chunks must have distinct idempotency keys")
+		})
+	}
+}
+
+func TestReporter_UpdateMetrics_closed(t *testing.T) {
+	srv := runServer(t, 0, false)
+	r := newReporter(t, srv, config.Config{})
+	r.conn.Close()
+
+	m := models.NewGauge("alloc", 1)
+	err := r.UpdateMetrics(context.Background(), []*models.Metric{&m})
+
+	var chunksErr *agent.ChunksError
+	require.True(t, errors.As(err, &chunksErr))
+	assert.ErrorIs(t, chunksErr.Failed[0].Err, nats.ErrConnectionClosed)
+}
+
+func TestReporter_UpdateMetrics_noStream(t *testing.T) {
+	srv := runServer(t, 0, true)
+	r := newReporter(t, srv, config.Config{})
+
+	m := models.NewCounter("poll_count", 1)
+	err := r.UpdateMetrics(context.Background(), []*models.Metric{&m})
+
+	var chunksErr *agent.ChunksError
+	require.True(t, errors.As(err, &chunksErr), "unacknowledged batch must fail")
+	assert.ErrorIs(t, chunksErr.Failed[0].Err, jetstream.ErrNoStreamResponse)
+}
@@ -0,0 +1,116 @@
+package remotewrite
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers of the prometheus.WriteRequest message and its nested messages, remote write spec 1.0
+const (
+	writeRequestTimeseriesField = 1
+
+	timeSeriesLabelsField  = 1
+	timeSeriesSamplesField = 2
+
+	labelNameField  = 1
+	labelValueField = 2
+
+	sampleValueField     = 1
+	sampleTimestampField = 2
+)
+
+// nameLabel is the reserved label with the metric name
+const nameLabel = "__name__"
+
+type label struct {
+	name  string
+	value string
+}
+
+type timeSeries struct {
+	labels    []label
+	value     float64
+	timestamp int64 // milliseconds since epoch
+}
+
+// newLabels builds sorted series labels, the metric name label is added to the static labels from config
+func newLabels(name string, static []label) []label {
+	labels := make([]label, 0, len(static)+1)
+	labels = append(labels, label{name: nameLabel, value: sanitizeName(name)})
+	labels = append(labels, static...)
+
+	sort.Slice(labels, func(i, j int) bool { return labels[i].name < labels[j].name })
+
+	return labels
+}
+
+// staticLabels converts labels from config, invalid label names are sanitized
+func staticLabels(cfg map[string]string) []label {
+	labels := make([]label, 0, len(cfg))
+	for name, value := range cfg {
+		if name = sanitizeName(name); name == nameLabel {
+			continue
+		}
+
+		labels = append(labels, label{name: name, value: value})
+	}
+
+	return labels
+}
+
+// sanitizeName replaces characters which are not allowed in prometheus metric and label names
+func sanitizeName(name string) string {
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_', r == ':':
+			return r
+		case r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// encodeWriteRequest marshals series into the prometheus.WriteRequest protobuf message
+func encodeWriteRequest(series []timeSeries) []byte {
+	var b []byte
+	for _, ts := range series {
+		b = protowire.AppendTag(b, writeRequestTimeseriesField, protowire.BytesType)
+		b = protowire.AppendBytes(b, encodeTimeSeries(ts))
+	}
+
+	return b
+}
+
+func encodeTimeSeries(ts timeSeries) []byte {
+	var b []byte
+	for _, l := range ts.labels {
+		var lb []byte
+		lb = protowire.AppendTag(lb, labelNameField, protowire.BytesType)
+		lb = protowire.AppendString(lb, l.name)
+		lb = protowire.AppendTag(lb, labelValueField, protowire.BytesType)
+		lb = protowire.AppendString(lb, l.value)
+
+		b = protowire.AppendTag(b, timeSeriesLabelsField, protowire.BytesType)
+		b = protowire.AppendBytes(b, lb)
+	}
+
+	var sb []byte
+	sb = protowire.AppendTag(sb, sampleValueField, protowire.Fixed64Type)
+	sb = protowire.AppendFixed64(sb, math.Float64bits(ts.value))
+	sb = protowire.AppendTag(sb, sampleTimestampField, protowire.VarintType)
+	sb = protowire.AppendVarint(sb, uint64(ts.timestamp))
+
+	b = protowire.AppendTag(b, timeSeriesSamplesField, protowire.BytesType)
+	b = protowire.AppendBytes(b, sb)
+
+	return b
+}
@@ -0,0 +1,194 @@
+// Package remotewrite implements agent adapter for Prometheus compatible remote write receivers.
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/clients"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"go.uber.org/zap"
+)
+
+// ErrRecoverable is returned when the receiver kept failing with a retryable status, the data can be resent later
+var ErrRecoverable = errors.New("remote write: recoverable error")
+
+const (
+	protocolVersion = "0.1.0"
+	userAgent       = "mem_stats_monitoring-agent"
+
+	// maxErrorBody limits the receiver error message written to the log
+	maxErrorBody = 512
+)
+
+// Reporter sends metrics with the remote write protocol. Prometheus counters are cumulative,
+// so counters are sent as running totals of the reported deltas.
+//
+// Per the spec 5xx and 429 responses are retried, other 4xx responses mean the data is malformed
+// and it is dropped without retries.
+type Reporter struct {
+	client      clients.Requester
+	url         string
+	lg          *logging.ZapLogger
+	labels      []label
+	maxAttempts uint8
+	delay       func(attempt uint8) time.Duration
+	now         func() time.Time
+
+	mu       sync.Mutex
+	counters map[string]int64
+}
+
+func NewReporter(cfg *config.Config, lg *logging.ZapLogger, client clients.Requester) (*Reporter, error) {
+	if cfg.RemoteWriteURL == "" {
+		return nil, fmt.Errorf("internal/agent/clients/remotewrite/reporter: remote write url is empty")
+	}
+
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = 1
+	}
+
+	return &Reporter{
+		client:      client,
+		url:         cfg.RemoteWriteURL,
+		lg:          lg,
+		labels:      staticLabels(cfg.RemoteWriteLabels),
+		maxAttempts: maxAttempts,
+		delay:       attemptDelay,
+		now:         time.Now,
+		counters:    make(map[string]int64),
+	}, nil
+}
+
+func (r *Reporter) UpdateMetric(ctx context.Context, m models.Metric) error {
+	return r.write(ctx, []models.Metric{m})
+}
+
+func (r *Reporter) UpdateMetrics(ctx context.Context, data []*models.Metric) error {
+	metrics := make([]models.Metric, 0, len(data))
+	for _, m := range data {
+		metrics = append(metrics, *m)
+	}
+
+	return r.write(ctx, metrics)
+}
+
+func (r *Reporter) write(ctx context.Context, metrics []models.Metric) error {
+	ctx = r.lg.WithContextFields(ctx, zap.String("name", "remote_write"))
+	ctx = context.WithoutCancel(ctx)
+
+	// totals are locked until the request is done, so concurrent writes can't reorder counter samples
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	timestamp := r.now().UnixMilli()
+	totals := make(map[string]int64)
+	series := make([]timeSeries, 0, len(metrics))
+
+	for _, m := range metrics {
+		ts := timeSeries{labels: newLabels(m.Name, r.labels), timestamp: timestamp}
+
+		switch m.Type {
+		case models.GaugeType:
+			ts.value = m.Value
+		case models.CounterType:
+			total, ok := totals[m.Name]
+			if !ok {
+				total = r.counters[m.Name]
+			}
+			total += m.Delta
+			totals[m.Name] = total
+			ts.value = float64(total)
+		default:
+			return fmt.Errorf("internal/agent/clients/remotewrite/reporter: unknown metric type %s", m.Type)
+		}
+
+		series = append(series, ts)
+	}
+
+	body := snappy.Encode(nil, encodeWriteRequest(series))
+	if err := r.send(ctx, body); err != nil {
+		return err
+	}
+
+	for name, total := range totals {
+		r.counters[name] = total
+	}
+
+	return nil
+}
+
+// send posts body until the receiver accepts or rejects it, or attempts are exhausted.
+// Rejected body is dropped, nil is returned since resending it would fail again.
+func (r *Reporter) send(ctx context.Context, body []byte) error {
+	var lastErr error
+
+	for i := range r.maxAttempts {
+		if i > 0 {
+			time.Sleep(r.delay(i - 1))
+		}
+
+		status, msg, err := r.post(ctx, body)
+		switch {
+		case err != nil:
+			lastErr = err
+		case status/100 == 2:
+			return nil
+		case status >= 500 || status == http.StatusTooManyRequests:
+			lastErr = fmt.Errorf("%w: status %d, %s", ErrRecoverable, status, msg)
+		default:
+			r.lg.ErrorCtx(ctx, "remote write rejected, data dropped", zap.Int("status", status), zap.String("message", msg))
+			return nil
+		}
+
+		r.lg.DebugCtx(ctx, "remote write failed",
+			zap.Uint8("attempt", i+1),
+			zap.Uint8("limit", r.maxAttempts),
+			zap.Error(lastErr),
+		)
+	}
+
+	return fmt.Errorf("internal/agent/clients/remotewrite/reporter: send error %w", lastErr)
+}
+
+func (r *Reporter) post(ctx context.Context, body []byte) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", fmt.Errorf("internal/agent/clients/remotewrite/reporter: create request error %w", err)
+	}
+
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("X-Prometheus-Remote-Write-Version", protocolVersion)
+
+	resp, err := r.client.Request(req)
+	if err != nil {
+		return 0, "", fmt.Errorf("%w: request error %w", ErrRecoverable, err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			r.lg.ErrorCtx(ctx, "close body error", zap.Error(err))
+		}
+	}()
+
+	// the message is used for logging only, status is enough to handle the response
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	return resp.StatusCode, string(msg), nil
+}
+
+func attemptDelay(i uint8) time.Duration {
+	return time.Duration(utils.Delay(i) * float64(time.Second))
+}
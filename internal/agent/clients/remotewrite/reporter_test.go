@@ -0,0 +1,277 @@
+package remotewrite
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/clients"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// receiver is a remote write stand-in which answers with the queued statuses and records accepted series
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests int
+	series   []timeSeries
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.requests++
+	status := http.StatusNoContent
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+
+	if r.Header.Get("Content-Encoding") != "snappy" ||
+		r.Header.Get("Content-Type") != "application/x-protobuf" ||
+		r.Header.Get("X-Prometheus-Remote-Write-Version") != protocolVersion {
+		http.Error(w, "unexpected headers", http.StatusBadRequest)
+		return
+	}
+
+	if status/100 == 2 {
+		compressed, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		body, err := snappy.Decode(nil, compressed)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		series, err := decodeWriteRequest(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		rc.series = append(rc.series, series...)
+	}
+
+	w.WriteHeader(status)
+}
+
+func TestReporter_UpdateMetrics(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantErr      bool
+		wantRequests int
+		wantSeries   []timeSeries
+		wantCounter  int64 // PollCount total after the report
+	}{
+		{
+			name:         "accepted",
+			wantRequests: 1,
+			wantSeries: []timeSeries{
+				{
+					labels:    []label{{name: nameLabel, value: "PollCount"}, {name: "host", value: "db1"}},
+					value:     12,
+					timestamp: now.UnixMilli(),
+				},
+				{
+					labels:    []label{{name: nameLabel, value: "go_Alloc"}, {name: "host", value: "db1"}},
+					value:     1.5,
+					timestamp: now.UnixMilli(),
+				},
+			},
+			wantCounter: 12,
+		},
+		{
+			name:         "5xx is retried",
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusOK},
+			wantRequests: 2,
+			wantSeries: []timeSeries{
+				{labels: []label{{name: nameLabel, value: "PollCount"}, {name: "host", value: "db1"}}, value: 12, timestamp: now.UnixMilli()},
+				{labels: []label{{name: nameLabel, value: "go_Alloc"}, {name: "host", value: "db1"}}, value: 1.5, timestamp: now.UnixMilli()},
+			},
+			wantCounter: 12,
+		},
+		{
+			name:         "429 is retried until attempts are exhausted",
+			statuses:     []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusTooManyRequests},
+			wantErr:      true,
+			wantRequests: 3,
+			wantCounter:  10,
+		},
+		{
+			name:         "4xx is dropped without retries",
+			statuses:     []int{http.StatusBadRequest},
+			wantRequests: 1,
+			wantCounter:  12,
+		},
+	}
+
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+	require.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := &receiver{statuses: tt.statuses}
+			srv := httptest.NewServer(rc)
+			defer srv.Close()
+
+			r, err := NewReporter(&config.Config{
+				RemoteWriteURL:    srv.URL,
+				RemoteWriteLabels: map[string]string{"host": "db1"},
+				MaxAttempts:       3,
+			}, lg, clients.NewDefaulut())
+			require.NoError(t, err)
+
+			r.delay = func(uint8) time.Duration { return 0 }
+			r.now = func() time.Time { return now }
+			r.counters["PollCount"] = 10
+
+			pollCount := models.NewCounter("PollCount", 2)
+			alloc := models.NewGauge("go.Alloc", 1.5)
+			err = r.UpdateMetrics(context.Background(), []*models.Metric{&pollCount, &alloc})
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrRecoverable)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wantRequests, rc.requests)
+			assert.Equal(t, tt.wantSeries, rc.series)
+			assert.Equal(t, tt.wantCounter, r.counters["PollCount"])
+		})
+	}
+}
+
+func TestReporter_UpdateMetric_unreachable(t *testing.T) {
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	r, err := NewReporter(&config.Config{RemoteWriteURL: srv.URL}, lg, clients.NewDefaulut())
+	require.NoError(t, err)
+
+	err = r.UpdateMetric(context.Background(), models.NewCounter("PollCount", 1))
+	assert.ErrorIs(t, err, ErrRecoverable)
+	assert.Empty(t, r.counters)
+}
+
+func TestNewReporter(t *testing.T) {
+	_, err := NewReporter(&config.Config{}, nil, clients.NewDefaulut())
+	assert.Error(t, err)
+}
+
+func TestSanitizeName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"PollCount", "PollCount"},
+		{"cpu.utilization-1", "cpu_utilization_1"},
+		{"1min", "_1min"},
+		{"ns:metric", "ns:metric"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			assert.Equal(t, tt.want, sanitizeName(tt.in))
+		})
+	}
+}
+
+// decodeWriteRequest is the receiver side of encodeWriteRequest, series are expected to have a single sample
+func decodeWriteRequest(b []byte) ([]timeSeries, error) {
+	var series []timeSeries
+
+	err := eachField(b, func(num protowire.Number, v []byte, _ uint64) error {
+		if num != writeRequestTimeseriesField {
+			return nil
+		}
+
+		var ts timeSeries
+		err := eachField(v, func(num protowire.Number, v []byte, _ uint64) error {
+			switch num {
+			case timeSeriesLabelsField:
+				var l label
+				err := eachField(v, func(num protowire.Number, v []byte, _ uint64) error {
+					if num == labelNameField {
+						l.name = string(v)
+					} else if num == labelValueField {
+						l.value = string(v)
+					}
+					return nil
+				})
+				ts.labels = append(ts.labels, l)
+				return err
+			case timeSeriesSamplesField:
+				return eachField(v, func(num protowire.Number, _ []byte, n uint64) error {
+					if num == sampleValueField {
+						ts.value = math.Float64frombits(n)
+					} else if num == sampleTimestampField {
+						ts.timestamp = int64(n)
+					}
+					return nil
+				})
+			}
+			return nil
+		})
+		series = append(series, ts)
+
+		return err
+	})
+
+	return series, err
+}
+
+// eachField calls fn with bytes of length delimited fields and numbers of varint and fixed64 fields
+func eachField(b []byte, fn func(num protowire.Number, v []byte, n uint64) error) error {
+	for len(b) > 0 {
+		num, typ, l := protowire.ConsumeTag(b)
+		if l < 0 {
+			return protowire.ParseError(l)
+		}
+		b = b[l:]
+
+		var (
+			v []byte
+			n uint64
+		)
+		switch typ {
+		case protowire.BytesType:
+			v, l = protowire.ConsumeBytes(b)
+		case protowire.VarintType:
+			n, l = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			n, l = protowire.ConsumeFixed64(b)
+		default:
+			l = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if l < 0 {
+			return protowire.ParseError(l)
+		}
+		b = b[l:]
+
+		if err := fn(num, v, n); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
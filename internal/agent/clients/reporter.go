@@ -2,7 +2,7 @@ package clients
 
 import (
 	"bytes"
-	"compress/flate"
+	"compress/gzip"
 	"context"
 	"crypto/hmac"
 	"crypto/sha256"
@@ -12,16 +12,18 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"strconv"
+	"sync"
 	"time"
 
-	"github.com/mailru/easyjson"
 	uuid "github.com/satori/go.uuid"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/agent"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/failover"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/utils"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/capabilities"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/crypto"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/idempotency"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
 	"go.uber.org/zap"
 )
@@ -50,8 +52,17 @@ type Reporter struct {
 	semaphore       *semaphore
 	publicKeyPath   io.Reader
 	encryptor       Encryptor
-	repository      *agent.MetricsRepository
 	ipAddressSetter IRealIPHeaderSetter
+	maxBatchSize    int
+	maxBatchBytes   int
+	servers         *failover.Pool
+
+	negotiation capabilities.Session
+
+	mu         sync.RWMutex
+	caps       *capabilities.Capabilities // advertised by the server, nil until negotiated
+	noBatch    bool                       // the server responded 404 to the batch request
+	noCompress bool                       // the server responded 415 to the compressed request
 }
 
 // NewReporter creates a new Reporter instance with basic configuration
@@ -88,10 +99,11 @@ func NewSemaphore(maxReq int) *semaphore {
 
 type Encryptor interface {
 	Encrypt(b []byte) (string, error)
+	MaxMessageSize() (int, error)
 }
 
 // NewCompReporter creates a new Reporter instance with compression and rate limiting
-func NewCompReporter(address string, lg *logging.ZapLogger, cfg *config.Config, client Requester, ips IRealIPHeaderSetter, repository *agent.MetricsRepository) *Reporter {
+func NewCompReporter(address string, lg *logging.ZapLogger, cfg *config.Config, client Requester, ips IRealIPHeaderSetter) *Reporter {
 	reporter := &Reporter{
 		address:         address,
 		client:          client,
@@ -102,7 +114,8 @@ func NewCompReporter(address string, lg *logging.ZapLogger, cfg *config.Config,
 		semaphore:       NewSemaphore(cfg.RateLimit),
 		publicKeyPath:   cfg.HTTPCert,
 		ipAddressSetter: ips,
-		repository:      repository,
+		maxBatchSize:    cfg.MaxBatchSize,
+		maxBatchBytes:   cfg.MaxBatchBytes,
 	}
 
 	if cfg.HTTPCert != nil {
@@ -112,108 +125,224 @@ func NewCompReporter(address string, lg *logging.ZapLogger, cfg *config.Config,
 	return reporter
 }
 
-// UpdateMetric sends a single metric update to the server
-func (c *Reporter) UpdateMetric(ctx context.Context, mType, mName, value string) error {
-	reqCtx := c.lg.WithContextFields(ctx, zap.String("name", "http"))
-	reqCtx = context.WithoutCancel(reqCtx)
-	body, err := c.prepareBody(mType, mName, value)
-	if err != nil {
-		return err
+// WithServers makes reporter send requests to the current server of the pool instead of the single address
+// and report request results to the pool
+func (c *Reporter) WithServers(servers *failover.Pool) *Reporter {
+	c.servers = servers
+	return c
+}
+
+// serverAddress returns address of the server to send the next request to
+func (c *Reporter) serverAddress() string {
+	if c.servers == nil {
+		return c.address
 	}
 
-	req, err := http.NewRequest(
-		"POST", fmt.Sprintf("%s/update/", c.address),
-		body,
-	)
+	return c.servers.Current()
+}
 
-	if err != nil {
-		return fmt.Errorf("internal/agent/clients/reporter: create request err: %w", err)
+// trackResult reports request result to the servers pool, client errors don't affect the server health
+func (c *Reporter) trackResult(addr string, resp *http.Response, err error) {
+	if c.servers == nil {
+		return
 	}
 
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("X-Request-ID", uuid.NewV4().String())
+	if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+		c.servers.ReportFailure(addr)
+		return
+	}
 
-	resp, err := c.processRequest(reqCtx, req)
-	defer func() {
-		if resp != nil {
-			if closeErr := resp.Body.Close(); err != nil {
-				c.lg.ErrorCtx(reqCtx, "close body erorr", zap.Error(closeErr))
-			}
+	c.servers.ReportSuccess(addr)
+}
+
+// PingProber checks the server with /ping request
+func PingProber(client Requester) failover.Prober {
+	return func(ctx context.Context, addr string) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, addr+"/ping", nil)
+		if err != nil {
+			return fmt.Errorf("internal/agent/clients/reporter: create ping request error %w", err)
 		}
-	}()
 
-	if err != nil {
-		return fmt.Errorf("internal/agent/clients/reporter: send request err: %w", err)
+		resp, err := client.Request(req)
+		if err != nil {
+			return fmt.Errorf("internal/agent/clients/reporter: ping request error %w", err)
+		}
+
+		if err := resp.Body.Close(); err != nil {
+			return fmt.Errorf("internal/agent/clients/reporter: close ping body error %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			return ErrUnsuccessfulResponse
+		}
+
+		return nil
 	}
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return ErrUnsuccessfulResponse
+// UpdateMetric sends a single metric update to the server
+func (c *Reporter) UpdateMetric(ctx context.Context, m models.Metric) error {
+	reqCtx := c.lg.WithContextFields(ctx, zap.String("name", "http"))
+	reqCtx = context.WithoutCancel(reqCtx)
+	c.renegotiate(reqCtx)
+
+	body, err := c.prepareBody(m)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	_, err = c.send(reqCtx, "/update/", body)
+	return err
 }
 
-// UpdateMetrics sends a batch of metric updates to the server
+// UpdateMetrics sends a batch of metric updates to the server.
+// The batch is split into chunks limited by max batch size and max body size, chunks are sent concurrently
+// within the rate limit. If some of the chunks fail, *agent.ChunksError with failed chunks only is returned.
+// When the server doesn't accept batches, metrics are sent one by one.
 func (c *Reporter) UpdateMetrics(ctx context.Context, data []*models.Metric) error {
 	reqCtx := c.lg.WithContextFields(ctx, zap.String("name", "http"))
 	reqCtx = context.WithoutCancel(reqCtx)
+	c.renegotiate(reqCtx)
 
-	metricsBody := make([]MetricsBody, 0, len(data))
+	items := make([][]byte, 0, len(data))
 	for _, m := range data {
-		name, mType, value := c.repository.SafeRead(m)
-		rec, err := generateMetric(mType, name, value)
+		rec, err := generateMetric(*m)
 		if err != nil {
 			return fmt.Errorf("internal/agent/clients/reporter: generate metric %+v error %w", m, err)
 		}
 
-		metricsBody = append(metricsBody, rec)
+		item, err := json.Marshal(rec)
+		if err != nil {
+			return fmt.Errorf("internal/agent/clients/reporter: encode metric %+v error %w", m, err)
+		}
+
+		items = append(items, item)
+	}
+
+	maxBytes, err := c.maxBodySize()
+	if err != nil {
+		return fmt.Errorf("internal/agent/clients/reporter: calc max body size error %w", err)
+	}
+
+	batch := c.batchEnabled()
+	maxSize := capabilities.Limit(c.maxBatchSize, c.serverCaps().MaxBatchSize)
+	if !batch {
+		maxSize = 1
+	}
+
+	chunks := agent.SplitBatch(ctx, data, maxSize, maxBytes, len(batchOpen)+len(batchClose), func(i int) int {
+		return len(items[i]) + len(batchSep)
+	})
+
+	if len(chunks) > 1 && batch {
+		c.lg.DebugCtx(reqCtx, "batch split into chunks", zap.Int("metrics", len(data)), zap.Int("chunks", len(chunks)))
 	}
 
+	return agent.SendChunks(reqCtx, chunks, func(ctx context.Context, ch agent.Chunk) error {
+		if !batch {
+			return c.sendItems(ctx, ch.Metrics, items[ch.From:ch.To])
+		}
+
+		return c.sendBatch(ctx, ch.Metrics, items[ch.From:ch.To])
+	})
+}
+
+var (
+	batchOpen  = []byte("[")
+	batchSep   = []byte(",")
+	batchClose = []byte("]\n")
+)
+
+// sendBatch sends already encoded metrics as a single json array. When the server responds 404
+// batches are disabled and the metrics are sent one by one.
+func (c *Reporter) sendBatch(ctx context.Context, data []*models.Metric, items [][]byte) error {
 	var body bytes.Buffer
 
-	if err := json.NewEncoder(&body).Encode(metricsBody); err != nil {
-		return err
+	body.Write(batchOpen)
+	body.Write(bytes.Join(items, batchSep))
+	body.Write(batchClose)
+
+	status, err := c.send(ctx, "/updates/", &body)
+	if status == http.StatusNotFound {
+		c.lg.InfoCtx(ctx, "server does not accept batches, metrics are sent one by one")
+		c.disableBatch()
+
+		return c.sendItems(ctx, data, items)
+	}
+
+	return err
+}
+
+// sendItems sends already encoded metrics one by one, idempotency keys of the metrics are derived from the chunk key
+func (c *Reporter) sendItems(ctx context.Context, data []*models.Metric, items [][]byte) error {
+	key := idempotency.KeyFromContext(ctx)
+
+	for i, item := range items {
+		itemCtx := idempotency.WithKey(ctx, idempotency.MetricKey(key, data[i].Name, len(items)))
+
+		if _, err := c.send(itemCtx, "/update/", bytes.NewBuffer(item)); err != nil {
+			return err
+		}
 	}
 
+	return nil
+}
+
+// send posts the json body to the path of the current server, the response status is returned with the error
+func (c *Reporter) send(ctx context.Context, path string, body *bytes.Buffer) (int, error) {
+	addr := c.serverAddress()
 	req, err := http.NewRequest(
-		"POST", fmt.Sprintf("%s/updates/", c.address),
-		&body,
+		"POST", addr+path,
+		body,
 	)
 	if err != nil {
-		return fmt.Errorf("internal/agent/clients/reporter: create request err: %w", err)
+		return 0, fmt.Errorf("internal/agent/clients/reporter: create request err: %w", err)
 	}
 
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("X-Request-ID", uuid.NewV4().String())
-	resp, err := c.processRequest(reqCtx, req)
-	defer func() {
-		if resp != nil {
-			if closeErr := resp.Body.Close(); err != nil {
-				c.lg.ErrorCtx(reqCtx, "close body erorr", zap.Error(closeErr))
-			}
-		}
-	}()
+	if key := idempotency.KeyFromContext(ctx); key != "" {
+		req.Header.Add(idempotency.Header, key)
+	}
 
+	resp, err := c.processRequest(ctx, req)
+	c.trackResult(addr, resp, err)
 	if err != nil {
-		return fmt.Errorf("internal/agent/clients/reporter: send request err: %w", err)
+		return 0, fmt.Errorf("internal/agent/clients/reporter: send request err: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return ErrUnsuccessfulResponse
+		return resp.StatusCode, ErrUnsuccessfulResponse
 	}
 
-	return nil
+	return resp.StatusCode, nil
 }
 
-func generateMetric(mType, mName, mValue string) (MetricsBody, error) {
-	rec := MetricsBody{MName: mName, MType: mType}
-	switch mType {
+// maxBodySize returns the limit of the batch body size. When encryption is enabled the body
+// must fit into a single RSA block, so the limit is reduced to the encryptor capacity.
+func (c *Reporter) maxBodySize() (int, error) {
+	limit := capabilities.Limit(c.maxBatchBytes, int(c.serverCaps().MaxBodyBytes))
+	if c.encryptor == nil {
+		return limit, nil
+	}
+
+	encLimit, err := c.encryptor.MaxMessageSize()
+	if err != nil {
+		return 0, err
+	}
+
+	return capabilities.Limit(limit, encLimit), nil
+}
+
+func generateMetric(m models.Metric) (MetricsBody, error) {
+	rec := MetricsBody{MName: m.Name, MType: m.Type}
+	switch m.Type {
 	case models.GaugeType:
-		rec.Value = mValue
+		rec.Value = m.Value
 	case models.CounterType:
-		rec.Delta = mValue
+		rec.Delta = m.Delta
 	default:
-		return MetricsBody{}, fmt.Errorf("internal/agent/clients/reporter.go: underfined type: %s, for name: %s, value: %s", mType, mName, mValue)
+		return MetricsBody{}, fmt.Errorf("internal/agent/clients/reporter.go: underfined type: %s, for name: %s", m.Type, m.Name)
 	}
 
 	return rec, nil
@@ -272,7 +401,63 @@ func (c *Reporter) doRequest(ctx context.Context, req *http.Request) (*http.Resp
 	return nil, fmt.Errorf("reporter: request max attempts exceeded errpr %w", err)
 }
 
+// processRequest prepares and sends the request. When the server rejects the compressed body with 415
+// compression is disabled and the request is sent again uncompressed.
 func (c *Reporter) processRequest(ctx context.Context, req *http.Request) (*http.Response, error) {
+	compress := c.compressionEnabled()
+	retry := req.Clone(ctx)
+
+	reqCtx, err := c.prepareRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if compress {
+		if err := c.compressRequest(req); err != nil {
+			return nil, fmt.Errorf("internal/agent/clients/reporter compress request error %w", err)
+		}
+	}
+
+	resp, reqErr := c.doRequest(reqCtx, req)
+	if reqErr != nil {
+		return nil, fmt.Errorf("internal/agent/clients/reporter send request error %w", reqErr)
+	}
+
+	if compress && resp.StatusCode == http.StatusUnsupportedMediaType {
+		c.lg.InfoCtx(ctx, "server does not accept compressed body, compression is disabled")
+		c.disableCompression()
+
+		if retry.Body, err = retry.GetBody(); err != nil {
+			return nil, fmt.Errorf("internal/agent/clients/reporter read body error %w", err)
+		}
+
+		return c.processRequest(ctx, retry)
+	}
+
+	return resp, nil
+}
+
+// compressRequest compresses the prepared body of the request
+func (c *Reporter) compressRequest(r *http.Request) error {
+	var buff bytes.Buffer
+	if _, err := io.Copy(&buff, r.Body); err != nil {
+		return err
+	}
+
+	compressed, err := c.compressor(&buff)
+	if err != nil {
+		return err
+	}
+
+	r.Body = io.NopCloser(compressed)
+	r.ContentLength = int64(compressed.Len())
+	r.Header.Set("Content-Encoding", capabilities.CompressionGzip)
+
+	return nil
+}
+
+// prepareRequest signs and encrypts the body and sets the agent ip header
+func (c *Reporter) prepareRequest(ctx context.Context, req *http.Request) (context.Context, error) {
 	buff := bytes.Buffer{}
 
 	reader, readerErr := req.GetBody()
@@ -298,12 +483,7 @@ func (c *Reporter) processRequest(ctx context.Context, req *http.Request) (*http
 		return nil, fmt.Errorf("internal/agent/clients/reporter set ip address error %w", setIpErr)
 	}
 
-	resp, reqErr := c.doRequest(reqCtx, req)
-	if reqErr != nil {
-		return nil, fmt.Errorf("internal/agent/clients/reporter send request error %w", reqErr)
-	}
-
-	return resp, nil
+	return reqCtx, nil
 }
 
 var ErrBodyKeyNotFoundInContext error = fmt.Errorf("internal/agent/clients/reporter.go there is no key %s in current context", bKey)
@@ -350,46 +530,17 @@ func (c *Reporter) encryptRequest(ctx context.Context, r *http.Request) error {
 }
 
 // MetricsBody represents the structure of a metric in the request body
+//
+//easyjson:json
 type MetricsBody struct {
-	MName string `json:"id"`              // metric name
-	MType string `json:"type"`            // metric type (gauge or counter)
-	Delta string `json:"delta,omitempty"` // metric value for counter type
-	Value string `json:"value,omitempty"` // metric value for gauge type
-}
-
-// MetricsBodyAlias provides type conversion for metric values
-type MetricsBodyAlias struct {
-	MetricsBody
-	Delta int     `json:"delta,omitempty"` // metric value for counter type (converted to int)
-	Value float64 `json:"value,omitempty"` // metric value for gauge type (converted to float64)
-}
-
-func (m MetricsBody) MarshalJSON() ([]byte, error) {
-	aliasValue := MetricsBodyAlias{
-		MetricsBody: m,
-	}
-
-	if m.Value != "" {
-		if v, err := strconv.ParseFloat(m.Value, 64); err != nil {
-			return nil, err
-		} else {
-			aliasValue.Value = v
-		}
-	}
-
-	if m.Delta != "" {
-		if v, err := strconv.Atoi(m.Delta); err != nil {
-			return nil, err
-		} else {
-			aliasValue.Delta = v
-		}
-	}
-
-	return easyjson.Marshal(aliasValue)
+	MName string  `json:"id"`              // metric name
+	MType string  `json:"type"`            // metric type (gauge or counter)
+	Delta int64   `json:"delta,omitempty"` // metric value for counter type
+	Value float64 `json:"value,omitempty"` // metric value for gauge type
 }
 
-func (c Reporter) prepareBody(mType, mName, value string) (*bytes.Buffer, error) {
-	rec, err := generateMetric(mType, mName, value)
+func (c *Reporter) prepareBody(m models.Metric) (*bytes.Buffer, error) {
+	rec, err := generateMetric(m)
 	if err != nil {
 		return nil, err
 	}
@@ -403,7 +554,7 @@ func (c Reporter) prepareBody(mType, mName, value string) (*bytes.Buffer, error)
 
 func gzbody(b *bytes.Buffer) (*bytes.Buffer, error) {
 	res := &bytes.Buffer{}
-	w, err := flate.NewWriter(res, flate.BestCompression)
+	w, err := gzip.NewWriterLevel(res, gzip.BestCompression)
 	if err != nil {
 		return nil, err
 	}
@@ -4,7 +4,6 @@ package clients
 
 import (
 	json "encoding/json"
-
 	easyjson "github.com/mailru/easyjson"
 	jlexer "github.com/mailru/easyjson/jlexer"
 	jwriter "github.com/mailru/easyjson/jwriter"
@@ -18,34 +17,50 @@ var (
 	_ easyjson.Marshaler
 )
 
-func easyjsonF860cb9bEncodeGithubComVysogota0399MemStatsMonitoringInternalAgentClients2(out *jwriter.Writer, in MetricsBodyAlias) {
-	out.RawByte('{')
-	first := true
-	_ = first
-	if in.Delta != 0 {
-		const prefix string = ",\"delta\":"
-		first = false
-		out.RawString(prefix[1:])
-		out.Int(int(in.Delta))
+func easyjsonF860cb9bDecodeGithubComVysogota0399MemStatsMonitoringInternalAgentClients(in *jlexer.Lexer, out *MetricsBody) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
 	}
-	if in.Value != 0 {
-		const prefix string = ",\"value\":"
-		if first {
-			first = false
-			out.RawString(prefix[1:])
-		} else {
-			out.RawString(prefix)
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
 		}
-		out.Float64(float64(in.Value))
+		switch key {
+		case "id":
+			out.MName = string(in.String())
+		case "type":
+			out.MType = string(in.String())
+		case "delta":
+			out.Delta = int64(in.Int64())
+		case "value":
+			out.Value = float64(in.Float64())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
 	}
+}
+func easyjsonF860cb9bEncodeGithubComVysogota0399MemStatsMonitoringInternalAgentClients(out *jwriter.Writer, in MetricsBody) {
+	out.RawByte('{')
+	first := true
+	_ = first
 	{
 		const prefix string = ",\"id\":"
-		if first {
-			first = false
-			out.RawString(prefix[1:])
-		} else {
-			out.RawString(prefix)
-		}
+		out.RawString(prefix[1:])
 		out.String(string(in.MName))
 	}
 	{
@@ -53,10 +68,39 @@ func easyjsonF860cb9bEncodeGithubComVysogota0399MemStatsMonitoringInternalAgentC
 		out.RawString(prefix)
 		out.String(string(in.MType))
 	}
+	if in.Delta != 0 {
+		const prefix string = ",\"delta\":"
+		out.RawString(prefix)
+		out.Int64(int64(in.Delta))
+	}
+	if in.Value != 0 {
+		const prefix string = ",\"value\":"
+		out.RawString(prefix)
+		out.Float64(float64(in.Value))
+	}
 	out.RawByte('}')
 }
 
+// MarshalJSON supports json.Marshaler interface
+func (v MetricsBody) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonF860cb9bEncodeGithubComVysogota0399MemStatsMonitoringInternalAgentClients(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
 // MarshalEasyJSON supports easyjson.Marshaler interface
-func (v MetricsBodyAlias) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonF860cb9bEncodeGithubComVysogota0399MemStatsMonitoringInternalAgentClients2(w, v)
+func (v MetricsBody) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonF860cb9bEncodeGithubComVysogota0399MemStatsMonitoringInternalAgentClients(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *MetricsBody) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonF860cb9bDecodeGithubComVysogota0399MemStatsMonitoringInternalAgentClients(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *MetricsBody) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonF860cb9bDecodeGithubComVysogota0399MemStatsMonitoringInternalAgentClients(l, v)
 }
@@ -2,14 +2,17 @@ package clients
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"math/rand"
 	"net/http"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -18,9 +21,10 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/agent"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/failover"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
-	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/storage"
 	mocks "github.com/vysogota0399/mem_stats_monitoring/internal/mocks/agent/clients"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/idempotency"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
 )
 
@@ -42,23 +46,21 @@ func BenchmarkReporter_UpdateMetrics(b *testing.B) {
 	reporter := NewCompReporter(
 		"",
 		lg,
-		&config.Config{RateLimit: 10},
+		&config.Config{RateLimit: 10, MaxAttempts: 1},
 		client,
 		ips,
-		agent.NewMetricsRepository(storage.NewMemoryStorage(nil)),
 	)
 	ctx := context.Background()
 
-	types := []string{models.CounterType, models.CounterType}
 	mCount := 10_000
 	metrics := make([]*models.Metric, mCount)
 
 	for b.Loop() {
 		for i := range mCount {
-			metrics[i] = &models.Metric{
-				Name:  gofakeit.Animal(),
-				Type:  types[rand.Int31n(2)],
-				Value: gofakeit.Digit(),
+			if rand.Int31n(2) == 0 {
+				metrics[i] = &models.Metric{Name: gofakeit.Animal(), Type: models.CounterType, Delta: gofakeit.Int64()}
+			} else {
+				metrics[i] = &models.Metric{Name: gofakeit.Animal(), Type: models.GaugeType, Value: gofakeit.Float64()}
 			}
 		}
 
@@ -67,28 +69,67 @@ func BenchmarkReporter_UpdateMetrics(b *testing.B) {
 	}
 }
 
+func BenchmarkReporter_UpdateMetrics_encode(b *testing.B) {
+	ctrl := gomock.NewController(b)
+	defer ctrl.Finish()
+
+	client := NewMockRequester(ctrl)
+	ips := mocks.NewMockIRealIPHeaderSetter(ctrl)
+
+	ips.EXPECT().Call(gomock.Any()).AnyTimes().Return(nil)
+	client.EXPECT().Request(gomock.Any()).AnyTimes().DoAndReturn(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBuffer(nil))}, nil
+	})
+
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: 2})
+	assert.NoError(b, err)
+
+	reporter := NewCompReporter(
+		"",
+		lg,
+		&config.Config{RateLimit: 10, MaxAttempts: 1},
+		client,
+		ips,
+	)
+	ctx := context.Background()
+
+	metrics := make([]*models.Metric, 1000)
+	for i := range metrics {
+		if i%2 == 0 {
+			metrics[i] = &models.Metric{Name: fmt.Sprintf("gauge%d", i), Type: models.GaugeType, Value: 123.456}
+		} else {
+			metrics[i] = &models.Metric{Name: fmt.Sprintf("counter%d", i), Type: models.CounterType, Delta: 42}
+		}
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		assert.NoError(b, reporter.UpdateMetrics(ctx, metrics))
+	}
+}
+
 func BenchmarkReporter_bytesreader(b *testing.B) {
 	metrics := []*models.Metric{
-		{Name: "asd", Type: "gauge", Value: "123"},
-		{Name: "asd", Type: "gauge", Value: "123"},
-		{Name: "asd", Type: "gauge", Value: "123"},
-		{Name: "asd", Type: "gauge", Value: "123"},
-		{Name: "asd", Type: "gauge", Value: "123"},
-		{Name: "asd", Type: "gauge", Value: "123"},
-		{Name: "asd", Type: "gauge", Value: "123"},
-		{Name: "asd", Type: "gauge", Value: "123"},
-		{Name: "asd", Type: "gauge", Value: "123"},
-		{Name: "asd", Type: "gauge", Value: "123"},
-		{Name: "asd", Type: "gauge", Value: "123"},
-		{Name: "asd", Type: "gauge", Value: "123"},
-		{Name: "asd", Type: "gauge", Value: "123"},
-		{Name: "asd", Type: "gauge", Value: "123"},
-		{Name: "asd", Type: "gauge", Value: "123"},
-		{Name: "asd", Type: "gauge", Value: "123"},
-		{Name: "asd", Type: "gauge", Value: "123"},
-		{Name: "asd", Type: "gauge", Value: "123"},
-		{Name: "asd", Type: "gauge", Value: "123"},
-		{Name: "asd", Type: "gauge", Value: "123"},
+		{Name: "asd", Type: "gauge", Value: 123},
+		{Name: "asd", Type: "gauge", Value: 123},
+		{Name: "asd", Type: "gauge", Value: 123},
+		{Name: "asd", Type: "gauge", Value: 123},
+		{Name: "asd", Type: "gauge", Value: 123},
+		{Name: "asd", Type: "gauge", Value: 123},
+		{Name: "asd", Type: "gauge", Value: 123},
+		{Name: "asd", Type: "gauge", Value: 123},
+		{Name: "asd", Type: "gauge", Value: 123},
+		{Name: "asd", Type: "gauge", Value: 123},
+		{Name: "asd", Type: "gauge", Value: 123},
+		{Name: "asd", Type: "gauge", Value: 123},
+		{Name: "asd", Type: "gauge", Value: 123},
+		{Name: "asd", Type: "gauge", Value: 123},
+		{Name: "asd", Type: "gauge", Value: 123},
+		{Name: "asd", Type: "gauge", Value: 123},
+		{Name: "asd", Type: "gauge", Value: 123},
+		{Name: "asd", Type: "gauge", Value: 123},
+		{Name: "asd", Type: "gauge", Value: 123},
+		{Name: "asd", Type: "gauge", Value: 123},
 	}
 	var body bytes.Buffer
 
@@ -155,10 +196,8 @@ func TestReporter_UpdateMetric(t *testing.T) {
 		secretKey   []byte
 	}
 	type args struct {
-		ctx   context.Context
-		mType string
-		mName string
-		value string
+		ctx context.Context
+		m   models.Metric
 	}
 
 	type testCase struct {
@@ -182,10 +221,8 @@ func TestReporter_UpdateMetric(t *testing.T) {
 				encryptor:   mocks.NewMockEncryptor(ctrl),
 			},
 			args: args{
-				ctx:   context.Background(),
-				mType: models.GaugeType,
-				mName: "testGauge",
-				value: "123.45",
+				ctx: context.Background(),
+				m:   models.Metric{Name: "testGauge", Type: models.GaugeType, Value: 123.45},
 			},
 			wantErr: false,
 			prepare: func(f *fields) {
@@ -215,10 +252,8 @@ func TestReporter_UpdateMetric(t *testing.T) {
 				encryptor:   mocks.NewMockEncryptor(ctrl),
 			},
 			args: args{
-				ctx:   context.Background(),
-				mType: models.CounterType,
-				mName: "testCounter",
-				value: "42",
+				ctx: context.Background(),
+				m:   models.Metric{Name: "testCounter", Type: models.CounterType, Delta: 42},
 			},
 			wantErr: false,
 			prepare: func(f *fields) {
@@ -248,10 +283,8 @@ func TestReporter_UpdateMetric(t *testing.T) {
 				encryptor:   mocks.NewMockEncryptor(ctrl),
 			},
 			args: args{
-				ctx:   context.Background(),
-				mType: "invalid",
-				mName: "testInvalid",
-				value: "123",
+				ctx: context.Background(),
+				m:   models.Metric{Name: "testInvalid", Type: "invalid", Value: 123},
 			},
 			wantErr: true,
 			prepare: func(f *fields) {
@@ -270,10 +303,8 @@ func TestReporter_UpdateMetric(t *testing.T) {
 				encryptor:   mocks.NewMockEncryptor(ctrl),
 			},
 			args: args{
-				ctx:   context.Background(),
-				mType: models.GaugeType,
-				mName: "testError",
-				value: "123",
+				ctx: context.Background(),
+				m:   models.Metric{Name: "testError", Type: models.GaugeType, Value: 123},
 			},
 			wantErr: true,
 			prepare: func(f *fields) {
@@ -298,10 +329,8 @@ func TestReporter_UpdateMetric(t *testing.T) {
 				secretKey:   nil,
 			},
 			args: args{
-				ctx:   context.Background(),
-				mType: models.GaugeType,
-				mName: "testBadStatus",
-				value: "123",
+				ctx: context.Background(),
+				m:   models.Metric{Name: "testBadStatus", Type: models.GaugeType, Value: 123},
 			},
 			wantErr: true,
 			prepare: func(f *fields) {
@@ -337,11 +366,10 @@ func TestReporter_UpdateMetric(t *testing.T) {
 				},
 				tt.fields.client,
 				tt.fields.ips,
-				agent.NewMetricsRepository(storage.NewMemoryStorage(nil)),
 			)
 
 			c.encryptor = tt.fields.encryptor
-			err := c.UpdateMetric(tt.args.ctx, tt.args.mType, tt.args.mName, tt.args.value)
+			err := c.UpdateMetric(tt.args.ctx, tt.args.m)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -394,8 +422,8 @@ func TestReporter_UpdateMetrics(t *testing.T) {
 			args: args{
 				ctx: context.Background(),
 				data: []*models.Metric{
-					{Name: "gauge1", Type: models.GaugeType, Value: "123.45"},
-					{Name: "counter1", Type: models.CounterType, Value: "42"},
+					{Name: "gauge1", Type: models.GaugeType, Value: 123.45},
+					{Name: "counter1", Type: models.CounterType, Delta: 42},
 				},
 			},
 			wantErr: false,
@@ -449,8 +477,8 @@ func TestReporter_UpdateMetrics(t *testing.T) {
 			args: args{
 				ctx: context.Background(),
 				data: []*models.Metric{
-					{Name: "valid", Type: models.GaugeType, Value: "123.45"},
-					{Name: "invalid", Type: "invalid_type", Value: "42"},
+					{Name: "valid", Type: models.GaugeType, Value: 123.45},
+					{Name: "invalid", Type: "invalid_type", Value: 42},
 				},
 			},
 			wantErr: true,
@@ -471,7 +499,7 @@ func TestReporter_UpdateMetrics(t *testing.T) {
 			args: args{
 				ctx: context.Background(),
 				data: []*models.Metric{
-					{Name: "metric1", Type: models.GaugeType, Value: "123.45"},
+					{Name: "metric1", Type: models.GaugeType, Value: 123.45},
 				},
 			},
 			wantErr: true,
@@ -493,7 +521,7 @@ func TestReporter_UpdateMetrics(t *testing.T) {
 			args: args{
 				ctx: context.Background(),
 				data: []*models.Metric{
-					{Name: "metric1", Type: models.GaugeType, Value: "123.45"},
+					{Name: "metric1", Type: models.GaugeType, Value: 123.45},
 				},
 			},
 			wantErr: true,
@@ -524,7 +552,6 @@ func TestReporter_UpdateMetrics(t *testing.T) {
 				},
 				tt.fields.client,
 				tt.fields.ips,
-				agent.NewMetricsRepository(storage.NewMemoryStorage(nil)),
 			)
 			c.encryptor = nil
 			err := c.UpdateMetrics(tt.args.ctx, tt.args.data)
@@ -537,6 +564,101 @@ func TestReporter_UpdateMetrics(t *testing.T) {
 	}
 }
 
+func TestReporter_UpdateMetrics_chunks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: 1})
+	assert.NoError(t, err)
+
+	data := []*models.Metric{
+		{Name: "gauge1", Type: models.GaugeType, Value: 1.5},
+		{Name: "gauge2", Type: models.GaugeType, Value: 2.5},
+		{Name: "fail", Type: models.GaugeType, Value: 3.5},
+		{Name: "counter1", Type: models.CounterType, Delta: 4},
+		{Name: "counter2", Type: models.CounterType, Delta: 5},
+	}
+
+	client := NewMockRequester(ctrl)
+	ips := mocks.NewMockIRealIPHeaderSetter(ctrl)
+	ips.EXPECT().Call(gomock.Any()).Times(3).Return(nil)
+
+	var (
+		mu     sync.Mutex
+		bodies []string
+		keys   []string
+	)
+	client.EXPECT().Request(gomock.Any()).Times(3).DoAndReturn(func(r *http.Request) (*http.Response, error) {
+		b, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		mu.Lock()
+		bodies = append(bodies, string(b))
+		keys = append(keys, r.Header.Get(idempotency.Header))
+		mu.Unlock()
+
+		if strings.Contains(string(b), `"fail"`) {
+			return nil, errors.New("request failed")
+		}
+
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBuffer(nil))}, nil
+	})
+
+	c := NewCompReporter(
+		"http://test-server",
+		lg,
+		&config.Config{RateLimit: 2, MaxAttempts: 1, MaxBatchSize: 2},
+		client,
+		ips,
+	)
+
+	err = c.UpdateMetrics(idempotency.WithKey(context.Background(), "report"), data)
+	assert.ElementsMatch(t, []string{"report-0", "report-2", "report/counter2"}, keys)
+
+	var chErr *agent.ChunksError
+	assert.ErrorAs(t, err, &chErr)
+	assert.Equal(t, 3, chErr.Total)
+	assert.Equal(t, data[2:4], chErr.FailedMetrics())
+
+	for _, b := range bodies {
+		var items []map[string]any
+		assert.NoError(t, json.Unmarshal([]byte(b), &items))
+		assert.LessOrEqual(t, len(items), 2)
+	}
+}
+
+func TestReporter_maxBodySize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name          string
+		maxBatchBytes int
+		encLimit      int
+		withEncryptor bool
+		want          int
+	}{
+		{name: "without encryption", maxBatchBytes: 1024, want: 1024},
+		{name: "encryption limit is less", maxBatchBytes: 1024, encLimit: 190, withEncryptor: true, want: 190},
+		{name: "batch limit is less", maxBatchBytes: 100, encLimit: 190, withEncryptor: true, want: 100},
+		{name: "unlimited batch with encryption", encLimit: 190, withEncryptor: true, want: 190},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Reporter{maxBatchBytes: tt.maxBatchBytes}
+			if tt.withEncryptor {
+				enc := mocks.NewMockEncryptor(ctrl)
+				enc.EXPECT().MaxMessageSize().Return(tt.encLimit, nil)
+				c.encryptor = enc
+			}
+
+			got, err := c.maxBodySize()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func Test_attemptDelay(t *testing.T) {
 	type args struct {
 		i uint8
@@ -594,6 +716,12 @@ func Test_gzbody(t *testing.T) {
 			}
 			assert.NoError(t, err)
 			assert.NotNil(t, got)
+
+			zr, err := gzip.NewReader(got)
+			assert.NoError(t, err)
+			plain, err := io.ReadAll(zr)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.input, string(plain))
 		})
 	}
 }
@@ -615,7 +743,6 @@ func TestReporter_signRequest(t *testing.T) {
 		semaphore       *semaphore
 		publicKeyPath   io.Reader
 		encryptor       Encryptor
-		repository      *agent.MetricsRepository
 		ipAddressSetter IRealIPHeaderSetter
 	}
 	type args struct {
@@ -692,7 +819,6 @@ func TestReporter_signRequest(t *testing.T) {
 				semaphore:       tt.fields.semaphore,
 				publicKeyPath:   tt.fields.publicKeyPath,
 				encryptor:       tt.fields.encryptor,
-				repository:      tt.fields.repository,
 				ipAddressSetter: tt.fields.ipAddressSetter,
 			}
 			err := c.signRequest(tt.args.ctx, tt.args.r)
@@ -714,3 +840,80 @@ func TestReporter_signRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestReporter_UpdateMetric_failover(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+	assert.NoError(t, err)
+
+	client := NewMockRequester(ctrl)
+	ips := mocks.NewMockIRealIPHeaderSetter(ctrl)
+	ips.EXPECT().Call(gomock.Any()).AnyTimes().Return(nil)
+
+	var hosts []string
+	client.EXPECT().Request(gomock.Any()).Times(4).DoAndReturn(func(r *http.Request) (*http.Response, error) {
+		hosts = append(hosts, r.URL.Host)
+
+		status := http.StatusOK
+		if r.URL.Host == "primary" {
+			status = http.StatusInternalServerError
+		}
+
+		return &http.Response{StatusCode: status, Body: io.NopCloser(bytes.NewBuffer(nil))}, nil
+	})
+
+	servers := failover.NewPool([]string{"http://primary", "http://secondary"}, 2, lg)
+	c := NewCompReporter(
+		"http://primary",
+		lg,
+		&config.Config{RateLimit: 1, MaxAttempts: 1},
+		client,
+		ips,
+	).WithServers(servers)
+
+	for range 4 {
+		_ = c.UpdateMetric(context.Background(), models.NewGauge("Alloc", 1))
+	}
+
+	assert.Equal(t, []string{"primary", "primary", "secondary", "secondary"}, hosts)
+	assert.False(t, servers.Healthy("http://primary"))
+}
+
+func TestPingProber(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name    string
+		status  int
+		err     error
+		wantErr bool
+	}{
+		{name: "alive", status: http.StatusOK},
+		{name: "storage is down", status: http.StatusInternalServerError, wantErr: true},
+		{name: "unreachable", err: errors.New("connection refused"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewMockRequester(ctrl)
+			client.EXPECT().Request(gomock.Any()).DoAndReturn(func(r *http.Request) (*http.Response, error) {
+				assert.Equal(t, "http://primary/ping", r.URL.String())
+				if tt.err != nil {
+					return nil, tt.err
+				}
+
+				return &http.Response{StatusCode: tt.status, Body: io.NopCloser(bytes.NewBuffer(nil))}, nil
+			})
+
+			err := PingProber(client)(context.Background(), "http://primary")
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,435 @@
+// Package cgroup collects resource usage of the container the agent runs in.
+// Inside a container host metrics like mem.VirtualMemory describe the host, the container
+// limits and usage are only visible in the cgroup filesystem.
+package cgroup
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/collectors"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+)
+
+// ErrNotFound is returned when there is no cgroup filesystem to collect from
+var ErrNotFound = errors.New("cgroup: cgroup filesystem not found")
+
+// Metric names
+const (
+	MemoryUsage         = "CgroupMemoryUsage"
+	MemoryLimit         = "CgroupMemoryLimit"
+	OOMKills            = "CgroupOOMKills"
+	CPUUsageUsec        = "CgroupCPUUsageUsec"
+	CPUPeriods          = "CgroupCPUPeriods"
+	CPUThrottledPeriods = "CgroupCPUThrottledPeriods"
+	CPUThrottledUsec    = "CgroupCPUThrottledUsec"
+	Pids                = "CgroupPids"
+	PidsLimit           = "CgroupPidsLimit"
+	IOReadBytes         = "CgroupIOReadBytes"
+	IOWriteBytes        = "CgroupIOWriteBytes"
+	IOReadOps           = "CgroupIOReadOps"
+	IOWriteOps          = "CgroupIOWriteOps"
+)
+
+// Version of the cgroup hierarchy
+type Version int
+
+const (
+	V1 Version = 1
+	V2 Version = 2
+)
+
+// v1UnlimitedThreshold is the limit of cgroup v1 files, larger values mean there is no limit
+const v1UnlimitedThreshold = 1 << 62
+
+// Collector reads the cgroup of the agent process. Memory and pids limits are not reported when unlimited.
+type Collector struct {
+	version Version
+	// dirs maps controller to the cgroup directory, v2 has the single "" controller
+	dirs   map[string]string
+	deltas *collectors.Deltas
+}
+
+// NewCollector detects cgroup version under root and resolves the cgroup of the process from procCgroup,
+// usually /sys/fs/cgroup and /proc/self/cgroup. ErrNotFound is returned when root is not a cgroup filesystem.
+func NewCollector(root, procCgroup string) (*Collector, error) {
+	paths, err := readProcCgroup(procCgroup)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Collector{dirs: make(map[string]string), deltas: collectors.NewDeltas()}
+
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err == nil {
+		c.version = V2
+		c.dirs[""] = cgroupDir(root, paths[""])
+
+		return c, nil
+	}
+
+	for _, controller := range []string{"memory", "cpu", "cpuacct", "pids", "blkio"} {
+		base := filepath.Join(root, controller)
+		if _, err := os.Stat(base); err != nil {
+			continue
+		}
+
+		c.dirs[controller] = cgroupDir(base, paths[controller])
+	}
+
+	if len(c.dirs) == 0 {
+		return nil, ErrNotFound
+	}
+
+	c.version = V1
+
+	return c, nil
+}
+
+// Version returns detected cgroup version
+func (c *Collector) Version() Version {
+	return c.version
+}
+
+func (c *Collector) Name() string {
+	return "cgroup"
+}
+
+// Collect reads the cgroup files, missing files are skipped since controllers may be disabled
+func (c *Collector) Collect(ctx context.Context) ([]models.Metric, error) {
+	var (
+		s   stats
+		err error
+	)
+
+	if c.version == V2 {
+		s, err = c.readV2()
+	} else {
+		s, err = c.readV1()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("internal/agent/collectors/cgroup: read cgroup v%d error %w", c.version, err)
+	}
+
+	return s.metrics(c.deltas), nil
+}
+
+// stats are the values read from the cgroup files, unavailable values are absent
+type stats struct {
+	gauges   map[string]float64
+	counters map[string]uint64
+}
+
+func newStats() stats {
+	return stats{gauges: make(map[string]float64), counters: make(map[string]uint64)}
+}
+
+func (s stats) metrics(deltas *collectors.Deltas) []models.Metric {
+	metrics := make([]models.Metric, 0, len(s.gauges)+len(s.counters))
+	for name, val := range s.gauges {
+		metrics = append(metrics, models.NewGauge(name, val))
+	}
+
+	for name, val := range s.counters {
+		if m, ok := deltas.Counter(name, val); ok {
+			metrics = append(metrics, m)
+		}
+	}
+
+	return metrics
+}
+
+func (c *Collector) readV2() (stats, error) {
+	dir := c.dirs[""]
+	s := newStats()
+
+	if err := readGauge(s, MemoryUsage, filepath.Join(dir, "memory.current"), 0); err != nil {
+		return s, err
+	}
+
+	if err := readGauge(s, MemoryLimit, filepath.Join(dir, "memory.max"), 0); err != nil {
+		return s, err
+	}
+
+	if err := readFlatKeyed(filepath.Join(dir, "memory.events"), map[string]func(uint64){
+		"oom_kill": func(v uint64) { s.counters[OOMKills] = v },
+	}); err != nil {
+		return s, err
+	}
+
+	if err := readFlatKeyed(filepath.Join(dir, "cpu.stat"), map[string]func(uint64){
+		"usage_usec":     func(v uint64) { s.counters[CPUUsageUsec] = v },
+		"nr_periods":     func(v uint64) { s.counters[CPUPeriods] = v },
+		"nr_throttled":   func(v uint64) { s.counters[CPUThrottledPeriods] = v },
+		"throttled_usec": func(v uint64) { s.counters[CPUThrottledUsec] = v },
+	}); err != nil {
+		return s, err
+	}
+
+	if err := readGauge(s, Pids, filepath.Join(dir, "pids.current"), 0); err != nil {
+		return s, err
+	}
+
+	if err := readGauge(s, PidsLimit, filepath.Join(dir, "pids.max"), 0); err != nil {
+		return s, err
+	}
+
+	return s, readIOStatV2(s, filepath.Join(dir, "io.stat"))
+}
+
+func (c *Collector) readV1() (stats, error) {
+	s := newStats()
+
+	if dir, ok := c.dirs["memory"]; ok {
+		if err := readGauge(s, MemoryUsage, filepath.Join(dir, "memory.usage_in_bytes"), 0); err != nil {
+			return s, err
+		}
+
+		if err := readGauge(s, MemoryLimit, filepath.Join(dir, "memory.limit_in_bytes"), v1UnlimitedThreshold); err != nil {
+			return s, err
+		}
+
+		if err := readFlatKeyed(filepath.Join(dir, "memory.oom_control"), map[string]func(uint64){
+			"oom_kill": func(v uint64) { s.counters[OOMKills] = v },
+		}); err != nil {
+			return s, err
+		}
+	}
+
+	if dir, ok := c.dirs["cpuacct"]; ok {
+		usage, found, err := readUint(filepath.Join(dir, "cpuacct.usage"))
+		if err != nil {
+			return s, err
+		}
+		if found {
+			s.counters[CPUUsageUsec] = usage / 1000
+		}
+	}
+
+	if dir, ok := c.dirs["cpu"]; ok {
+		if err := readFlatKeyed(filepath.Join(dir, "cpu.stat"), map[string]func(uint64){
+			"nr_periods":     func(v uint64) { s.counters[CPUPeriods] = v },
+			"nr_throttled":   func(v uint64) { s.counters[CPUThrottledPeriods] = v },
+			"throttled_time": func(v uint64) { s.counters[CPUThrottledUsec] = v / 1000 },
+		}); err != nil {
+			return s, err
+		}
+	}
+
+	if dir, ok := c.dirs["pids"]; ok {
+		if err := readGauge(s, Pids, filepath.Join(dir, "pids.current"), 0); err != nil {
+			return s, err
+		}
+
+		if err := readGauge(s, PidsLimit, filepath.Join(dir, "pids.max"), 0); err != nil {
+			return s, err
+		}
+	}
+
+	if dir, ok := c.dirs["blkio"]; ok {
+		if err := readBlkioV1(s, filepath.Join(dir, "blkio.throttle.io_service_bytes"), IOReadBytes, IOWriteBytes); err != nil {
+			return s, err
+		}
+
+		if err := readBlkioV1(s, filepath.Join(dir, "blkio.throttle.io_serviced"), IOReadOps, IOWriteOps); err != nil {
+			return s, err
+		}
+	}
+
+	return s, nil
+}
+
+// readProcCgroup parses /proc/self/cgroup, returns cgroup path by controller, v2 path has "" controller
+func readProcCgroup(path string) (map[string]string, error) {
+	paths := make(map[string]string)
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return paths, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("internal/agent/collectors/cgroup: read %s error %w", path, err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+
+		for _, controller := range strings.Split(parts[1], ",") {
+			paths[strings.TrimPrefix(controller, "name=")] = parts[2]
+		}
+	}
+
+	return paths, scanner.Err()
+}
+
+// cgroupDir returns directory of the process cgroup, base is used when the path is not visible,
+// it happens in containers with cgroup namespaces where the container cgroup is mounted as root
+func cgroupDir(base, path string) string {
+	if path == "" || path == "/" {
+		return base
+	}
+
+	dir := filepath.Join(base, path)
+	if _, err := os.Stat(dir); err != nil {
+		return base
+	}
+
+	return dir
+}
+
+// readUint reads a single number file, found is false when the file doesn't exist or contains "max"
+func readUint(path string) (uint64, bool, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("read %s error %w", path, err)
+	}
+
+	val := strings.TrimSpace(string(b))
+	if val == "max" {
+		return 0, false, nil
+	}
+
+	n, err := strconv.ParseUint(val, 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("parse %s error %w", path, err)
+	}
+
+	return n, true, nil
+}
+
+// readGauge reads a single number file into gauge name, values not less than unlimited are skipped if it is set
+func readGauge(s stats, name, path string, unlimited uint64) error {
+	n, found, err := readUint(path)
+	if err != nil || !found {
+		return err
+	}
+
+	if unlimited > 0 && n >= unlimited {
+		return nil
+	}
+
+	s.gauges[name] = float64(n)
+
+	return nil
+}
+
+// readFlatKeyed reads "key value" lines and passes values of the known keys to the handlers
+func readFlatKeyed(path string, handlers map[string]func(uint64)) error {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read %s error %w", path, err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+
+		handler, ok := handlers[fields[0]]
+		if !ok {
+			continue
+		}
+
+		n, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("parse %s key %s error %w", path, fields[0], err)
+		}
+		handler(n)
+	}
+
+	return scanner.Err()
+}
+
+// readIOStatV2 sums io.stat of all devices, line format: "8:0 rbytes=1 wbytes=2 rios=3 wios=4 dbytes=0 dios=0"
+func readIOStatV2(s stats, path string) error {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read %s error %w", path, err)
+	}
+
+	names := map[string]string{"rbytes": IOReadBytes, "wbytes": IOWriteBytes, "rios": IOReadOps, "wios": IOWriteOps}
+	for _, name := range names {
+		s.counters[name] = 0
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		for _, f := range fields[min(1, len(fields)):] {
+			key, val, ok := strings.Cut(f, "=")
+			name, known := names[key]
+			if !ok || !known {
+				continue
+			}
+
+			n, err := strconv.ParseUint(val, 10, 64)
+			if err != nil {
+				return fmt.Errorf("parse %s key %s error %w", path, key, err)
+			}
+			s.counters[name] += n
+		}
+	}
+
+	return scanner.Err()
+}
+
+// readBlkioV1 sums Read and Write operations of all devices, line format: "8:0 Read 1024", "Total 2048"
+func readBlkioV1(s stats, path, read, write string) error {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read %s error %w", path, err)
+	}
+
+	s.counters[read], s.counters[write] = 0, 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 {
+			continue
+		}
+
+		var name string
+		switch fields[1] {
+		case "Read":
+			name = read
+		case "Write":
+			name = write
+		default:
+			continue
+		}
+
+		n, err := strconv.ParseUint(fields[2], 10, 64)
+		if err != nil {
+			return fmt.Errorf("parse %s error %w", path, err)
+		}
+		s.counters[name] += n
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,166 @@
+package cgroup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+)
+
+// fixture copies testdata directory, so the test can change the counters between polls
+func fixture(t *testing.T, name string) (root, procCgroup string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.CopyFS(dir, os.DirFS(filepath.Join("testdata", name))))
+
+	return filepath.Join(dir, "sys"), filepath.Join(dir, "proc", "cgroup")
+}
+
+func TestNewCollector(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+		want    Version
+		wantErr error
+	}{
+		{name: "unified hierarchy", fixture: "v2", want: V2},
+		{name: "legacy hierarchy", fixture: "v1", want: V1},
+		{name: "not a cgroup filesystem", fixture: "none", wantErr: ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewCollector(fixture(t, tt.fixture))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, c.Version())
+		})
+	}
+}
+
+func TestCollector_Collect(t *testing.T) {
+	tests := []struct {
+		name        string
+		fixture     string
+		update      map[string]string // files rewritten before the second poll
+		wantGauges  map[string]float64
+		wantCounter map[string]int64 // counters of the second poll
+	}{
+		{
+			name:    "v2",
+			fixture: "v2",
+			update: map[string]string{
+				"system.slice/app.service/cpu.stat":      "usage_usec 5500000\nnr_periods 110\nnr_throttled 12\nthrottled_usec 250000\n",
+				"system.slice/app.service/memory.events": "oom_kill 1\n",
+				"system.slice/app.service/io.stat":       "8:0 rbytes=1100 wbytes=2000 rios=11 wios=20\n259:0 rbytes=500 wbytes=100 rios=5 wios=1\n",
+			},
+			wantGauges: map[string]float64{
+				MemoryUsage: 104857600,
+				MemoryLimit: 536870912,
+				Pids:        12,
+			},
+			wantCounter: map[string]int64{
+				OOMKills:            0,
+				CPUUsageUsec:        500000,
+				CPUPeriods:          10,
+				CPUThrottledPeriods: 2,
+				CPUThrottledUsec:    50000,
+				IOReadBytes:         100,
+				IOWriteBytes:        100,
+				IOReadOps:           1,
+				IOWriteOps:          1,
+			},
+		},
+		{
+			name:    "v1",
+			fixture: "v1",
+			update: map[string]string{
+				"cpuacct/docker/abc/cpuacct.usage":                 "3500000000\n",
+				"cpu/docker/abc/cpu.stat":                          "nr_periods 60\nnr_throttled 5\nthrottled_time 100000000\n",
+				"memory/docker/abc/memory.oom_control":             "oom_kill 3\n",
+				"blkio/docker/abc/blkio.throttle.io_service_bytes": "8:0 Read 5120\n8:0 Write 8192\nTotal 13312\n",
+				"blkio/docker/abc/blkio.throttle.io_serviced":      "8:0 Read 5\n8:0 Write 8\nTotal 13\n",
+			},
+			wantGauges: map[string]float64{
+				MemoryUsage: 52428800,
+				Pids:        7,
+				PidsLimit:   100,
+			},
+			wantCounter: map[string]int64{
+				OOMKills:            1,
+				CPUUsageUsec:        500000,
+				CPUPeriods:          10,
+				CPUThrottledPeriods: 0,
+				CPUThrottledUsec:    0,
+				IOReadBytes:         1024,
+				IOWriteBytes:        0,
+				IOReadOps:           1,
+				IOWriteOps:          0,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, procCgroup := fixture(t, tt.fixture)
+			c, err := NewCollector(root, procCgroup)
+			require.NoError(t, err)
+
+			first, err := c.Collect(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantGauges, gauges(first), "first poll reports gauges only, counters take the baseline")
+			assert.Empty(t, counters(first))
+
+			for name, content := range tt.update {
+				require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(content), 0644))
+			}
+
+			second, err := c.Collect(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantGauges, gauges(second))
+			assert.Equal(t, tt.wantCounter, counters(second))
+		})
+	}
+}
+
+func TestCollector_Collect_brokenFile(t *testing.T) {
+	root, procCgroup := fixture(t, "v2")
+	c, err := NewCollector(root, procCgroup)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "system.slice/app.service/memory.current"), []byte("abc"), 0644))
+
+	_, err = c.Collect(context.Background())
+	assert.Error(t, err)
+}
+
+func gauges(metrics []models.Metric) map[string]float64 {
+	res := make(map[string]float64)
+	for _, m := range metrics {
+		if m.Type == models.GaugeType {
+			res[m.Name] = m.Value
+		}
+	}
+
+	return res
+}
+
+func counters(metrics []models.Metric) map[string]int64 {
+	res := make(map[string]int64)
+	for _, m := range metrics {
+		if m.Type == models.CounterType {
+			res[m.Name] = m.Delta
+		}
+	}
+
+	return res
+}
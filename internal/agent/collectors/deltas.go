@@ -0,0 +1,39 @@
+// Package collectors contains helpers shared by the agent collectors.
+package collectors
+
+import (
+	"sync"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+)
+
+// Deltas converts monotonic kernel counters into counter metrics. The agent reports counter increments,
+// so only the difference since the previous observation is reported. The first observation is a baseline
+// and produces no metric, a value less than the previous one means the counter was reset and is reported as is.
+type Deltas struct {
+	mu   sync.Mutex
+	prev map[string]uint64
+}
+
+func NewDeltas() *Deltas {
+	return &Deltas{prev: make(map[string]uint64)}
+}
+
+// Counter returns counter metric with the increment of val, false is returned for the baseline observation
+func (d *Deltas) Counter(name string, val uint64) (models.Metric, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	prev, ok := d.prev[name]
+	d.prev[name] = val
+	if !ok {
+		return models.Metric{}, false
+	}
+
+	delta := val
+	if val >= prev {
+		delta = val - prev
+	}
+
+	return models.NewCounter(name, int64(delta)), true
+}
@@ -0,0 +1,36 @@
+package collectors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+)
+
+func TestDeltas_Counter(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []uint64
+		want   []int64 // -1 means no metric
+	}{
+		{name: "baseline", values: []uint64{100}, want: []int64{-1}},
+		{name: "increments", values: []uint64{100, 150, 150, 200}, want: []int64{-1, 50, 0, 50}},
+		{name: "reset", values: []uint64{100, 20, 30}, want: []int64{-1, 20, 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDeltas()
+			for i, v := range tt.values {
+				m, ok := d.Counter("c", v)
+				if tt.want[i] < 0 {
+					assert.False(t, ok)
+					continue
+				}
+
+				assert.True(t, ok)
+				assert.Equal(t, models.NewCounter("c", tt.want[i]), m)
+			}
+		})
+	}
+}
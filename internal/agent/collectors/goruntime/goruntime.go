@@ -0,0 +1,274 @@
+// Package goruntime collects Go runtime metrics with runtime/metrics, which unlike runtime.ReadMemStats
+// doesn't stop the world.
+package goruntime
+
+import (
+	"context"
+	"math"
+	"runtime/metrics"
+	"strconv"
+	"strings"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/collectors"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+)
+
+// histograms are summarised as quantiles of the observations made since the previous poll
+var histograms = []string{
+	"/sched/pauses/total/gc:seconds",
+	"/sched/latencies:seconds",
+}
+
+// quantiles reported for every histogram as <name>_p<quantile*100>
+var quantiles = []float64{0.5, 0.9, 0.99}
+
+// alias reports runtime/metrics values under the runtime.MemStats name, so dashboards built
+// for the memstats collector keep working. LastGC, Lookups and PauseTotalNs have no equivalent.
+type alias struct {
+	name  string
+	value func(v map[string]float64) (float64, bool)
+}
+
+var aliases = []alias{
+	{name: "Alloc", value: sum("/memory/classes/heap/objects:bytes")},
+	{name: "BuckHashSys", value: sum("/memory/classes/profiling/buckets:bytes")},
+	{name: "Frees", value: sum("/gc/heap/frees:objects")},
+	{name: "GCCPUFraction", value: ratio("/cpu/classes/gc/total:cpu-seconds", "/cpu/classes/total:cpu-seconds")},
+	{name: "GCSys", value: sum("/memory/classes/metadata/other:bytes")},
+	{name: "HeapAlloc", value: sum("/memory/classes/heap/objects:bytes")},
+	{name: "HeapIdle", value: sum("/memory/classes/heap/released:bytes", "/memory/classes/heap/free:bytes")},
+	{name: "HeapInuse", value: sum("/memory/classes/heap/objects:bytes", "/memory/classes/heap/unused:bytes")},
+	{name: "HeapObjects", value: sum("/gc/heap/objects:objects")},
+	{name: "HeapReleased", value: sum("/memory/classes/heap/released:bytes")},
+	{name: "HeapSys", value: sum(
+		"/memory/classes/heap/objects:bytes",
+		"/memory/classes/heap/unused:bytes",
+		"/memory/classes/heap/free:bytes",
+		"/memory/classes/heap/released:bytes",
+	)},
+	{name: "MCacheInuse", value: sum("/memory/classes/metadata/mcache/inuse:bytes")},
+	{name: "MCacheSys", value: sum("/memory/classes/metadata/mcache/inuse:bytes", "/memory/classes/metadata/mcache/free:bytes")},
+	{name: "MSpanInuse", value: sum("/memory/classes/metadata/mspan/inuse:bytes")},
+	{name: "MSpanSys", value: sum("/memory/classes/metadata/mspan/inuse:bytes", "/memory/classes/metadata/mspan/free:bytes")},
+	{name: "Mallocs", value: sum("/gc/heap/allocs:objects")},
+	{name: "NextGC", value: sum("/gc/heap/goal:bytes")},
+	{name: "NumForcedGC", value: sum("/gc/cycles/forced:gc-cycles")},
+	{name: "NumGC", value: sum("/gc/cycles/total:gc-cycles")},
+	{name: "OtherSys", value: sum("/memory/classes/other:bytes")},
+	{name: "StackInuse", value: sum("/memory/classes/heap/stacks:bytes")},
+	{name: "StackSys", value: sum("/memory/classes/heap/stacks:bytes", "/memory/classes/os-stacks:bytes")},
+	{name: "Sys", value: sum("/memory/classes/total:bytes")},
+	{name: "TotalAlloc", value: sum("/gc/heap/allocs:bytes")},
+}
+
+func sum(names ...string) func(v map[string]float64) (float64, bool) {
+	return func(v map[string]float64) (float64, bool) {
+		var res float64
+		for _, name := range names {
+			val, ok := v[name]
+			if !ok {
+				return 0, false
+			}
+			res += val
+		}
+
+		return res, true
+	}
+}
+
+func ratio(numerator, denominator string) func(v map[string]float64) (float64, bool) {
+	return func(v map[string]float64) (float64, bool) {
+		num, ok := v[numerator]
+		if !ok {
+			return 0, false
+		}
+
+		den, ok := v[denominator]
+		if !ok {
+			return 0, false
+		}
+
+		if den == 0 {
+			return 0, true
+		}
+
+		return num / den, true
+	}
+}
+
+// Collector reports all supported scalar runtime metrics, cumulative integer metrics are reported as counters,
+// the rest as gauges. Metric names are derived from runtime/metrics names: /gc/heap/allocs:bytes is go_gc_heap_allocs_bytes.
+type Collector struct {
+	descriptions []metrics.Description
+	samples      []metrics.Sample
+	deltas       *collectors.Deltas
+	prevCounts   map[string][]uint64
+}
+
+// NewCollector creates collector of the metrics supported by the running Go version
+func NewCollector() *Collector {
+	c := &Collector{
+		deltas:     collectors.NewDeltas(),
+		prevCounts: make(map[string][]uint64),
+	}
+
+	for _, d := range metrics.All() {
+		switch d.Kind {
+		case metrics.KindUint64, metrics.KindFloat64:
+		case metrics.KindFloat64Histogram:
+			if !isSummarised(d.Name) {
+				continue
+			}
+		default:
+			continue
+		}
+
+		c.descriptions = append(c.descriptions, d)
+		c.samples = append(c.samples, metrics.Sample{Name: d.Name})
+	}
+
+	return c
+}
+
+func (c *Collector) Name() string {
+	return "goruntime"
+}
+
+func (c *Collector) Collect(ctx context.Context) ([]models.Metric, error) {
+	metrics.Read(c.samples)
+
+	res := make([]models.Metric, 0, len(c.samples)+len(aliases))
+	values := make(map[string]float64, len(c.samples))
+
+	for i, s := range c.samples {
+		d := c.descriptions[i]
+
+		switch s.Value.Kind() {
+		case metrics.KindUint64:
+			val := s.Value.Uint64()
+			values[s.Name] = float64(val)
+
+			if !d.Cumulative {
+				res = append(res, models.NewGauge(metricName(s.Name), float64(val)))
+				continue
+			}
+
+			if m, ok := c.deltas.Counter(metricName(s.Name), val); ok {
+				res = append(res, m)
+			}
+		case metrics.KindFloat64:
+			val := s.Value.Float64()
+			values[s.Name] = val
+			res = append(res, models.NewGauge(metricName(s.Name), val))
+		case metrics.KindFloat64Histogram:
+			res = append(res, c.summarise(s.Name, s.Value.Float64Histogram())...)
+		}
+	}
+
+	for _, a := range aliases {
+		if val, ok := a.value(values); ok {
+			res = append(res, models.NewGauge(a.name, val))
+		}
+	}
+
+	return res, nil
+}
+
+// summarise reports number of observations since the previous poll and their quantiles,
+// quantiles are not reported when there were no observations
+func (c *Collector) summarise(name string, h *metrics.Float64Histogram) []models.Metric {
+	var (
+		prev   = c.prevCounts[name]
+		window = make([]uint64, len(h.Counts))
+		total  uint64
+		all    uint64
+	)
+
+	for i, cnt := range h.Counts {
+		all += cnt
+
+		window[i] = cnt
+		if len(prev) == len(h.Counts) && prev[i] <= cnt {
+			window[i] = cnt - prev[i]
+		}
+		total += window[i]
+	}
+	c.prevCounts[name] = append(prev[:0], h.Counts...)
+
+	base := metricName(name)
+
+	var res []models.Metric
+	if m, ok := c.deltas.Counter(base+"_count", all); ok {
+		res = append(res, m)
+	}
+
+	if total == 0 {
+		return res
+	}
+
+	for _, q := range quantiles {
+		res = append(res, models.NewGauge(base+"_p"+strconv.Itoa(int(math.Round(q*100))), quantile(window, h.Buckets, total, q)))
+	}
+
+	return res
+}
+
+// quantile estimates q quantile by linear interpolation inside the bucket, infinite bounds are replaced by the finite one
+func quantile(counts []uint64, buckets []float64, total uint64, q float64) float64 {
+	rank := q * float64(total)
+
+	var cum float64
+	for i, cnt := range counts {
+		if cnt == 0 {
+			continue
+		}
+
+		if cum+float64(cnt) >= rank {
+			lo, hi := buckets[i], buckets[i+1]
+			switch {
+			case math.IsInf(lo, -1):
+				return hi
+			case math.IsInf(hi, 1):
+				return lo
+			}
+
+			return lo + (hi-lo)*(rank-cum)/float64(cnt)
+		}
+
+		cum += float64(cnt)
+	}
+
+	return buckets[len(buckets)-1]
+}
+
+func isSummarised(name string) bool {
+	for _, h := range histograms {
+		if h == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// metricName converts runtime/metrics name to the name accepted by the server
+func metricName(name string) string {
+	var b strings.Builder
+	b.WriteString("go")
+
+	underscore := false
+	for _, r := range name {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			if underscore {
+				b.WriteByte('_')
+				underscore = false
+			}
+			b.WriteRune(r)
+			continue
+		}
+
+		underscore = true
+	}
+
+	return b.String()
+}
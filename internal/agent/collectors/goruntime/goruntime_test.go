@@ -0,0 +1,140 @@
+package goruntime
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"runtime/metrics"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+)
+
+var sink []byte
+
+func TestCollector_Collect(t *testing.T) {
+	c := NewCollector()
+
+	first, err := c.Collect(context.Background())
+	require.NoError(t, err)
+
+	firstGauges := gauges(first)
+	for _, a := range aliases {
+		assert.Contains(t, firstGauges, a.name, "memstats name is kept as alias")
+	}
+	assert.Contains(t, firstGauges, "go_memory_classes_heap_objects_bytes")
+	assert.Equal(t, firstGauges["HeapAlloc"], firstGauges["go_memory_classes_heap_objects_bytes"])
+	assert.Empty(t, counters(first), "first poll takes the counters baseline")
+
+	for range 100 {
+		sink = make([]byte, 1<<10)
+	}
+	runtime.GC()
+
+	second, err := c.Collect(context.Background())
+	require.NoError(t, err)
+
+	secondCounters := counters(second)
+	assert.GreaterOrEqual(t, secondCounters["go_gc_cycles_total_gc_cycles"], int64(1))
+	assert.GreaterOrEqual(t, secondCounters["go_gc_heap_allocs_objects"], int64(100))
+	assert.GreaterOrEqual(t, gauges(second)["NumGC"], firstGauges["NumGC"]+1)
+	assert.Contains(t, gauges(second), "go_sched_pauses_total_gc_seconds_p99", "gc pause observed since the previous poll")
+}
+
+func TestCollector_summarise(t *testing.T) {
+	c := NewCollector()
+	h := &metrics.Float64Histogram{
+		Counts:  []uint64{0, 10, 0},
+		Buckets: []float64{math.Inf(-1), 0, 1, math.Inf(1)},
+	}
+
+	first := c.summarise("/sched/latencies:seconds", h)
+	assert.Empty(t, counters(first))
+	assert.Equal(t, map[string]float64{
+		"go_sched_latencies_seconds_p50": 0.5,
+		"go_sched_latencies_seconds_p90": 0.9,
+		"go_sched_latencies_seconds_p99": 0.99,
+	}, gauges(first))
+
+	h.Counts = []uint64{0, 10, 0}
+	second := c.summarise("/sched/latencies:seconds", h)
+	assert.Equal(t, map[string]int64{"go_sched_latencies_seconds_count": 0}, counters(second))
+	assert.Empty(t, gauges(second), "no observations since the previous poll")
+
+	h.Counts = []uint64{0, 10, 4}
+	third := c.summarise("/sched/latencies:seconds", h)
+	assert.Equal(t, map[string]int64{"go_sched_latencies_seconds_count": 4}, counters(third))
+	assert.Equal(t, map[string]float64{
+		"go_sched_latencies_seconds_p50": 1,
+		"go_sched_latencies_seconds_p90": 1,
+		"go_sched_latencies_seconds_p99": 1,
+	}, gauges(third), "only the window is summarised, infinite bound is replaced by the finite one")
+}
+
+func Test_quantile(t *testing.T) {
+	buckets := []float64{0, 1, 2, 4}
+
+	tests := []struct {
+		name   string
+		counts []uint64
+		q      float64
+		want   float64
+	}{
+		{name: "median in the first bucket", counts: []uint64{2, 0, 0}, q: 0.5, want: 0.5},
+		{name: "median between buckets", counts: []uint64{1, 1, 0}, q: 0.5, want: 1},
+		{name: "tail in the last bucket", counts: []uint64{5, 3, 2}, q: 0.9, want: 3},
+		{name: "max", counts: []uint64{5, 3, 2}, q: 1, want: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var total uint64
+			for _, c := range tt.counts {
+				total += c
+			}
+
+			assert.InDelta(t, tt.want, quantile(tt.counts, buckets, total, tt.q), 1e-9)
+		})
+	}
+}
+
+func Test_metricName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "/gc/heap/allocs:bytes", want: "go_gc_heap_allocs_bytes"},
+		{name: "/cpu/classes/gc/mark/assist:cpu-seconds", want: "go_cpu_classes_gc_mark_assist_cpu_seconds"},
+		{name: "/sched/gomaxprocs:threads", want: "go_sched_gomaxprocs_threads"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, metricName(tt.name))
+		})
+	}
+}
+
+func gauges(metrics []models.Metric) map[string]float64 {
+	res := make(map[string]float64)
+	for _, m := range metrics {
+		if m.Type == models.GaugeType {
+			res[m.Name] = m.Value
+		}
+	}
+
+	return res
+}
+
+func counters(metrics []models.Metric) map[string]int64 {
+	res := make(map[string]int64)
+	for _, m := range metrics {
+		if m.Type == models.CounterType {
+			res[m.Name] = m.Delta
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,66 @@
+package logtail
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// fingerprintSize is the number of the first bytes of the file identifying it in the checkpoint
+const fingerprintSize = 1024
+
+// position of the tailed file persisted between agent restarts
+type position struct {
+	Offset      int64  `json:"offset"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// loadCheckpoint reads positions by file path, missing checkpoint has no positions
+func loadCheckpoint(path string) (map[string]position, error) {
+	positions := make(map[string]position)
+	if path == "" {
+		return positions, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return positions, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read checkpoint error %w", err)
+	}
+
+	if err := json.Unmarshal(b, &positions); err != nil {
+		return nil, fmt.Errorf("parse checkpoint %s error %w", path, err)
+	}
+
+	return positions, nil
+}
+
+// writeCheckpoint replaces the checkpoint atomically, so it is never left half written
+func writeCheckpoint(path string, b []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("create checkpoint error %w", err)
+	}
+
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("write checkpoint error %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("close checkpoint error %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("rename checkpoint error %w", err)
+	}
+
+	return nil
+}
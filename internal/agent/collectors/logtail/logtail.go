@@ -0,0 +1,233 @@
+// Package logtail derives metrics from lines appended to log files.
+package logtail
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"go.uber.org/zap"
+)
+
+type rule struct {
+	config.LogTailRule
+	re    *regexp.Regexp
+	group int // index of the value capture group, -1 when the counter is incremented by 1
+}
+
+func newRule(r config.LogTailRule) (rule, error) {
+	if r.Name == "" {
+		return rule{}, errors.New("name is empty")
+	}
+
+	re, err := regexp.Compile(r.Regex)
+	if err != nil {
+		return rule{}, fmt.Errorf("compile regex %q error %w", r.Regex, err)
+	}
+
+	group := -1
+	if r.Value != "" {
+		if group = re.SubexpIndex(r.Value); group < 0 {
+			return rule{}, fmt.Errorf("regex %q has no capture group %q", r.Regex, r.Value)
+		}
+	}
+
+	switch r.Type {
+	case models.CounterType:
+	case models.GaugeType:
+		if group < 0 {
+			return rule{}, errors.New("gauge value is empty")
+		}
+	default:
+		return rule{}, fmt.Errorf("unknown metric type %q", r.Type)
+	}
+
+	return rule{LogTailRule: r, re: re, group: group}, nil
+}
+
+// Collector tails the configured files on every poll. Matching lines increment counters or set gauges,
+// counters are reported as increments of the poll, gauges are reported with the last matched value.
+// Files are read from the end on the first start, read offsets are persisted in the checkpoint,
+// so after restart the agent continues from the offset without counting the lines again.
+type Collector struct {
+	tailers    []*tailer
+	checkpoint string
+	saved      []byte
+	lg         *logging.ZapLogger
+}
+
+// NewCollector compiles the rules and opens the files, checkpoint is optional
+func NewCollector(files []config.LogTailFile, checkpoint string, lg *logging.ZapLogger) (*Collector, error) {
+	positions, err := loadCheckpoint(checkpoint)
+	if err != nil {
+		return nil, fmt.Errorf("internal/agent/collectors/logtail: %w", err)
+	}
+
+	c := &Collector{checkpoint: checkpoint, lg: lg}
+	for _, file := range files {
+		t := &tailer{path: file.Path}
+		for i, r := range file.Rules {
+			compiled, err := newRule(r)
+			if err != nil {
+				_ = c.Close()
+				return nil, fmt.Errorf("internal/agent/collectors/logtail: %s rule %d error %w", file.Path, i, err)
+			}
+
+			t.rules = append(t.rules, compiled)
+		}
+
+		if err := c.restore(t, positions); err != nil {
+			_ = t.close()
+			_ = c.Close()
+			return nil, fmt.Errorf("internal/agent/collectors/logtail: %w", err)
+		}
+
+		c.tailers = append(c.tailers, t)
+	}
+
+	return c, nil
+}
+
+// restore continues from the checkpoint when it belongs to the same file, the file replaced while the agent
+// was stopped is read from the beginning, unknown file is read from the end
+func (c *Collector) restore(t *tailer, positions map[string]position) error {
+	if err := t.open(0); err != nil {
+		return err
+	}
+
+	if t.f == nil {
+		return nil
+	}
+
+	pos, ok := positions[t.path]
+	if !ok {
+		t.offset = t.info.Size()
+		return nil
+	}
+
+	if pos.Offset > t.info.Size() {
+		return nil
+	}
+
+	t.offset = pos.Offset
+	fingerprint, err := t.fingerprint()
+	if err != nil {
+		return err
+	}
+
+	if fingerprint != pos.Fingerprint {
+		t.offset = 0
+	}
+
+	return nil
+}
+
+func (c *Collector) Name() string {
+	return "logtail"
+}
+
+func (c *Collector) Collect(ctx context.Context) ([]models.Metric, error) {
+	var (
+		counters = make(map[string]int64)
+		gauges   = make(map[string]float64)
+	)
+
+	for _, t := range c.tailers {
+		err := t.poll(func(line string) {
+			for _, r := range t.rules {
+				c.match(ctx, r, line, counters, gauges)
+			}
+		})
+		if err != nil {
+			c.lg.ErrorCtx(ctx, "failed to tail log file", zap.String("path", t.path), zap.Error(err))
+		}
+	}
+
+	if err := c.save(); err != nil {
+		c.lg.ErrorCtx(ctx, "failed to save log tail checkpoint", zap.String("checkpoint", c.checkpoint), zap.Error(err))
+	}
+
+	metrics := make([]models.Metric, 0, len(counters)+len(gauges))
+	for name, delta := range counters {
+		metrics = append(metrics, models.NewCounter(name, delta))
+	}
+	for name, val := range gauges {
+		metrics = append(metrics, models.NewGauge(name, val))
+	}
+
+	return metrics, nil
+}
+
+func (c *Collector) match(ctx context.Context, r rule, line string, counters map[string]int64, gauges map[string]float64) {
+	match := r.re.FindStringSubmatch(line)
+	if match == nil {
+		return
+	}
+
+	val := 1.0
+	if r.group >= 0 {
+		var err error
+		if val, err = strconv.ParseFloat(match[r.group], 64); err != nil {
+			c.lg.DebugCtx(ctx, "log line value is not a number", zap.String("metric", r.Name), zap.String("value", match[r.group]))
+			return
+		}
+	}
+
+	if r.Type == models.CounterType {
+		counters[r.Name] += int64(math.Round(val))
+		return
+	}
+
+	gauges[r.Name] = val
+}
+
+// save writes the checkpoint when offsets are changed
+func (c *Collector) save() error {
+	if c.checkpoint == "" {
+		return nil
+	}
+
+	positions := make(map[string]position, len(c.tailers))
+	for _, t := range c.tailers {
+		fingerprint, err := t.fingerprint()
+		if err != nil {
+			return err
+		}
+
+		positions[t.path] = position{Offset: t.offset, Fingerprint: fingerprint}
+	}
+
+	b, err := json.Marshal(positions)
+	if err != nil {
+		return fmt.Errorf("marshal checkpoint error %w", err)
+	}
+
+	if bytes.Equal(b, c.saved) {
+		return nil
+	}
+
+	if err := writeCheckpoint(c.checkpoint, b); err != nil {
+		return err
+	}
+
+	c.saved = b
+	return nil
+}
+
+// Close closes the tailed files
+func (c *Collector) Close() error {
+	var errs []error
+	for _, t := range c.tailers {
+		errs = append(errs, t.close())
+	}
+
+	return errors.Join(errs...)
+}
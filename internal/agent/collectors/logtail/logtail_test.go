@@ -0,0 +1,187 @@
+package logtail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+)
+
+var rules = []config.LogTailRule{
+	{Name: "AppErrors", Type: models.CounterType, Regex: `level=error`},
+	{Name: "AppBytes", Type: models.CounterType, Regex: `bytes=(?P<bytes>\d+)`, Value: "bytes"},
+	{Name: "AppLatency", Type: models.GaugeType, Regex: `latency=(?P<ms>[0-9.]+)`, Value: "ms"},
+}
+
+func newTestCollector(t *testing.T, path, checkpoint string) *Collector {
+	t.Helper()
+
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+	require.NoError(t, err)
+
+	c, err := NewCollector([]config.LogTailFile{{Path: path, Rules: rules}}, checkpoint, lg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	return c
+}
+
+func appendLines(t *testing.T, path, lines string) {
+	t.Helper()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	defer f.Close()
+
+	_, err = f.WriteString(lines)
+	require.NoError(t, err)
+}
+
+func collect(t *testing.T, c *Collector) (map[string]int64, map[string]float64) {
+	t.Helper()
+
+	metrics, err := c.Collect(context.Background())
+	require.NoError(t, err)
+
+	counters := make(map[string]int64)
+	gauges := make(map[string]float64)
+	for _, m := range metrics {
+		switch m.Type {
+		case models.CounterType:
+			counters[m.Name] = m.Delta
+		case models.GaugeType:
+			gauges[m.Name] = m.Value
+		}
+	}
+
+	return counters, gauges
+}
+
+func TestCollector_Collect(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendLines(t, path, "level=error written before the agent started\n")
+
+	c := newTestCollector(t, path, "")
+
+	counters, gauges := collect(t, c)
+	assert.Empty(t, counters, "existing lines are skipped on the first start")
+	assert.Empty(t, gauges)
+
+	appendLines(t, path, "level=error msg=boom\nlevel=info bytes=100 latency=12.5\nlevel=info bytes=50 latency=7\nlevel=info bytes=abc\n")
+
+	counters, gauges = collect(t, c)
+	assert.Equal(t, map[string]int64{"AppErrors": 1, "AppBytes": 150}, counters)
+	assert.Equal(t, map[string]float64{"AppLatency": 7}, gauges, "gauge has the last value")
+
+	counters, gauges = collect(t, c)
+	assert.Empty(t, counters)
+	assert.Empty(t, gauges)
+}
+
+func TestCollector_Collect_partialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	c := newTestCollector(t, path, "")
+
+	appendLines(t, path, "level=err")
+	counters, _ := collect(t, c)
+	assert.Empty(t, counters, "incomplete line waits for the rest")
+
+	appendLines(t, path, "or\n")
+	counters, _ = collect(t, c)
+	assert.Equal(t, map[string]int64{"AppErrors": 1}, counters)
+}
+
+func TestCollector_Collect_rotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendLines(t, path, "")
+
+	c := newTestCollector(t, path, "")
+	collect(t, c)
+
+	appendLines(t, path, "level=error before rotation\n")
+	require.NoError(t, os.Rename(path, filepath.Join(dir, "app.log.1")))
+	appendLines(t, filepath.Join(dir, "app.log.1"), "level=error written to the rotated file\n")
+
+	counters, _ := collect(t, c)
+	assert.Equal(t, map[string]int64{"AppErrors": 2}, counters, "rotated file is read while the new one is not created")
+
+	appendLines(t, filepath.Join(dir, "app.log.1"), "level=error the last line of the rotated file")
+	appendLines(t, path, "level=error the first line of the new file\n")
+
+	counters, _ = collect(t, c)
+	assert.Equal(t, map[string]int64{"AppErrors": 2}, counters, "rest of the rotated file and the new file are read")
+}
+
+func TestCollector_Collect_truncation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	c := newTestCollector(t, path, "")
+
+	appendLines(t, path, "level=error first\nlevel=error second\n")
+	counters, _ := collect(t, c)
+	assert.Equal(t, map[string]int64{"AppErrors": 2}, counters)
+
+	require.NoError(t, os.Truncate(path, 0))
+	appendLines(t, path, "level=error\n")
+
+	counters, _ = collect(t, c)
+	assert.Equal(t, map[string]int64{"AppErrors": 1}, counters)
+}
+
+func TestCollector_checkpoint(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	checkpoint := filepath.Join(dir, "checkpoint.json")
+
+	c := newTestCollector(t, path, checkpoint)
+	appendLines(t, path, "level=error first\n")
+	counters, _ := collect(t, c)
+	assert.Equal(t, map[string]int64{"AppErrors": 1}, counters)
+	require.NoError(t, c.Close())
+
+	t.Run("restart continues from the offset", func(t *testing.T) {
+		appendLines(t, path, "level=error written while the agent was stopped\n")
+
+		c := newTestCollector(t, path, checkpoint)
+		counters, _ := collect(t, c)
+		assert.Equal(t, map[string]int64{"AppErrors": 1}, counters)
+	})
+
+	t.Run("file replaced while the agent was stopped is read from the beginning", func(t *testing.T) {
+		require.NoError(t, os.Remove(path))
+		appendLines(t, path, "level=error new file with other content\nlevel=error second line\nlevel=info\n")
+
+		c := newTestCollector(t, path, checkpoint)
+		counters, _ := collect(t, c)
+		assert.Equal(t, map[string]int64{"AppErrors": 2}, counters)
+	})
+}
+
+func TestNewCollector_invalidRule(t *testing.T) {
+	tests := []struct {
+		name string
+		rule config.LogTailRule
+	}{
+		{name: "empty name", rule: config.LogTailRule{Type: models.CounterType, Regex: "error"}},
+		{name: "invalid regex", rule: config.LogTailRule{Name: "Errors", Type: models.CounterType, Regex: "("}},
+		{name: "unknown capture group", rule: config.LogTailRule{Name: "Bytes", Type: models.CounterType, Regex: `bytes=(\d+)`, Value: "bytes"}},
+		{name: "gauge without value", rule: config.LogTailRule{Name: "Latency", Type: models.GaugeType, Regex: "latency"}},
+		{name: "unknown type", rule: config.LogTailRule{Name: "Errors", Type: "histogram", Regex: "error"}},
+	}
+
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+	require.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCollector([]config.LogTailFile{{Path: filepath.Join(t.TempDir(), "app.log"), Rules: []config.LogTailRule{tt.rule}}}, "", lg)
+			assert.Error(t, err)
+		})
+	}
+}
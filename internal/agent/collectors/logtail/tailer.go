@@ -0,0 +1,138 @@
+package logtail
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// tailer follows appended lines of the file by path. When the file is replaced (rotation) the rest of
+// the old file is read before switching to the new one, the new file is read from the beginning.
+// When the file shrinks (truncation) it is read from the beginning.
+type tailer struct {
+	path   string
+	rules  []rule
+	f      *os.File
+	info   os.FileInfo
+	offset int64
+}
+
+// open opens the file and continues from the offset, missing file is opened by the next poll
+func (t *tailer) open(offset int64) error {
+	f, err := os.Open(t.path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.offset = 0
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open %s error %w", t.path, err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("stat %s error %w", t.path, err)
+	}
+
+	t.f, t.info, t.offset = f, info, offset
+	return nil
+}
+
+// poll reads complete lines appended since the previous poll
+func (t *tailer) poll(handle func(line string)) error {
+	info, err := os.Stat(t.path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		// the file is rotated and the new one is not created yet, the writer may still append to the old one
+		if t.f == nil {
+			return nil
+		}
+
+		return t.read(handle, false)
+	case err != nil:
+		return fmt.Errorf("stat %s error %w", t.path, err)
+	}
+
+	if t.f != nil && !os.SameFile(t.info, info) {
+		if err := t.read(handle, true); err != nil {
+			return err
+		}
+
+		if err := t.close(); err != nil {
+			return err
+		}
+	}
+
+	if t.f == nil {
+		if err := t.open(0); err != nil {
+			return err
+		}
+
+		if t.f == nil {
+			return nil
+		}
+	}
+
+	if info.Size() < t.offset {
+		t.offset = 0
+	}
+
+	return t.read(handle, false)
+}
+
+// read handles lines from the offset to the end of the file, incomplete last line is left for the next poll
+// unless the file is final, i.e. it is rotated and won't be written anymore
+func (t *tailer) read(handle func(line string), final bool) error {
+	r := bufio.NewReader(io.NewSectionReader(t.f, t.offset, math.MaxInt64-t.offset))
+
+	for {
+		line, err := r.ReadString('\n')
+		if errors.Is(err, io.EOF) {
+			if final && line != "" {
+				t.offset += int64(len(line))
+				handle(strings.TrimRight(line, "\r\n"))
+			}
+
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read %s error %w", t.path, err)
+		}
+
+		t.offset += int64(len(line))
+		handle(strings.TrimRight(line, "\r\n"))
+	}
+}
+
+// fingerprint returns hash of the first bytes of the read part of the file, it identifies the file after restart
+func (t *tailer) fingerprint() (string, error) {
+	if t.f == nil {
+		return "", nil
+	}
+
+	buf := make([]byte, min(t.offset, fingerprintSize))
+	if _, err := t.f.ReadAt(buf, 0); err != nil {
+		return "", fmt.Errorf("read %s fingerprint error %w", t.path, err)
+	}
+
+	return fmt.Sprintf("%d:%x", len(buf), sha256.Sum256(buf)), nil
+}
+
+func (t *tailer) close() error {
+	if t.f == nil {
+		return nil
+	}
+
+	err := t.f.Close()
+	t.f, t.info, t.offset = nil, nil, 0
+	if err != nil {
+		return fmt.Errorf("close %s error %w", t.path, err)
+	}
+
+	return nil
+}
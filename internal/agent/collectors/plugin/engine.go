@@ -0,0 +1,48 @@
+// Package plugin runs collectors shipped as WebAssembly modules.
+//
+// Plugin ABI: the module exports memory, alloc(size i32) i32 used by the host to pass data
+// to the module, and collect() i64 returning ptr<<32|len of JSON encoded samples in the module memory:
+//
+//	[{"name":"QueueLength","type":"gauge","value":12},{"name":"Processed","type":"counter","value":3}]
+//
+// Counters are increments since the previous collect. The host module "host" provides
+// read_file(path_ptr, path_len i32) i64 returning ptr<<32|len of the file contents written with alloc,
+// or a negative value when access is denied or the file can't be read, and log(ptr, len i32).
+package plugin
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNoEngine is returned when the collector is created without a WebAssembly engine
+var ErrNoEngine = errors.New("plugin: webassembly engine is not available")
+
+// DefaultEngine executes plugins with the wazero runtime
+var DefaultEngine Engine = NewWazeroEngine()
+
+// Limits restrict resources of a single plugin
+type Limits struct {
+	MemoryPages uint32        // 64KiB pages of the linear memory
+	Timeout     time.Duration // of a single collect call
+}
+
+// HostAPI is the part of the agent exposed to the plugin
+type HostAPI interface {
+	ReadFile(path string) ([]byte, error)
+	Log(msg string)
+}
+
+// Engine compiles and instantiates plugin modules, the implementation wraps the WebAssembly runtime
+type Engine interface {
+	Instantiate(ctx context.Context, name string, wasm []byte, limits Limits, host HostAPI) (Instance, error)
+}
+
+// Instance is an instantiated plugin module
+type Instance interface {
+	// Collect calls the exported collect function and returns the encoded samples,
+	// the call must be interrupted when ctx is done
+	Collect(ctx context.Context) ([]byte, error)
+	Close(ctx context.Context) error
+}
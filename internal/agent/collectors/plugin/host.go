@@ -0,0 +1,84 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"go.uber.org/zap"
+)
+
+// maxFileSize limits the file passed to the plugin
+const maxFileSize = 1 << 20
+
+// ErrAccessDenied is returned when the plugin reads the file not allowed by the config
+var ErrAccessDenied = errors.New("plugin: access denied")
+
+// host lets the plugin read only the allowed files and directories, symlinks are resolved before the check
+type host struct {
+	plugin  string
+	allowed []string
+	lg      *logging.ZapLogger
+}
+
+func newHost(plugin string, allowed []string, lg *logging.ZapLogger) (*host, error) {
+	h := &host{plugin: plugin, lg: lg}
+	for _, path := range allowed {
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			path = resolved
+		}
+
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("allowed path %q error %w", path, err)
+		}
+
+		h.allowed = append(h.allowed, filepath.Clean(abs))
+	}
+
+	return h, nil
+}
+
+func (h *host) ReadFile(path string) ([]byte, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return nil, ErrAccessDenied
+	}
+
+	resolved, err = filepath.Abs(resolved)
+	if err != nil || !h.isAllowed(resolved) {
+		return nil, ErrAccessDenied
+	}
+
+	f, err := os.Open(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("open %s error %w", path, err)
+	}
+	defer f.Close()
+
+	b, err := io.ReadAll(io.LimitReader(f, maxFileSize))
+	if err != nil {
+		return nil, fmt.Errorf("read %s error %w", path, err)
+	}
+
+	return b, nil
+}
+
+func (h *host) Log(msg string) {
+	h.lg.InfoCtx(context.Background(), msg, zap.String("plugin", h.plugin))
+}
+
+func (h *host) isAllowed(path string) bool {
+	for _, allowed := range h.allowed {
+		if path == allowed || strings.HasPrefix(path, allowed+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,124 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+)
+
+const (
+	defaultMemoryLimitMB = 16
+	defaultTimeout       = time.Second
+
+	wasmPageSize = 64 << 10
+)
+
+type sample struct {
+	Name  string  `json:"name"`
+	Type  string  `json:"type"`
+	Value float64 `json:"value"`
+}
+
+// Collector polls a single plugin, the collect call is interrupted when it exceeds the plugin timeout
+type Collector struct {
+	name     string
+	instance Instance
+	limits   Limits
+}
+
+// NewCollector loads the module and instantiates it with the configured limits
+func NewCollector(ctx context.Context, engine Engine, p config.Plugin, lg *logging.ZapLogger) (*Collector, error) {
+	if engine == nil {
+		return nil, ErrNoEngine
+	}
+
+	if p.Name == "" {
+		return nil, errors.New("internal/agent/collectors/plugin: name is empty")
+	}
+
+	wasm, err := os.ReadFile(p.Path)
+	if err != nil {
+		return nil, fmt.Errorf("internal/agent/collectors/plugin: %s read module error %w", p.Name, err)
+	}
+
+	h, err := newHost(p.Name, p.AllowedFiles, lg)
+	if err != nil {
+		return nil, fmt.Errorf("internal/agent/collectors/plugin: %s %w", p.Name, err)
+	}
+
+	memoryMB := p.MemoryLimitMB
+	if memoryMB == 0 {
+		memoryMB = defaultMemoryLimitMB
+	}
+
+	limits := Limits{
+		MemoryPages: uint32(uint64(memoryMB) << 20 / wasmPageSize),
+		Timeout:     time.Duration(p.TimeoutMs) * time.Millisecond,
+	}
+	if limits.Timeout <= 0 {
+		limits.Timeout = defaultTimeout
+	}
+
+	instance, err := engine.Instantiate(ctx, p.Name, wasm, limits, h)
+	if err != nil {
+		return nil, fmt.Errorf("internal/agent/collectors/plugin: %s instantiate error %w", p.Name, err)
+	}
+
+	return &Collector{name: p.Name, instance: instance, limits: limits}, nil
+}
+
+func (c *Collector) Name() string {
+	return "plugin:" + c.name
+}
+
+func (c *Collector) Collect(ctx context.Context) ([]models.Metric, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.limits.Timeout)
+	defer cancel()
+
+	b, err := c.instance.Collect(ctx)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("internal/agent/collectors/plugin: %s collect exceeded timeout %s", c.name, c.limits.Timeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("internal/agent/collectors/plugin: %s collect error %w", c.name, err)
+	}
+
+	var samples []sample
+	if err := json.Unmarshal(b, &samples); err != nil {
+		return nil, fmt.Errorf("internal/agent/collectors/plugin: %s decode samples error %w", c.name, err)
+	}
+
+	metrics := make([]models.Metric, 0, len(samples))
+	for _, s := range samples {
+		if s.Name == "" {
+			return nil, fmt.Errorf("internal/agent/collectors/plugin: %s sample name is empty", c.name)
+		}
+
+		switch s.Type {
+		case models.GaugeType:
+			metrics = append(metrics, models.NewGauge(s.Name, s.Value))
+		case models.CounterType:
+			metrics = append(metrics, models.NewCounter(s.Name, int64(s.Value)))
+		default:
+			return nil, fmt.Errorf("internal/agent/collectors/plugin: %s sample %s unknown type %q", c.name, s.Name, s.Type)
+		}
+	}
+
+	return metrics, nil
+}
+
+// Close releases the plugin instance
+func (c *Collector) Close() error {
+	if err := c.instance.Close(context.Background()); err != nil {
+		return fmt.Errorf("internal/agent/collectors/plugin: %s close error %w", c.name, err)
+	}
+
+	return nil
+}
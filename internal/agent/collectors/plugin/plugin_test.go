@@ -0,0 +1,181 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+)
+
+// fakeEngine instantiates plugins implemented in go
+type fakeEngine struct {
+	limits  Limits
+	host    HostAPI
+	collect func(ctx context.Context, host HostAPI) ([]byte, error)
+}
+
+func (e *fakeEngine) Instantiate(_ context.Context, _ string, _ []byte, limits Limits, host HostAPI) (Instance, error) {
+	e.limits, e.host = limits, host
+	return &fakeInstance{engine: e}, nil
+}
+
+type fakeInstance struct {
+	engine *fakeEngine
+	closed bool
+}
+
+func (i *fakeInstance) Collect(ctx context.Context) ([]byte, error) {
+	return i.engine.collect(ctx, i.engine.host)
+}
+
+func (i *fakeInstance) Close(context.Context) error {
+	i.closed = true
+	return nil
+}
+
+func newTestCollector(t *testing.T, engine Engine, p config.Plugin) (*Collector, error) {
+	t.Helper()
+
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+	require.NoError(t, err)
+
+	p.Path = filepath.Join(t.TempDir(), "plugin.wasm")
+	require.NoError(t, os.WriteFile(p.Path, []byte("\x00asm"), 0644))
+
+	return NewCollector(context.Background(), engine, p, lg)
+}
+
+func TestNewCollector(t *testing.T) {
+	t.Run("limits", func(t *testing.T) {
+		engine := &fakeEngine{}
+		_, err := newTestCollector(t, engine, config.Plugin{Name: "queue", MemoryLimitMB: 2, TimeoutMs: 250})
+		require.NoError(t, err)
+
+		assert.Equal(t, Limits{MemoryPages: 32, Timeout: 250 * time.Millisecond}, engine.limits)
+	})
+
+	t.Run("default limits", func(t *testing.T) {
+		engine := &fakeEngine{}
+		_, err := newTestCollector(t, engine, config.Plugin{Name: "queue"})
+		require.NoError(t, err)
+
+		assert.Equal(t, Limits{MemoryPages: 256, Timeout: time.Second}, engine.limits)
+	})
+
+	t.Run("no engine", func(t *testing.T) {
+		_, err := newTestCollector(t, nil, config.Plugin{Name: "queue"})
+		assert.ErrorIs(t, err, ErrNoEngine)
+	})
+}
+
+func TestCollector_Collect(t *testing.T) {
+	tests := []struct {
+		name    string
+		collect func(ctx context.Context, host HostAPI) ([]byte, error)
+		want    []models.Metric
+		wantErr bool
+	}{
+		{
+			name: "samples",
+			collect: func(context.Context, HostAPI) ([]byte, error) {
+				return []byte(`[{"name":"QueueLength","type":"gauge","value":12.5},{"name":"Processed","type":"counter","value":3}]`), nil
+			},
+			want: []models.Metric{models.NewGauge("QueueLength", 12.5), models.NewCounter("Processed", 3)},
+		},
+		{
+			name: "timeout",
+			collect: func(ctx context.Context, _ HostAPI) ([]byte, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+			wantErr: true,
+		},
+		{
+			name: "plugin error",
+			collect: func(context.Context, HostAPI) ([]byte, error) {
+				return nil, errors.New("trap: unreachable")
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid samples",
+			collect: func(context.Context, HostAPI) ([]byte, error) {
+				return []byte(`[{"name":"QueueLength","type":"histogram","value":1}]`), nil
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := newTestCollector(t, &fakeEngine{collect: tt.collect}, config.Plugin{Name: "queue", TimeoutMs: 20})
+			require.NoError(t, err)
+
+			got, err := c.Collect(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCollector_Close(t *testing.T) {
+	c, err := newTestCollector(t, &fakeEngine{}, config.Plugin{Name: "queue"})
+	require.NoError(t, err)
+
+	require.NoError(t, c.Close())
+	assert.True(t, c.instance.(*fakeInstance).closed)
+}
+
+func Test_host_ReadFile(t *testing.T) {
+	dir := t.TempDir()
+	allowed := filepath.Join(dir, "allowed")
+	require.NoError(t, os.Mkdir(allowed, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(allowed, "queue"), []byte("12"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "secret"), []byte("token"), 0644))
+	require.NoError(t, os.Symlink(filepath.Join(dir, "secret"), filepath.Join(allowed, "link")))
+
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+	require.NoError(t, err)
+
+	h, err := newHost("queue", []string{allowed}, lg)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr error
+	}{
+		{name: "allowed file", path: filepath.Join(allowed, "queue"), want: "12"},
+		{name: "file outside of the allowed directory", path: filepath.Join(dir, "secret"), wantErr: ErrAccessDenied},
+		{name: "relative path escaping the directory", path: filepath.Join(allowed, "..", "secret"), wantErr: ErrAccessDenied},
+		{name: "symlink escaping the directory", path: filepath.Join(allowed, "link"), wantErr: ErrAccessDenied},
+		{name: "prefix of the allowed directory", path: allowed + "-other", wantErr: ErrAccessDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := h.ReadFile(tt.path)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
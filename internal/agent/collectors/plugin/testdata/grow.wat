@@ -0,0 +1,13 @@
+;; grow requests 1000 pages of memory and reports no samples when it is refused
+(module
+  (memory (export "memory") 1)
+
+  (data (i32.const 0) "[]")
+
+  (func (export "alloc") (param $size i32) (result i32)
+    (i32.const 0))
+
+  (func (export "collect") (result i64)
+    (if (i32.ne (memory.grow (i32.const 1000)) (i32.const -1))
+      (then unreachable))
+    (i64.const 2)))
@@ -0,0 +1,11 @@
+;; loop never returns from collect
+(module
+  (memory (export "memory") 1)
+
+  (func (export "alloc") (param $size i32) (result i32)
+    (i32.const 0))
+
+  (func (export "collect") (result i64)
+    (loop $spin
+      (br $spin))
+    (i64.const 0)))
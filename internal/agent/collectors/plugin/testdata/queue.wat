@@ -0,0 +1,38 @@
+;; queue reports samples read from testdata/queue.json through the host
+(module
+  (import "host" "read_file" (func $read_file (param i32 i32) (result i64)))
+  (import "host" "log" (func $log (param i32 i32)))
+
+  (memory (export "memory") 2)
+  (global $heap (mut i32) (i32.const 1024))
+
+  (data (i32.const 0) "testdata/queue.json")
+  (data (i32.const 32) "collect")
+
+  ;; bump allocator, the memory grows when the block doesn't fit
+  (func (export "alloc") (param $size i32) (result i32)
+    (local $ptr i32)
+    (local $end i32)
+    (local.set $ptr (global.get $heap))
+    (local.set $end (i32.add (local.get $ptr) (local.get $size)))
+    (if (i32.gt_u (local.get $end) (i32.shl (memory.size) (i32.const 16)))
+      (then
+        (if (i32.eq
+              (memory.grow
+                (i32.shr_u
+                  (i32.add
+                    (i32.sub (local.get $end) (i32.shl (memory.size) (i32.const 16)))
+                    (i32.const 65535))
+                  (i32.const 16)))
+              (i32.const -1))
+          (then unreachable))))
+    (global.set $heap (local.get $end))
+    (local.get $ptr))
+
+  (func (export "collect") (result i64)
+    (local $res i64)
+    (call $log (i32.const 32) (i32.const 7))
+    (local.set $res (call $read_file (i32.const 0) (i32.const 19)))
+    (if (i64.lt_s (local.get $res) (i64.const 0))
+      (then unreachable))
+    (local.get $res)))
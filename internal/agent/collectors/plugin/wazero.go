@@ -0,0 +1,149 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// hostModule is the name of the module providing the host functions to the plugin
+const hostModule = "host"
+
+// WazeroEngine runs plugins with the wazero runtime. Every plugin gets its own runtime,
+// so the memory limit and the host functions are not shared between plugins.
+type WazeroEngine struct{}
+
+var _ Engine = (*WazeroEngine)(nil)
+
+func NewWazeroEngine() *WazeroEngine {
+	return &WazeroEngine{}
+}
+
+func (e *WazeroEngine) Instantiate(ctx context.Context, name string, wasm []byte, limits Limits, host HostAPI) (Instance, error) {
+	cfg := wazero.NewRuntimeConfig().WithCloseOnContextDone(true)
+	if limits.MemoryPages > 0 {
+		cfg = cfg.WithMemoryLimitPages(limits.MemoryPages)
+	}
+
+	i := &wazeroInstance{name: name, runtime: wazero.NewRuntimeWithConfig(ctx, cfg)}
+
+	if err := i.setup(ctx, wasm, host); err != nil {
+		return nil, errors.Join(err, i.runtime.Close(ctx))
+	}
+
+	return i, nil
+}
+
+// wazeroInstance calls are serialized, since the module memory is not safe for concurrent use
+type wazeroInstance struct {
+	mu       sync.Mutex
+	name     string
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	module   api.Module
+}
+
+var _ Instance = (*wazeroInstance)(nil)
+
+func (i *wazeroInstance) setup(ctx context.Context, wasm []byte, host HostAPI) error {
+	_, err := i.runtime.NewHostModuleBuilder(hostModule).
+		NewFunctionBuilder().WithFunc(readFileFunc(host)).Export("read_file").
+		NewFunctionBuilder().WithFunc(logFunc(host)).Export("log").
+		Instantiate(ctx)
+	if err != nil {
+		return fmt.Errorf("instantiate host module error %w", err)
+	}
+
+	i.compiled, err = i.runtime.CompileModule(ctx, wasm)
+	if err != nil {
+		return fmt.Errorf("compile module error %w", err)
+	}
+
+	return i.instantiate(ctx)
+}
+
+// instantiate creates the module from the compiled one and checks the exports required by the plugin ABI
+func (i *wazeroInstance) instantiate(ctx context.Context) error {
+	module, err := i.runtime.InstantiateModule(ctx, i.compiled, wazero.NewModuleConfig().WithName(i.name))
+	if err != nil {
+		return fmt.Errorf("instantiate module error %w", err)
+	}
+
+	if module.Memory() == nil || module.ExportedFunction("alloc") == nil || module.ExportedFunction("collect") == nil {
+		return errors.Join(errors.New("module must export memory, alloc and collect"), module.Close(ctx))
+	}
+
+	i.module = module
+	return nil
+}
+
+// Collect calls collect of the module. The runtime closes the module when ctx is done during the call,
+// the module is instantiated again by the next call.
+func (i *wazeroInstance) Collect(ctx context.Context) ([]byte, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.module.IsClosed() {
+		if err := i.instantiate(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := i.module.ExportedFunction("collect").Call(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("call collect error %w", err)
+	}
+
+	ptr, size := uint32(res[0]>>32), uint32(res[0])
+	b, ok := i.module.Memory().Read(ptr, size)
+	if !ok {
+		return nil, fmt.Errorf("collect result [%d:%d] is out of memory range", ptr, ptr+size)
+	}
+
+	return bytes.Clone(b), nil
+}
+
+func (i *wazeroInstance) Close(ctx context.Context) error {
+	return i.runtime.Close(ctx)
+}
+
+// readFileFunc writes the file into the memory allocated by the module and returns ptr<<32|len,
+// the result is negative when the file can't be read or passed to the module
+func readFileFunc(host HostAPI) func(ctx context.Context, m api.Module, ptr, size uint32) int64 {
+	return func(ctx context.Context, m api.Module, ptr, size uint32) int64 {
+		path, ok := m.Memory().Read(ptr, size)
+		if !ok {
+			return -1
+		}
+
+		b, err := host.ReadFile(string(path))
+		if err != nil {
+			return -1
+		}
+
+		res, err := m.ExportedFunction("alloc").Call(ctx, uint64(len(b)))
+		if err != nil {
+			return -1
+		}
+
+		dst := uint32(res[0])
+		if !m.Memory().Write(dst, b) {
+			return -1
+		}
+
+		return int64(dst)<<32 | int64(len(b))
+	}
+}
+
+func logFunc(host HostAPI) func(ctx context.Context, m api.Module, ptr, size uint32) {
+	return func(ctx context.Context, m api.Module, ptr, size uint32) {
+		if msg, ok := m.Memory().Read(ptr, size); ok {
+			host.Log(string(msg))
+		}
+	}
+}
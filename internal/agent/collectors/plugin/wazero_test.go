@@ -0,0 +1,166 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+)
+
+// recordingHost serves files from memory and records the plugin logs
+type recordingHost struct {
+	mu    sync.Mutex
+	files map[string]string
+	paths []string
+	logs  []string
+}
+
+func (h *recordingHost) ReadFile(path string) ([]byte, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.paths = append(h.paths, path)
+	content, ok := h.files[path]
+	if !ok {
+		return nil, ErrAccessDenied
+	}
+
+	return []byte(content), nil
+}
+
+func (h *recordingHost) Log(msg string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.logs = append(h.logs, msg)
+}
+
+// instantiateFixture instantiates testdata/<fixture>.wasm, the modules are built from the .wat sources with wat2wasm
+func instantiateFixture(t *testing.T, fixture string, limits Limits, host HostAPI) (Instance, error) {
+	t.Helper()
+
+	wasm, err := os.ReadFile("testdata/" + fixture + ".wasm")
+	require.NoError(t, err)
+
+	instance, err := NewWazeroEngine().Instantiate(context.Background(), fixture, wasm, limits, host)
+	if err == nil {
+		t.Cleanup(func() { assert.NoError(t, instance.Close(context.Background())) })
+	}
+
+	return instance, err
+}
+
+func TestWazeroEngine_hostFunctions(t *testing.T) {
+	host := &recordingHost{files: map[string]string{"testdata/queue.json": `[{"name":"QueueLength","type":"gauge","value":1}]`}}
+
+	instance, err := instantiateFixture(t, "queue", Limits{MemoryPages: 16}, host)
+	require.NoError(t, err)
+
+	for range 2 {
+		got, err := instance.Collect(context.Background())
+		require.NoError(t, err)
+		assert.JSONEq(t, `[{"name":"QueueLength","type":"gauge","value":1}]`, string(got))
+	}
+
+	assert.Equal(t, []string{"testdata/queue.json", "testdata/queue.json"}, host.paths)
+	assert.Equal(t, []string{"collect", "collect"}, host.logs)
+
+	// the plugin traps when the file is denied
+	host.files = nil
+	_, err = instance.Collect(context.Background())
+	assert.Error(t, err)
+}
+
+func TestWazeroEngine_memoryLimit(t *testing.T) {
+	t.Run("memory of the module exceeds the limit", func(t *testing.T) {
+		_, err := instantiateFixture(t, "queue", Limits{MemoryPages: 1}, &recordingHost{})
+		assert.ErrorContains(t, err, "over limit")
+	})
+
+	t.Run("memory growth is refused beyond the limit", func(t *testing.T) {
+		instance, err := instantiateFixture(t, "grow", Limits{MemoryPages: 16}, &recordingHost{})
+		require.NoError(t, err)
+
+		got, err := instance.Collect(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "[]", string(got))
+	})
+
+	t.Run("memory growth within the limit", func(t *testing.T) {
+		instance, err := instantiateFixture(t, "grow", Limits{MemoryPages: 2048}, &recordingHost{})
+		require.NoError(t, err)
+
+		_, err = instance.Collect(context.Background())
+		assert.ErrorContains(t, err, "unreachable")
+	})
+}
+
+func TestWazeroEngine_interrupt(t *testing.T) {
+	instance, err := instantiateFixture(t, "loop", Limits{MemoryPages: 16}, &recordingHost{})
+	require.NoError(t, err)
+
+	t.Run("timeout", func(t *testing.T) {
+		// the module closed by the interrupted call is instantiated again
+		for range 2 {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			_, err := instance.Collect(ctx)
+			cancel()
+
+			assert.ErrorContains(t, err, "deadline exceeded")
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		_, err := instance.Collect(ctx)
+		assert.ErrorContains(t, err, "canceled")
+	})
+}
+
+func TestCollector_wazero(t *testing.T) {
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+	require.NoError(t, err)
+
+	t.Run("allowed file", func(t *testing.T) {
+		c, err := NewCollector(context.Background(), DefaultEngine, config.Plugin{
+			Name:         "queue",
+			Path:         "testdata/queue.wasm",
+			AllowedFiles: []string{"testdata/queue.json"},
+		}, lg)
+		require.NoError(t, err)
+		t.Cleanup(func() { assert.NoError(t, c.Close()) })
+
+		got, err := c.Collect(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []models.Metric{models.NewGauge("QueueLength", 12), models.NewCounter("Processed", 3)}, got)
+	})
+
+	t.Run("denied file", func(t *testing.T) {
+		c, err := NewCollector(context.Background(), DefaultEngine, config.Plugin{Name: "queue", Path: "testdata/queue.wasm"}, lg)
+		require.NoError(t, err)
+		t.Cleanup(func() { assert.NoError(t, c.Close()) })
+
+		_, err = c.Collect(context.Background())
+		assert.Error(t, err)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		c, err := NewCollector(context.Background(), DefaultEngine, config.Plugin{Name: "loop", Path: "testdata/loop.wasm", TimeoutMs: 50}, lg)
+		require.NoError(t, err)
+		t.Cleanup(func() { assert.NoError(t, c.Close()) })
+
+		start := time.Now()
+		_, err = c.Collect(context.Background())
+		assert.ErrorContains(t, err, "exceeded timeout")
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
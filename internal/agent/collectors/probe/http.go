@@ -0,0 +1,85 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+)
+
+// maxBodySize limits the part of the response body matched against the regex
+const maxBodySize = 1 << 20
+
+// probeHTTP makes GET request, the check passes when the status is expected and the body matches
+func (c *Collector) probeHTTP(ctx context.Context, p probe) []models.Metric {
+	start := c.now()
+	status, ok, expiry, err := c.doHTTP(ctx, p)
+	duration := c.now().Sub(start)
+
+	if err != nil {
+		c.failed(ctx, p, err)
+	}
+
+	metrics := []models.Metric{
+		p.metric(Success, success(ok)),
+		p.metric(DurationSeconds, duration.Seconds()),
+		p.metric(HTTPStatus, float64(status)),
+	}
+
+	if expiry != nil {
+		metrics = append(metrics, p.metric(TLSExpirySeconds, *expiry))
+	}
+
+	return metrics
+}
+
+func (c *Collector) doHTTP(ctx context.Context, p probe) (status int, ok bool, expiry *float64, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.Target, http.NoBody)
+	if err != nil {
+		return 0, false, nil, fmt.Errorf("build request error %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, false, nil, fmt.Errorf("request error %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) != 0 {
+		left := resp.TLS.PeerCertificates[0].NotAfter.Sub(c.now()).Seconds()
+		expiry = &left
+	}
+
+	status = resp.StatusCode
+	if len(p.ExpectStatus) != 0 {
+		ok = slices.Contains(p.ExpectStatus, status)
+	} else {
+		ok = status >= http.StatusOK && status < http.StatusMultipleChoices
+	}
+
+	if !ok {
+		return status, false, expiry, fmt.Errorf("unexpected status %d", status)
+	}
+
+	if p.body == nil {
+		return status, true, expiry, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return status, false, expiry, fmt.Errorf("read body error %w", err)
+	}
+
+	if !p.body.Match(body) {
+		return status, false, expiry, fmt.Errorf("body doesn't match %q", p.BodyRegex)
+	}
+
+	return status, true, expiry, nil
+}
@@ -0,0 +1,202 @@
+// Package probe makes blackbox-style synthetic checks of HTTP and TCP endpoints.
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"go.uber.org/zap"
+)
+
+// defaultTimeout limits a single probe when the timeout is not configured
+const defaultTimeout = 5 * time.Second
+
+// Metric name suffixes, names are Probe<Name><Suffix>
+const (
+	Success          = "Success"          // 1 when the check passed, 0 otherwise
+	DurationSeconds  = "DurationSeconds"  // duration of the check, connect time for tcp
+	HTTPStatus       = "HTTPStatus"       // response status, 0 when there is no response
+	TLSExpirySeconds = "TLSExpirySeconds" // time left until the server certificate expires
+)
+
+type probe struct {
+	config.Probe
+	timeout time.Duration
+	body    *regexp.Regexp
+}
+
+func newProbe(p config.Probe) (probe, error) {
+	if p.Name == "" {
+		return probe{}, errors.New("name is empty")
+	}
+
+	if p.Target == "" {
+		return probe{}, errors.New("target is empty")
+	}
+
+	res := probe{Probe: p, timeout: time.Duration(p.TimeoutMs) * time.Millisecond}
+	if res.timeout <= 0 {
+		res.timeout = defaultTimeout
+	}
+
+	switch p.Type {
+	case config.ProbeHTTP:
+		if p.BodyRegex != "" {
+			re, err := regexp.Compile(p.BodyRegex)
+			if err != nil {
+				return probe{}, fmt.Errorf("compile body regex %q error %w", p.BodyRegex, err)
+			}
+			res.body = re
+		}
+	case config.ProbeTCP:
+		if _, _, err := net.SplitHostPort(p.Target); err != nil {
+			return probe{}, fmt.Errorf("invalid tcp target %q error %w", p.Target, err)
+		}
+	default:
+		return probe{}, fmt.Errorf("unknown probe type %q", p.Type)
+	}
+
+	return res, nil
+}
+
+func (p probe) metric(suffix string, val float64) models.Metric {
+	return models.NewGauge("Probe"+p.Name+suffix, val)
+}
+
+// Collector runs the probes in background with its own interval, every probe is limited by its own timeout.
+// Collect returns the results of the latest round without waiting, so a slow or hanging target
+// never delays the poll and failures of other collectors never cancel a probe.
+type Collector struct {
+	probes []probe
+	client *http.Client
+	dialer *net.Dialer
+	now    func() time.Time
+	lg     *logging.ZapLogger
+
+	mu      sync.RWMutex
+	results map[string][]models.Metric
+}
+
+// NewCollector validates the probes, they are not run until Start
+func NewCollector(probes []config.Probe, lg *logging.ZapLogger) (*Collector, error) {
+	c := &Collector{
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy:             http.ProxyFromEnvironment,
+				DisableKeepAlives: true,
+			},
+		},
+		dialer:  &net.Dialer{},
+		now:     time.Now,
+		lg:      lg,
+		results: make(map[string][]models.Metric, len(probes)),
+	}
+
+	names := make(map[string]bool, len(probes))
+	for i, p := range probes {
+		compiled, err := newProbe(p)
+		if err != nil {
+			return nil, fmt.Errorf("internal/agent/collectors/probe: probe %d error %w", i, err)
+		}
+
+		if names[p.Name] {
+			return nil, fmt.Errorf("internal/agent/collectors/probe: probe %d name %q is not unique", i, p.Name)
+		}
+		names[p.Name] = true
+
+		c.probes = append(c.probes, compiled)
+	}
+
+	return c, nil
+}
+
+func (c *Collector) Name() string {
+	return "probe"
+}
+
+func (c *Collector) Collect(ctx context.Context) ([]models.Metric, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var metrics []models.Metric
+	for _, p := range c.probes {
+		metrics = append(metrics, c.results[p.Name]...)
+	}
+
+	return metrics, nil
+}
+
+// Start runs the probes immediately and then every interval until ctx is done
+func (c *Collector) Start(ctx context.Context, interval time.Duration) {
+	if len(c.probes) == 0 || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		c.Run(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Run makes a round of the probes concurrently and waits for all of them
+func (c *Collector) Run(ctx context.Context) {
+	wg := sync.WaitGroup{}
+
+	for _, p := range c.probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			probeCtx, cancel := context.WithTimeout(ctx, p.timeout)
+			defer cancel()
+
+			var metrics []models.Metric
+			switch p.Type {
+			case config.ProbeHTTP:
+				metrics = c.probeHTTP(probeCtx, p)
+			case config.ProbeTCP:
+				metrics = c.probeTCP(probeCtx, p)
+			}
+
+			// the round interrupted by shutdown says nothing about the target
+			if ctx.Err() != nil {
+				return
+			}
+
+			c.mu.Lock()
+			c.results[p.Name] = metrics
+			c.mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (c *Collector) failed(ctx context.Context, p probe, err error) {
+	c.lg.DebugCtx(ctx, "probe failed", zap.String("probe", p.Name), zap.String("target", p.Target), zap.Error(err))
+}
+
+func success(ok bool) float64 {
+	if ok {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,199 @@
+package probe
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+)
+
+func newTestCollector(t *testing.T, probes ...config.Probe) *Collector {
+	t.Helper()
+
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+	require.NoError(t, err)
+
+	c, err := NewCollector(probes, lg)
+	require.NoError(t, err)
+
+	return c
+}
+
+func gauges(t *testing.T, c *Collector) map[string]float64 {
+	t.Helper()
+
+	metrics, err := c.Collect(context.Background())
+	require.NoError(t, err)
+
+	res := make(map[string]float64, len(metrics))
+	for _, m := range metrics {
+		require.Equal(t, models.GaugeType, m.Type)
+		res[m.Name] = m.Value
+	}
+
+	return res
+}
+
+func TestCollector_Run_http(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			_, _ = w.Write([]byte(`{"status":"ok"}`))
+		case "/slow":
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name        string
+		probe       config.Probe
+		wantSuccess float64
+		wantStatus  float64
+	}{
+		{
+			name:        "healthy",
+			probe:       config.Probe{Name: "Api", Type: config.ProbeHTTP, Target: srv.URL + "/health", BodyRegex: `"status":"ok"`},
+			wantSuccess: 1,
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "body doesn't match",
+			probe:       config.Probe{Name: "Api", Type: config.ProbeHTTP, Target: srv.URL + "/health", BodyRegex: `"status":"degraded"`},
+			wantSuccess: 0,
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "unexpected status",
+			probe:       config.Probe{Name: "Api", Type: config.ProbeHTTP, Target: srv.URL + "/down"},
+			wantSuccess: 0,
+			wantStatus:  http.StatusServiceUnavailable,
+		},
+		{
+			name:        "expected status",
+			probe:       config.Probe{Name: "Api", Type: config.ProbeHTTP, Target: srv.URL + "/down", ExpectStatus: []int{http.StatusServiceUnavailable}},
+			wantSuccess: 1,
+			wantStatus:  http.StatusServiceUnavailable,
+		},
+		{
+			name:        "timeout",
+			probe:       config.Probe{Name: "Api", Type: config.ProbeHTTP, Target: srv.URL + "/slow", TimeoutMs: 50},
+			wantSuccess: 0,
+			wantStatus:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestCollector(t, tt.probe)
+
+			start := time.Now()
+			c.Run(context.Background())
+			assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+			got := gauges(t, c)
+			assert.Equal(t, tt.wantSuccess, got["ProbeApiSuccess"])
+			assert.Equal(t, tt.wantStatus, got["ProbeApiHTTPStatus"])
+			assert.Contains(t, got, "ProbeApiDurationSeconds")
+			assert.NotContains(t, got, "ProbeApiTLSExpirySeconds")
+		})
+	}
+}
+
+func TestCollector_Run_tls(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	c := newTestCollector(t, config.Probe{Name: "Api", Type: config.ProbeHTTP, Target: srv.URL})
+	c.client = srv.Client()
+
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	c.Run(context.Background())
+
+	got := gauges(t, c)
+	assert.Equal(t, 1.0, got["ProbeApiSuccess"])
+	assert.Equal(t, srv.Certificate().NotAfter.Sub(now).Seconds(), got["ProbeApiTLSExpirySeconds"])
+}
+
+func TestCollector_Run_tcp(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	require.NoError(t, closed.Close())
+
+	c := newTestCollector(t,
+		config.Probe{Name: "Up", Type: config.ProbeTCP, Target: l.Addr().String()},
+		config.Probe{Name: "Down", Type: config.ProbeTCP, Target: closed.Addr().String()},
+	)
+	c.Run(context.Background())
+
+	got := gauges(t, c)
+	assert.Equal(t, 1.0, got["ProbeUpSuccess"])
+	assert.Equal(t, 0.0, got["ProbeDownSuccess"])
+	assert.Contains(t, got, "ProbeUpDurationSeconds")
+	assert.Contains(t, got, "ProbeDownDurationSeconds")
+}
+
+func TestCollector_Collect_doesNotWaitForProbes(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	c := newTestCollector(t, config.Probe{Name: "Hanging", Type: config.ProbeHTTP, Target: srv.URL, TimeoutMs: 10000})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Start(ctx, time.Hour)
+
+	start := time.Now()
+	got := gauges(t, c)
+	assert.Empty(t, got, "no results until the first round is done")
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestNewCollector_invalidProbe(t *testing.T) {
+	tests := []struct {
+		name   string
+		probes []config.Probe
+	}{
+		{name: "empty name", probes: []config.Probe{{Type: config.ProbeTCP, Target: "localhost:80"}}},
+		{name: "empty target", probes: []config.Probe{{Name: "Db", Type: config.ProbeTCP}}},
+		{name: "unknown type", probes: []config.Probe{{Name: "Db", Type: "icmp", Target: "localhost"}}},
+		{name: "tcp target without port", probes: []config.Probe{{Name: "Db", Type: config.ProbeTCP, Target: "localhost"}}},
+		{name: "invalid body regex", probes: []config.Probe{{Name: "Api", Type: config.ProbeHTTP, Target: "http://localhost", BodyRegex: "("}}},
+		{name: "duplicate name", probes: []config.Probe{
+			{Name: "Db", Type: config.ProbeTCP, Target: "localhost:5432"},
+			{Name: "Db", Type: config.ProbeTCP, Target: "localhost:5433"},
+		}},
+	}
+
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+	require.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCollector(tt.probes, lg)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package probe
+
+import (
+	"context"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+)
+
+// probeTCP connects to the target, the duration is the connect time
+func (c *Collector) probeTCP(ctx context.Context, p probe) []models.Metric {
+	start := c.now()
+	conn, err := c.dialer.DialContext(ctx, "tcp", p.Target)
+	duration := c.now().Sub(start)
+
+	if err != nil {
+		c.failed(ctx, p, err)
+	} else if closeErr := conn.Close(); closeErr != nil {
+		c.failed(ctx, p, closeErr)
+	}
+
+	return []models.Metric{
+		p.metric(Success, success(err == nil)),
+		p.metric(DurationSeconds, duration.Seconds()),
+	}
+}
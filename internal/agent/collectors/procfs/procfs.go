@@ -0,0 +1,261 @@
+// Package procfs collects Linux kernel pressure, virtual memory and load statistics from /proc.
+package procfs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/collectors"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+)
+
+// ErrNotFound is returned when there is no proc filesystem to collect from
+var ErrNotFound = errors.New("procfs: proc filesystem not found")
+
+// Metric names, pressure metrics are named Pressure<Resource><Some|Full><Avg10|Avg60|Avg300|TotalUsec>
+const (
+	Load1         = "Load1"
+	Load5         = "Load5"
+	Load15        = "Load15"
+	ProcsRunning  = "ProcsRunning"
+	ProcsTotal    = "ProcsTotal"
+	UptimeSeconds = "UptimeSeconds"
+
+	VmstatPageFaults      = "VmstatPageFaults"
+	VmstatMajorPageFaults = "VmstatMajorPageFaults"
+	VmstatSwapIn          = "VmstatSwapIn"
+	VmstatSwapOut         = "VmstatSwapOut"
+	VmstatOOMKills        = "VmstatOOMKills"
+)
+
+// vmstatCounters are the reported /proc/vmstat counters
+var vmstatCounters = map[string]string{
+	"pgfault":    VmstatPageFaults,
+	"pgmajfault": VmstatMajorPageFaults,
+	"pswpin":     VmstatSwapIn,
+	"pswpout":    VmstatSwapOut,
+	"oom_kill":   VmstatOOMKills,
+}
+
+// pressureResources are the files of /proc/pressure with metric name parts
+var pressureResources = map[string]string{
+	"cpu":    "CPU",
+	"memory": "Memory",
+	"io":     "IO",
+}
+
+// Collector reads /proc. Averages and current values are reported as gauges, monotonic kernel counters
+// are reported as counter increments since the previous poll. Missing files are skipped,
+// e.g. pressure stall information is disabled on some kernels.
+type Collector struct {
+	root   string
+	deltas *collectors.Deltas
+}
+
+// NewCollector creates collector of the proc filesystem mounted at root, usually /proc
+func NewCollector(root string) (*Collector, error) {
+	if _, err := os.Stat(filepath.Join(root, "loadavg")); err != nil {
+		return nil, ErrNotFound
+	}
+
+	return &Collector{root: root, deltas: collectors.NewDeltas()}, nil
+}
+
+func (c *Collector) Name() string {
+	return "procfs"
+}
+
+func (c *Collector) Collect(ctx context.Context) ([]models.Metric, error) {
+	var metrics []models.Metric
+
+	readers := []func() ([]models.Metric, error){c.loadavg, c.uptime, c.vmstat, c.pressure}
+	for _, read := range readers {
+		m, err := read()
+		if err != nil {
+			return nil, fmt.Errorf("internal/agent/collectors/procfs: collect error %w", err)
+		}
+
+		metrics = append(metrics, m...)
+	}
+
+	return metrics, nil
+}
+
+// loadavg parses "0.52 0.58 0.59 2/1234 56789"
+func (c *Collector) loadavg() ([]models.Metric, error) {
+	fields, err := c.fields("loadavg")
+	if err != nil || fields == nil {
+		return nil, err
+	}
+
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("unexpected loadavg format %q", strings.Join(fields, " "))
+	}
+
+	metrics := make([]models.Metric, 0, 5)
+	for i, name := range []string{Load1, Load5, Load15} {
+		val, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse loadavg %s error %w", name, err)
+		}
+		metrics = append(metrics, models.NewGauge(name, val))
+	}
+
+	running, total, ok := strings.Cut(fields[3], "/")
+	if !ok {
+		return nil, fmt.Errorf("unexpected loadavg processes format %q", fields[3])
+	}
+
+	for name, val := range map[string]string{ProcsRunning: running, ProcsTotal: total} {
+		n, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse loadavg %s error %w", name, err)
+		}
+		metrics = append(metrics, models.NewGauge(name, n))
+	}
+
+	return metrics, nil
+}
+
+// uptime parses "12345.67 54321.00", the first number is the system uptime in seconds
+func (c *Collector) uptime() ([]models.Metric, error) {
+	fields, err := c.fields("uptime")
+	if err != nil || fields == nil {
+		return nil, err
+	}
+
+	val, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse uptime error %w", err)
+	}
+
+	return []models.Metric{models.NewGauge(UptimeSeconds, val)}, nil
+}
+
+// vmstat parses "name value" lines and reports increments of the selected counters
+func (c *Collector) vmstat() ([]models.Metric, error) {
+	lines, err := c.lines("vmstat")
+	if err != nil {
+		return nil, err
+	}
+
+	var metrics []models.Metric
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+
+		name, ok := vmstatCounters[fields[0]]
+		if !ok {
+			continue
+		}
+
+		n, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse vmstat %s error %w", fields[0], err)
+		}
+
+		if m, ok := c.deltas.Counter(name, n); ok {
+			metrics = append(metrics, m)
+		}
+	}
+
+	return metrics, nil
+}
+
+// pressure parses /proc/pressure files, line format: "some avg10=0.00 avg60=0.00 avg300=0.00 total=0"
+func (c *Collector) pressure() ([]models.Metric, error) {
+	var metrics []models.Metric
+
+	for resource, resourceName := range pressureResources {
+		lines, err := c.lines(filepath.Join("pressure", resource))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, line := range lines {
+			fields := strings.Fields(line)
+			if len(fields) == 0 {
+				continue
+			}
+
+			var prefix string
+			switch fields[0] {
+			case "some":
+				prefix = "Pressure" + resourceName + "Some"
+			case "full":
+				prefix = "Pressure" + resourceName + "Full"
+			default:
+				continue
+			}
+
+			for _, f := range fields[1:] {
+				key, val, ok := strings.Cut(f, "=")
+				if !ok {
+					continue
+				}
+
+				switch key {
+				case "avg10", "avg60", "avg300":
+					avg, err := strconv.ParseFloat(val, 64)
+					if err != nil {
+						return nil, fmt.Errorf("parse pressure %s %s error %w", resource, key, err)
+					}
+					metrics = append(metrics, models.NewGauge(prefix+"Avg"+strings.TrimPrefix(key, "avg"), avg))
+				case "total":
+					total, err := strconv.ParseUint(val, 10, 64)
+					if err != nil {
+						return nil, fmt.Errorf("parse pressure %s total error %w", resource, err)
+					}
+					if m, ok := c.deltas.Counter(prefix+"TotalUsec", total); ok {
+						metrics = append(metrics, m)
+					}
+				}
+			}
+		}
+	}
+
+	return metrics, nil
+}
+
+// lines reads lines of the file under root, nil is returned when the file doesn't exist
+func (c *Collector) lines(name string) ([]string, error) {
+	b, err := os.ReadFile(filepath.Join(c.root, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s error %w", name, err)
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	return lines, scanner.Err()
+}
+
+// fields reads fields of the single line file, nil is returned when the file doesn't exist
+func (c *Collector) fields(name string) ([]string, error) {
+	lines, err := c.lines(name)
+	if err != nil || len(lines) == 0 {
+		return nil, err
+	}
+
+	fields := strings.Fields(lines[0])
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%s is empty", name)
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,180 @@
+package procfs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+)
+
+// fixture copies testdata directory, so the test can change the counters between polls
+func fixture(t *testing.T, name string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.CopyFS(dir, os.DirFS(filepath.Join("testdata", name))))
+
+	return dir
+}
+
+func TestNewCollector(t *testing.T) {
+	_, err := NewCollector(fixture(t, "proc"))
+	require.NoError(t, err)
+
+	_, err = NewCollector(t.TempDir())
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestCollector_Collect(t *testing.T) {
+	tests := []struct {
+		name        string
+		fixture     string
+		update      map[string]string // files rewritten before the second poll
+		wantGauges  map[string]float64
+		wantCounter map[string]int64 // counters of the second poll
+	}{
+		{
+			name:    "pressure, vmstat and load",
+			fixture: "proc",
+			update: map[string]string{
+				"loadavg":         "1.52 0.58 0.59 3/1240 56800\n",
+				"uptime":          "12360.67 54351.00\n",
+				"vmstat":          "nr_free_pages 120000\npgfault 1500\npgmajfault 12\npswpin 0\npswpout 5\noom_kill 2\npgpgin 1999\n",
+				"pressure/cpu":    "some avg10=1.50 avg60=0.75 avg300=0.10 total=150000\nfull avg10=0.00 avg60=0.00 avg300=0.00 total=0\n",
+				"pressure/memory": "some avg10=0.00 avg60=0.00 avg300=0.00 total=5000\nfull avg10=0.00 avg60=0.00 avg300=0.00 total=2000\n",
+				"pressure/io":     "some avg10=2.00 avg60=1.00 avg300=0.50 total=100\nfull avg10=1.00 avg60=0.50 avg300=0.25 total=50\n",
+			},
+			wantGauges: map[string]float64{
+				Load1:         1.52,
+				Load5:         0.58,
+				Load15:        0.59,
+				ProcsRunning:  3,
+				ProcsTotal:    1240,
+				UptimeSeconds: 12360.67,
+
+				"PressureCPUSomeAvg10":     1.5,
+				"PressureCPUSomeAvg60":     0.75,
+				"PressureCPUSomeAvg300":    0.1,
+				"PressureCPUFullAvg10":     0,
+				"PressureCPUFullAvg60":     0,
+				"PressureCPUFullAvg300":    0,
+				"PressureMemorySomeAvg10":  0,
+				"PressureMemorySomeAvg60":  0,
+				"PressureMemorySomeAvg300": 0,
+				"PressureMemoryFullAvg10":  0,
+				"PressureMemoryFullAvg60":  0,
+				"PressureMemoryFullAvg300": 0,
+				"PressureIOSomeAvg10":      2,
+				"PressureIOSomeAvg60":      1,
+				"PressureIOSomeAvg300":     0.5,
+				"PressureIOFullAvg10":      1,
+				"PressureIOFullAvg60":      0.5,
+				"PressureIOFullAvg300":     0.25,
+			},
+			wantCounter: map[string]int64{
+				VmstatPageFaults:      500,
+				VmstatMajorPageFaults: 2,
+				VmstatSwapIn:          0,
+				VmstatSwapOut:         0,
+				VmstatOOMKills:        1,
+
+				"PressureCPUSomeTotalUsec":    50000,
+				"PressureCPUFullTotalUsec":    0,
+				"PressureMemorySomeTotalUsec": 0,
+				"PressureMemoryFullTotalUsec": 0,
+				// the counter went backwards, e.g. after a reboot, the new value is reported as is
+				"PressureIOSomeTotalUsec": 100,
+				"PressureIOFullTotalUsec": 50,
+			},
+		},
+		{
+			name:    "pressure stall information is disabled",
+			fixture: "nopsi",
+			update: map[string]string{
+				"uptime": "20.00 40.00\n",
+			},
+			wantGauges: map[string]float64{
+				Load1:         1,
+				Load5:         2,
+				Load15:        3,
+				ProcsRunning:  1,
+				ProcsTotal:    10,
+				UptimeSeconds: 20,
+			},
+			wantCounter: map[string]int64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := fixture(t, tt.fixture)
+			c, err := NewCollector(root)
+			require.NoError(t, err)
+
+			first, err := c.Collect(context.Background())
+			require.NoError(t, err)
+			assert.Empty(t, counters(first), "first poll takes the counters baseline")
+
+			for name, content := range tt.update {
+				require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(content), 0644))
+			}
+
+			second, err := c.Collect(context.Background())
+			require.NoError(t, err)
+			assert.InDeltaMapValues(t, tt.wantGauges, gauges(second), 1e-9)
+			assert.Equal(t, tt.wantCounter, counters(second))
+		})
+	}
+}
+
+func TestCollector_Collect_brokenFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{name: "loadavg", file: "loadavg", content: "0.1 0.2\n"},
+		{name: "uptime", file: "uptime", content: "abc 1.0\n"},
+		{name: "vmstat", file: "vmstat", content: "pgfault -1\n"},
+		{name: "pressure", file: "pressure/cpu", content: "some avg10=x avg60=0.00 avg300=0.00 total=0\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := fixture(t, "proc")
+			c, err := NewCollector(root)
+			require.NoError(t, err)
+
+			require.NoError(t, os.WriteFile(filepath.Join(root, tt.file), []byte(tt.content), 0644))
+
+			_, err = c.Collect(context.Background())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func gauges(metrics []models.Metric) map[string]float64 {
+	res := make(map[string]float64)
+	for _, m := range metrics {
+		if m.Type == models.GaugeType {
+			res[m.Name] = m.Value
+		}
+	}
+
+	return res
+}
+
+func counters(metrics []models.Metric) map[string]int64 {
+	res := make(map[string]int64)
+	for _, m := range metrics {
+		if m.Type == models.CounterType {
+			res[m.Name] = m.Delta
+		}
+	}
+
+	return res
+}
@@ -3,12 +3,15 @@ package config
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"runtime"
 	"strconv"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -20,7 +23,8 @@ type FileConfigurer interface {
 }
 
 type Config struct {
-	ServerURL      string        `json:"server_url"`
+	ServerURL      string        `json:"server_url"`  // the highest priority server
+	ServerURLs     []string      `json:"server_urls"` // servers in priority order, -a and ADDRESS accept comma separated list
 	PollInterval   time.Duration `json:"poll_interval"`
 	ReportInterval time.Duration `json:"report_interval"`
 	LogLevel       int64         `json:"log_level" env:"LOG_LEVEL" envDefault:"0"`
@@ -32,6 +36,124 @@ type Config struct {
 	ConfigPath     string        `json:"config_path" env:"CONFIG" envDefault:""`
 	GRPCPort       string        `json:"grpc_port" env:"GRPC_PORT" envDefault:"3200"`
 	BatchReport    bool          `json:"batch_report" env:"BATCH_REPORT"`
+	MaxBatchSize   int           `json:"max_batch_size" env:"MAX_BATCH_SIZE"`
+	MaxBatchBytes  int           `json:"max_batch_bytes" env:"MAX_BATCH_BYTES"`
+	TLSCert        string        `json:"tls_cert" env:"TLS_CERT"`
+	TLSKey         string        `json:"tls_key" env:"TLS_KEY"`
+	TLSCA          string        `json:"tls_ca" env:"TLS_CA"`
+	Relabel        []RelabelRule `json:"relabel"`
+	RelabelDryRun  bool          `json:"relabel_dry_run" env:"RELABEL_DRY_RUN"`
+
+	Output               string        `json:"output" env:"OUTPUT"` // file, stdout, remote_write or nats, reports are sent to the server when empty
+	OutputPath           string        `json:"output_path" env:"OUTPUT_PATH"`
+	OutputMaxBytes       int64         `json:"output_max_bytes" env:"OUTPUT_MAX_BYTES"`
+	OutputRotateInterval time.Duration `json:"output_rotate_interval" env:"OUTPUT_ROTATE_INTERVAL"`
+	OutputGzip           bool          `json:"output_gzip" env:"OUTPUT_GZIP"`
+
+	RemoteWriteURL    string            `json:"remote_write_url" env:"REMOTE_WRITE_URL"`
+	RemoteWriteLabels map[string]string `json:"remote_write_labels" env:"REMOTE_WRITE_LABELS"` // env format: name=value,name=value
+
+	NATSURL     string `json:"nats_url" env:"NATS_URL"`         // comma separated nats servers for the nats output
+	NATSSubject string `json:"nats_subject" env:"NATS_SUBJECT"` // subject the batches are published to
+
+	GRPCAddresses     []string      `json:"grpc_addresses" env:"GRPC_ADDRESSES"` // grpc servers in priority order, :GRPCPort when empty
+	FailoverThreshold int           `json:"failover_threshold" env:"FAILOVER_THRESHOLD"`
+	FailbackInterval  time.Duration `json:"failback_interval" env:"FAILBACK_INTERVAL"`
+
+	CgroupRoot string `json:"cgroup_root" env:"CGROUP_ROOT"` // cgroup filesystem mount point, empty disables cgroup metrics
+	ProcRoot   string `json:"proc_root" env:"PROC_ROOT"`     // proc filesystem mount point, empty disables pressure, vmstat and load metrics
+
+	RuntimeCollector string `json:"runtime_collector" env:"RUNTIME_COLLECTOR"` // memstats or runtime_metrics
+
+	LogTail           []LogTailFile `json:"log_tail"`
+	LogTailCheckpoint string        `json:"log_tail_checkpoint" env:"LOG_TAIL_CHECKPOINT"` // file with read offsets, empty disables checkpoints
+
+	Probes        []Probe       `json:"probes"`
+	ProbeInterval time.Duration `json:"probe_interval" env:"PROBE_INTERVAL"` // PollInterval when zero
+
+	Plugins []Plugin `json:"plugins"`
+
+	ControlAddress string `json:"control_address" env:"CONTROL_ADDRESS"` // local endpoint queried by the status command, empty disables it
+
+	CollectorTimeout     time.Duration `json:"collector_timeout" env:"COLLECTOR_TIMEOUT"`           // PollInterval when zero
+	CollectorErrorBudget int           `json:"collector_error_budget" env:"COLLECTOR_ERROR_BUDGET"` // consecutive failures of the collector before the poll fails
+	DisabledCollectors   []string      `json:"disabled_collectors" env:"DISABLED_COLLECTORS"`       // names of the collectors skipped by the poller
+
+	AgentID              string        `json:"agent_id" env:"AGENT_ID"`                             // hostname when empty
+	RemoteConfigInterval time.Duration `json:"remote_config_interval" env:"REMOTE_CONFIG_INTERVAL"` // interval of fetching the profile from the server, 0 - disabled
+}
+
+// Outputs used instead of the metrics server
+const (
+	OutputFile        = "file"
+	OutputStdout      = "stdout"
+	OutputRemoteWrite = "remote_write"
+	OutputNATS        = "nats"
+)
+
+// Go runtime metrics sources
+const (
+	RuntimeCollectorMemStats = "memstats"        // runtime.ReadMemStats, stops the world
+	RuntimeCollectorMetrics  = "runtime_metrics" // runtime/metrics, memstats names are reported as aliases
+)
+
+// Relabel rule actions
+const (
+	RelabelKeep   = "keep"   // keep only metrics matching regex
+	RelabelDrop   = "drop"   // drop metrics matching regex
+	RelabelRename = "rename" // replace name of matching metrics with replacement, $1 refers capture groups
+	RelabelPrefix = "prefix" // add prefix to names of matching metrics
+	RelabelType   = "type"   // change type of matching metrics
+)
+
+// RelabelRule describes a single step of the relabeling pipeline. Rules are applied in order,
+// regex must match the whole metric name, empty regex matches all metrics.
+type RelabelRule struct {
+	Action      string `json:"action"`
+	Regex       string `json:"regex,omitempty"`
+	Replacement string `json:"replacement,omitempty"`
+	Prefix      string `json:"prefix,omitempty"`
+	Type        string `json:"type,omitempty"`
+}
+
+// LogTailFile is a log file tailed by the agent, metrics are derived from the appended lines by the rules
+type LogTailFile struct {
+	Path  string        `json:"path"`
+	Rules []LogTailRule `json:"rules"`
+}
+
+// LogTailRule matches regex against every line of the file. Counter is incremented by the value of the capture group
+// or by 1 when value is empty, gauge is set to the value of the capture group.
+type LogTailRule struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Regex string `json:"regex"`
+	Value string `json:"value,omitempty"` // name of the capture group
+}
+
+// Synthetic probe types
+const (
+	ProbeHTTP = "http" // GET of the url, checks status, body and TLS certificate expiry
+	ProbeTCP  = "tcp"  // connect to host:port
+)
+
+// Probe is a synthetic check of the endpoint, Name is a part of the reported metric names
+type Probe struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	Target       string `json:"target"`                  // url for http, host:port for tcp
+	TimeoutMs    int64  `json:"timeout_ms,omitempty"`    // 5s when zero
+	ExpectStatus []int  `json:"expect_status,omitempty"` // any 2xx when empty
+	BodyRegex    string `json:"body_regex,omitempty"`
+}
+
+// Plugin is a collector compiled to WebAssembly, it runs in a sandbox and reads only the allowed files
+type Plugin struct {
+	Name          string   `json:"name"`
+	Path          string   `json:"path"`                      // .wasm module
+	MemoryLimitMB uint32   `json:"memory_limit_mb,omitempty"` // 16MB when zero
+	TimeoutMs     int64    `json:"timeout_ms,omitempty"`      // 1s when zero
+	AllowedFiles  []string `json:"allowed_files,omitempty"`   // files and directories readable by the plugin
 }
 
 func NewConfig(f FileConfigurer) (Config, error) {
@@ -113,15 +235,272 @@ func NewConfig(f FileConfigurer) (Config, error) {
 		c.BatchReport = flag
 	}
 
-	c.ServerURL = fmt.Sprintf("http://%s", c.ServerURL)
+	if val, ok := os.LookupEnv("MAX_BATCH_SIZE"); ok {
+		size, err := strconv.Atoi(val)
+		if err != nil {
+			return c, err
+		}
+		c.MaxBatchSize = size
+	}
+
+	if val, ok := os.LookupEnv("MAX_BATCH_BYTES"); ok {
+		size, err := strconv.Atoi(val)
+		if err != nil {
+			return c, err
+		}
+		c.MaxBatchBytes = size
+	}
+
+	if val, ok := os.LookupEnv("TLS_CERT"); ok {
+		c.TLSCert = val
+	}
+
+	if val, ok := os.LookupEnv("TLS_KEY"); ok {
+		c.TLSKey = val
+	}
+
+	if val, ok := os.LookupEnv("TLS_CA"); ok {
+		c.TLSCA = val
+	}
+
+	if val, ok := os.LookupEnv("RELABEL_DRY_RUN"); ok {
+		dryRun, err := strconv.ParseBool(val)
+		if err != nil {
+			return c, err
+		}
+
+		c.RelabelDryRun = dryRun
+	}
+
+	if val, ok := os.LookupEnv("OUTPUT"); ok {
+		c.Output = val
+	}
+
+	if val, ok := os.LookupEnv("OUTPUT_PATH"); ok {
+		c.OutputPath = val
+	}
+
+	if val, ok := os.LookupEnv("OUTPUT_MAX_BYTES"); ok {
+		size, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return c, err
+		}
+		c.OutputMaxBytes = size
+	}
+
+	if val, ok := os.LookupEnv("OUTPUT_ROTATE_INTERVAL"); ok {
+		if val, err := strconv.Atoi(val); err == nil {
+			c.OutputRotateInterval = time.Duration(val) * time.Second
+		}
+	}
+
+	if val, ok := os.LookupEnv("OUTPUT_GZIP"); ok {
+		gz, err := strconv.ParseBool(val)
+		if err != nil {
+			return c, err
+		}
+
+		c.OutputGzip = gz
+	}
+
+	if val, ok := os.LookupEnv("REMOTE_WRITE_URL"); ok {
+		c.RemoteWriteURL = val
+	}
+
+	if val, ok := os.LookupEnv("REMOTE_WRITE_LABELS"); ok {
+		labels, err := parseLabels(val)
+		if err != nil {
+			return c, err
+		}
+		c.RemoteWriteLabels = labels
+	}
+
+	if val, ok := os.LookupEnv("NATS_URL"); ok {
+		c.NATSURL = val
+	}
+
+	if val, ok := os.LookupEnv("NATS_SUBJECT"); ok {
+		c.NATSSubject = val
+	}
+
+	if val, ok := os.LookupEnv("GRPC_ADDRESSES"); ok {
+		c.GRPCAddresses = splitList(val)
+	}
+
+	if val, ok := os.LookupEnv("FAILOVER_THRESHOLD"); ok {
+		threshold, err := strconv.Atoi(val)
+		if err != nil {
+			return c, err
+		}
+		c.FailoverThreshold = threshold
+	}
+
+	if val, ok := os.LookupEnv("FAILBACK_INTERVAL"); ok {
+		if val, err := strconv.Atoi(val); err == nil {
+			c.FailbackInterval = time.Duration(val) * time.Second
+		}
+	}
+
+	if val, ok := os.LookupEnv("CGROUP_ROOT"); ok {
+		c.CgroupRoot = val
+	}
+
+	if val, ok := os.LookupEnv("PROC_ROOT"); ok {
+		c.ProcRoot = val
+	}
+
+	if val, ok := os.LookupEnv("RUNTIME_COLLECTOR"); ok {
+		c.RuntimeCollector = val
+	}
+
+	if val, ok := os.LookupEnv("LOG_TAIL_CHECKPOINT"); ok {
+		c.LogTailCheckpoint = val
+	}
+
+	if val, ok := os.LookupEnv("CONTROL_ADDRESS"); ok {
+		c.ControlAddress = val
+	}
+
+	if val, ok := os.LookupEnv("PROBE_INTERVAL"); ok {
+		if val, err := strconv.Atoi(val); err == nil {
+			c.ProbeInterval = time.Duration(val) * time.Second
+		}
+	}
+
+	if val, ok := os.LookupEnv("COLLECTOR_TIMEOUT"); ok {
+		if val, err := strconv.Atoi(val); err == nil {
+			c.CollectorTimeout = time.Duration(val) * time.Second
+		}
+	}
+
+	if val, ok := os.LookupEnv("COLLECTOR_ERROR_BUDGET"); ok {
+		if val, err := strconv.Atoi(val); err == nil {
+			c.CollectorErrorBudget = val
+		}
+	}
+
+	if val, ok := os.LookupEnv("DISABLED_COLLECTORS"); ok {
+		c.DisabledCollectors = splitList(val)
+	}
+
+	if val, ok := os.LookupEnv("AGENT_ID"); ok {
+		c.AgentID = val
+	}
+
+	if val, ok := os.LookupEnv("REMOTE_CONFIG_INTERVAL"); ok {
+		if val, err := strconv.Atoi(val); err == nil {
+			c.RemoteConfigInterval = time.Duration(val) * time.Second
+		}
+	}
 
 	if err := fromFile(&c, f); err != nil {
 		return Config{}, err
 	}
 
+	if err := c.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	scheme := "http"
+	if c.IsTLSEnabled() {
+		scheme = "https"
+	}
+
+	c.ServerURLs = nil
+	for _, addr := range splitList(c.ServerURL) {
+		c.ServerURLs = append(c.ServerURLs, fmt.Sprintf("%s://%s", scheme, addr))
+	}
+
+	if len(c.ServerURLs) == 0 {
+		c.ServerURLs = append(c.ServerURLs, fmt.Sprintf("%s://%s", scheme, c.ServerURL))
+	}
+	c.ServerURL = c.ServerURLs[0]
+
 	return c, nil
 }
 
+// Validate checks values which can't be checked while parsing
+func (c *Config) Validate() error {
+	switch c.RuntimeCollector {
+	case "", RuntimeCollectorMemStats, RuntimeCollectorMetrics:
+	default:
+		return fmt.Errorf("config: unknown runtime collector %q", c.RuntimeCollector)
+	}
+
+	switch c.Output {
+	case "", OutputStdout:
+	case OutputFile:
+		if c.OutputPath == "" {
+			return errors.New("config: output_path is required for the file output")
+		}
+	case OutputRemoteWrite:
+		if c.RemoteWriteURL == "" {
+			return errors.New("config: remote_write_url is required for the remote_write output")
+		}
+	case OutputNATS:
+		if c.NATSURL == "" || c.NATSSubject == "" {
+			return errors.New("config: nats_url and nats_subject are required for the nats output")
+		}
+	default:
+		return fmt.Errorf("config: unknown output %q", c.Output)
+	}
+
+	return nil
+}
+
+// Redacted returns copy of the config safe to print, secrets are replaced and passwords are removed from urls
+func (c Config) Redacted() Config {
+	const redacted = "[REDACTED]"
+
+	if c.Key != "" {
+		c.Key = redacted
+	}
+
+	c.ServerURL = redactURL(c.ServerURL)
+	c.RemoteWriteURL = redactURL(c.RemoteWriteURL)
+
+	natsURLs := strings.Split(c.NATSURL, ",")
+	for i, u := range natsURLs {
+		natsURLs[i] = redactURL(u)
+	}
+	c.NATSURL = strings.Join(natsURLs, ",")
+
+	urls := make([]string, 0, len(c.ServerURLs))
+	for _, u := range c.ServerURLs {
+		urls = append(urls, redactURL(u))
+	}
+	c.ServerURLs = urls
+
+	grpcAddresses := make([]string, 0, len(c.GRPCAddresses))
+	for _, addr := range c.GRPCAddresses {
+		grpcAddresses = append(grpcAddresses, redactURL(addr))
+	}
+	c.GRPCAddresses = grpcAddresses
+
+	probes := make([]Probe, 0, len(c.Probes))
+	for _, p := range c.Probes {
+		p.Target = redactURL(p.Target)
+		probes = append(probes, p)
+	}
+	c.Probes = probes
+
+	return c
+}
+
+func redactURL(val string) string {
+	u, err := url.Parse(val)
+	if err != nil || u.User == nil {
+		return val
+	}
+
+	return u.Redacted()
+}
+
+// IsTLSEnabled checks if the agent should connect to the server over https
+func (c *Config) IsTLSEnabled() bool {
+	return c.TLSCA != "" || c.TLSCert != ""
+}
+
 func (c *Config) LLevel() zapcore.Level {
 	return zapcore.Level(c.LogLevel)
 }
@@ -133,17 +512,30 @@ func (c *Config) String() string {
 
 func (c *Config) parseFlags() error {
 	var (
-		pollInterval   int64
-		reportInterval int64
+		pollInterval         int64
+		reportInterval       int64
+		outputRotateInterval int64
+		failbackInterval     int64
+		probeInterval        int64
+		collectorTimeout     int64
+		remoteConfigInterval int64
+		grpcAddresses        string
+		disabledCollectors   string
 	)
 
 	const (
 		defaultReportIntercal = 2
 		defaultPollInterval   = 2
+		defaultMaxBatchSize   = 500
+		defaultMaxBatchBytes  = 1 << 20
+		defaultOutputMaxBytes = 64 << 20
+		defaultErrorBudget    = 3
+		defaultFailover       = 3
+		defaultFailback       = 30
 	)
 
 	if flag.Lookup("a") == nil {
-		flag.StringVar(&c.ServerURL, "a", "localhost:8080", "address and port to run server")
+		flag.StringVar(&c.ServerURL, "a", "localhost:8080", "address and port of the server, comma separated list in priority order for failover")
 	}
 
 	if flag.Lookup("p") == nil {
@@ -184,14 +576,163 @@ func (c *Config) parseFlags() error {
 		flag.BoolVar(&c.BatchReport, "batch-report", true, "send metrics in batches")
 	}
 
+	if flag.Lookup("batch-size") == nil {
+		flag.IntVar(&c.MaxBatchSize, "batch-size", defaultMaxBatchSize, "max metrics count in a single batch request, 0 - unlimited")
+	}
+
+	if flag.Lookup("batch-bytes") == nil {
+		flag.IntVar(&c.MaxBatchBytes, "batch-bytes", defaultMaxBatchBytes, "max encoded size of a single batch request, 0 - unlimited")
+	}
+
+	if flag.Lookup("tls-cert") == nil {
+		flag.StringVar(&c.TLSCert, "tls-cert", "", "path to the agent tls certificate presented to the server")
+	}
+
+	if flag.Lookup("tls-key") == nil {
+		flag.StringVar(&c.TLSKey, "tls-key", "", "path to the agent tls private key")
+	}
+
+	if flag.Lookup("tls-ca") == nil {
+		flag.StringVar(&c.TLSCA, "tls-ca", "", "path to the ca certificate to verify the server")
+	}
+
+	if flag.Lookup("relabel-dry-run") == nil {
+		flag.BoolVar(&c.RelabelDryRun, "relabel-dry-run", false, "log relabel rules results without applying them")
+	}
+
+	if flag.Lookup("output") == nil {
+		flag.StringVar(&c.Output, "output", "", "send reports to another output instead of the server: file, stdout, remote_write or nats")
+	}
+
+	if flag.Lookup("output-path") == nil {
+		flag.StringVar(&c.OutputPath, "output-path", "", "path to the ndjson file for the file output")
+	}
+
+	if flag.Lookup("output-max-bytes") == nil {
+		flag.Int64Var(&c.OutputMaxBytes, "output-max-bytes", defaultOutputMaxBytes, "rotate output file when it grows over the size, 0 - disabled")
+	}
+
+	if flag.Lookup("output-rotate-interval") == nil {
+		flag.Int64Var(&outputRotateInterval, "output-rotate-interval", 0, "rotate output file every n seconds, 0 - disabled")
+	}
+
+	if flag.Lookup("output-gzip") == nil {
+		flag.BoolVar(&c.OutputGzip, "output-gzip", false, "gzip rotated output files")
+	}
+
+	if flag.Lookup("remote-write-url") == nil {
+		flag.StringVar(&c.RemoteWriteURL, "remote-write-url", "", "prometheus remote write receiver url")
+	}
+
+	if flag.Lookup("nats-url") == nil {
+		flag.StringVar(&c.NATSURL, "nats-url", "", "comma separated nats servers for the nats output")
+	}
+
+	if flag.Lookup("nats-subject") == nil {
+		flag.StringVar(&c.NATSSubject, "nats-subject", "metrics.updates", "nats subject the metric batches are published to")
+	}
+
+	if flag.Lookup("grpc-addresses") == nil {
+		flag.StringVar(&grpcAddresses, "grpc-addresses", "", "comma separated grpc server addresses in priority order")
+	}
+
+	if flag.Lookup("failover-threshold") == nil {
+		flag.IntVar(&c.FailoverThreshold, "failover-threshold", defaultFailover, "consecutive failed requests before switching to the next server")
+	}
+
+	if flag.Lookup("failback-interval") == nil {
+		flag.Int64Var(&failbackInterval, "failback-interval", defaultFailback, "interval in seconds of probing higher priority servers")
+	}
+
+	if flag.Lookup("cgroup-root") == nil {
+		flag.StringVar(&c.CgroupRoot, "cgroup-root", "/sys/fs/cgroup", "cgroup filesystem mount point, empty disables container metrics")
+	}
+
+	if flag.Lookup("proc-root") == nil {
+		flag.StringVar(&c.ProcRoot, "proc-root", "/proc", "proc filesystem mount point, empty disables pressure, vmstat and load metrics")
+	}
+
+	if flag.Lookup("runtime-collector") == nil {
+		flag.StringVar(&c.RuntimeCollector, "runtime-collector", RuntimeCollectorMemStats, "source of go runtime metrics: memstats or runtime_metrics")
+	}
+
+	if flag.Lookup("log-tail-checkpoint") == nil {
+		flag.StringVar(&c.LogTailCheckpoint, "log-tail-checkpoint", "", "file to persist offsets of the tailed logs, empty disables checkpoints")
+	}
+
+	if flag.Lookup("probe-interval") == nil {
+		flag.Int64Var(&probeInterval, "probe-interval", 0, "interval in seconds of synthetic probes, poll interval when 0")
+	}
+
+	if flag.Lookup("control-address") == nil {
+		flag.StringVar(&c.ControlAddress, "control-address", "localhost:8091", "local control endpoint queried by the status command, empty disables it")
+	}
+
+	if flag.Lookup("collector-timeout") == nil {
+		flag.Int64Var(&collectorTimeout, "collector-timeout", 0, "timeout in seconds of a single collector, poll interval when 0")
+	}
+
+	if flag.Lookup("collector-error-budget") == nil {
+		flag.IntVar(&c.CollectorErrorBudget, "collector-error-budget", defaultErrorBudget, "consecutive failures of a collector tolerated before the poll fails")
+	}
+
+	if flag.Lookup("disabled-collectors") == nil {
+		flag.StringVar(&disabledCollectors, "disabled-collectors", "", "comma separated names of the collectors skipped by the poller")
+	}
+
+	if flag.Lookup("agent-id") == nil {
+		flag.StringVar(&c.AgentID, "agent-id", "", "agent id used to assign the config profile, hostname when empty")
+	}
+
+	if flag.Lookup("remote-config-interval") == nil {
+		flag.Int64Var(&remoteConfigInterval, "remote-config-interval", 0, "interval in seconds of fetching the config profile from the server, 0 - disabled")
+	}
+
 	flag.Parse()
 
 	c.PollInterval = time.Duration(pollInterval) * time.Second
 	c.ReportInterval = time.Duration(reportInterval) * time.Second
+	c.OutputRotateInterval = time.Duration(outputRotateInterval) * time.Second
+	c.FailbackInterval = time.Duration(failbackInterval) * time.Second
+	c.ProbeInterval = time.Duration(probeInterval) * time.Second
+	c.CollectorTimeout = time.Duration(collectorTimeout) * time.Second
+	c.GRPCAddresses = splitList(grpcAddresses)
+	c.DisabledCollectors = splitList(disabledCollectors)
+	c.RemoteConfigInterval = time.Duration(remoteConfigInterval) * time.Second
 
 	return nil
 }
 
+// splitList splits comma separated list, empty items are skipped
+func splitList(val string) []string {
+	var items []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// parseLabels parses labels in name=value,name=value format
+func parseLabels(val string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(val, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("config: invalid label %q, expected name=value", pair)
+		}
+		labels[name] = value
+	}
+
+	return labels, nil
+}
+
 func prepareCert(val string) (io.Reader, error) {
 	if val == "" {
 		return nil, nil
@@ -13,6 +13,50 @@ type FileConfig struct {
 	ReportInterval int64  `json:"report_interval"`
 	PollInterval   int64  `json:"poll_interval"`
 	HTTPCert       string `json:"crypto_key"`
+	TLSCert        string `json:"tls_cert"`
+	TLSKey         string `json:"tls_key"`
+	TLSCA          string `json:"tls_ca"`
+
+	Relabel       []RelabelRule `json:"relabel"`
+	RelabelDryRun bool          `json:"relabel_dry_run"`
+
+	Output               string `json:"output"`
+	OutputPath           string `json:"output_path"`
+	OutputMaxBytes       int64  `json:"output_max_bytes"`
+	OutputRotateInterval int64  `json:"output_rotate_interval"`
+	OutputGzip           bool   `json:"output_gzip"`
+
+	RemoteWriteURL    string            `json:"remote_write_url"`
+	RemoteWriteLabels map[string]string `json:"remote_write_labels"`
+
+	NATSURL     string `json:"nats_url"`
+	NATSSubject string `json:"nats_subject"`
+
+	GRPCAddresses     []string `json:"grpc_addresses"`
+	FailoverThreshold int      `json:"failover_threshold"`
+	FailbackInterval  int64    `json:"failback_interval"`
+
+	CgroupRoot string `json:"cgroup_root"`
+	ProcRoot   string `json:"proc_root"`
+
+	RuntimeCollector string `json:"runtime_collector"`
+
+	LogTail           []LogTailFile `json:"log_tail"`
+	LogTailCheckpoint string        `json:"log_tail_checkpoint"`
+
+	Probes        []Probe `json:"probes"`
+	ProbeInterval int64   `json:"probe_interval"`
+
+	Plugins []Plugin `json:"plugins"`
+
+	ControlAddress string `json:"control_address"`
+
+	CollectorTimeout     int64 `json:"collector_timeout"`
+	CollectorErrorBudget int   `json:"collector_error_budget"`
+
+	DisabledCollectors   []string `json:"disabled_collectors"`
+	AgentID              string   `json:"agent_id"`
+	RemoteConfigInterval int64    `json:"remote_config_interval"`
 }
 
 func NewFileConfig() *FileConfig {
@@ -47,5 +91,129 @@ func (f *FileConfig) Configure(c *Config, source io.Reader) error {
 		c.HTTPCert = targer
 	}
 
+	if c.TLSCert == "" && f.TLSCert != "" {
+		c.TLSCert = f.TLSCert
+	}
+
+	if c.TLSKey == "" && f.TLSKey != "" {
+		c.TLSKey = f.TLSKey
+	}
+
+	if c.TLSCA == "" && f.TLSCA != "" {
+		c.TLSCA = f.TLSCA
+	}
+
+	if len(c.Relabel) == 0 && len(f.Relabel) != 0 {
+		c.Relabel = f.Relabel
+	}
+
+	if f.RelabelDryRun {
+		c.RelabelDryRun = true
+	}
+
+	if c.Output == "" && f.Output != "" {
+		c.Output = f.Output
+	}
+
+	if c.OutputPath == "" && f.OutputPath != "" {
+		c.OutputPath = f.OutputPath
+	}
+
+	if c.OutputMaxBytes == 0 && f.OutputMaxBytes != 0 {
+		c.OutputMaxBytes = f.OutputMaxBytes
+	}
+
+	if c.OutputRotateInterval == 0 && f.OutputRotateInterval != 0 {
+		c.OutputRotateInterval = time.Duration(f.OutputRotateInterval) * time.Second
+	}
+
+	if f.OutputGzip {
+		c.OutputGzip = true
+	}
+
+	if c.RemoteWriteURL == "" && f.RemoteWriteURL != "" {
+		c.RemoteWriteURL = f.RemoteWriteURL
+	}
+
+	if len(c.RemoteWriteLabels) == 0 && len(f.RemoteWriteLabels) != 0 {
+		c.RemoteWriteLabels = f.RemoteWriteLabels
+	}
+
+	if c.NATSURL == "" && f.NATSURL != "" {
+		c.NATSURL = f.NATSURL
+	}
+
+	if c.NATSSubject == "" && f.NATSSubject != "" {
+		c.NATSSubject = f.NATSSubject
+	}
+
+	if len(c.GRPCAddresses) == 0 && len(f.GRPCAddresses) != 0 {
+		c.GRPCAddresses = f.GRPCAddresses
+	}
+
+	if c.FailoverThreshold == 0 && f.FailoverThreshold != 0 {
+		c.FailoverThreshold = f.FailoverThreshold
+	}
+
+	if c.FailbackInterval == 0 && f.FailbackInterval != 0 {
+		c.FailbackInterval = time.Duration(f.FailbackInterval) * time.Second
+	}
+
+	if c.CgroupRoot == "" && f.CgroupRoot != "" {
+		c.CgroupRoot = f.CgroupRoot
+	}
+
+	if c.ProcRoot == "" && f.ProcRoot != "" {
+		c.ProcRoot = f.ProcRoot
+	}
+
+	if c.RuntimeCollector == "" && f.RuntimeCollector != "" {
+		c.RuntimeCollector = f.RuntimeCollector
+	}
+
+	if len(c.LogTail) == 0 && len(f.LogTail) != 0 {
+		c.LogTail = f.LogTail
+	}
+
+	if c.LogTailCheckpoint == "" && f.LogTailCheckpoint != "" {
+		c.LogTailCheckpoint = f.LogTailCheckpoint
+	}
+
+	if len(c.Probes) == 0 && len(f.Probes) != 0 {
+		c.Probes = f.Probes
+	}
+
+	if c.ProbeInterval == 0 && f.ProbeInterval != 0 {
+		c.ProbeInterval = time.Duration(f.ProbeInterval) * time.Second
+	}
+
+	if len(c.Plugins) == 0 && len(f.Plugins) != 0 {
+		c.Plugins = f.Plugins
+	}
+
+	if c.ControlAddress == "" && f.ControlAddress != "" {
+		c.ControlAddress = f.ControlAddress
+	}
+
+	if c.CollectorTimeout == 0 && f.CollectorTimeout != 0 {
+		c.CollectorTimeout = time.Duration(f.CollectorTimeout) * time.Second
+	}
+
+	if c.CollectorErrorBudget == 0 && f.CollectorErrorBudget != 0 {
+		c.CollectorErrorBudget = f.CollectorErrorBudget
+	}
+
+	if len(c.DisabledCollectors) == 0 && len(f.DisabledCollectors) != 0 {
+		c.DisabledCollectors = f.DisabledCollectors
+	}
+
+	if c.AgentID == "" && f.AgentID != "" {
+		c.AgentID = f.AgentID
+	}
+
+	if c.RemoteConfigInterval == 0 && f.RemoteConfigInterval != 0 {
+		c.RemoteConfigInterval = time.Duration(f.RemoteConfigInterval) * time.Second
+	}
+
 	return nil
 }
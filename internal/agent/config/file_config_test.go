@@ -78,3 +78,95 @@ func TestFileConfig_Configure(t *testing.T) {
 		})
 	}
 }
+
+func TestFileConfig_Configure_relabel(t *testing.T) {
+	source := `{"relabel":[{"action":"drop","regex":"Sys.*"},{"action":"prefix","prefix":"host_"}],"relabel_dry_run":true}`
+
+	c := &Config{}
+	err := NewFileConfig().Configure(c, bytes.NewBufferString(source))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []RelabelRule{
+		{Action: RelabelDrop, Regex: "Sys.*"},
+		{Action: RelabelPrefix, Prefix: "host_"},
+	}, c.Relabel)
+	assert.True(t, c.RelabelDryRun)
+}
+
+func TestFileConfig_Configure_logTail(t *testing.T) {
+	source := `{
+		"log_tail": [{"path": "/var/log/app.log", "rules": [
+			{"name": "AppErrors", "type": "counter", "regex": "level=error"},
+			{"name": "AppLatency", "type": "gauge", "regex": "latency=(?P<ms>[0-9.]+)", "value": "ms"}
+		]}],
+		"log_tail_checkpoint": "/var/lib/agent/log_tail.json"
+	}`
+
+	c := &Config{}
+	err := NewFileConfig().Configure(c, bytes.NewBufferString(source))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []LogTailFile{{
+		Path: "/var/log/app.log",
+		Rules: []LogTailRule{
+			{Name: "AppErrors", Type: "counter", Regex: "level=error"},
+			{Name: "AppLatency", Type: "gauge", Regex: "latency=(?P<ms>[0-9.]+)", Value: "ms"},
+		},
+	}}, c.LogTail)
+	assert.Equal(t, "/var/lib/agent/log_tail.json", c.LogTailCheckpoint)
+}
+
+func TestFileConfig_Configure_probes(t *testing.T) {
+	source := `{
+		"probes": [
+			{"name": "Api", "type": "http", "target": "https://api.local/health", "timeout_ms": 1500, "expect_status": [200, 204], "body_regex": "ok"},
+			{"name": "Db", "type": "tcp", "target": "db.local:5432"}
+		],
+		"probe_interval": 15
+	}`
+
+	c := &Config{}
+	err := NewFileConfig().Configure(c, bytes.NewBufferString(source))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Probe{
+		{Name: "Api", Type: ProbeHTTP, Target: "https://api.local/health", TimeoutMs: 1500, ExpectStatus: []int{200, 204}, BodyRegex: "ok"},
+		{Name: "Db", Type: ProbeTCP, Target: "db.local:5432"},
+	}, c.Probes)
+	assert.Equal(t, 15*time.Second, c.ProbeInterval)
+}
+
+func TestFileConfig_Configure_plugins(t *testing.T) {
+	source := `{"plugins": [{"name": "queue", "path": "/etc/agent/queue.wasm", "memory_limit_mb": 8, "timeout_ms": 500, "allowed_files": ["/var/lib/queue"]}]}`
+
+	c := &Config{}
+	err := NewFileConfig().Configure(c, bytes.NewBufferString(source))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Plugin{
+		{Name: "queue", Path: "/etc/agent/queue.wasm", MemoryLimitMB: 8, TimeoutMs: 500, AllowedFiles: []string{"/var/lib/queue"}},
+	}, c.Plugins)
+}
+
+func TestFileConfig_Configure_collectors(t *testing.T) {
+	source := `{"collector_timeout": 3, "collector_error_budget": 5}`
+
+	c := &Config{}
+	err := NewFileConfig().Configure(c, bytes.NewBufferString(source))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 3*time.Second, c.CollectorTimeout)
+	assert.Equal(t, 5, c.CollectorErrorBudget)
+}
+
+func TestFileConfig_Configure_remoteConfig(t *testing.T) {
+	source := `{"agent_id": "db-1", "remote_config_interval": 30, "disabled_collectors": ["cpu", "procfs"]}`
+
+	c := &Config{}
+	err := NewFileConfig().Configure(c, bytes.NewBufferString(source))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "db-1", c.AgentID)
+	assert.Equal(t, 30*time.Second, c.RemoteConfigInterval)
+	assert.Equal(t, []string{"cpu", "procfs"}, c.DisabledCollectors)
+}
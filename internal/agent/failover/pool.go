@@ -0,0 +1,202 @@
+// Package failover tracks health of the servers the agent reports to.
+// Servers are ordered by priority, the agent uses the first healthy one.
+package failover
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"go.uber.org/zap"
+)
+
+// probeTimeout limits a single probe
+const probeTimeout = 5 * time.Second
+
+// Prober checks if the server is able to accept reports
+type Prober func(ctx context.Context, addr string) error
+
+type target struct {
+	addr     string
+	failures int
+	healthy  bool
+}
+
+// Pool is an ordered list of servers with health tracking. Server is marked unhealthy after threshold
+// consecutive failures and gets back only after the successful probe or request.
+type Pool struct {
+	mu        sync.RWMutex
+	targets   []*target
+	threshold int
+	lg        *logging.ZapLogger
+}
+
+// NewPool creates pool of servers in priority order, threshold less than 1 is treated as 1
+func NewPool(addrs []string, threshold int, lg *logging.ZapLogger) *Pool {
+	if threshold < 1 {
+		threshold = 1
+	}
+
+	p := &Pool{
+		targets:   make([]*target, 0, len(addrs)),
+		threshold: threshold,
+		lg:        lg,
+	}
+
+	for _, addr := range addrs {
+		p.targets = append(p.targets, &target{addr: addr, healthy: true})
+	}
+
+	return p
+}
+
+// Addresses returns servers in priority order
+func (p *Pool) Addresses() []string {
+	addrs := make([]string, 0, len(p.targets))
+	for _, t := range p.targets {
+		addrs = append(addrs, t.addr)
+	}
+
+	return addrs
+}
+
+// Current returns the first healthy server. When all servers are unhealthy the highest priority one is returned.
+func (p *Pool) Current() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if len(p.targets) == 0 {
+		return ""
+	}
+
+	return p.targets[p.current()].addr
+}
+
+// Healthy reports whether addr is healthy, unknown addresses are unhealthy
+func (p *Pool) Healthy(addr string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	t := p.find(addr)
+
+	return t != nil && t.healthy
+}
+
+// ReportSuccess resets failures of addr and marks it healthy
+func (p *Pool) ReportSuccess(addr string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	t := p.find(addr)
+	if t == nil {
+		return
+	}
+
+	t.failures = 0
+	if !t.healthy {
+		t.healthy = true
+		p.lg.InfoCtx(context.Background(), "server is healthy again", zap.String("addr", addr))
+	}
+}
+
+// ReportFailure counts failure of addr, the server is marked unhealthy when failures reach the threshold
+func (p *Pool) ReportFailure(addr string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	t := p.find(addr)
+	if t == nil {
+		return
+	}
+
+	t.failures++
+	if t.healthy && t.failures >= p.threshold {
+		t.healthy = false
+		p.lg.WarnCtx(context.Background(), "server is unhealthy, failover",
+			zap.String("addr", addr),
+			zap.Int("failures", t.failures),
+			zap.String("next", p.targets[p.current()].addr),
+		)
+	}
+}
+
+// Probe checks unhealthy servers with higher priority than the current one and marks the alive ones healthy,
+// so the agent fails back to them. When all servers are unhealthy all of them are probed.
+func (p *Pool) Probe(ctx context.Context, probe Prober) {
+	for _, addr := range p.probeTargets() {
+		probeCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+		err := probe(probeCtx, addr)
+		cancel()
+
+		if err != nil {
+			p.lg.DebugCtx(ctx, "probe failed", zap.String("addr", addr), zap.Error(err))
+			continue
+		}
+
+		p.ReportSuccess(addr)
+	}
+}
+
+// Start probes servers every interval until ctx is done
+func (p *Pool) Start(ctx context.Context, probe Prober, interval time.Duration) {
+	if len(p.targets) < 2 || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.Probe(ctx, probe)
+		}
+	}
+}
+
+func (p *Pool) probeTargets() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if len(p.targets) == 0 {
+		return nil
+	}
+
+	limit := p.current()
+	if !p.targets[limit].healthy {
+		limit = len(p.targets)
+	}
+
+	addrs := make([]string, 0, limit)
+	for _, t := range p.targets[:limit] {
+		if !t.healthy {
+			addrs = append(addrs, t.addr)
+		}
+	}
+
+	return addrs
+}
+
+// current returns index of the first healthy target, 0 when there are no healthy targets
+func (p *Pool) current() int {
+	for i, t := range p.targets {
+		if t.healthy {
+			return i
+		}
+	}
+
+	return 0
+}
+
+func (p *Pool) find(addr string) *target {
+	for _, t := range p.targets {
+		if t.addr == addr {
+			return t
+		}
+	}
+
+	return nil
+}
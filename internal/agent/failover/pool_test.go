@@ -0,0 +1,124 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+)
+
+func newTestPool(t *testing.T, threshold int) *Pool {
+	t.Helper()
+
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+	require.NoError(t, err)
+
+	return NewPool([]string{"a", "b", "c"}, threshold, lg)
+}
+
+func TestPool_Current(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		failures  []string
+		successes []string
+		want      string
+	}{
+		{name: "highest priority by default", threshold: 2, want: "a"},
+		{name: "failures below threshold", threshold: 2, failures: []string{"a"}, want: "a"},
+		{name: "failover after threshold", threshold: 2, failures: []string{"a", "a"}, want: "b"},
+		{name: "success resets failures", threshold: 2, failures: []string{"a"}, successes: []string{"a"}, want: "a"},
+		{name: "failover to the next healthy", threshold: 1, failures: []string{"a", "b"}, want: "c"},
+		{name: "all unhealthy", threshold: 1, failures: []string{"a", "b", "c"}, want: "a"},
+		{name: "unknown address is ignored", threshold: 1, failures: []string{"d"}, want: "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPool(t, tt.threshold)
+
+			for _, addr := range tt.failures {
+				p.ReportFailure(addr)
+			}
+			for _, addr := range tt.successes {
+				p.ReportSuccess(addr)
+			}
+
+			assert.Equal(t, tt.want, p.Current())
+		})
+	}
+}
+
+func TestPool_Probe(t *testing.T) {
+	tests := []struct {
+		name       string
+		unhealthy  []string
+		alive      map[string]bool
+		wantProbed []string
+		want       string
+	}{
+		{
+			name:       "fail back to higher priority",
+			unhealthy:  []string{"a"},
+			alive:      map[string]bool{"a": true},
+			wantProbed: []string{"a"},
+			want:       "a",
+		},
+		{
+			name:       "higher priority is still down",
+			unhealthy:  []string{"a"},
+			wantProbed: []string{"a"},
+			want:       "b",
+		},
+		{
+			name:      "lower priority servers are not probed",
+			unhealthy: []string{"c"},
+			want:      "a",
+		},
+		{
+			name:       "all servers are probed when all are unhealthy",
+			unhealthy:  []string{"a", "b", "c"},
+			alive:      map[string]bool{"b": true},
+			wantProbed: []string{"a", "b", "c"},
+			want:       "b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPool(t, 1)
+			for _, addr := range tt.unhealthy {
+				p.ReportFailure(addr)
+			}
+
+			var probed []string
+			p.Probe(context.Background(), func(ctx context.Context, addr string) error {
+				probed = append(probed, addr)
+				if tt.alive[addr] {
+					return nil
+				}
+				return errors.New("connection refused")
+			})
+
+			assert.Equal(t, tt.wantProbed, probed)
+			assert.Equal(t, tt.want, p.Current())
+		})
+	}
+}
+
+func TestPool_Start(t *testing.T) {
+	p := newTestPool(t, 1)
+	p.ReportFailure("a")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go p.Start(ctx, func(ctx context.Context, addr string) error { return nil }, time.Millisecond)
+
+	assert.Eventually(t, func() bool { return p.Current() == "a" }, time.Second, time.Millisecond)
+}
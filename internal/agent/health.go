@@ -0,0 +1,151 @@
+package agent
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CollectorHealth is the state of the collector after the last polls
+type CollectorHealth struct {
+	Name                string    `json:"name"`
+	Healthy             bool      `json:"healthy"` // consecutive failures are within the error budget
+	LastSuccessAt       time.Time `json:"last_success_at,omitzero"`
+	LastError           string    `json:"last_error,omitempty"`
+	LastErrorAt         time.Time `json:"last_error_at,omitzero"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+}
+
+// collectorsHealth tracks results of the collectors, the collector is unhealthy
+// when it failed more than budget times in a row
+type collectorsHealth struct {
+	mu      sync.Mutex
+	budget  int
+	entries map[string]*healthEntry
+}
+
+type healthEntry struct {
+	health CollectorHealth
+	busy   bool // collect of the previous poll is still running after the timeout
+}
+
+func (h *collectorsHealth) entry(name string) *healthEntry {
+	if h.entries == nil {
+		h.entries = make(map[string]*healthEntry)
+	}
+
+	e, ok := h.entries[name]
+	if !ok {
+		e = &healthEntry{health: CollectorHealth{Name: name, Healthy: true}}
+		h.entries[name] = e
+	}
+
+	return e
+}
+
+// setBudget replaces the error budget, health of the collectors is updated on their next poll
+func (h *collectorsHealth) setBudget(budget int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.budget = budget
+}
+
+// forget drops health of the collectors which are not polled anymore
+func (h *collectorsHealth) forget(names ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, name := range names {
+		delete(h.entries, name)
+	}
+}
+
+// begin marks the collector as running, false is returned when the previous collect is not finished yet
+func (h *collectorsHealth) begin(name string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	e := h.entry(name)
+	if e.busy {
+		return false
+	}
+
+	e.busy = true
+	return true
+}
+
+// end marks the collector as finished
+func (h *collectorsHealth) end(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.entry(name).busy = false
+}
+
+// succeeded resets failures of the collector, returns true when the unhealthy collector recovered
+func (h *collectorsHealth) succeeded(name string, now time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	e := h.entry(name)
+	recovered := !e.health.Healthy
+
+	e.health.LastSuccessAt = now
+	e.health.ConsecutiveFailures = 0
+	e.health.Healthy = true
+
+	return recovered
+}
+
+// failed records the failure, returns true when the collector has just run out of the error budget
+func (h *collectorsHealth) failed(name string, now time.Time, err error) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	e := h.entry(name)
+	wasHealthy := e.health.Healthy
+
+	e.health.LastError = err.Error()
+	e.health.LastErrorAt = now
+	e.health.ConsecutiveFailures++
+	e.health.Healthy = e.health.ConsecutiveFailures <= h.budget
+
+	return wasHealthy && !e.health.Healthy
+}
+
+// unhealthy returns errors of the collectors which ran out of the error budget
+func (h *collectorsHealth) unhealthy() []error {
+	var errs []error
+	for _, c := range h.list() {
+		if !c.Healthy {
+			errs = append(errs, fmt.Errorf("collector %s failed %d times in a row: %s", c.Name, c.ConsecutiveFailures, c.LastError))
+		}
+	}
+
+	return errs
+}
+
+// list returns health of the collectors sorted by name
+func (h *collectorsHealth) list() []CollectorHealth {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var res []CollectorHealth
+	for _, e := range h.entries {
+		res = append(res, e.health)
+	}
+
+	slices.SortFunc(res, func(a, b CollectorHealth) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return res
+}
+
+// CollectorsHealth returns health of the collectors polled at least once
+func (a *Agent) CollectorsHealth() []CollectorHealth {
+	return a.health.list()
+}
@@ -0,0 +1,105 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/storage"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+)
+
+type namedCollector struct {
+	name    string
+	collect func(ctx context.Context) ([]models.Metric, error)
+}
+
+func (c namedCollector) Name() string { return c.name }
+
+func (c namedCollector) Collect(ctx context.Context) ([]models.Metric, error) {
+	return c.collect(ctx)
+}
+
+func TestCollectorsHealth(t *testing.T) {
+	h := collectorsHealth{budget: 1}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.False(t, h.succeeded("cpu", now))
+	assert.False(t, h.failed("cpu", now.Add(time.Second), errors.New("read error")), "first failure is within the budget")
+	assert.Empty(t, h.unhealthy())
+	assert.True(t, h.failed("cpu", now.Add(2*time.Second), errors.New("read error")), "second failure exhausts the budget")
+	assert.False(t, h.failed("cpu", now.Add(3*time.Second), errors.New("read error")), "exhaustion is reported once")
+	assert.Len(t, h.unhealthy(), 1)
+
+	assert.Equal(t, []CollectorHealth{{
+		Name:                "cpu",
+		LastSuccessAt:       now,
+		LastError:           "read error",
+		LastErrorAt:         now.Add(3 * time.Second),
+		ConsecutiveFailures: 3,
+	}}, h.list())
+
+	assert.True(t, h.succeeded("cpu", now.Add(4*time.Second)))
+	assert.Empty(t, h.unhealthy())
+}
+
+func TestRunPollerPipe_isolation(t *testing.T) {
+	logger, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+	require.NoError(t, err)
+
+	failing := namedCollector{name: "failing", collect: func(context.Context) ([]models.Metric, error) {
+		return nil, errors.New("read error")
+	}}
+	healthy := namedCollector{name: "healthy", collect: func(context.Context) ([]models.Metric, error) {
+		return []models.Metric{models.NewGauge("QueueLength", 3)}, nil
+	}}
+
+	rep := NewMetricsRepository(storage.NewMemoryStorage(logger))
+	agent := NewAgent(logger, config.Config{CollectorErrorBudget: 1}, rep, nil, nil, failing, healthy)
+
+	got, err := agent.Once(context.Background())
+	require.NoError(t, err, "failure within the budget does not fail the poll")
+	assert.Contains(t, got, models.NewGauge("QueueLength", 3))
+
+	got, err = agent.Once(context.Background())
+	assert.ErrorContains(t, err, "collector failing failed 2 times in a row")
+	assert.Contains(t, got, models.NewGauge("QueueLength", 3), "metrics of other collectors are saved")
+
+	health := make(map[string]CollectorHealth)
+	for _, h := range agent.CollectorsHealth() {
+		health[h.Name] = h
+	}
+
+	assert.False(t, health["failing"].Healthy)
+	assert.True(t, health["healthy"].Healthy)
+	assert.True(t, health["cpu"].Healthy)
+}
+
+func TestRunPollerPipe_timeout(t *testing.T) {
+	logger, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	// the collector ignores ctx and is abandoned after the timeout
+	hung := namedCollector{name: "hung", collect: func(context.Context) ([]models.Metric, error) {
+		<-release
+		return nil, nil
+	}}
+
+	rep := NewMetricsRepository(storage.NewMemoryStorage(logger))
+	agent := NewAgent(logger, config.Config{CollectorTimeout: 20 * time.Millisecond}, rep, nil, nil, hung)
+
+	got, err := agent.Once(context.Background())
+	assert.ErrorContains(t, err, "timed out")
+	assert.NotEmpty(t, got, "built-in metrics are saved")
+
+	_, err = agent.Once(context.Background())
+	assert.ErrorContains(t, err, errCollectorBusy.Error())
+}
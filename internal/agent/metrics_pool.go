@@ -20,18 +20,14 @@ func NewMetricsPool() MetricsPool {
 	}
 }
 
-func (p *MetricsPool) Get(name, mtype, value string) *models.Metric {
+func (p *MetricsPool) Get(src models.Metric) *models.Metric {
 	m := p.pool.Get().(*models.Metric)
-	m.Name = name
-	m.Type = mtype
-	m.Value = value
+	*m = src
 	return m
 }
 
 func (p *MetricsPool) Put(m *models.Metric) {
-	m.Name = ""
-	m.Type = ""
-	m.Value = ""
+	*m = models.Metric{}
 	p.pool.Put(m)
 }
 
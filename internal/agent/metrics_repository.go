@@ -3,32 +3,57 @@ package agent
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 
 	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/storage"
 )
 
+// MetricsRepository stores collected metrics in a double buffer. The poller writes into the active buffer,
+// the reporter atomically swaps it with an empty one and drains the snapshot, so they never wait for each other.
 type MetricsRepository struct {
+	active atomic.Pointer[buffer]
+	pool   MetricsPool
+}
+
+// buffer is a storage the poller writes into until the reporter takes it as a snapshot.
+// Writers hold the read lock, so the reporter is able to wait for writes started before the swap.
+type buffer struct {
 	mu      sync.RWMutex
 	storage *storage.Memory
-	pool    MetricsPool
 }
 
 func NewMetricsRepository(st *storage.Memory) *MetricsRepository {
-	return &MetricsRepository{
-		mu:      sync.RWMutex{},
-		storage: st,
-		pool:    NewMetricsPool(),
+	r := &MetricsRepository{
+		pool: NewMetricsPool(),
+	}
+	r.active.Store(&buffer{storage: st})
+
+	return r
+}
+
+// acquire returns the active buffer locked for writing, it must be released with RUnlock
+func (r *MetricsRepository) acquire() *buffer {
+	for {
+		b := r.active.Load()
+		b.mu.RLock()
+
+		// buffer was swapped out before the lock was taken, retry with the new one
+		if b == r.active.Load() {
+			return b
+		}
+
+		b.mu.RUnlock()
 	}
 }
 
 func (r *MetricsRepository) Get(name, mtype string) (*models.Metric, error) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+	b := r.acquire()
+	defer b.mu.RUnlock()
 
-	m := r.pool.Get(name, mtype, "")
+	m := r.pool.Get(models.Metric{Name: name, Type: mtype})
 
-	err := r.storage.Get(m)
+	err := b.storage.Get(m)
 	if err != nil {
 		r.pool.Put(m)
 		return nil, err
@@ -37,35 +62,46 @@ func (r *MetricsRepository) Get(name, mtype string) (*models.Metric, error) {
 	return m, nil
 }
 
-func (r *MetricsRepository) New(name, mtype, value string) *models.Metric {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	return r.pool.Get(name, mtype, value)
+// New returns pooled copy of the metric, it must be released with Release or SaveAndRelease
+func (r *MetricsRepository) New(m models.Metric) *models.Metric {
+	return r.pool.Get(m)
 }
 
 func (r *MetricsRepository) Release(metrics ...*models.Metric) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
 	r.pool.Free(metrics)
 }
 
+// SaveAndRelease writes the metric into the active buffer. Counter deltas are accumulated until the next snapshot.
 func (r *MetricsRepository) SaveAndRelease(ctx context.Context, m *models.Metric) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
 	defer r.pool.Put(m)
 
-	return r.storage.Set(ctx, m)
+	b := r.acquire()
+	defer b.mu.RUnlock()
+
+	return b.storage.Add(ctx, m)
 }
 
-func (r *MetricsRepository) SafeRead(m *models.Metric) (name, mtype, value string) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+// Snapshot swaps the active buffer with an empty one and returns pooled copies of the collected metrics.
+// Counters start from zero in the new buffer, gauges are carried over unless they were polled after the swap,
+// so the last value of a gauge is reported again until it is polled. Returned metrics must be released with Release.
+func (r *MetricsRepository) Snapshot() []*models.Metric {
+	cur := r.active.Load()
+	next := &buffer{storage: cur.storage.Empty()}
+	old := r.active.Swap(next)
+
+	// wait for writes started before the swap
+	old.mu.Lock()
+	defer old.mu.Unlock()
+
+	data := old.storage.All()
+	res := make([]*models.Metric, 0, len(data))
+	for _, m := range data {
+		if m.Type == models.GaugeType {
+			next.storage.SetIfAbsent(&m)
+		}
+
+		res = append(res, r.pool.Get(m))
+	}
 
-	name = m.Name
-	mtype = m.Type
-	value = m.Value
-	return
+	return res
 }
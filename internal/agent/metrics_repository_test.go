@@ -0,0 +1,107 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/storage"
+)
+
+func TestMetricsRepository_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	rep := NewMetricsRepository(storage.NewMemoryStorage(nil))
+
+	require.NoError(t, rep.SaveAndRelease(ctx, rep.New(models.NewGauge("Alloc", 1.5))))
+	require.NoError(t, rep.SaveAndRelease(ctx, rep.New(models.NewGauge("Alloc", 2.5))))
+	require.NoError(t, rep.SaveAndRelease(ctx, rep.New(models.NewCounter("PollCount", 1))))
+	require.NoError(t, rep.SaveAndRelease(ctx, rep.New(models.NewCounter("PollCount", 1))))
+
+	snapshot := rep.Snapshot()
+	got := make(map[string]models.Metric, len(snapshot))
+	for _, m := range snapshot {
+		got[m.Name] = *m
+	}
+	rep.Release(snapshot...)
+
+	assert.Equal(t, map[string]models.Metric{
+		"Alloc":     models.NewGauge("Alloc", 2.5),
+		"PollCount": models.NewCounter("PollCount", 2),
+	}, got)
+
+	// counters are reset after snapshot, gauges keep their last value
+	_, err := rep.Get("PollCount", models.CounterType)
+	assert.ErrorIs(t, err, storage.ErrNoRecords)
+
+	snapshot = rep.Snapshot()
+	require.Len(t, snapshot, 1)
+	assert.Equal(t, models.NewGauge("Alloc", 2.5), *snapshot[0])
+	rep.Release(snapshot...)
+}
+
+func TestMetricsRepository_Snapshot_gaugeRepolled(t *testing.T) {
+	ctx := context.Background()
+	rep := NewMetricsRepository(storage.NewMemoryStorage(nil))
+
+	require.NoError(t, rep.SaveAndRelease(ctx, rep.New(models.NewGauge("Alloc", 1.5))))
+	rep.Release(rep.Snapshot()...)
+
+	require.NoError(t, rep.SaveAndRelease(ctx, rep.New(models.NewGauge("Alloc", 3))))
+	snapshot := rep.Snapshot()
+	require.Len(t, snapshot, 1)
+	assert.Equal(t, models.NewGauge("Alloc", 3), *snapshot[0], "polled value replaces the carried one")
+	rep.Release(snapshot...)
+}
+
+func TestMetricsRepository_Snapshot_concurrent(t *testing.T) {
+	const (
+		writers = 10
+		writes  = 1000
+	)
+
+	ctx := context.Background()
+	rep := NewMetricsRepository(storage.NewMemoryStorage(nil))
+
+	var (
+		wg    sync.WaitGroup
+		total int64
+	)
+
+	for range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range writes {
+				assert.NoError(t, rep.SaveAndRelease(ctx, rep.New(models.NewCounter("PollCount", 1))))
+			}
+		}()
+	}
+
+	collect := func() {
+		snapshot := rep.Snapshot()
+		for _, m := range snapshot {
+			total += m.Delta
+		}
+		rep.Release(snapshot...)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	for {
+		select {
+		case <-done:
+			collect()
+			assert.Equal(t, int64(writers*writes), total, "every counter increment must get into exactly one snapshot")
+			return
+		default:
+			collect()
+		}
+	}
+}
@@ -8,9 +8,20 @@ const GaugeType = "gauge"
 const CounterType = "counter"
 
 type Metric struct {
-	Name  string `json:"name"`
-	Type  string `json:"type"`
-	Value string `json:"value"`
+	Name  string  `json:"name"`
+	Type  string  `json:"type"`
+	Delta int64   `json:"delta,omitempty"` // metric value for counter type
+	Value float64 `json:"value,omitempty"` // metric value for gauge type
+}
+
+// NewGauge creates gauge metric
+func NewGauge(name string, value float64) Metric {
+	return Metric{Name: name, Type: GaugeType, Value: value}
+}
+
+// NewCounter creates counter metric
+func NewCounter(name string, delta int64) Metric {
+	return Metric{Name: name, Type: CounterType, Delta: delta}
 }
 
 func (m Metric) String() string {
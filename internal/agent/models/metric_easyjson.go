@@ -4,7 +4,6 @@ package models
 
 import (
 	json "encoding/json"
-
 	easyjson "github.com/mailru/easyjson"
 	jlexer "github.com/mailru/easyjson/jlexer"
 	jwriter "github.com/mailru/easyjson/jwriter"
@@ -41,8 +40,10 @@ func easyjson9478868cDecodeGithubComVysogota0399MemStatsMonitoringInternalAgentM
 			out.Name = string(in.String())
 		case "type":
 			out.Type = string(in.String())
+		case "delta":
+			out.Delta = int64(in.Int64())
 		case "value":
-			out.Value = string(in.String())
+			out.Value = float64(in.Float64())
 		default:
 			in.SkipRecursive()
 		}
@@ -67,14 +68,26 @@ func easyjson9478868cEncodeGithubComVysogota0399MemStatsMonitoringInternalAgentM
 		out.RawString(prefix)
 		out.String(string(in.Type))
 	}
-	{
+	if in.Delta != 0 {
+		const prefix string = ",\"delta\":"
+		out.RawString(prefix)
+		out.Int64(int64(in.Delta))
+	}
+	if in.Value != 0 {
 		const prefix string = ",\"value\":"
 		out.RawString(prefix)
-		out.String(string(in.Value))
+		out.Float64(float64(in.Value))
 	}
 	out.RawByte('}')
 }
 
+// MarshalJSON supports json.Marshaler interface
+func (v Metric) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson9478868cEncodeGithubComVysogota0399MemStatsMonitoringInternalAgentModels(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v Metric) MarshalEasyJSON(w *jwriter.Writer) {
 	easyjson9478868cEncodeGithubComVysogota0399MemStatsMonitoringInternalAgentModels(w, v)
@@ -86,3 +99,8 @@ func (v *Metric) UnmarshalJSON(data []byte) error {
 	easyjson9478868cDecodeGithubComVysogota0399MemStatsMonitoringInternalAgentModels(&r, v)
 	return r.Error()
 }
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Metric) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson9478868cDecodeGithubComVysogota0399MemStatsMonitoringInternalAgentModels(l, v)
+}
@@ -8,7 +8,8 @@ func TestMetric_String(t *testing.T) {
 	type fields struct {
 		Name  string
 		Type  string
-		Value string
+		Delta int64
+		Value float64
 	}
 	tests := []struct {
 		name   string
@@ -18,38 +19,37 @@ func TestMetric_String(t *testing.T) {
 		{
 			name: "empty metric",
 			fields: fields{
-				Name:  "",
-				Type:  "",
-				Value: "",
+				Name: "",
+				Type: "",
 			},
-			want: `{"name":"","type":"","value":""}`,
+			want: `{"name":"","type":""}`,
 		},
 		{
 			name: "gauge metric",
 			fields: fields{
 				Name:  "Alloc",
 				Type:  GaugeType,
-				Value: "123.45",
+				Value: 123.45,
 			},
-			want: `{"name":"Alloc","type":"gauge","value":"123.45"}`,
+			want: `{"name":"Alloc","type":"gauge","value":123.45}`,
 		},
 		{
 			name: "counter metric",
 			fields: fields{
 				Name:  "PollCount",
 				Type:  CounterType,
-				Value: "42",
+				Delta: 42,
 			},
-			want: `{"name":"PollCount","type":"counter","value":"42"}`,
+			want: `{"name":"PollCount","type":"counter","delta":42}`,
 		},
 		{
 			name: "metric with special characters",
 			fields: fields{
 				Name:  "test-metric",
 				Type:  GaugeType,
-				Value: "1.2.3",
+				Value: 0.5,
 			},
-			want: `{"name":"test-metric","type":"gauge","value":"1.2.3"}`,
+			want: `{"name":"test-metric","type":"gauge","value":0.5}`,
 		},
 	}
 	for _, tt := range tests {
@@ -57,6 +57,7 @@ func TestMetric_String(t *testing.T) {
 			m := Metric{
 				Name:  tt.fields.Name,
 				Type:  tt.fields.Type,
+				Delta: tt.fields.Delta,
 				Value: tt.fields.Value,
 			}
 			if got := m.String(); got != tt.want {
@@ -70,7 +71,8 @@ func TestMetric_FromJSON(t *testing.T) {
 	type fields struct {
 		Name  string
 		Type  string
-		Value string
+		Delta int64
+		Value float64
 	}
 	type args struct {
 		inp []byte
@@ -86,55 +88,59 @@ func TestMetric_FromJSON(t *testing.T) {
 			fields: fields{
 				Name:  "Alloc",
 				Type:  GaugeType,
-				Value: "123.45",
+				Value: 123.45,
 			},
 			args: args{
-				inp: []byte(`{"name":"Alloc","type":"gauge","value":"123.45"}`),
+				inp: []byte(`{"name":"Alloc","type":"gauge","value":123.45}`),
 			},
 			wantErr: false,
 		},
+		{
+			name: "string value",
+			args: args{
+				inp: []byte(`{"name":"Alloc","type":"gauge","value":"123.45"}`),
+			},
+			wantErr: true,
+		},
 		{
 			name: "valid counter metric",
 			fields: fields{
 				Name:  "PollCount",
 				Type:  CounterType,
-				Value: "42",
+				Delta: 42,
 			},
 			args: args{
-				inp: []byte(`{"name":"PollCount","type":"counter","value":"42"}`),
+				inp: []byte(`{"name":"PollCount","type":"counter","delta":42}`),
 			},
 			wantErr: false,
 		},
 		{
 			name: "empty metric",
 			fields: fields{
-				Name:  "",
-				Type:  "",
-				Value: "",
+				Name: "",
+				Type: "",
 			},
 			args: args{
-				inp: []byte(`{"name":"","type":"","value":""}`),
+				inp: []byte(`{"name":"","type":""}`),
 			},
 			wantErr: false,
 		},
 		{
 			name: "invalid JSON",
 			fields: fields{
-				Name:  "",
-				Type:  "",
-				Value: "",
+				Name: "",
+				Type: "",
 			},
 			args: args{
-				inp: []byte(`{"name":"Alloc","type":"gauge","value":"123.45"`), // missing closing brace
+				inp: []byte(`{"name":"Alloc","type":"gauge","value":123.45`), // missing closing brace
 			},
 			wantErr: true,
 		},
 		{
 			name: "missing required field",
 			fields: fields{
-				Name:  "",
-				Type:  "",
-				Value: "",
+				Name: "",
+				Type: "",
 			},
 			args: args{
 				inp: []byte(`{"name":"Alloc","type":"gauge"}`), // missing value field
@@ -146,10 +152,10 @@ func TestMetric_FromJSON(t *testing.T) {
 			fields: fields{
 				Name:  "Alloc",
 				Type:  GaugeType,
-				Value: "123.45",
+				Value: 123.45,
 			},
 			args: args{
-				inp: []byte(`{"name":"Alloc","type":"gauge","value":"123.45","extra":"field"}`),
+				inp: []byte(`{"name":"Alloc","type":"gauge","value":123.45,"extra":"field"}`),
 			},
 			wantErr: false, // extra fields are ignored
 		},
@@ -159,6 +165,7 @@ func TestMetric_FromJSON(t *testing.T) {
 			m := &Metric{
 				Name:  tt.fields.Name,
 				Type:  tt.fields.Type,
+				Delta: tt.fields.Delta,
 				Value: tt.fields.Value,
 			}
 			if err := m.FromJSON(tt.args.inp); (err != nil) != tt.wantErr {
@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Notifier tells the service manager about the state of the agent, it is implemented by systemd.Notifier
+type Notifier interface {
+	Ready(status string) error
+	Status(status string) error
+	Stopping() error
+	KeepAlive(ctx context.Context, healthy func() bool)
+}
+
+// stallFactor is how many intervals the poll and report loops may miss before the agent is considered hung
+const stallFactor = 3
+
+// WithNotifier makes the agent report readiness after the first successful poll, report results as status
+// and ping the watchdog while the poll and report loops are running
+func (a *Agent) WithNotifier(n Notifier) *Agent {
+	a.notifier = n
+	return a
+}
+
+// startNotifier pings the watchdog until ctx is done and reports stopping on shutdown
+func (a *Agent) startNotifier(ctx context.Context) {
+	if a.notifier == nil {
+		return
+	}
+
+	go a.notifier.KeepAlive(ctx, func() bool { return a.alive(time.Now()) })
+
+	go func() {
+		<-ctx.Done()
+		if err := a.notifier.Stopping(); err != nil {
+			a.lg.ErrorCtx(ctx, "stopping notification failed", zap.Error(err))
+		}
+	}()
+}
+
+// alive reports whether the poll loop finished an iteration and the report loop finished a request recently,
+// the poll may take up to the collector timeout in addition to the interval. The report loop is checked by
+// requests, since resending many pending reports to the unavailable server may take longer than a few intervals.
+func (a *Agent) alive(now time.Time) bool {
+	cfg := a.config()
+	poll, report := a.status.lastActivity()
+
+	return now.Sub(poll) <= stallFactor*cfg.PollInterval+cfg.CollectorTimeout &&
+		now.Sub(report) <= stallFactor*cfg.ReportInterval
+}
+
+// notifyPolled reports readiness after the first successful poll
+func (a *Agent) notifyPolled(ctx context.Context, err error) {
+	if a.notifier == nil || err != nil {
+		return
+	}
+
+	a.ready.Do(func() {
+		cfg := a.config()
+		status := fmt.Sprintf("polling every %s, reporting every %s", cfg.PollInterval, cfg.ReportInterval)
+		if err := a.notifier.Ready(status); err != nil {
+			a.lg.ErrorCtx(ctx, "ready notification failed", zap.Error(err))
+		}
+	})
+}
+
+// notifyReported reports the result of the report and the reports waiting for resend as status
+func (a *Agent) notifyReported(ctx context.Context, now time.Time, err error) {
+	if a.notifier == nil {
+		return
+	}
+
+	pending, _ := a.pending.Depth()
+
+	status := fmt.Sprintf("last report at %s, pending reports %d", now.Format(time.TimeOnly), pending)
+	if err != nil {
+		status = fmt.Sprintf("last report failed at %s: %s, pending reports %d", now.Format(time.TimeOnly), err, pending)
+	}
+
+	if err := a.notifier.Status(status); err != nil {
+		a.lg.ErrorCtx(ctx, "status notification failed", zap.Error(err))
+	}
+}
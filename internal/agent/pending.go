@@ -0,0 +1,101 @@
+package agent
+
+import (
+	"sync"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+)
+
+// maxPendingReports limits the number of unacknowledged reports kept by the agent.
+// On overflow the oldest reports are merged, so counters are still delivered at least once.
+const maxPendingReports = 100
+
+// pendingReport holds counter deltas of the report which was not acknowledged by the server.
+// It is resent with the same idempotency key, so the server drops it if the previous attempt was applied.
+type pendingReport struct {
+	key     string
+	metrics []models.Metric
+}
+
+// pendingReports queue of unacknowledged reports
+type pendingReports struct {
+	mu      sync.Mutex
+	reports []pendingReport
+}
+
+// Add keeps counters of the unacknowledged report, other metrics are dropped since the next poll replaces them
+func (p *pendingReports) Add(key string, metrics []models.Metric) {
+	counters := make([]models.Metric, 0, len(metrics))
+	for _, m := range metrics {
+		if m.Type == models.CounterType {
+			counters = append(counters, m)
+		}
+	}
+
+	if len(counters) == 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.reports = append(p.reports, pendingReport{key: key, metrics: counters})
+
+	if len(p.reports) > maxPendingReports {
+		p.reports[1] = mergeReports(p.reports[0], p.reports[1])
+		p.reports = p.reports[1:]
+	}
+}
+
+// Take returns all pending reports and clears the queue
+func (p *pendingReports) Take() []pendingReport {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	res := p.reports
+	p.reports = nil
+
+	return res
+}
+
+// Len returns the number of pending reports
+func (p *pendingReports) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.reports)
+}
+
+// Depth returns the number of pending reports and counters in them
+func (p *pendingReports) Depth() (reports, metrics int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, r := range p.reports {
+		metrics += len(r.metrics)
+	}
+
+	return len(p.reports), metrics
+}
+
+// mergeReports sums counters of both reports under a new key. Either report might be already applied by the server,
+// so its counters may be counted twice, but the merged report is never dropped as a duplicate of them.
+func mergeReports(older, newer pendingReport) pendingReport {
+	idx := make(map[string]int, len(newer.metrics))
+	res := pendingReport{key: uuid.NewV4().String(), metrics: make([]models.Metric, 0, len(older.metrics)+len(newer.metrics))}
+
+	for _, batch := range [][]models.Metric{newer.metrics, older.metrics} {
+		for _, m := range batch {
+			if i, ok := idx[m.Name]; ok {
+				res.metrics[i].Delta += m.Delta
+				continue
+			}
+
+			idx[m.Name] = len(res.metrics)
+			res.metrics = append(res.metrics, m)
+		}
+	}
+
+	return res
+}
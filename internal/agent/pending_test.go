@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+)
+
+func TestPendingReports_Add(t *testing.T) {
+	var p pendingReports
+
+	p.Add("gauges", []models.Metric{models.NewGauge("Alloc", 1)})
+	assert.Equal(t, 0, p.Len(), "report without counters is not kept")
+
+	p.Add("report", []models.Metric{models.NewGauge("Alloc", 1), models.NewCounter("PollCount", 2)})
+	assert.Equal(t, []pendingReport{{key: "report", metrics: []models.Metric{models.NewCounter("PollCount", 2)}}}, p.Take())
+	assert.Equal(t, 0, p.Len())
+}
+
+func TestPendingReports_Add_overflow(t *testing.T) {
+	var p pendingReports
+
+	for i := range maxPendingReports + 1 {
+		p.Add(fmt.Sprintf("report-%d", i), []models.Metric{models.NewCounter("PollCount", 1)})
+	}
+
+	reports := p.Take()
+	assert.Len(t, reports, maxPendingReports)
+	assert.NotContains(t, []string{"report-0", "report-1"}, reports[0].key)
+	assert.Equal(t, []models.Metric{models.NewCounter("PollCount", 2)}, reports[0].metrics)
+	assert.Equal(t, "report-2", reports[1].key)
+}
+
+func TestPendingReports_Add_overflowApplied(t *testing.T) {
+	var p pendingReports
+
+	for i := range maxPendingReports + 1 {
+		p.Add(fmt.Sprintf("report-%d", i), []models.Metric{models.NewCounter("PollCount", 1)})
+	}
+
+	// the server applied report-1, but the acknowledgement was lost
+	applied := map[string]bool{"report-1": true}
+	total := int64(1)
+
+	for _, r := range p.Take() {
+		if applied[r.key] {
+			continue
+		}
+
+		applied[r.key] = true
+		for _, m := range r.metrics {
+			total += m.Delta
+		}
+	}
+
+	assert.GreaterOrEqual(t, total, int64(maxPendingReports+1), "counters of report-0 are not lost")
+}
+
+func TestMergeReports(t *testing.T) {
+	older := pendingReport{key: "a", metrics: []models.Metric{models.NewCounter("PollCount", 1), models.NewCounter("Errors", 5)}}
+	newer := pendingReport{key: "b", metrics: []models.Metric{models.NewCounter("PollCount", 2)}}
+
+	merged := mergeReports(older, newer)
+	assert.NotContains(t, []string{"a", "b"}, merged.key)
+	assert.Equal(t, []models.Metric{models.NewCounter("PollCount", 3), models.NewCounter("Errors", 5)}, merged.metrics)
+}
@@ -2,9 +2,12 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"runtime"
+	"slices"
 	"sync"
+	"time"
 
 	uuid "github.com/satori/go.uuid"
 	"github.com/shirou/gopsutil/v4/cpu"
@@ -14,10 +17,11 @@ import (
 	"golang.org/x/sync/errgroup"
 )
 
-func (a *Agent) runPollerPipe(ctx context.Context) error {
-	a.reporterPipeLock.Lock()
-	defer a.reporterPipeLock.Unlock()
+// errCollectorBusy is returned when the collector abandoned after the timeout is still running
+var errCollectorBusy = errors.New("previous collect is still running")
 
+// runPollerPipe collects metrics into the active repository buffer, it does not wait for the reporter
+func (a *Agent) runPollerPipe(ctx context.Context) error {
 	operationID := uuid.NewV4()
 	ctx = a.lg.WithContextFields(ctx, zap.String("operation_id", operationID.String()))
 
@@ -29,12 +33,21 @@ func (a *Agent) runPollerPipe(ctx context.Context) error {
 		return fmt.Errorf("poller_pile: collect metrics failed error %w", err)
 	}
 
+	// failed collectors do not affect others, the poll fails only when some collector ran out of the error budget
+	if errs := a.health.unhealthy(); len(errs) > 0 {
+		return fmt.Errorf("poller_pile: collect metrics failed error %w", errors.Join(errs...))
+	}
+
 	return nil
 }
 
 func (a *Agent) saveMetrics(ctx context.Context, g *errgroup.Group, metrics <-chan *models.Metric) {
 	numWorkers := 10
 
+	a.mu.RLock()
+	relabeler := a.relabeler
+	a.mu.RUnlock()
+
 	for range numWorkers {
 		g.Go(func() error {
 			for m := range metrics {
@@ -42,6 +55,11 @@ func (a *Agent) saveMetrics(ctx context.Context, g *errgroup.Group, metrics <-ch
 				case <-ctx.Done():
 					return nil
 				default:
+					if !relabeler.Apply(ctx, m) {
+						a.repository.Release(m)
+						continue
+					}
+
 					if err := a.repository.SaveAndRelease(ctx, m); err != nil {
 						return fmt.Errorf("internal/agent/poller_pipe save metric %+v to storate error %w", metrics, err)
 					}
@@ -53,16 +71,59 @@ func (a *Agent) saveMetrics(ctx context.Context, g *errgroup.Group, metrics <-ch
 	}
 }
 
+// source is a group of metrics polled with its own timeout and health
+type source struct {
+	name    string
+	collect func(ctx context.Context) ([]models.Metric, error)
+}
+
+// sources returns built-in metrics followed by the collectors, disabled collectors are skipped
+func (a *Agent) sources() []source {
+	all := make([]source, 0, 4+len(a.collectors))
+	if len(a.runtimeMetrics) > 0 {
+		all = append(all, source{name: "memstats", collect: a.collectRuntimeMetrics})
+	}
+
+	all = append(all,
+		source{name: "custom", collect: a.collectCustomMetrics},
+		source{name: "virtual_memory", collect: a.collectVirtualMemoryMetrics},
+		source{name: "cpu", collect: a.collectCPUMetrics},
+	)
+
+	for _, c := range a.collectors {
+		all = append(all, source{name: c.Name(), collect: c.Collect})
+	}
+
+	disabled := a.config().DisabledCollectors
+	if len(disabled) == 0 {
+		return all
+	}
+
+	sources := all[:0]
+	for _, s := range all {
+		if !slices.Contains(disabled, s.name) {
+			sources = append(sources, s)
+		}
+	}
+
+	return sources
+}
+
 func (a *Agent) genMetrics(ctx context.Context, g *errgroup.Group) chan *models.Metric {
 	wg := &sync.WaitGroup{}
 	metrics := make(chan *models.Metric)
 	done := make(chan struct{})
 
-	// Start all metric generators
-	a.genRuntimeMetrics(ctx, wg, g, metrics, done)
-	a.genCustromMetrics(ctx, wg, g, metrics, done)
-	a.genVirtualMemoryMetrics(ctx, wg, g, metrics, done)
-	a.genCPUMetrics(ctx, wg, g, metrics, done)
+	// Start all metric generators, errors are recorded in the collector health and don't cancel other generators
+	for _, s := range a.sources() {
+		wg.Add(1)
+		g.Go(func() error {
+			defer wg.Done()
+
+			a.genSourceMetrics(ctx, s, metrics, done)
+			return nil
+		})
+	}
 
 	// Close metrics channel when all generators are done
 	g.Go(func() error {
@@ -75,188 +136,141 @@ func (a *Agent) genMetrics(ctx context.Context, g *errgroup.Group) chan *models.
 	return metrics
 }
 
-func (a *Agent) genCPUMetrics(
-	ctx context.Context,
-	wg *sync.WaitGroup,
-	g *errgroup.Group,
-	metrics chan *models.Metric,
-	done chan struct{},
-) {
-	wg.Add(1)
-	g.Go(func() error {
-		defer wg.Done()
+// genSourceMetrics collects the source and sends metrics, partial results of the failed source are sent too
+func (a *Agent) genSourceMetrics(ctx context.Context, s source, metrics chan *models.Metric, done chan struct{}) {
+	collected, err := a.collect(ctx, s)
+	if ctx.Err() != nil {
+		// the poll is canceled, it is not the collector failure
+		return
+	}
 
-		stats, err := cpu.Info()
-		if err != nil {
-			return fmt.Errorf("internal/agent/poller_pipe calc cpu error %w", err)
+	now := time.Now()
+	if err != nil {
+		if a.health.failed(s.name, now, err) {
+			a.lg.ErrorCtx(ctx, "collector ran out of the error budget", zap.String("collector", s.name), zap.Error(err))
+		} else {
+			a.lg.WarnCtx(ctx, "collector failed", zap.String("collector", s.name), zap.Error(err))
 		}
+	} else if a.health.succeeded(s.name, now) {
+		a.lg.InfoCtx(ctx, "collector recovered", zap.String("collector", s.name))
+	}
 
-		for _, m := range a.cpuMetrics {
-			select {
-			case <-ctx.Done():
-				a.lg.InfoCtx(ctx, "genCPUMetrics context done with context cancellation")
-				return nil
-			case <-done:
-				return nil
-			default:
-				val, err := convertToStr(m.generateValue(stats))
-				if err != nil {
-					return fmt.Errorf("internal/agent/poller_pipe convert to str error %w", err)
-				}
+	for _, m := range collected {
+		res := a.repository.New(m)
+
+		select {
+		case metrics <- res:
+		case <-ctx.Done():
+			a.repository.Release(res)
+			a.lg.InfoCtx(ctx, "genSourceMetrics context done with context cancellation", zap.String("collector", s.name))
+			return
+		case <-done:
+			a.repository.Release(res)
+			return
+		}
+	}
+}
 
-				res := a.repository.New(m.Name, m.Type, val)
+// collect runs the source with the collector timeout, the source which ignores ctx is abandoned
+// after the timeout and skipped until it returns
+func (a *Agent) collect(ctx context.Context, s source) ([]models.Metric, error) {
+	if !a.health.begin(s.name) {
+		return nil, fmt.Errorf("internal/agent/poller_pipe collect %s error %w", s.name, errCollectorBusy)
+	}
 
-				select {
-				case metrics <- res:
-				case <-ctx.Done():
-					a.repository.Release(res)
-					a.lg.InfoCtx(ctx, "genCPUMetrics context done with context cancellation")
-					return nil
-				case <-done:
-					return nil
-				}
-			}
-		}
+	cfg := a.config()
+	timeout := cfg.CollectorTimeout
+	if timeout <= 0 {
+		timeout = cfg.PollInterval
+	}
 
-		return nil
-	})
-}
+	if timeout <= 0 {
+		defer a.health.end(s.name)
+		return s.collect(ctx)
+	}
 
-func (a *Agent) genVirtualMemoryMetrics(
-	ctx context.Context,
-	wg *sync.WaitGroup,
-	g *errgroup.Group,
-	metrics chan *models.Metric,
-	done chan struct{},
-) {
-	wg.Add(1)
-	g.Go(func() error {
-		defer wg.Done()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
 
-		stat, err := mem.VirtualMemory()
-		if err != nil {
-			return fmt.Errorf("internal/agent/poller_pipe calc virtual memory error %w", err)
-		}
+	type result struct {
+		metrics []models.Metric
+		err     error
+	}
 
-		for _, m := range a.virtualMemoryMetrics {
-			select {
-			case <-ctx.Done():
-				a.lg.InfoCtx(ctx, "genVirtualMemoryMetrics context done with context cancellation")
-				return nil
-			case <-done:
-				return nil
-			default:
-				val, err := convertToStr(m.generateValue(stat))
-				if err != nil {
-					return fmt.Errorf("internal/agent/poller_pipe convert to str error %w", err)
-				}
+	results := make(chan result, 1)
+	go func() {
+		defer a.health.end(s.name)
 
-				res := a.repository.New(m.Name, m.Type, val)
+		collected, err := s.collect(ctx)
+		results <- result{metrics: collected, err: err}
+	}()
 
-				select {
-				case metrics <- res:
-				case <-ctx.Done():
-					a.repository.Release(res)
-					a.lg.InfoCtx(ctx, "genVirtualMemoryMetrics context done with context cancellation")
-					return nil
-				case <-done:
-					return nil
-				}
-			}
+	select {
+	case res := <-results:
+		if res.err != nil {
+			return res.metrics, fmt.Errorf("internal/agent/poller_pipe collect %s error %w", s.name, res.err)
 		}
 
-		return nil
-	})
+		return res.metrics, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("internal/agent/poller_pipe collect %s timed out after %s error %w", s.name, timeout, ctx.Err())
+	}
 }
 
-func (a *Agent) genCustromMetrics(
-	ctx context.Context,
-	wg *sync.WaitGroup,
-	g *errgroup.Group,
-	metrics chan *models.Metric,
-	done chan struct{},
-) {
-	wg.Add(1)
-	g.Go(func() error {
-		defer wg.Done()
-
-		for _, m := range a.customMetrics {
-			select {
-			case <-ctx.Done():
-				a.lg.InfoCtx(ctx, "genCustromMetrics context done with context cancellation")
-				return nil
-			case <-done:
-				return nil
-			default:
-				val, err := m.generateValue(m, a)
-				if err != nil {
-					return fmt.Errorf("internal/agent/poller_pipe generate val error %w", err)
-				}
+func (a *Agent) collectCPUMetrics(context.Context) ([]models.Metric, error) {
+	stats, err := cpu.Info()
+	if err != nil {
+		return nil, fmt.Errorf("internal/agent/poller_pipe calc cpu error %w", err)
+	}
 
-				sVal, err := convertToStr(val)
-				if err != nil {
-					return fmt.Errorf("internal/agent/poller_pipe generate val error %w", err)
-				}
+	res := make([]models.Metric, 0, len(a.cpuMetrics))
+	for _, m := range a.cpuMetrics {
+		res = append(res, newMetric(m.Name, m.Type, m.generateValue(stats)))
+	}
 
-				res := a.repository.New(m.Name, m.Type, sVal)
+	return res, nil
+}
 
-				select {
-				case metrics <- res:
-				case <-ctx.Done():
-					a.repository.Release(res)
-					a.lg.InfoCtx(ctx, "genCustromMetrics context done with context cancellation")
-					return nil
-				case <-done:
-					return nil
-				}
-			}
-		}
+func (a *Agent) collectVirtualMemoryMetrics(context.Context) ([]models.Metric, error) {
+	stat, err := mem.VirtualMemory()
+	if err != nil {
+		return nil, fmt.Errorf("internal/agent/poller_pipe calc virtual memory error %w", err)
+	}
 
-		return nil
-	})
-}
+	res := make([]models.Metric, 0, len(a.virtualMemoryMetrics))
+	for _, m := range a.virtualMemoryMetrics {
+		res = append(res, newMetric(m.Name, m.Type, m.generateValue(stat)))
+	}
 
-func (a *Agent) genRuntimeMetrics(
-	ctx context.Context,
-	wg *sync.WaitGroup,
-	g *errgroup.Group,
-	metrics chan *models.Metric,
-	done chan struct{},
-) {
-	wg.Add(1)
-	g.Go(func() error {
-		defer wg.Done()
-
-		memStat := &runtime.MemStats{}
-		runtime.ReadMemStats(memStat)
-
-		for _, m := range a.runtimeMetrics {
-			select {
-			case <-ctx.Done():
-				a.lg.InfoCtx(ctx, "genRuntimeMetrics context done with context cancellation")
-				return nil
-			case <-done:
-				return nil
-			default:
-				val, err := convertToStr(m.generateValue(memStat))
-				if err != nil {
-					return fmt.Errorf("internal/agent/poller_pipe convert to str error %w", err)
-				}
+	return res, nil
+}
 
-				res := a.repository.New(m.Name, m.Type, val)
+// collectCustomMetrics returns generated metrics even if some of them failed
+func (a *Agent) collectCustomMetrics(context.Context) ([]models.Metric, error) {
+	var errs []error
 
-				select {
-				case metrics <- res:
-				case <-ctx.Done():
-					a.repository.Release(res)
-					a.lg.InfoCtx(ctx, "genRuntimeMetrics context done with context cancellation")
-					return nil
-				case <-done:
-					return nil
-				}
-			}
+	res := make([]models.Metric, 0, len(a.customMetrics))
+	for _, m := range a.customMetrics {
+		val, err := m.generateValue(m, a)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("internal/agent/poller_pipe generate %s val error %w", m.Name, err))
+			continue
 		}
 
-		return nil
-	})
+		res = append(res, val)
+	}
+
+	return res, errors.Join(errs...)
+}
+
+func (a *Agent) collectRuntimeMetrics(context.Context) ([]models.Metric, error) {
+	memStat := &runtime.MemStats{}
+	runtime.ReadMemStats(memStat)
+
+	res := make([]models.Metric, 0, len(a.runtimeMetrics))
+	for _, m := range a.runtimeMetrics {
+		res = append(res, newMetric(m.Name, m.Type, m.generateValue(memStat)))
+	}
+
+	return res, nil
 }
@@ -0,0 +1,157 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"go.uber.org/zap"
+)
+
+type relabelRule struct {
+	config.RelabelRule
+	re *regexp.Regexp
+}
+
+// Relabeler applies ordered relabel rules to the collected metrics before they get into the repository.
+// In dry-run mode the rules are evaluated on a copy of the metric and only logged.
+type Relabeler struct {
+	rules  []relabelRule
+	dryRun bool
+	lg     *logging.ZapLogger
+}
+
+// NewRelabeler compiles relabel rules, returns nil when there is nothing to apply
+func NewRelabeler(rules []config.RelabelRule, dryRun bool, lg *logging.ZapLogger) (*Relabeler, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	r := &Relabeler{rules: make([]relabelRule, 0, len(rules)), dryRun: dryRun, lg: lg}
+	for i, rule := range rules {
+		expr := rule.Regex
+		if expr == "" {
+			expr = ".*"
+		}
+
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("internal/agent/relabel: rule %d compile regex %q error %w", i, rule.Regex, err)
+		}
+
+		switch rule.Action {
+		case config.RelabelKeep, config.RelabelDrop:
+		case config.RelabelRename:
+			if rule.Replacement == "" {
+				return nil, fmt.Errorf("internal/agent/relabel: rule %d replacement is empty", i)
+			}
+		case config.RelabelPrefix:
+			if rule.Prefix == "" {
+				return nil, fmt.Errorf("internal/agent/relabel: rule %d prefix is empty", i)
+			}
+		case config.RelabelType:
+			if rule.Type != models.GaugeType && rule.Type != models.CounterType {
+				return nil, fmt.Errorf("internal/agent/relabel: rule %d unknown metric type %q", i, rule.Type)
+			}
+		default:
+			return nil, fmt.Errorf("internal/agent/relabel: rule %d unknown action %q", i, rule.Action)
+		}
+
+		r.rules = append(r.rules, relabelRule{RelabelRule: rule, re: re})
+	}
+
+	return r, nil
+}
+
+// Apply runs the rules over the metric in order and reports whether the metric should be kept.
+// Nil relabeler keeps all metrics untouched.
+func (r *Relabeler) Apply(ctx context.Context, m *models.Metric) bool {
+	if r == nil {
+		return true
+	}
+
+	if !r.dryRun {
+		for _, rule := range r.rules {
+			if !rule.apply(m) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	target := *m
+	for i, rule := range r.rules {
+		before := target
+		keep := rule.apply(&target)
+
+		if !keep {
+			r.lg.InfoCtx(ctx, "relabel dry run: metric dropped",
+				zap.Int("rule", i),
+				zap.String("action", rule.Action),
+				zap.String("before", before.String()),
+			)
+			break
+		}
+
+		if before != target {
+			r.lg.InfoCtx(ctx, "relabel dry run: metric changed",
+				zap.Int("rule", i),
+				zap.String("action", rule.Action),
+				zap.String("before", before.String()),
+				zap.String("after", target.String()),
+			)
+		}
+	}
+
+	return true
+}
+
+func (rule relabelRule) apply(m *models.Metric) bool {
+	match := rule.re.FindStringSubmatchIndex(m.Name)
+
+	switch rule.Action {
+	case config.RelabelKeep:
+		return match != nil
+	case config.RelabelDrop:
+		return match == nil
+	}
+
+	if match == nil {
+		return true
+	}
+
+	switch rule.Action {
+	case config.RelabelRename:
+		// replacement referencing missing groups may expand to nothing, the metric keeps its name then
+		if name := rule.re.ExpandString(nil, rule.Replacement, m.Name, match); len(name) > 0 {
+			m.Name = string(name)
+		}
+	case config.RelabelPrefix:
+		m.Name = rule.Prefix + m.Name
+	case config.RelabelType:
+		convertType(m, rule.Type)
+	}
+
+	return true
+}
+
+func convertType(m *models.Metric, mtype string) {
+	if m.Type == mtype {
+		return
+	}
+
+	switch mtype {
+	case models.CounterType:
+		m.Delta = int64(m.Value)
+		m.Value = 0
+	case models.GaugeType:
+		m.Value = float64(m.Delta)
+		m.Delta = 0
+	}
+
+	m.Type = mtype
+}
@@ -0,0 +1,153 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+)
+
+func TestNewRelabeler(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []config.RelabelRule
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "no rules", wantNil: true},
+		{name: "valid rules", rules: []config.RelabelRule{{Action: config.RelabelKeep, Regex: "Alloc|Sys"}}},
+		{name: "bad regex", rules: []config.RelabelRule{{Action: config.RelabelDrop, Regex: "("}}, wantErr: true},
+		{name: "unknown action", rules: []config.RelabelRule{{Action: "replace"}}, wantErr: true},
+		{name: "empty replacement", rules: []config.RelabelRule{{Action: config.RelabelRename, Regex: "Heap(.*)"}}, wantErr: true},
+		{name: "empty prefix", rules: []config.RelabelRule{{Action: config.RelabelPrefix}}, wantErr: true},
+		{name: "unknown type", rules: []config.RelabelRule{{Action: config.RelabelType, Type: "histogram"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewRelabeler(tt.rules, false, nil)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantNil, r == nil)
+		})
+	}
+}
+
+func TestRelabeler_Apply(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    []config.RelabelRule
+		metric   models.Metric
+		wantKeep bool
+		want     models.Metric
+	}{
+		{
+			name:     "keep matching",
+			rules:    []config.RelabelRule{{Action: config.RelabelKeep, Regex: "Heap.*"}},
+			metric:   models.NewGauge("HeapAlloc", 1),
+			wantKeep: true,
+			want:     models.NewGauge("HeapAlloc", 1),
+		},
+		{
+			name:   "keep drops not matching, regex is anchored",
+			rules:  []config.RelabelRule{{Action: config.RelabelKeep, Regex: "Heap"}},
+			metric: models.NewGauge("HeapAlloc", 1),
+		},
+		{
+			name:   "drop matching",
+			rules:  []config.RelabelRule{{Action: config.RelabelDrop, Regex: "Sys|Frees"}},
+			metric: models.NewGauge("Frees", 1),
+		},
+		{
+			name:     "rename with capture groups",
+			rules:    []config.RelabelRule{{Action: config.RelabelRename, Regex: "Heap(.*)", Replacement: "heap_${1}_bytes"}},
+			metric:   models.NewGauge("HeapAlloc", 1),
+			wantKeep: true,
+			want:     models.NewGauge("heap_Alloc_bytes", 1),
+		},
+		{
+			name:     "rename to empty name keeps original",
+			rules:    []config.RelabelRule{{Action: config.RelabelRename, Regex: "Heap(.*)", Replacement: "$2"}},
+			metric:   models.NewGauge("HeapAlloc", 1),
+			wantKeep: true,
+			want:     models.NewGauge("HeapAlloc", 1),
+		},
+		{
+			name:     "prefix all",
+			rules:    []config.RelabelRule{{Action: config.RelabelPrefix, Prefix: "host1_"}},
+			metric:   models.NewCounter("PollCount", 1),
+			wantKeep: true,
+			want:     models.NewCounter("host1_PollCount", 1),
+		},
+		{
+			name:     "gauge to counter",
+			rules:    []config.RelabelRule{{Action: config.RelabelType, Regex: "NumGC", Type: models.CounterType}},
+			metric:   models.NewGauge("NumGC", 42),
+			wantKeep: true,
+			want:     models.NewCounter("NumGC", 42),
+		},
+		{
+			name:     "counter to gauge",
+			rules:    []config.RelabelRule{{Action: config.RelabelType, Type: models.GaugeType}},
+			metric:   models.NewCounter("PollCount", 5),
+			wantKeep: true,
+			want:     models.NewGauge("PollCount", 5),
+		},
+		{
+			name: "rules are applied in order",
+			rules: []config.RelabelRule{
+				{Action: config.RelabelRename, Regex: "RandomValue", Replacement: "random"},
+				{Action: config.RelabelDrop, Regex: "RandomValue"},
+				{Action: config.RelabelPrefix, Regex: "random", Prefix: "custom_"},
+			},
+			metric:   models.NewGauge("RandomValue", 0.5),
+			wantKeep: true,
+			want:     models.NewGauge("custom_random", 0.5),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewRelabeler(tt.rules, false, nil)
+			require.NoError(t, err)
+
+			m := tt.metric
+			keep := r.Apply(context.Background(), &m)
+			assert.Equal(t, tt.wantKeep, keep)
+			if keep {
+				assert.Equal(t, tt.want, m)
+			}
+		})
+	}
+}
+
+func TestRelabeler_Apply_dryRun(t *testing.T) {
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+	require.NoError(t, err)
+
+	r, err := NewRelabeler([]config.RelabelRule{
+		{Action: config.RelabelPrefix, Prefix: "host1_"},
+		{Action: config.RelabelDrop, Regex: "host1_.*"},
+	}, true, lg)
+	require.NoError(t, err)
+
+	m := models.NewGauge("Alloc", 1)
+	assert.True(t, r.Apply(context.Background(), &m))
+	assert.Equal(t, models.NewGauge("Alloc", 1), m)
+}
+
+func TestRelabeler_Apply_nil(t *testing.T) {
+	var r *Relabeler
+
+	m := models.NewGauge("Alloc", 1)
+	assert.True(t, r.Apply(context.Background(), &m))
+	assert.Equal(t, models.NewGauge("Alloc", 1), m)
+}
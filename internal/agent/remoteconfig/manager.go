@@ -0,0 +1,158 @@
+package remoteconfig
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"go.uber.org/zap"
+)
+
+// ErrNoProfile is returned by the fetcher when no profile is assigned to the agent
+var ErrNoProfile = errors.New("remoteconfig: no profile assigned to the agent")
+
+// Profile is the config profile assigned to the agent on the server
+type Profile struct {
+	Name    string
+	Version int64
+	Config  json.RawMessage
+}
+
+// Request identifies the agent and reports the config it is running
+type Request struct {
+	AgentID        string
+	Host           string
+	RunningVersion int64  // 0 when the agent runs the local config
+	ApplyError     string // why the last fetched profile was rejected
+}
+
+// Fetcher fetches the profile of the agent from the server
+type Fetcher interface {
+	FetchAgentConfig(ctx context.Context, req Request) (Profile, error)
+}
+
+// Applier validates and applies the config to the running agent
+type Applier interface {
+	ApplyConfig(cfg config.Config) error
+}
+
+// Manager periodically fetches the profile and applies it on top of the local config.
+// The running config is kept while the server is unreachable, the rejected profile is not applied again
+// until its version changes.
+type Manager struct {
+	lg      *logging.ZapLogger
+	fetcher Fetcher
+	applier Applier
+	local   config.Config
+	host    string
+
+	mu            sync.Mutex
+	running       int64
+	failedVersion int64
+	applyErr      string
+}
+
+// NewManager creates manager of the agent running the local config
+func NewManager(lg *logging.ZapLogger, local config.Config, host string, fetcher Fetcher, applier Applier) *Manager {
+	return &Manager{
+		lg:      lg,
+		fetcher: fetcher,
+		applier: applier,
+		local:   local,
+		host:    host,
+	}
+}
+
+// Start syncs the config every interval until ctx is done
+func (m *Manager) Start(ctx context.Context, interval time.Duration) {
+	ctx = m.lg.WithContextFields(ctx, zap.String("actor", "remote_config"))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Sync(ctx); err != nil {
+			m.lg.WarnCtx(ctx, "remote config sync failed", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunningVersion returns version of the applied profile, 0 when the agent runs the local config
+func (m *Manager) RunningVersion() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.running
+}
+
+// Sync fetches the profile and applies it when its version differs from the running one
+func (m *Manager) Sync(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	profile, err := m.fetcher.FetchAgentConfig(ctx, Request{
+		AgentID:        m.local.AgentID,
+		Host:           m.host,
+		RunningVersion: m.running,
+		ApplyError:     m.applyErr,
+	})
+
+	if errors.Is(err, ErrNoProfile) {
+		return m.revert(ctx)
+	}
+
+	if err != nil {
+		return fmt.Errorf("internal/agent/remoteconfig: fetch profile error %w", err)
+	}
+
+	if profile.Version == m.running || profile.Version == m.failedVersion {
+		return nil
+	}
+
+	if err := m.apply(profile); err != nil {
+		m.failedVersion, m.applyErr = profile.Version, err.Error()
+		return fmt.Errorf("internal/agent/remoteconfig: apply profile %s version %d error %w", profile.Name, profile.Version, err)
+	}
+
+	m.running, m.failedVersion, m.applyErr = profile.Version, 0, ""
+	m.lg.InfoCtx(ctx, "remote config applied", zap.String("profile", profile.Name), zap.Int64("version", profile.Version))
+
+	return nil
+}
+
+func (m *Manager) apply(profile Profile) error {
+	settings, err := ParseSettings(profile.Config)
+	if err != nil {
+		return err
+	}
+
+	return m.applier.ApplyConfig(settings.Apply(m.local))
+}
+
+// revert returns the agent to the local config when the profile is unassigned
+func (m *Manager) revert(ctx context.Context) error {
+	m.failedVersion, m.applyErr = 0, ""
+	if m.running == 0 {
+		return nil
+	}
+
+	if err := m.applier.ApplyConfig(m.local); err != nil {
+		return fmt.Errorf("internal/agent/remoteconfig: revert to local config error %w", err)
+	}
+
+	m.running = 0
+	m.lg.InfoCtx(ctx, "profile unassigned, local config restored")
+
+	return nil
+}
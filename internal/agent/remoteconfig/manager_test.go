@@ -0,0 +1,188 @@
+package remoteconfig
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+)
+
+type fetcherFunc func(ctx context.Context, req Request) (Profile, error)
+
+func (f fetcherFunc) FetchAgentConfig(ctx context.Context, req Request) (Profile, error) {
+	return f(ctx, req)
+}
+
+type applierFunc func(cfg config.Config) error
+
+func (f applierFunc) ApplyConfig(cfg config.Config) error {
+	return f(cfg)
+}
+
+func TestManager_Sync(t *testing.T) {
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: 0})
+	require.NoError(t, err)
+
+	local := config.Config{AgentID: "db-1", PollInterval: 2 * time.Second, ReportInterval: 10 * time.Second}
+
+	type fetched struct {
+		profile Profile
+		err     error
+	}
+	steps := []struct {
+		name        string
+		fetched     fetched
+		applyErr    error
+		wantErr     bool
+		wantRequest Request
+		wantApplied *config.Config
+		wantRunning int64
+	}{
+		{
+			name:        "when no profile is assigned local config is kept",
+			fetched:     fetched{err: ErrNoProfile},
+			wantRequest: Request{AgentID: "db-1", Host: "host-1"},
+		},
+		{
+			name:        "when profile is assigned it is applied over the local config",
+			fetched:     fetched{profile: Profile{Name: "db", Version: 3, Config: json.RawMessage(`{"poll_interval":5,"batch_report":true}`)}},
+			wantRequest: Request{AgentID: "db-1", Host: "host-1"},
+			wantApplied: &config.Config{AgentID: "db-1", PollInterval: 5 * time.Second, ReportInterval: 10 * time.Second, BatchReport: true},
+			wantRunning: 3,
+		},
+		{
+			name:        "when version is not changed profile is not applied again",
+			fetched:     fetched{profile: Profile{Name: "db", Version: 3, Config: json.RawMessage(`{"poll_interval":5,"batch_report":true}`)}},
+			wantRequest: Request{AgentID: "db-1", Host: "host-1", RunningVersion: 3},
+			wantRunning: 3,
+		},
+		{
+			name:        "when server is unreachable running config is kept",
+			fetched:     fetched{err: errors.New("connection refused")},
+			wantErr:     true,
+			wantRequest: Request{AgentID: "db-1", Host: "host-1", RunningVersion: 3},
+			wantRunning: 3,
+		},
+		{
+			name:        "when profile has unknown settings it is rejected",
+			fetched:     fetched{profile: Profile{Name: "db", Version: 4, Config: json.RawMessage(`{"pol_interval":5}`)}},
+			wantErr:     true,
+			wantRequest: Request{AgentID: "db-1", Host: "host-1", RunningVersion: 3},
+			wantRunning: 3,
+		},
+		{
+			name:        "when rejected version is fetched again the error is reported",
+			fetched:     fetched{profile: Profile{Name: "db", Version: 4, Config: json.RawMessage(`{"pol_interval":5}`)}},
+			wantRequest: Request{AgentID: "db-1", Host: "host-1", RunningVersion: 3, ApplyError: "internal/agent/remoteconfig: decode settings error json: unknown field \"pol_interval\""},
+			wantRunning: 3,
+		},
+		{
+			name:        "when agent rejects the config it is not applied",
+			fetched:     fetched{profile: Profile{Name: "db", Version: 5, Config: json.RawMessage(`{"poll_interval":0}`)}},
+			applyErr:    errors.New("invalid poll interval"),
+			wantErr:     true,
+			wantRequest: Request{AgentID: "db-1", Host: "host-1", RunningVersion: 3, ApplyError: "internal/agent/remoteconfig: decode settings error json: unknown field \"pol_interval\""},
+			wantApplied: &config.Config{AgentID: "db-1", ReportInterval: 10 * time.Second},
+			wantRunning: 3,
+		},
+		{
+			name:        "when profile is unassigned local config is restored",
+			fetched:     fetched{err: ErrNoProfile},
+			wantRequest: Request{AgentID: "db-1", Host: "host-1", RunningVersion: 3, ApplyError: "invalid poll interval"},
+			wantApplied: &local,
+		},
+	}
+
+	var (
+		step    int
+		request Request
+		applied *config.Config
+	)
+
+	fetcher := fetcherFunc(func(_ context.Context, req Request) (Profile, error) {
+		request = req
+		return steps[step].fetched.profile, steps[step].fetched.err
+	})
+	applier := applierFunc(func(cfg config.Config) error {
+		applied = &cfg
+		return steps[step].applyErr
+	})
+
+	m := NewManager(lg, local, "host-1", fetcher, applier)
+
+	for i, tt := range steps {
+		step, applied = i, nil
+
+		t.Run(tt.name, func(t *testing.T) {
+			err := m.Sync(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wantRequest, request)
+			assert.Equal(t, tt.wantApplied, applied)
+			assert.Equal(t, tt.wantRunning, m.RunningVersion())
+		})
+	}
+}
+
+func TestSettings_Apply(t *testing.T) {
+	local := config.Config{
+		PollInterval:       2 * time.Second,
+		ReportInterval:     10 * time.Second,
+		DisabledCollectors: []string{"cpu"},
+		Relabel:            []config.RelabelRule{{Action: config.RelabelDrop, Regex: "Heap.*"}},
+	}
+
+	tests := []struct {
+		name    string
+		config  string
+		want    config.Config
+		wantErr bool
+	}{
+		{
+			name:   "when settings are empty",
+			config: `{}`,
+			want:   local,
+		},
+		{
+			name:   "when settings override the local config",
+			config: `{"report_interval":30,"collector_timeout":1,"collector_error_budget":5,"disabled_collectors":[],"relabel":[{"action":"prefix","prefix":"db_"}],"relabel_dry_run":true}`,
+			want: config.Config{
+				PollInterval:         2 * time.Second,
+				ReportInterval:       30 * time.Second,
+				CollectorTimeout:     time.Second,
+				CollectorErrorBudget: 5,
+				DisabledCollectors:   []string{},
+				Relabel:              []config.RelabelRule{{Action: config.RelabelPrefix, Prefix: "db_"}},
+				RelabelDryRun:        true,
+			},
+		},
+		{
+			name:    "when settings are not supported",
+			config:  `{"server_url":"localhost:8080"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseSettings([]byte(tt.config))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, s.Apply(local))
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// Package remoteconfig keeps the agent config in sync with the profile assigned to the agent on the server.
+// Profile settings override the local config, the agent returns to the local config when the profile is removed.
+package remoteconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/config"
+)
+
+// Settings are the parts of the agent config which can be changed by the profile without restart,
+// nil fields keep the local value. Intervals are in seconds like in the config file.
+type Settings struct {
+	PollInterval         *int64                `json:"poll_interval,omitempty"`
+	ReportInterval       *int64                `json:"report_interval,omitempty"`
+	BatchReport          *bool                 `json:"batch_report,omitempty"`
+	CollectorTimeout     *int64                `json:"collector_timeout,omitempty"`
+	CollectorErrorBudget *int                  `json:"collector_error_budget,omitempty"`
+	DisabledCollectors   *[]string             `json:"disabled_collectors,omitempty"`
+	Relabel              *[]config.RelabelRule `json:"relabel,omitempty"`
+	RelabelDryRun        *bool                 `json:"relabel_dry_run,omitempty"`
+}
+
+// ParseSettings decodes the profile config, unknown settings are rejected so typos are not ignored silently
+func ParseSettings(b []byte) (Settings, error) {
+	var s Settings
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&s); err != nil {
+		return Settings{}, fmt.Errorf("internal/agent/remoteconfig: decode settings error %w", err)
+	}
+
+	return s, nil
+}
+
+// Apply returns the local config overridden by the settings
+func (s Settings) Apply(local config.Config) config.Config {
+	cfg := local
+
+	if s.PollInterval != nil {
+		cfg.PollInterval = time.Duration(*s.PollInterval) * time.Second
+	}
+
+	if s.ReportInterval != nil {
+		cfg.ReportInterval = time.Duration(*s.ReportInterval) * time.Second
+	}
+
+	if s.BatchReport != nil {
+		cfg.BatchReport = *s.BatchReport
+	}
+
+	if s.CollectorTimeout != nil {
+		cfg.CollectorTimeout = time.Duration(*s.CollectorTimeout) * time.Second
+	}
+
+	if s.CollectorErrorBudget != nil {
+		cfg.CollectorErrorBudget = *s.CollectorErrorBudget
+	}
+
+	if s.DisabledCollectors != nil {
+		cfg.DisabledCollectors = *s.DisabledCollectors
+	}
+
+	if s.Relabel != nil {
+		cfg.Relabel = *s.Relabel
+	}
+
+	if s.RelabelDryRun != nil {
+		cfg.RelabelDryRun = *s.RelabelDryRun
+	}
+
+	return cfg
+}
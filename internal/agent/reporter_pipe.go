@@ -2,18 +2,24 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	uuid "github.com/satori/go.uuid"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/idempotency"
 	"go.uber.org/zap"
-	"golang.org/x/sync/errgroup"
 )
 
 // runReporterPipe executes the complete reporting pipeline:
-// 1. Loads metrics from storage
-// 2. Sends metrics to the server
+// 1. Resends unacknowledged counters of the previous reports with their idempotency keys, every pending report
+// is sent unsplit while it fits the limits, since the limits it was split with might be changed by the capabilities
+// negotiation. The report exceeding the current limits is split into chunks with keys derived from its key.
+// 2. Takes snapshot of the collected metrics, poller continues with a buffer holding only the last gauges
+// 3. Sends metrics to the server, counters of the failed requests are kept until the server acknowledges them
+// Runs are serialized, so pending reports are never sent twice at once.
 func (a *Agent) runReporterPipe(ctx context.Context) {
 	a.reporterPipeLock.Lock()
 	defer a.reporterPipeLock.Unlock()
@@ -21,148 +27,119 @@ func (a *Agent) runReporterPipe(ctx context.Context) {
 	operationID := uuid.NewV4()
 	ctx = a.lg.WithContextFields(ctx, zap.String("operation_id", operationID.String()))
 
-	g, ctx := errgroup.WithContext(ctx)
+	var errs []error
+	for _, p := range a.pending.Take() {
+		metrics := make([]*models.Metric, 0, len(p.metrics))
+		for _, m := range p.metrics {
+			metrics = append(metrics, a.repository.New(m))
+		}
 
-	a.report(ctx, g, a.loadMetrics(g))
+		if err := a.report(WithoutSplit(ctx), p.key, metrics); err != nil {
+			a.lg.ErrorCtx(ctx, "resend pending report failed", zap.String("idempotency_key", p.key), zap.Error(err))
+			errs = append(errs, err)
+		}
+	}
 
-	if err := g.Wait(); err != nil {
+	if err := a.report(ctx, operationID.String(), a.repository.Snapshot()); err != nil {
 		a.lg.ErrorCtx(ctx, "report failed", zap.Error(err))
+		errs = append(errs, err)
 	}
+	now, err := time.Now(), errors.Join(errs...)
+	a.status.reported(now, err)
+	a.notifyReported(ctx, now, err)
 
-	for _, f := range a.resetMetrics {
-		if err := f(ctx, a); err != nil {
-			a.lg.ErrorCtx(ctx, "reset metric failed", zap.Error(err))
-		}
+	if n := a.pending.Len(); n > 0 {
+		a.lg.InfoCtx(ctx, "reports are waiting for acknowledgement", zap.Int("pending", n))
 	}
 
 	a.lg.InfoCtx(ctx, "finished")
 }
 
-// loadMetrics loads metrics from various sources in parallel using errgroup
-func (a *Agent) loadMetrics(g *errgroup.Group) chan *models.Metric {
-	metrics := make(chan *models.Metric)
-
-	wg := sync.WaitGroup{}
-
-	wg.Add(1)
-	g.Go(func() error {
-		defer wg.Done()
-
-		for _, m := range a.runtimeMetrics {
-			if err := a.load(m, metrics); err != nil {
-				return err
-			}
-		}
+// report sends metrics to the server one by one or in batches with the idempotency key.
+// Counters of the requests which were not acknowledged are put to the pending reports.
+// Metrics are released after the report.
+func (a *Agent) report(ctx context.Context, key string, metrics []*models.Metric) error {
+	defer a.repository.Release(metrics...)
 
+	if len(metrics) == 0 {
 		return nil
-	})
+	}
 
-	wg.Add(1)
-	g.Go(func() error {
-		defer wg.Done()
+	a.mu.RLock()
+	batchReport := a.batchReport
+	a.mu.RUnlock()
 
-		for _, m := range a.customMetrics {
-			if err := a.load(m, metrics); err != nil {
-				return err
-			}
-		}
+	if !batchReport {
+		return a.reportOneByOne(ctx, key, metrics)
+	}
 
+	err := a.reporter.UpdateMetrics(idempotency.WithKey(ctx, key), metrics)
+	a.status.reportProgressed(time.Now())
+	if err == nil {
 		return nil
-	})
+	}
 
-	wg.Add(1)
-	g.Go(func() error {
-		defer wg.Done()
+	var chErr *ChunksError
+	if !errors.As(err, &chErr) {
+		a.pending.Add(key, a.read(metrics))
+		return fmt.Errorf("reporter_pipe: update batch metrics failed error %w", err)
+	}
 
-		for _, m := range a.virtualMemoryMetrics {
-			if err := a.load(m, metrics); err != nil {
-				return err
-			}
-		}
+	a.lg.ErrorCtx(
+		ctx,
+		"batch partially delivered",
+		zap.Int("failed_chunks", len(chErr.Failed)),
+		zap.Int("chunks", chErr.Total),
+		zap.Int("failed_metrics", len(chErr.FailedMetrics())),
+	)
 
-		return nil
-	})
+	for _, f := range chErr.Failed {
+		a.pending.Add(f.Key, a.read(f.Metrics))
+	}
 
-	wg.Add(1)
-	g.Go(func() error {
-		defer wg.Done()
+	return fmt.Errorf("reporter_pipe: update batch metrics failed error %w", err)
+}
 
-		for _, m := range a.cpuMetrics {
-			if err := a.load(m, metrics); err != nil {
-				return err
-			}
-		}
+// reportOneByOne sends every metric in a separate request, each request has its own idempotency key derived
+// with idempotency.MetricKey
+func (a *Agent) reportOneByOne(ctx context.Context, key string, metrics []*models.Metric) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
 
-		return nil
-	})
+	for _, m := range metrics {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
 
-	go func() {
-		wg.Wait()
-		close(metrics)
-	}()
+			metric := *m
+			metricKey := idempotency.MetricKey(key, metric.Name, len(metrics))
 
-	return metrics
-}
+			err := a.reporter.UpdateMetric(idempotency.WithKey(ctx, metricKey), metric)
+			a.status.reportProgressed(time.Now())
+			if err != nil {
+				a.pending.Add(metricKey, []models.Metric{metric})
 
-// load loads a single metric from storage
-func (a *Agent) load(
-	r Reportable,
-	b chan *models.Metric,
-) error {
-	m, err := r.Load(a.repository)
-	if err != nil {
-		return fmt.Errorf("reporter_pipe: load metric %+v error %w", m, err)
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("report_pipe: upload metric err %w", err))
+				mu.Unlock()
+			}
+		}()
 	}
 
-	b <- m
-	return nil
-}
+	wg.Wait()
 
-// report sends metrics to the server in batches
-func (a *Agent) report(
-	ctx context.Context,
-	g *errgroup.Group,
-	metrics chan *models.Metric,
-) {
-	batch := make([]*models.Metric, 0)
-	batchLock := &sync.Mutex{}
-
-	for m := range metrics {
-		if !a.batchReport {
-			g.Go(
-				func() error {
-					name, mtype, value := a.repository.SafeRead(m)
-					if err := a.reporter.UpdateMetric(ctx, mtype, name, value); err != nil {
-						a.repository.Release(m)
-						return fmt.Errorf("report_pipe: upload metric err %w", err)
-					}
-
-					return nil
-				},
-			)
-		} else {
-			batchLock.Lock()
-			batch = append(batch, m)
-			batchLock.Unlock()
-		}
-	}
-
-	if len(batch) == 0 {
-		return
-	}
+	return errors.Join(errs...)
+}
 
-	if !a.batchReport {
-		return
+// read returns copies of the metrics
+func (a *Agent) read(metrics []*models.Metric) []models.Metric {
+	res := make([]models.Metric, 0, len(metrics))
+	for _, m := range metrics {
+		res = append(res, *m)
 	}
 
-	g.Go(
-		func() error {
-			defer a.repository.Release(batch...)
-			if err := a.reporter.UpdateMetrics(ctx, batch); err != nil {
-				return fmt.Errorf("reporter_pipe: update batch metrics failed error %w", err)
-			}
-
-			return nil
-		},
-	)
+	return res
 }
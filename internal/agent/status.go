@@ -0,0 +1,144 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Status is the state of the running agent served by the control endpoint
+type Status struct {
+	StartedAt      time.Time `json:"started_at"`
+	LastPollAt     time.Time `json:"last_poll_at,omitzero"`
+	LastReportAt   time.Time `json:"last_report_at,omitzero"` // the last report delivered without errors
+	LastError      string    `json:"last_error,omitempty"`
+	LastErrorAt    time.Time `json:"last_error_at,omitzero"`
+	Errors         int64     `json:"errors"`          // poll and report errors since start
+	PendingReports int       `json:"pending_reports"` // unacknowledged reports waiting for resend
+	PendingMetrics int       `json:"pending_metrics"` // counters of the pending reports
+
+	Collectors []CollectorHealth `json:"collectors,omitempty"`
+}
+
+// statusTracker records results of the poller and reporter pipes
+type statusTracker struct {
+	mu               sync.Mutex
+	status           Status
+	reportProgressAt time.Time // the last report request finished with or without errors
+}
+
+func (s *statusTracker) started(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status.StartedAt = now
+}
+
+func (s *statusTracker) polled(now time.Time, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status.LastPollAt = now
+	s.failed(now, err)
+}
+
+func (s *statusTracker) reported(now time.Time, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err == nil {
+		s.status.LastReportAt = now
+	}
+	s.reportProgressAt = now
+	s.failed(now, err)
+}
+
+// reportProgressed records a finished report request, so the long report run of many requests is not considered hung
+func (s *statusTracker) reportProgressed(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reportProgressAt = now
+}
+
+func (s *statusTracker) failed(now time.Time, err error) {
+	if err == nil {
+		return
+	}
+
+	s.status.Errors++
+	s.status.LastError = err.Error()
+	s.status.LastErrorAt = now
+}
+
+// lastActivity returns when the poll loop last finished an iteration and the report loop last finished a request,
+// start time until then
+func (s *statusTracker) lastActivity() (poll, report time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	poll, report = s.status.StartedAt, s.status.StartedAt
+	if s.status.LastPollAt.After(poll) {
+		poll = s.status.LastPollAt
+	}
+	if s.reportProgressAt.After(report) {
+		report = s.reportProgressAt
+	}
+
+	return poll, report
+}
+
+func (s *statusTracker) get() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.status
+}
+
+// Status returns the current state of the agent
+func (a *Agent) Status() Status {
+	status := a.status.get()
+	status.PendingReports, status.PendingMetrics = a.pending.Depth()
+	status.Collectors = a.health.list()
+
+	return status
+}
+
+// startControl serves the local control endpoint until ctx is done
+func (a *Agent) startControl(ctx context.Context) {
+	if a.cfg.ControlAddress == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(a.Status()); err != nil {
+			a.lg.ErrorCtx(r.Context(), "failed to write status", zap.Error(err))
+		}
+	})
+
+	srv := &http.Server{Addr: a.cfg.ControlAddress, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			a.lg.ErrorCtx(ctx, "control endpoint shutdown failed", zap.Error(err))
+		}
+	}()
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			a.lg.ErrorCtx(ctx, "control endpoint failed", zap.String("address", a.cfg.ControlAddress), zap.Error(err))
+		}
+	}()
+}
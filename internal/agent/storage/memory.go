@@ -18,13 +18,13 @@ type Storage interface {
 }
 
 type Memory struct {
-	storage map[string]map[string]string
+	storage map[string]map[string]models.Metric
 	mutex   sync.RWMutex
 	lg      *logging.ZapLogger
 }
 
 func NewMemoryStorage(lg *logging.ZapLogger) *Memory {
-	storage := make(map[string]map[string]string)
+	storage := make(map[string]map[string]models.Metric)
 
 	return &Memory{
 		lg:      lg,
@@ -39,14 +39,72 @@ func (s *Memory) Set(ctx context.Context, m *models.Metric) error {
 
 	mTypeStorage, ok := s.storage[m.Type]
 	if !ok {
-		mTypeStorage = make(map[string]string)
+		mTypeStorage = make(map[string]models.Metric)
 		s.storage[m.Type] = mTypeStorage
 	}
 
-	mTypeStorage[m.Name] = m.Value
+	mTypeStorage[m.Name] = *m
 	return nil
 }
 
+// SetIfAbsent stores the metric unless the metric with the same name and type is stored already
+func (s *Memory) SetIfAbsent(m *models.Metric) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	mTypeStorage, ok := s.storage[m.Type]
+	if !ok {
+		mTypeStorage = make(map[string]models.Metric)
+		s.storage[m.Type] = mTypeStorage
+	}
+
+	if _, ok := mTypeStorage[m.Name]; !ok {
+		mTypeStorage[m.Name] = *m
+	}
+}
+
+// Add accumulates counter delta with the stored one, other metric types are replaced like with Set
+func (s *Memory) Add(ctx context.Context, m *models.Metric) error {
+	if m.Type != models.CounterType {
+		return s.Set(ctx, m)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	mTypeStorage, ok := s.storage[m.Type]
+	if !ok {
+		mTypeStorage = make(map[string]models.Metric)
+		s.storage[m.Type] = mTypeStorage
+	}
+
+	stored := mTypeStorage[m.Name]
+	stored.Name, stored.Type = m.Name, m.Type
+	stored.Delta += m.Delta
+	mTypeStorage[m.Name] = stored
+	return nil
+}
+
+// All returns copies of all stored metrics
+func (s *Memory) All() []models.Metric {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	res := make([]models.Metric, 0)
+	for _, mTypeStorage := range s.storage {
+		for _, m := range mTypeStorage {
+			res = append(res, m)
+		}
+	}
+
+	return res
+}
+
+// Empty creates new empty storage with the same settings
+func (s *Memory) Empty() *Memory {
+	return NewMemoryStorage(s.lg)
+}
+
 func (s *Memory) Get(to *models.Metric) error {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
@@ -64,6 +122,7 @@ func (s *Memory) Get(to *models.Metric) error {
 		return fmt.Errorf("storage/memory: Got type: %v, name: %v - value %w", mType, mName, ErrNoRecords)
 	}
 
-	to.Value = val
+	to.Delta = val.Delta
+	to.Value = val.Value
 	return nil
 }
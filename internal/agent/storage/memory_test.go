@@ -11,49 +11,49 @@ import (
 	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
 )
 
-func NewMemoryStorageWithData(storage map[string]map[string]string, lg *logging.ZapLogger) *Memory {
+func NewMemoryStorageWithData(storage map[string]map[string]models.Metric, lg *logging.ZapLogger) *Memory {
 	return &Memory{storage: storage, lg: lg}
 }
 
 func TestGet(t *testing.T) {
 	tasks := []struct {
 		name      string
-		data      map[string]map[string]string
+		data      map[string]map[string]models.Metric
 		mName     string
 		mType     string
-		want      string
+		want      int64
 		wantError error
 	}{
 		{
 			name:      "when record found",
-			data:      map[string]map[string]string{"counter": {"test": "1"}},
+			data:      map[string]map[string]models.Metric{"counter": {"test": models.NewCounter("test", 1)}},
 			mName:     "test",
 			mType:     "counter",
-			want:      "1",
+			want:      1,
 			wantError: nil,
 		},
 		{
 			name:      "when type not found",
-			data:      map[string]map[string]string{"counter": {"test": "1"}},
+			data:      map[string]map[string]models.Metric{"counter": {"test": models.NewCounter("test", 1)}},
 			mName:     "test",
 			mType:     "hist",
-			want:      "",
+			want:      0,
 			wantError: ErrNoRecords,
 		},
 		{
 			name:      "when name not found",
-			data:      map[string]map[string]string{"counter": {"test": "1"}},
+			data:      map[string]map[string]models.Metric{"counter": {"test": models.NewCounter("test", 1)}},
 			mName:     "supertest",
 			mType:     "counter",
-			want:      "",
+			want:      0,
 			wantError: ErrNoRecords,
 		},
 		{
 			name:      "when slice empty",
-			data:      map[string]map[string]string{"counter": {"test": "1"}},
+			data:      map[string]map[string]models.Metric{"counter": {"test": models.NewCounter("test", 1)}},
 			mName:     "supertest",
 			mType:     "counter",
-			want:      "",
+			want:      0,
 			wantError: ErrNoRecords,
 		},
 	}
@@ -68,7 +68,7 @@ func TestGet(t *testing.T) {
 			}
 			err := storage.Get(m)
 			assert.ErrorIs(t, err, tt.wantError)
-			assert.Equal(t, tt.want, m.Value)
+			assert.Equal(t, tt.want, m.Delta)
 		})
 	}
 }
@@ -77,17 +77,17 @@ func TestSet(t *testing.T) {
 	tasks := []struct {
 		name string
 		val  models.Metric
-		data map[string]map[string]string
+		data map[string]map[string]models.Metric
 	}{
 		{
 			name: "push value to slice",
-			val:  models.Metric{Name: "value", Value: "2", Type: "counter"},
-			data: map[string]map[string]string{"counter": {"test": "2"}},
+			val:  models.NewCounter("value", 2),
+			data: map[string]map[string]models.Metric{"counter": {"test": models.NewCounter("test", 2)}},
 		},
 		{
 			name: "create new record",
-			val:  models.Metric{Name: "test", Value: "1", Type: "counter"},
-			data: make(map[string]map[string]string),
+			val:  models.NewCounter("test", 1),
+			data: make(map[string]map[string]models.Metric),
 		},
 	}
 
@@ -98,11 +98,89 @@ func TestSet(t *testing.T) {
 			assert.NoError(t, storage.Set(context.Background(), &tt.val))
 
 			actualValue := tt.data[tt.val.Type][tt.val.Name]
-			assert.Equal(t, tt.val.Value, actualValue)
+			assert.Equal(t, tt.val, actualValue)
 		})
 	}
 }
 
+func TestAdd(t *testing.T) {
+	tasks := []struct {
+		name string
+		val  models.Metric
+		data map[string]map[string]models.Metric
+		want models.Metric
+	}{
+		{
+			name: "accumulate counter",
+			val:  models.NewCounter("test", 2),
+			data: map[string]map[string]models.Metric{"counter": {"test": models.NewCounter("test", 3)}},
+			want: models.NewCounter("test", 5),
+		},
+		{
+			name: "create new counter",
+			val:  models.NewCounter("test", 1),
+			data: make(map[string]map[string]models.Metric),
+			want: models.NewCounter("test", 1),
+		},
+		{
+			name: "replace gauge",
+			val:  models.NewGauge("test", 1.5),
+			data: map[string]map[string]models.Metric{"gauge": {"test": models.NewGauge("test", 3)}},
+			want: models.NewGauge("test", 1.5),
+		},
+	}
+
+	for _, tt := range tasks {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := NewMemoryStorageWithData(tt.data, nil)
+			assert.NoError(t, storage.Add(context.Background(), &tt.val))
+			assert.Equal(t, tt.want, tt.data[tt.val.Type][tt.val.Name])
+		})
+	}
+}
+
+func TestSetIfAbsent(t *testing.T) {
+	tasks := []struct {
+		name string
+		val  models.Metric
+		data map[string]map[string]models.Metric
+		want models.Metric
+	}{
+		{
+			name: "keep stored gauge",
+			val:  models.NewGauge("test", 1.5),
+			data: map[string]map[string]models.Metric{"gauge": {"test": models.NewGauge("test", 3)}},
+			want: models.NewGauge("test", 3),
+		},
+		{
+			name: "create new gauge",
+			val:  models.NewGauge("test", 1.5),
+			data: make(map[string]map[string]models.Metric),
+			want: models.NewGauge("test", 1.5),
+		},
+	}
+
+	for _, tt := range tasks {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := NewMemoryStorageWithData(tt.data, nil)
+			storage.SetIfAbsent(&tt.val)
+			assert.Equal(t, tt.want, tt.data[tt.val.Type][tt.val.Name])
+		})
+	}
+}
+
+func TestAll(t *testing.T) {
+	storage := NewMemoryStorageWithData(map[string]map[string]models.Metric{
+		"counter": {"PollCount": models.NewCounter("PollCount", 1)},
+		"gauge":   {"Alloc": models.NewGauge("Alloc", 1.5)},
+	}, nil)
+
+	assert.ElementsMatch(t, []models.Metric{
+		models.NewCounter("PollCount", 1),
+		models.NewGauge("Alloc", 1.5),
+	}, storage.All())
+}
+
 func TestNewMemoryStorage(t *testing.T) {
 	type args struct {
 		lg *logging.ZapLogger
@@ -115,7 +193,7 @@ func TestNewMemoryStorage(t *testing.T) {
 		{
 			name: "successful memory storage creation",
 			args: args{lg: nil},
-			want: &Memory{storage: make(map[string]map[string]string), lg: nil},
+			want: &Memory{storage: make(map[string]map[string]models.Metric), lg: nil},
 		},
 	}
 	for _, tt := range tests {
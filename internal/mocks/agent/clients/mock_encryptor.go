@@ -47,3 +47,18 @@ func (mr *MockEncryptorMockRecorder) Encrypt(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Encrypt", reflect.TypeOf((*MockEncryptor)(nil).Encrypt), arg0)
 }
+
+// MaxMessageSize mocks base method.
+func (m *MockEncryptor) MaxMessageSize() (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MaxMessageSize")
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MaxMessageSize indicates an expected call of MaxMessageSize.
+func (mr *MockEncryptorMockRecorder) MaxMessageSize() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxMessageSize", reflect.TypeOf((*MockEncryptor)(nil).MaxMessageSize))
+}
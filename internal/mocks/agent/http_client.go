@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/vysogota0399/mem_stats_monitoring/internal/agent (interfaces: HTTPClient)
+// Source: github.com/vysogota0399/mem_stats_monitoring/internal/agent (interfaces: Adapter)
 
 // Package mocks is a generated GoMock package.
 package mocks
@@ -12,7 +12,7 @@ import (
 	models "github.com/vysogota0399/mem_stats_monitoring/internal/agent/models"
 )
 
-// MockHttpClient is a mock of HTTPClient interface.
+// MockHttpClient is a mock of Adapter interface.
 type MockHttpClient struct {
 	ctrl     *gomock.Controller
 	recorder *MockHttpClientMockRecorder
@@ -36,17 +36,17 @@ func (m *MockHttpClient) EXPECT() *MockHttpClientMockRecorder {
 }
 
 // UpdateMetric mocks base method.
-func (m *MockHttpClient) UpdateMetric(arg0 context.Context, arg1, arg2, arg3 string) error {
+func (m *MockHttpClient) UpdateMetric(arg0 context.Context, arg1 models.Metric) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateMetric", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "UpdateMetric", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateMetric indicates an expected call of UpdateMetric.
-func (mr *MockHttpClientMockRecorder) UpdateMetric(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+func (mr *MockHttpClientMockRecorder) UpdateMetric(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMetric", reflect.TypeOf((*MockHttpClient)(nil).UpdateMetric), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMetric", reflect.TypeOf((*MockHttpClient)(nil).UpdateMetric), arg0, arg1)
 }
 
 // UpdateMetrics mocks base method.
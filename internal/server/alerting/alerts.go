@@ -0,0 +1,221 @@
+package alerting
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+)
+
+// State of the alert
+type State string
+
+const (
+	StatePending  State = "pending"  // the condition holds for less than the rule duration
+	StateFiring   State = "firing"   // the condition holds for the rule duration
+	StateResolved State = "resolved" // the condition of the firing alert stopped holding
+)
+
+// resolvedRetention is how long resolved alerts are listed
+const resolvedRetention = time.Hour
+
+// Alert of the rule for the series
+type Alert struct {
+	Rule        string            `json:"rule"`
+	MType       string            `json:"type"`
+	MName       string            `json:"name"`
+	Severity    string            `json:"severity"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	State       State             `json:"state"`
+	Value       float64           `json:"value"`
+	ActiveAt    time.Time         `json:"active_at"`
+	FiredAt     time.Time         `json:"fired_at,omitzero"`
+	ResolvedAt  time.Time         `json:"resolved_at,omitzero"`
+}
+
+// Key identifies the alert of the rule for the series
+func (a Alert) Key() string {
+	return a.Rule + "/" + a.MType + "/" + a.MName
+}
+
+// observation is the result of the rule evaluation for the series
+type observation struct {
+	rule   Rule
+	mName  string
+	active bool
+	value  float64
+}
+
+func (o observation) key() string {
+	return Alert{Rule: o.rule.Name, MType: o.rule.Metric.Type, MName: o.mName}.Key()
+}
+
+// alertsFile is the persisted state of the alerts
+type alertsFile struct {
+	Alerts []Alert `json:"alerts"`
+}
+
+// Alerts tracks the state of the alerts, the state is persisted to the file on every transition
+// so pending durations and firing alerts survive restarts. The state is kept in memory only
+// when the path is not configured.
+type Alerts struct {
+	mu     sync.RWMutex
+	path   string
+	alerts map[string]Alert
+}
+
+func NewAlerts(cfg *config.Config) (*Alerts, error) {
+	a := &Alerts{path: cfg.AlertStatePath, alerts: make(map[string]Alert)}
+
+	if err := a.load(); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// List returns pending, firing and recently resolved alerts ordered by the rule and the series
+func (a *Alerts) List() []Alert {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	res := slices.AppendSeq(make([]Alert, 0, len(a.alerts)), maps.Values(a.alerts))
+	slices.SortFunc(res, func(x, y Alert) int {
+		return strings.Compare(x.Key(), y.Key())
+	})
+
+	return res
+}
+
+// Active returns pending and firing alerts
+func (a *Alerts) Active() []Alert {
+	return slices.DeleteFunc(a.List(), func(alert Alert) bool {
+		return alert.State == StateResolved
+	})
+}
+
+// apply moves alerts through the states by the observations and returns alerts which became pending,
+// firing or resolved. Alerts without observations are treated as inactive unless their rule is skipped,
+// so alerts of the deleted rules and the vanished series are resolved.
+func (a *Alerts) apply(observations []observation, skipped map[string]struct{}, now time.Time) ([]Alert, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	next := make(map[string]Alert, len(a.alerts))
+	observed := make(map[string]struct{}, len(observations))
+	transitions := make([]Alert, 0)
+	dirty := false
+
+	for _, o := range observations {
+		observed[o.key()] = struct{}{}
+
+		prev, ok := a.alerts[o.key()]
+		alert, keep, transition := step(prev, ok, o, now)
+		if transition {
+			transitions = append(transitions, alert)
+		}
+
+		dirty = dirty || transition || ok && !keep
+		if keep {
+			next[o.key()] = alert
+		}
+	}
+
+	for key, prev := range a.alerts {
+		if _, ok := observed[key]; ok {
+			continue
+		}
+
+		if _, ok := skipped[prev.Rule]; ok {
+			next[key] = prev
+			continue
+		}
+
+		alert, keep, transition := step(prev, true, observation{rule: Rule{Name: prev.Rule}, value: prev.Value}, now)
+		if transition {
+			transitions = append(transitions, alert)
+		}
+
+		dirty = dirty || transition || !keep
+		if keep {
+			next[key] = alert
+		}
+	}
+
+	if dirty {
+		if err := a.save(next); err != nil {
+			return nil, err
+		}
+	}
+
+	a.alerts = next
+	return transitions, nil
+}
+
+// step moves the alert to the next state, it returns whether the alert is kept and whether its state changed
+func step(prev Alert, exists bool, o observation, now time.Time) (Alert, bool, bool) {
+	if !o.active {
+		switch {
+		case !exists:
+			return prev, false, false
+		case prev.State == StateFiring:
+			prev.State, prev.ResolvedAt, prev.Value = StateResolved, now, o.value
+			return prev, true, true
+		case prev.State == StateResolved:
+			return prev, now.Sub(prev.ResolvedAt) < resolvedRetention, false
+		default:
+			return prev, false, false
+		}
+	}
+
+	alert, transition := prev, false
+	if !exists || prev.State == StateResolved {
+		alert = Alert{State: StatePending, ActiveAt: now}
+		transition = true
+	}
+
+	alert.Rule, alert.MType, alert.MName = o.rule.Name, o.rule.Metric.Type, o.mName
+	alert.Severity, alert.Labels, alert.Value = o.rule.Severity, o.rule.Labels, o.value
+
+	if alert.State == StatePending && now.Sub(alert.ActiveAt) >= time.Duration(o.rule.For) {
+		alert.State, alert.FiredAt = StateFiring, now
+		transition = true
+	}
+
+	alert.Annotations = o.rule.annotations(alert)
+	return alert, true, transition
+}
+
+func (a *Alerts) load() error {
+	if a.path == "" {
+		return nil
+	}
+
+	var file alertsFile
+	if err := readJSON(a.path, &file); err != nil {
+		return err
+	}
+
+	for _, alert := range file.Alerts {
+		a.alerts[alert.Key()] = alert
+	}
+
+	return nil
+}
+
+func (a *Alerts) save(alerts map[string]Alert) error {
+	if a.path == "" {
+		return nil
+	}
+
+	file := alertsFile{Alerts: slices.Collect(maps.Values(alerts))}
+	slices.SortFunc(file.Alerts, func(x, y Alert) int {
+		return strings.Compare(x.Key(), y.Key())
+	})
+
+	return writeJSON(a.path, file)
+}
@@ -0,0 +1,167 @@
+package alerting
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+)
+
+func TestAlerts_apply(t *testing.T) {
+	now := time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+	at := func(sec int) time.Time { return now.Add(time.Duration(sec) * time.Second) }
+
+	rule := validRule()
+	rule.Labels = map[string]string{"team": "core"}
+	rule.Annotations = map[string]string{"summary": "{{ .MName }} is {{ .Value }}"}
+
+	active := func(value float64) observation {
+		return observation{rule: rule, mName: "Alloc", active: true, value: value}
+	}
+	inactive := observation{rule: rule, mName: "Alloc", value: 50}
+
+	type step struct {
+		at           time.Time
+		observations []observation
+		skipped      map[string]struct{}
+		want         []State // states of the transitions
+		wantList     []State
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "pending alert fires after the duration and resolves",
+			steps: []step{
+				{at: at(0), observations: []observation{active(120)}, want: []State{StatePending}, wantList: []State{StatePending}},
+				{at: at(30), observations: []observation{active(130)}, want: []State{}, wantList: []State{StatePending}},
+				{at: at(60), observations: []observation{active(140)}, want: []State{StateFiring}, wantList: []State{StateFiring}},
+				{at: at(90), observations: []observation{active(150)}, want: []State{}, wantList: []State{StateFiring}},
+				{at: at(120), observations: []observation{inactive}, want: []State{StateResolved}, wantList: []State{StateResolved}},
+				{at: at(180), observations: []observation{inactive}, want: []State{}, wantList: []State{StateResolved}},
+				{at: at(120).Add(resolvedRetention), observations: []observation{inactive}, want: []State{}, wantList: []State{}},
+			},
+		},
+		{
+			name: "pending alert is dropped when the condition stops holding",
+			steps: []step{
+				{at: at(0), observations: []observation{active(120)}, want: []State{StatePending}, wantList: []State{StatePending}},
+				{at: at(30), observations: []observation{inactive}, want: []State{}, wantList: []State{}},
+			},
+		},
+		{
+			name: "resolved alert becomes pending again",
+			steps: []step{
+				{at: at(0), observations: []observation{active(120)}, want: []State{StatePending}, wantList: []State{StatePending}},
+				{at: at(60), observations: []observation{active(120)}, want: []State{StateFiring}, wantList: []State{StateFiring}},
+				{at: at(90), observations: []observation{inactive}, want: []State{StateResolved}, wantList: []State{StateResolved}},
+				{at: at(100), observations: []observation{active(120)}, want: []State{StatePending}, wantList: []State{StatePending}},
+			},
+		},
+		{
+			name: "alert without observation is resolved",
+			steps: []step{
+				{at: at(0), observations: []observation{active(120)}, want: []State{StatePending}, wantList: []State{StatePending}},
+				{at: at(60), observations: []observation{active(120)}, want: []State{StateFiring}, wantList: []State{StateFiring}},
+				{at: at(90), want: []State{StateResolved}, wantList: []State{StateResolved}},
+			},
+		},
+		{
+			name: "alert of the skipped rule keeps the state",
+			steps: []step{
+				{at: at(0), observations: []observation{active(120)}, want: []State{StatePending}, wantList: []State{StatePending}},
+				{at: at(60), skipped: map[string]struct{}{rule.Name: {}}, want: []State{}, wantList: []State{StatePending}},
+				{at: at(90), observations: []observation{active(120)}, want: []State{StateFiring}, wantList: []State{StateFiring}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alerts, err := NewAlerts(&config.Config{})
+			require.NoError(t, err)
+
+			for i, s := range tt.steps {
+				transitions, err := alerts.apply(s.observations, s.skipped, s.at)
+				require.NoError(t, err)
+
+				got := make([]State, 0, len(transitions))
+				for _, alert := range transitions {
+					got = append(got, alert.State)
+				}
+				assert.Equal(t, s.want, got, "step %d", i)
+
+				list := make([]State, 0)
+				for _, alert := range alerts.List() {
+					list = append(list, alert.State)
+				}
+				assert.Equal(t, s.wantList, list, "step %d", i)
+			}
+		})
+	}
+}
+
+func TestAlerts_fields(t *testing.T) {
+	now := time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	rule := validRule()
+	rule.For = 0
+	rule.Labels = map[string]string{"team": "core"}
+	rule.Annotations = map[string]string{"summary": "{{ .MName }} is {{ .Value }}"}
+
+	alerts, err := NewAlerts(&config.Config{})
+	require.NoError(t, err)
+
+	transitions, err := alerts.apply([]observation{{rule: rule, mName: "Alloc", active: true, value: 120}}, nil, now)
+	require.NoError(t, err)
+
+	want := Alert{
+		Rule:        rule.Name,
+		MType:       rule.Metric.Type,
+		MName:       "Alloc",
+		Severity:    SeverityWarning,
+		Labels:      map[string]string{"team": "core"},
+		Annotations: map[string]string{"summary": "Alloc is 120"},
+		State:       StateFiring,
+		Value:       120,
+		ActiveAt:    now,
+		FiredAt:     now,
+	}
+	assert.Equal(t, []Alert{want}, transitions)
+	assert.Equal(t, []Alert{want}, alerts.Active())
+}
+
+func TestAlerts_persistence(t *testing.T) {
+	now := time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "state", "alerts.json")
+	cfg := &config.Config{AlertStatePath: path}
+
+	rule := validRule()
+	o := observation{rule: rule, mName: "Alloc", active: true, value: 120}
+
+	alerts, err := NewAlerts(cfg)
+	require.NoError(t, err)
+
+	_, err = alerts.apply([]observation{o}, nil, now)
+	require.NoError(t, err)
+
+	// the pending duration survives the restart
+	restarted, err := NewAlerts(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, alerts.List(), restarted.List())
+
+	transitions, err := restarted.apply([]observation{o}, nil, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, transitions, 1)
+	assert.Equal(t, StateFiring, transitions[0].State)
+	assert.Equal(t, now, transitions[0].ActiveAt)
+
+	restarted, err = NewAlerts(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, StateFiring, restarted.List()[0].State)
+}
@@ -0,0 +1,110 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"text/template"
+	"time"
+)
+
+// Types of the notification channels
+const (
+	ChannelWebhook = "webhook" // POST of the JSON document to the url
+	ChannelSMTP    = "smtp"    // email sent over SMTP
+	ChannelFile    = "file"    // JSON line appended to the local file
+	ChannelExec    = "exec"    // local command reading the notification JSON from stdin
+)
+
+// Statuses of the notifications
+const (
+	NotificationFiring   = "firing"   // some alerts of the group are firing
+	NotificationResolved = "resolved" // all notified alerts of the group are resolved
+)
+
+// Notification about the group of the alerts, firing alerts go first followed by resolved ones
+type Notification struct {
+	Group     string            `json:"group"`
+	Status    string            `json:"status"`
+	Labels    map[string]string `json:"labels,omitempty"` // values of the group_by fields of the route
+	Alerts    []Alert           `json:"alerts"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Channel delivers notifications, the error means the notification should be retried
+type Channel interface {
+	Send(ctx context.Context, n Notification) error
+}
+
+// ChannelConfig describes the channel, only the settings of the type are used
+type ChannelConfig struct {
+	Name    string         `json:"name"`
+	Type    string         `json:"type"`
+	Webhook *WebhookConfig `json:"webhook,omitempty"`
+	SMTP    *SMTPConfig    `json:"smtp,omitempty"`
+	File    *FileConfig    `json:"file,omitempty"`
+	Exec    *ExecConfig    `json:"exec,omitempty"`
+}
+
+// newChannel builds the channel of the type
+func newChannel(cfg ChannelConfig) (Channel, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("%w: channel name is empty", ErrInvalidNotifications)
+	}
+
+	var (
+		ch  Channel
+		err error
+	)
+
+	switch {
+	case cfg.Type == ChannelWebhook && cfg.Webhook != nil:
+		ch, err = newWebhookChannel(*cfg.Webhook)
+	case cfg.Type == ChannelSMTP && cfg.SMTP != nil:
+		ch, err = newSMTPChannel(*cfg.SMTP)
+	case cfg.Type == ChannelFile && cfg.File != nil:
+		ch, err = newFileChannel(*cfg.File)
+	case cfg.Type == ChannelExec && cfg.Exec != nil:
+		ch, err = newExecChannel(*cfg.Exec)
+	default:
+		return nil, fmt.Errorf("%w: channel %s unexpected type(%s) or missing settings", ErrInvalidNotifications, cfg.Name, cfg.Type)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: channel %s: %s", ErrInvalidNotifications, cfg.Name, err)
+	}
+
+	return ch, nil
+}
+
+// notificationFuncs are available in the templates of the channels
+var notificationFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// parseTemplate parses the template of the notification, the fallback is used when the text is empty
+func parseTemplate(name, text, fallback string) (*template.Template, error) {
+	if text == "" {
+		text = fallback
+	}
+
+	tmpl, err := template.New(name).Funcs(notificationFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s template error %w", name, err)
+	}
+
+	return tmpl, nil
+}
+
+func render(tmpl *template.Template, n Notification) ([]byte, error) {
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, n); err != nil {
+		return nil, fmt.Errorf("render %s template error %w", tmpl.Name(), err)
+	}
+
+	return b.Bytes(), nil
+}
@@ -0,0 +1,435 @@
+package alerting
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testNotification() Notification {
+	now := time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	return Notification{
+		Group:  "0/rule=high_alloc",
+		Status: NotificationFiring,
+		Labels: map[string]string{"rule": "high_alloc"},
+		Alerts: []Alert{{
+			Rule:        "high_alloc",
+			MType:       "gauge",
+			MName:       "Alloc",
+			Severity:    SeverityCritical,
+			Annotations: map[string]string{"summary": "Alloc is 120"},
+			State:       StateFiring,
+			Value:       120,
+			ActiveAt:    now,
+			FiredAt:     now,
+		}},
+		CreatedAt: now,
+	}
+}
+
+func TestWebhookChannel(t *testing.T) {
+	type request struct {
+		header http.Header
+		body   string
+	}
+
+	tests := []struct {
+		name     string
+		cfg      WebhookConfig
+		status   int
+		wantBody string
+		wantErr  bool
+	}{
+		{
+			name:     "notification as is",
+			cfg:      WebhookConfig{Headers: map[string]string{"Authorization": "Bearer token"}},
+			status:   http.StatusOK,
+			wantBody: `{"group":"0/rule=high_alloc","status":"firing","labels":{"rule":"high_alloc"},"alerts":[{"rule":"high_alloc","type":"gauge","name":"Alloc","severity":"critical","annotations":{"summary":"Alloc is 120"},"state":"firing","value":120,"active_at":"2025-10-18T12:00:00Z","fired_at":"2025-10-18T12:00:00Z"}],"created_at":"2025-10-18T12:00:00Z"}`,
+		},
+		{
+			name:     "templated body",
+			cfg:      WebhookConfig{Body: `{"text":{{ json (printf "%s: %d alert(s)" .Status (len .Alerts)) }},"names":[{{ range $i, $a := .Alerts }}{{ if $i }},{{ end }}{{ json $a.MName }}{{ end }}]}`},
+			status:   http.StatusAccepted,
+			wantBody: `{"text":"firing: 1 alert(s)","names":["Alloc"]}`,
+		},
+		{
+			name:    "body is not a JSON",
+			cfg:     WebhookConfig{Body: `{{ .Status }}`},
+			status:  http.StatusOK,
+			wantErr: true,
+		},
+		{
+			name:    "unexpected status",
+			status:  http.StatusServiceUnavailable,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := make(chan request, 1)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := io.ReadAll(r.Body)
+				requests <- request{header: r.Header, body: string(b)}
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			tt.cfg.URL = srv.URL
+			ch, err := newWebhookChannel(tt.cfg)
+			require.NoError(t, err)
+
+			err = ch.Send(context.Background(), testNotification())
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			req := <-requests
+			assert.JSONEq(t, tt.wantBody, req.body)
+			assert.Equal(t, "application/json", req.header.Get("Content-Type"))
+			for key, value := range tt.cfg.Headers {
+				assert.Equal(t, value, req.header.Get(key))
+			}
+		})
+	}
+}
+
+// smtpServer is an in-process SMTP server accepting every message
+type smtpServer struct {
+	ln       net.Listener
+	mu       sync.Mutex
+	messages []smtpMessage
+	reject   bool // reject recipients
+}
+
+type smtpMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &smtpServer{ln: ln}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { fmt.Fprintf(conn, "%s\r\n", line) }
+
+	reply("220 localhost ESMTP")
+
+	var msg smtpMessage
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			msg = smtpMessage{from: strings.Trim(strings.TrimSpace(line)[len("MAIL FROM:"):], "<>")}
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			if s.reject {
+				reply("550 mailbox unavailable")
+				continue
+			}
+
+			msg.to = append(msg.to, strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>"))
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 end data with <CR><LF>.<CR><LF>")
+
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+
+			msg.data = data.String()
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func (s *smtpServer) received() []smtpMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]smtpMessage(nil), s.messages...)
+}
+
+func TestSMTPChannel(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         SMTPConfig
+		reject      bool
+		wantSubject string
+		wantBody    string
+		wantErr     bool
+	}{
+		{
+			name:        "default templates",
+			cfg:         SMTPConfig{From: "monitoring@example.com", To: []string{"ops@example.com", "dev@example.com"}},
+			wantSubject: "Subject: [firing] 1 alert(s) of 0/rule=high_alloc",
+			wantBody:    "firing critical high_alloc: gauge/Alloc = 120\r\n  summary: Alloc is 120\r\n",
+		},
+		{
+			name: "custom templates",
+			cfg: SMTPConfig{
+				From:    "monitoring@example.com",
+				To:      []string{"ops@example.com"},
+				Subject: `{{ .Status }} {{ index .Labels "rule" }}`,
+				Body:    `{{ range .Alerts }}{{ .MName }}{{ end }}`,
+			},
+			wantSubject: "Subject: firing high_alloc",
+			wantBody:    "Alloc\r\n",
+		},
+		{
+			name:    "recipient is rejected",
+			cfg:     SMTPConfig{From: "monitoring@example.com", To: []string{"ops@example.com"}},
+			reject:  true,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newSMTPServer(t)
+			srv.reject = tt.reject
+
+			tt.cfg.Address = srv.ln.Addr().String()
+			ch, err := newSMTPChannel(tt.cfg)
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			err = ch.Send(ctx, testNotification())
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			messages := srv.received()
+			require.Len(t, messages, 1)
+			assert.Equal(t, tt.cfg.From, messages[0].from)
+			assert.Equal(t, tt.cfg.To, messages[0].to)
+
+			headers, body, ok := strings.Cut(messages[0].data, "\r\n\r\n")
+			require.True(t, ok)
+			assert.Contains(t, strings.Split(headers, "\r\n"), tt.wantSubject)
+			assert.Contains(t, headers, "To: "+strings.Join(tt.cfg.To, ", "))
+			assert.Equal(t, tt.wantBody, body)
+		})
+	}
+}
+
+func TestSMTPChannel_unavailable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	ch, err := newSMTPChannel(SMTPConfig{Address: addr, From: "monitoring@example.com", To: []string{"ops@example.com"}})
+	require.NoError(t, err)
+
+	assert.Error(t, ch.Send(context.Background(), testNotification()))
+}
+
+func TestFileChannel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications", "alerts.log")
+
+	ch, err := newFileChannel(FileConfig{Path: path})
+	require.NoError(t, err)
+
+	n := testNotification()
+	require.NoError(t, ch.Send(context.Background(), n))
+	require.NoError(t, ch.Send(context.Background(), n))
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	require.Len(t, lines, 2)
+
+	var got Notification
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &got))
+	assert.Equal(t, n, got)
+}
+
+func TestExecChannel(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.json")
+
+	tests := []struct {
+		name    string
+		cfg     ExecConfig
+		wantErr string
+	}{
+		{
+			name: "command reads the notification",
+			cfg:  ExecConfig{Command: "/bin/sh", Args: []string{"-c", `echo "$ALERT_STATUS $ALERT_GROUP" > ` + out + `.env && cat > ` + out}},
+		},
+		{
+			name:    "command fails",
+			cfg:     ExecConfig{Command: "/bin/sh", Args: []string{"-c", "echo broken >&2; exit 3"}},
+			wantErr: "broken",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch, err := newExecChannel(tt.cfg)
+			require.NoError(t, err)
+
+			n := testNotification()
+			err = ch.Send(context.Background(), n)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			b, err := os.ReadFile(out)
+			require.NoError(t, err)
+
+			var got Notification
+			require.NoError(t, json.Unmarshal(b, &got))
+			assert.Equal(t, n, got)
+
+			env, err := os.ReadFile(out + ".env")
+			require.NoError(t, err)
+			assert.Equal(t, "firing 0/rule=high_alloc\n", string(env))
+		})
+	}
+}
+
+func TestNewChannel(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     ChannelConfig
+		wantErr bool
+	}{
+		{
+			name: "webhook",
+			cfg:  ChannelConfig{Name: "hook", Type: ChannelWebhook, Webhook: &WebhookConfig{URL: "http://localhost:9000/alerts"}},
+		},
+		{
+			name: "smtp",
+			cfg:  ChannelConfig{Name: "mail", Type: ChannelSMTP, SMTP: &SMTPConfig{Address: "localhost:25", From: "a@example.com", To: []string{"b@example.com"}}},
+		},
+		{
+			name: "file",
+			cfg:  ChannelConfig{Name: "log", Type: ChannelFile, File: &FileConfig{Path: "alerts.log"}},
+		},
+		{
+			name: "exec",
+			cfg:  ChannelConfig{Name: "script", Type: ChannelExec, Exec: &ExecConfig{Command: "notify.sh"}},
+		},
+		{
+			name:    "without name",
+			cfg:     ChannelConfig{Type: ChannelFile, File: &FileConfig{Path: "alerts.log"}},
+			wantErr: true,
+		},
+		{
+			name:    "missing settings",
+			cfg:     ChannelConfig{Name: "hook", Type: ChannelWebhook, SMTP: &SMTPConfig{}},
+			wantErr: true,
+		},
+		{
+			name:    "unexpected type",
+			cfg:     ChannelConfig{Name: "pager", Type: "pager"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid url",
+			cfg:     ChannelConfig{Name: "hook", Type: ChannelWebhook, Webhook: &WebhookConfig{URL: "localhost:9000"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid template",
+			cfg:     ChannelConfig{Name: "hook", Type: ChannelWebhook, Webhook: &WebhookConfig{URL: "http://localhost", Body: "{{ .Status"}},
+			wantErr: true,
+		},
+		{
+			name:    "smtp without recipients",
+			cfg:     ChannelConfig{Name: "mail", Type: ChannelSMTP, SMTP: &SMTPConfig{Address: "localhost:25", From: "a@example.com"}},
+			wantErr: true,
+		},
+		{
+			name:    "smtp without port",
+			cfg:     ChannelConfig{Name: "mail", Type: ChannelSMTP, SMTP: &SMTPConfig{Address: "localhost", From: "a@example.com", To: []string{"b@example.com"}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch, err := newChannel(tt.cfg)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidNotifications)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NotNil(t, ch)
+		})
+	}
+}
@@ -0,0 +1,476 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	deliveryInterval = time.Second      // how often the outbox is checked for due deliveries
+	sendTimeout      = 10 * time.Second // timeout of a single delivery attempt
+)
+
+// errUnknownChannel drops deliveries to the channels removed from the notifications file
+var errUnknownChannel = fmt.Errorf("%w: unknown channel", ErrInvalidNotifications)
+
+// group is the notification state of the group of alerts
+type group struct {
+	Key      string            `json:"key"`
+	Route    int               `json:"route"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	ActiveAt time.Time         `json:"active_at"`        // when the group got the first firing alert
+	SentAt   time.Time         `json:"sent_at,omitzero"` // when the last notification was enqueued
+	Firing   []string          `json:"firing,omitempty"` // keys of the firing alerts of the last notification
+}
+
+// due reports whether the group should be notified about the firing alerts
+func (g *group) due(r Route, firing []string, now time.Time) bool {
+	switch {
+	case g.SentAt.IsZero():
+		return now.Sub(g.ActiveAt) >= time.Duration(r.GroupWait)
+	case !slices.Equal(firing, g.Firing):
+		return now.Sub(g.SentAt) >= time.Duration(r.GroupInterval)
+	default:
+		return now.Sub(g.SentAt) >= time.Duration(r.RepeatInterval)
+	}
+}
+
+// delivery of the notification to the channel waiting in the outbox
+type delivery struct {
+	ID           string       `json:"id"`
+	Channel      string       `json:"channel"`
+	Notification Notification `json:"notification"`
+	Attempts     int          `json:"attempts"`
+	NextAttempt  time.Time    `json:"next_attempt"`
+	LastError    string       `json:"last_error,omitempty"`
+}
+
+// outboxFile is the persisted state of the dispatcher
+type outboxFile struct {
+	Groups     []*group   `json:"groups"`
+	Deliveries []delivery `json:"deliveries"`
+}
+
+// Dispatcher routes alerts to the notification channels. Alerts are grouped, so a single notification is sent
+// for the group: after the group wait, on changes not more often than the group interval and every repeat
+// interval while alerts keep firing. Notifications are kept in the outbox persisted to the file until
+// they are delivered or run out of attempts, so they survive restarts.
+// Notifications are disabled when the notifications file is not configured.
+type Dispatcher struct {
+	mu       sync.Mutex
+	path     string
+	routes   []Route
+	retry    Retry
+	channels map[string]Channel
+	groups   map[string]*group
+	outbox   []delivery
+	lg       *logging.ZapLogger
+	now      func() time.Time
+	wake     chan struct{}
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+func NewDispatcher(lc fx.Lifecycle, cfg *config.Config, lg *logging.ZapLogger) (*Dispatcher, error) {
+	d := &Dispatcher{
+		path:     cfg.NotificationOutboxPath,
+		channels: make(map[string]Channel),
+		groups:   make(map[string]*group),
+		lg:       lg,
+		now:      time.Now,
+		wake:     make(chan struct{}, 1),
+	}
+
+	if err := d.configure(cfg.NotificationsPath); err != nil {
+		return nil, err
+	}
+
+	if len(d.routes) > 0 {
+		if err := d.load(); err != nil {
+			return nil, err
+		}
+	}
+
+	lc.Append(
+		fx.Hook{
+			OnStart: func(ctx context.Context) error {
+				if len(d.routes) == 0 {
+					return nil
+				}
+
+				d.start()
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				return d.stop(ctx)
+			},
+		},
+	)
+
+	return d, nil
+}
+
+// configure reads channels and routes from the file
+func (d *Dispatcher) configure(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("internal/server/alerting: notifications file error %w", err)
+	}
+
+	var file notificationsFile
+	if err := readJSON(path, &file); err != nil {
+		return err
+	}
+
+	if err := file.validate(); err != nil {
+		return fmt.Errorf("internal/server/alerting: load %s error %w", path, err)
+	}
+
+	for _, cfg := range file.Channels {
+		ch, err := newChannel(cfg)
+		if err != nil {
+			return fmt.Errorf("internal/server/alerting: load %s error %w", path, err)
+		}
+
+		d.channels[cfg.Name] = ch
+	}
+
+	d.routes, d.retry = file.Routes, file.Retry
+	return nil
+}
+
+func (d *Dispatcher) start() {
+	ctx, cancel := context.WithCancel(
+		d.lg.WithContextFields(context.Background(), zap.String("actor", "notification_dispatcher")),
+	)
+	d.cancel = cancel
+	d.done = make(chan struct{})
+
+	go func() {
+		defer close(d.done)
+
+		ticker := time.NewTicker(deliveryInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := d.deliver(ctx); err != nil {
+				d.lg.ErrorCtx(ctx, "notifications delivery error", zap.Error(err))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-d.wake:
+			}
+		}
+	}()
+}
+
+// stop waits for the running delivery
+func (d *Dispatcher) stop(ctx context.Context) error {
+	if d.cancel == nil {
+		return nil
+	}
+
+	d.cancel()
+
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("internal/server/alerting: stop dispatcher error %w", ctx.Err())
+	}
+}
+
+// bucket collects alerts of the group within a single notify call
+type bucket struct {
+	route    int
+	labels   map[string]string
+	firing   []Alert
+	resolved []Alert
+}
+
+// Notify enqueues notifications of the groups which are due, the alerts are the current state of all alerts.
+// Pending alerts are not notified, resolved alerts are notified only when they were notified as firing.
+func (d *Dispatcher) Notify(ctx context.Context, alerts []Alert) error {
+	if len(d.routes) == 0 {
+		return nil
+	}
+
+	buckets := make(map[string]*bucket)
+	for _, alert := range alerts {
+		if alert.State == StatePending {
+			continue
+		}
+
+		for i, r := range d.routes {
+			if !r.matches(alert) {
+				continue
+			}
+
+			key, labels := r.group(i, alert)
+			b, ok := buckets[key]
+			if !ok {
+				b = &bucket{route: i, labels: labels}
+				buckets[key] = b
+			}
+
+			if alert.State == StateFiring {
+				b.firing = append(b.firing, alert)
+			} else {
+				b.resolved = append(b.resolved, alert)
+			}
+
+			if !r.Continue {
+				break
+			}
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	dirty, enqueued := false, false
+
+	keys := slices.Sorted(maps.Keys(buckets))
+	for key := range d.groups {
+		if _, ok := buckets[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	for _, key := range keys {
+		b, ok := buckets[key]
+		if !ok {
+			b = &bucket{}
+		}
+
+		g, ok := d.groups[key]
+		if !ok {
+			if len(b.firing) == 0 {
+				continue
+			}
+
+			g = &group{Key: key, Route: b.route, Labels: b.labels, ActiveAt: now}
+			d.groups[key], dirty = g, true
+		}
+
+		firing := make([]string, 0, len(b.firing))
+		for _, alert := range b.firing {
+			firing = append(firing, alert.Key())
+		}
+		slices.Sort(firing)
+
+		// the route is gone or the group was never notified and has nothing firing
+		if g.Route >= len(d.routes) || len(firing) == 0 && len(g.Firing) == 0 {
+			delete(d.groups, key)
+			dirty = true
+			continue
+		}
+
+		route := d.routes[g.Route]
+		if !g.due(route, firing, now) {
+			continue
+		}
+
+		n := Notification{Group: key, Status: NotificationFiring, Labels: g.Labels, Alerts: b.firing, CreatedAt: now}
+		if len(firing) == 0 {
+			n.Status = NotificationResolved
+		}
+
+		for _, alert := range b.resolved {
+			if slices.Contains(g.Firing, alert.Key()) {
+				n.Alerts = append(n.Alerts, alert)
+			}
+		}
+
+		for _, channel := range route.Channels {
+			d.enqueue(channel, n)
+		}
+
+		g.SentAt, g.Firing = now, firing
+		if len(firing) == 0 {
+			delete(d.groups, key)
+		}
+
+		dirty, enqueued = true, true
+	}
+
+	if dirty {
+		if err := d.save(); err != nil {
+			return err
+		}
+	}
+
+	if enqueued {
+		select {
+		case d.wake <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// enqueue adds the notification to the outbox, undelivered notification of the group to the channel is replaced
+// by the new one so stale notifications are not sent after the channel recovers
+func (d *Dispatcher) enqueue(channel string, n Notification) {
+	del := delivery{
+		ID:           channel + "/" + n.Group + "/" + strconv.FormatInt(n.CreatedAt.UnixNano(), 10),
+		Channel:      channel,
+		Notification: n,
+		NextAttempt:  n.CreatedAt,
+	}
+
+	i := slices.IndexFunc(d.outbox, func(prev delivery) bool {
+		return prev.Channel == channel && prev.Notification.Group == n.Group
+	})
+	if i < 0 {
+		d.outbox = append(d.outbox, del)
+		return
+	}
+
+	del.Attempts, del.NextAttempt, del.LastError = d.outbox[i].Attempts, d.outbox[i].NextAttempt, d.outbox[i].LastError
+	d.outbox[i] = del
+}
+
+// deliver sends due notifications of the outbox, failed ones are retried with the backoff
+func (d *Dispatcher) deliver(ctx context.Context) error {
+	now := d.now()
+
+	d.mu.Lock()
+	due := make([]delivery, 0)
+	for _, del := range d.outbox {
+		if !del.NextAttempt.After(now) {
+			due = append(due, del)
+		}
+	}
+	d.mu.Unlock()
+
+	if len(due) == 0 {
+		return nil
+	}
+
+	results := make(map[string]error, len(due))
+	for _, del := range due {
+		ch, ok := d.channels[del.Channel]
+		if !ok {
+			results[del.ID] = errUnknownChannel
+			continue
+		}
+
+		sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+		err := ch.Send(sendCtx, del.Notification)
+		cancel()
+
+		// the attempt is not counted when the dispatcher is stopping
+		if ctx.Err() != nil {
+			break
+		}
+
+		results[del.ID] = err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	outbox := make([]delivery, 0, len(d.outbox))
+	for _, del := range d.outbox {
+		err, ok := results[del.ID]
+		if !ok {
+			outbox = append(outbox, del)
+			continue
+		}
+
+		fields := []zap.Field{
+			zap.String("channel", del.Channel),
+			zap.String("group", del.Notification.Group),
+			zap.String("status", del.Notification.Status),
+		}
+
+		if err == nil {
+			d.lg.DebugCtx(ctx, "notification delivered", fields...)
+			continue
+		}
+
+		del.Attempts++
+		del.LastError = err.Error()
+		fields = append(fields, zap.Int("attempts", del.Attempts), zap.Error(err))
+
+		if errors.Is(err, errUnknownChannel) || del.Attempts >= d.retry.MaxAttempts {
+			d.lg.ErrorCtx(ctx, "notification dropped", fields...)
+			continue
+		}
+
+		del.NextAttempt = now.Add(d.backoff(del.Attempts))
+		d.lg.WarnCtx(ctx, "notification delivery failed", append(fields, zap.Time("next_attempt", del.NextAttempt))...)
+		outbox = append(outbox, del)
+	}
+
+	d.outbox = outbox
+	return d.save()
+}
+
+// backoff returns the delay before the next attempt within [0, max backoff]. The exponent is compared
+// with the max backoff before the conversion, large attempts overflow the duration otherwise.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	maxBackoff := max(time.Duration(d.retry.MaxBackoff), 0)
+
+	delay := utils.Delay(uint8(min(max(attempts, 0), 255))) * float64(time.Second)
+	if delay >= float64(maxBackoff) {
+		return maxBackoff
+	}
+
+	return max(time.Duration(delay), 0)
+}
+
+func (d *Dispatcher) load() error {
+	if d.path == "" {
+		return nil
+	}
+
+	var file outboxFile
+	if err := readJSON(d.path, &file); err != nil {
+		return err
+	}
+
+	for _, g := range file.Groups {
+		d.groups[g.Key] = g
+	}
+	d.outbox = file.Deliveries
+
+	return nil
+}
+
+// save must be called with the lock held
+func (d *Dispatcher) save() error {
+	if d.path == "" {
+		return nil
+	}
+
+	file := outboxFile{Groups: slices.Collect(maps.Values(d.groups)), Deliveries: d.outbox}
+	slices.SortFunc(file.Groups, func(x, y *group) int {
+		return strings.Compare(x.Key, y.Key)
+	})
+
+	return writeJSON(d.path, file)
+}
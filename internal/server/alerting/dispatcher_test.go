@@ -0,0 +1,493 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+	"go.uber.org/fx/fxtest"
+)
+
+// fakeChannel fails the first attempts and records delivered notifications
+type fakeChannel struct {
+	mu        sync.Mutex
+	failures  int
+	delivered []Notification
+}
+
+func (ch *fakeChannel) Send(ctx context.Context, n Notification) error {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	if ch.failures > 0 {
+		ch.failures--
+		return errors.New("channel is unavailable")
+	}
+
+	ch.delivered = append(ch.delivered, n)
+	return nil
+}
+
+// writeNotifications writes the notifications file with file channels of the names
+func writeNotifications(t *testing.T, routes []Route, retry Retry, channels ...string) string {
+	dir := t.TempDir()
+
+	file := notificationsFile{Routes: routes, Retry: retry}
+	for _, name := range channels {
+		file.Channels = append(file.Channels, ChannelConfig{Name: name, Type: ChannelFile, File: &FileConfig{Path: filepath.Join(dir, name+".log")}})
+	}
+
+	b, err := json.Marshal(file)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "notifications.json")
+	require.NoError(t, os.WriteFile(path, b, 0o600))
+
+	return path
+}
+
+func newTestDispatcher(t *testing.T, cfg *config.Config, now *time.Time) *Dispatcher {
+	d, err := NewDispatcher(fxtest.NewLifecycle(t), cfg, newTestLogger(t))
+	require.NoError(t, err)
+
+	d.now = func() time.Time { return *now }
+	return d
+}
+
+func firing(rule, mName string, labels map[string]string) Alert {
+	return Alert{Rule: rule, MType: "gauge", MName: mName, Severity: SeverityWarning, Labels: labels, State: StateFiring}
+}
+
+func resolved(rule, mName string) Alert {
+	return Alert{Rule: rule, MType: "gauge", MName: mName, Severity: SeverityWarning, State: StateResolved}
+}
+
+func TestDispatcher_Notify(t *testing.T) {
+	start := time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+	now := start
+
+	path := writeNotifications(t, []Route{{
+		Channels:       []string{"log"},
+		GroupBy:        []string{"rule"},
+		GroupWait:      Duration(30 * time.Second),
+		GroupInterval:  Duration(time.Minute),
+		RepeatInterval: Duration(time.Hour),
+	}}, Retry{}, "log")
+	d := newTestDispatcher(t, &config.Config{NotificationsPath: path}, &now)
+
+	pending := firing("high", "Sys", nil)
+	pending.State = StatePending
+
+	type want struct {
+		status string
+		alerts []string
+	}
+
+	steps := []struct {
+		name   string
+		at     time.Duration
+		alerts []Alert
+		want   *want
+	}{
+		{
+			name:   "the group waits for more alerts",
+			alerts: []Alert{firing("high", "Alloc", nil)},
+		},
+		{
+			name:   "pending alerts are not notified",
+			at:     10 * time.Second,
+			alerts: []Alert{firing("high", "Alloc", nil), pending},
+		},
+		{
+			name:   "the group is notified after the wait",
+			at:     30 * time.Second,
+			alerts: []Alert{firing("high", "Alloc", nil), firing("high", "HeapAlloc", nil)},
+			want:   &want{status: NotificationFiring, alerts: []string{"Alloc", "HeapAlloc"}},
+		},
+		{
+			name:   "unchanged group is not notified again",
+			at:     40 * time.Second,
+			alerts: []Alert{firing("high", "Alloc", nil), firing("high", "HeapAlloc", nil)},
+		},
+		{
+			name:   "changes wait for the group interval",
+			at:     60 * time.Second,
+			alerts: []Alert{firing("high", "Alloc", nil), resolved("high", "HeapAlloc")},
+		},
+		{
+			name:   "changes are notified after the group interval",
+			at:     90 * time.Second,
+			alerts: []Alert{firing("high", "Alloc", nil), resolved("high", "HeapAlloc")},
+			want:   &want{status: NotificationFiring, alerts: []string{"Alloc", "HeapAlloc"}},
+		},
+		{
+			name:   "resolved alert is notified once",
+			at:     30 * time.Minute,
+			alerts: []Alert{firing("high", "Alloc", nil), resolved("high", "HeapAlloc")},
+		},
+		{
+			name:   "firing alerts are repeated",
+			at:     90*time.Second + time.Hour,
+			alerts: []Alert{firing("high", "Alloc", nil), resolved("high", "HeapAlloc")},
+			want:   &want{status: NotificationFiring, alerts: []string{"Alloc"}},
+		},
+		{
+			name:   "resolution waits for the group interval",
+			at:     100*time.Second + time.Hour,
+			alerts: []Alert{resolved("high", "Alloc")},
+		},
+		{
+			name:   "resolution of the group",
+			at:     150*time.Second + time.Hour,
+			alerts: []Alert{resolved("high", "Alloc")},
+			want:   &want{status: NotificationResolved, alerts: []string{"Alloc"}},
+		},
+		{
+			name:   "resolved group is forgotten",
+			at:     time.Hour + 5*time.Minute,
+			alerts: []Alert{resolved("high", "Alloc")},
+		},
+	}
+
+	for _, s := range steps {
+		now = start.Add(s.at)
+		require.NoError(t, d.Notify(context.Background(), s.alerts), s.name)
+
+		if s.want == nil {
+			assert.Empty(t, d.outbox, s.name)
+			continue
+		}
+
+		require.Len(t, d.outbox, 1, s.name)
+		n := d.outbox[0].Notification
+
+		names := make([]string, 0, len(n.Alerts))
+		for _, alert := range n.Alerts {
+			names = append(names, alert.MName)
+		}
+
+		assert.Equal(t, s.want.status, n.Status, s.name)
+		assert.Equal(t, s.want.alerts, names, s.name)
+		assert.Equal(t, "0/rule=high", n.Group, s.name)
+		assert.Equal(t, map[string]string{"rule": "high"}, n.Labels, s.name)
+		assert.Equal(t, now, n.CreatedAt, s.name)
+
+		d.outbox = nil
+	}
+
+	assert.Empty(t, d.groups)
+}
+
+func TestDispatcher_routing(t *testing.T) {
+	now := time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	path := writeNotifications(t, []Route{
+		{Severities: []string{SeverityCritical}, Channels: []string{"pager"}, Continue: true},
+		{Labels: map[string]string{"team": "core*"}, Channels: []string{"team"}, GroupBy: []string{"team", "name"}},
+		{Channels: []string{"all", "log"}},
+	}, Retry{}, "pager", "team", "all", "log")
+	d := newTestDispatcher(t, &config.Config{NotificationsPath: path}, &now)
+
+	critical := firing("high", "Alloc", map[string]string{"team": "core"})
+	critical.Severity = SeverityCritical
+
+	require.NoError(t, d.Notify(context.Background(), []Alert{
+		critical,
+		firing("high", "HeapAlloc", map[string]string{"team": "core-db"}),
+		firing("high", "Sys", map[string]string{"team": "web"}),
+		firing("low", "Frees", nil),
+	}))
+
+	got := make(map[string][]string)
+	for _, del := range d.outbox {
+		got[del.Channel+" "+del.Notification.Group] = nil
+		for _, alert := range del.Notification.Alerts {
+			got[del.Channel+" "+del.Notification.Group] = append(got[del.Channel+" "+del.Notification.Group], alert.MName)
+		}
+	}
+
+	assert.Equal(t, map[string][]string{
+		"pager 0":                            {"Alloc"},
+		"team 1/team=core/name=Alloc":        {"Alloc"},
+		"team 1/team=core-db/name=HeapAlloc": {"HeapAlloc"},
+		"all 2":                              {"Sys", "Frees"},
+		"log 2":                              {"Sys", "Frees"},
+	}, got)
+}
+
+func TestDispatcher_deliver(t *testing.T) {
+	start := time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+	now := start
+
+	path := writeNotifications(t, []Route{{Channels: []string{"hook"}, GroupInterval: Duration(time.Second)}}, Retry{MaxAttempts: 4, MaxBackoff: Duration(2 * time.Second)}, "hook")
+	d := newTestDispatcher(t, &config.Config{NotificationsPath: path}, &now)
+
+	ch := &fakeChannel{failures: 3}
+	d.channels["hook"] = ch
+
+	require.NoError(t, d.Notify(context.Background(), []Alert{firing("high", "Alloc", nil)}))
+
+	// failed attempts are retried with the growing backoff capped by the max backoff
+	wantBackoff := []time.Duration{time.Duration(math.Exp(0.5) * float64(time.Second)), 2 * time.Second}
+	for _, backoff := range wantBackoff {
+		require.NoError(t, d.deliver(context.Background()))
+		require.Len(t, d.outbox, 1)
+		assert.Equal(t, now.Add(backoff), d.outbox[0].NextAttempt)
+		assert.Equal(t, "channel is unavailable", d.outbox[0].LastError)
+
+		// not due yet
+		require.NoError(t, d.deliver(context.Background()))
+		assert.Len(t, d.outbox, 1)
+
+		now = d.outbox[0].NextAttempt
+	}
+
+	// undelivered notification of the group is replaced by the new one keeping the attempts
+	now = now.Add(time.Second)
+	require.NoError(t, d.Notify(context.Background(), []Alert{firing("high", "Alloc", nil), firing("high", "Sys", nil)}))
+	require.Len(t, d.outbox, 1)
+	assert.Equal(t, 2, d.outbox[0].Attempts)
+	assert.Len(t, d.outbox[0].Notification.Alerts, 2)
+
+	require.NoError(t, d.deliver(context.Background()))
+	require.Len(t, d.outbox, 1)
+	assert.Equal(t, 3, d.outbox[0].Attempts)
+
+	now = d.outbox[0].NextAttempt
+	require.NoError(t, d.deliver(context.Background()))
+	assert.Empty(t, d.outbox)
+	require.Len(t, ch.delivered, 1)
+	assert.Len(t, ch.delivered[0].Alerts, 2)
+}
+
+func TestDispatcher_backoff(t *testing.T) {
+	d := &Dispatcher{retry: Retry{MaxBackoff: Duration(5 * time.Minute)}}
+
+	tests := []struct {
+		name     string
+		attempts int
+		want     time.Duration
+	}{
+		{name: "first attempt", attempts: 1, want: time.Duration(math.Exp(0.5) * float64(time.Second))},
+		{name: "capped", attempts: 20, want: 5 * time.Minute},
+		{name: "overflows duration", attempts: 46, want: 5 * time.Minute},
+		{name: "large", attempts: 1000, want: 5 * time.Minute},
+		{name: "max int", attempts: math.MaxInt, want: 5 * time.Minute},
+		{name: "negative", attempts: -1, want: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, d.backoff(tt.attempts))
+		})
+	}
+}
+
+func TestDispatcher_deliverDrops(t *testing.T) {
+	now := time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	path := writeNotifications(t, []Route{{Channels: []string{"hook"}}}, Retry{MaxAttempts: 2}, "hook")
+	d := newTestDispatcher(t, &config.Config{NotificationsPath: path}, &now)
+	d.channels["hook"] = &fakeChannel{failures: 2}
+
+	require.NoError(t, d.Notify(context.Background(), []Alert{firing("high", "Alloc", nil)}))
+
+	require.NoError(t, d.deliver(context.Background()))
+	require.Len(t, d.outbox, 1)
+
+	now = d.outbox[0].NextAttempt
+	require.NoError(t, d.deliver(context.Background()))
+	assert.Empty(t, d.outbox, "out of attempts")
+
+	d.outbox = []delivery{{ID: "removed", Channel: "removed", NextAttempt: now}}
+	require.NoError(t, d.deliver(context.Background()))
+	assert.Empty(t, d.outbox, "unknown channel")
+}
+
+func TestDispatcher_persistence(t *testing.T) {
+	now := time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	cfg := &config.Config{
+		NotificationsPath:      writeNotifications(t, []Route{{Channels: []string{"hook"}}}, Retry{}, "hook"),
+		NotificationOutboxPath: filepath.Join(t.TempDir(), "state", "notifications.json"),
+	}
+	alerts := []Alert{firing("high", "Alloc", nil)}
+
+	d := newTestDispatcher(t, cfg, &now)
+	d.channels["hook"] = &fakeChannel{failures: 1}
+
+	require.NoError(t, d.Notify(context.Background(), alerts))
+	require.NoError(t, d.deliver(context.Background()))
+	require.Len(t, d.outbox, 1)
+
+	// undelivered notification and the state of the group survive the restart
+	now = now.Add(time.Minute)
+	restarted := newTestDispatcher(t, cfg, &now)
+	ch := &fakeChannel{}
+	restarted.channels["hook"] = ch
+
+	assert.Equal(t, d.outbox, restarted.outbox)
+	assert.Equal(t, d.groups, restarted.groups)
+
+	require.NoError(t, restarted.Notify(context.Background(), alerts))
+	require.NoError(t, restarted.deliver(context.Background()))
+	assert.Len(t, ch.delivered, 1)
+
+	restarted = newTestDispatcher(t, cfg, &now)
+	assert.Empty(t, restarted.outbox)
+	assert.Len(t, restarted.groups, 1)
+}
+
+func TestNewDispatcher(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "notifications.json")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+		return path
+	}
+
+	tests := []struct {
+		name       string
+		content    string
+		missing    bool
+		wantRoutes int
+		wantErr    bool
+	}{
+		{
+			name:    "notifications are disabled",
+			missing: true,
+		},
+		{
+			name: "channels and routes",
+			content: `{
+				"channels": [
+					{"name":"hook","type":"webhook","webhook":{"url":"http://localhost:9000/alerts"}},
+					{"name":"mail","type":"smtp","smtp":{"address":"localhost:25","from":"a@example.com","to":["b@example.com"]}}
+				],
+				"routes": [
+					{"severities":["critical"],"channels":["hook","mail"],"group_by":["rule"],"group_wait":"30s","repeat_interval":"1h"},
+					{"channels":["mail"]}
+				]
+			}`,
+			wantRoutes: 2,
+		},
+		{
+			name:    "unknown channel of the route",
+			content: `{"channels":[],"routes":[{"channels":["hook"]}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "route without channels",
+			content: `{"channels":[{"name":"log","type":"file","file":{"path":"a.log"}}],"routes":[{}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "duplicate channel",
+			content: `{"channels":[{"name":"log","type":"file","file":{"path":"a.log"}},{"name":"log","type":"file","file":{"path":"b.log"}}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid channel",
+			content: `{"channels":[{"name":"log","type":"file"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "negative interval",
+			content: `{"channels":[{"name":"log","type":"file","file":{"path":"a.log"}}],"routes":[{"channels":["log"],"group_wait":"-1s"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid label pattern",
+			content: `{"channels":[{"name":"log","type":"file","file":{"path":"a.log"}}],"routes":[{"channels":["log"],"labels":{"team":"["}}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "negative retry",
+			content: `{"retry":{"max_attempts":-1}}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			if !tt.missing {
+				cfg.NotificationsPath = write(t, tt.content)
+			}
+
+			d, err := NewDispatcher(fxtest.NewLifecycle(t), cfg, newTestLogger(t))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, d.routes, tt.wantRoutes)
+			assert.NoError(t, d.Notify(context.Background(), []Alert{firing("high", "Alloc", nil)}))
+
+			if tt.wantRoutes > 0 {
+				assert.Equal(t, Duration(time.Minute), d.routes[1].GroupInterval)
+				assert.Equal(t, Duration(time.Hour), d.routes[0].RepeatInterval)
+				assert.Equal(t, defaultMaxAttempts, d.retry.MaxAttempts)
+			}
+		})
+	}
+
+	_, err := NewDispatcher(fxtest.NewLifecycle(t), &config.Config{NotificationsPath: filepath.Join(t.TempDir(), "missing.json")}, newTestLogger(t))
+	assert.Error(t, err)
+}
+
+func TestDispatcher_webhook(t *testing.T) {
+	received := make(chan Notification, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n Notification
+		b, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(b, &n); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		received <- n
+	}))
+	defer srv.Close()
+
+	file := notificationsFile{
+		Channels: []ChannelConfig{{Name: "hook", Type: ChannelWebhook, Webhook: &WebhookConfig{URL: srv.URL}}},
+		Routes:   []Route{{Channels: []string{"hook"}}},
+	}
+	b, err := json.Marshal(file)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "notifications.json")
+	require.NoError(t, os.WriteFile(path, b, 0o600))
+
+	lc := fxtest.NewLifecycle(t)
+	d, err := NewDispatcher(lc, &config.Config{NotificationsPath: path}, newTestLogger(t))
+	require.NoError(t, err)
+
+	lc.RequireStart()
+	defer lc.RequireStop()
+
+	require.NoError(t, d.Notify(context.Background(), []Alert{firing("high", "Alloc", nil)}))
+
+	select {
+	case n := <-received:
+		assert.Equal(t, NotificationFiring, n.Status)
+		require.Len(t, n.Alerts, 1)
+		assert.Equal(t, "Alloc", n.Alerts[0].MName)
+	case <-time.After(5 * time.Second):
+		t.Fatal("notification is not delivered")
+	}
+}
@@ -0,0 +1,286 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/repositories"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/storages"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+type GaugeRepository interface {
+	All(ctx context.Context) ([]models.Gauge, error)
+}
+
+var _ GaugeRepository = (*repositories.GaugeRepository)(nil)
+
+type CounterRepository interface {
+	All(ctx context.Context) ([]models.Counter, error)
+}
+
+var _ CounterRepository = (*repositories.CounterRepository)(nil)
+
+type HistorySource interface {
+	Samples(ctx context.Context, mType, mName string, resolution time.Duration, from, to time.Time) ([]models.Sample, error)
+}
+
+var _ HistorySource = (storages.History)(nil)
+
+// Notifier is given the state of all alerts after every evaluation
+type Notifier interface {
+	Notify(ctx context.Context, alerts []Alert) error
+}
+
+var _ Notifier = (*Dispatcher)(nil)
+
+// Evaluator evaluates alerting rules in the background and moves alerts through the states.
+// The evaluator is disabled when the interval is not positive.
+type Evaluator struct {
+	rules    *Rules
+	alerts   *Alerts
+	gauges   GaugeRepository
+	counters CounterRepository
+	history  HistorySource
+	notifier Notifier
+	interval time.Duration
+	lg       *logging.ZapLogger
+	now      func() time.Time
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+func NewEvaluator(
+	lc fx.Lifecycle,
+	cfg *config.Config,
+	lg *logging.ZapLogger,
+	rules *Rules,
+	alerts *Alerts,
+	gauges GaugeRepository,
+	counters CounterRepository,
+	history HistorySource,
+	notifier Notifier,
+) *Evaluator {
+	e := &Evaluator{
+		rules:    rules,
+		alerts:   alerts,
+		gauges:   gauges,
+		counters: counters,
+		history:  history,
+		notifier: notifier,
+		interval: cfg.AlertEvaluationInterval,
+		lg:       lg,
+		now:      time.Now,
+	}
+
+	lc.Append(
+		fx.Hook{
+			OnStart: func(ctx context.Context) error {
+				if e.interval <= 0 {
+					return nil
+				}
+
+				e.start()
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				return e.stop(ctx)
+			},
+		},
+	)
+
+	return e
+}
+
+func (e *Evaluator) start() {
+	ctx, cancel := context.WithCancel(
+		e.lg.WithContextFields(context.Background(), zap.String("actor", "alert_evaluator")),
+	)
+	e.cancel = cancel
+	e.done = make(chan struct{})
+
+	go func() {
+		defer close(e.done)
+
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := e.Evaluate(ctx); err != nil {
+					e.lg.ErrorCtx(ctx, "alerts evaluation error", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+// stop waits for the running evaluation
+func (e *Evaluator) stop(ctx context.Context) error {
+	if e.cancel == nil {
+		return nil
+	}
+
+	e.cancel()
+
+	select {
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("internal/server/alerting: stop evaluator error %w", ctx.Err())
+	}
+}
+
+// Evaluate evaluates every rule and returns alerts which became pending, firing or resolved.
+// Alerts of the rules which failed to evaluate keep their state. The notifier is given all alerts
+// afterwards, so it is able to repeat notifications of the alerts which keep firing.
+func (e *Evaluator) Evaluate(ctx context.Context) ([]Alert, error) {
+	now := e.now()
+
+	values, err := e.values(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	observations := make([]observation, 0)
+	skipped := make(map[string]struct{})
+
+	for _, rule := range e.rules.List() {
+		res, err := e.evaluate(ctx, rule, values[rule.Metric.Type], now)
+		if err != nil {
+			e.lg.ErrorCtx(ctx, "rule evaluation error", zap.String("rule", rule.Name), zap.Error(err))
+			skipped[rule.Name] = struct{}{}
+			continue
+		}
+
+		observations = append(observations, res...)
+	}
+
+	transitions, err := e.alerts.apply(observations, skipped, now)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, alert := range transitions {
+		e.lg.InfoCtx(ctx, "alert state changed",
+			zap.String("rule", alert.Rule),
+			zap.String("type", alert.MType),
+			zap.String("name", alert.MName),
+			zap.String("severity", alert.Severity),
+			zap.String("state", string(alert.State)),
+			zap.Float64("value", alert.Value),
+		)
+	}
+
+	if err := e.notifier.Notify(ctx, e.alerts.List()); err != nil {
+		e.lg.ErrorCtx(ctx, "alerts notification error", zap.Error(err))
+	}
+
+	return transitions, nil
+}
+
+// values returns current values of the series by the type
+func (e *Evaluator) values(ctx context.Context) (map[string]map[string]float64, error) {
+	gauges, err := e.gauges.All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("internal/server/alerting: fetch gauges error %w", err)
+	}
+
+	counters, err := e.counters.All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("internal/server/alerting: fetch counters error %w", err)
+	}
+
+	values := map[string]map[string]float64{
+		models.GaugeType:   make(map[string]float64, len(gauges)),
+		models.CounterType: make(map[string]float64, len(counters)),
+	}
+
+	for _, g := range gauges {
+		values[models.GaugeType][g.Name] = g.Value
+	}
+
+	for _, c := range counters {
+		values[models.CounterType][c.Name] = float64(c.Value)
+	}
+
+	return values, nil
+}
+
+// evaluate returns observations of the series matching the rule selector. Absence rule with the exact
+// name is also evaluated for the series which has never been reported.
+func (e *Evaluator) evaluate(ctx context.Context, rule Rule, values map[string]float64, now time.Time) ([]observation, error) {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		if ok, _ := path.Match(rule.Metric.Name, name); ok {
+			names = append(names, name)
+		}
+	}
+
+	if rule.Condition.Kind == ConditionAbsence && rule.Metric.exact() && !slices.Contains(names, rule.Metric.Name) {
+		names = append(names, rule.Metric.Name)
+	}
+
+	slices.Sort(names)
+
+	res := make([]observation, 0, len(names))
+	for _, name := range names {
+		o := observation{rule: rule, mName: name, value: values[name]}
+
+		switch rule.Condition.Kind {
+		case ConditionThreshold:
+			o.active = rule.Condition.compare(o.value)
+		case ConditionAbsence:
+			samples, err := e.samples(ctx, rule, name, now)
+			if err != nil {
+				return nil, err
+			}
+
+			o.active = len(samples) == 0
+		case ConditionRate:
+			samples, err := e.samples(ctx, rule, name, now)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(samples) < 2 {
+				continue
+			}
+
+			first, last := samples[0], samples[len(samples)-1]
+			elapsed := last.Timestamp.Sub(first.Timestamp).Seconds()
+			if elapsed <= 0 {
+				continue
+			}
+
+			o.value = (last.Value - first.Value) / elapsed
+			o.active = rule.Condition.compare(o.value)
+		}
+
+		res = append(res, o)
+	}
+
+	return res, nil
+}
+
+// samples returns raw samples of the series within the window of the rule
+func (e *Evaluator) samples(ctx context.Context, rule Rule, name string, now time.Time) ([]models.Sample, error) {
+	from := now.Add(-time.Duration(rule.Condition.Window))
+
+	samples, err := e.history.Samples(ctx, rule.Metric.Type, name, 0, from, now)
+	if err != nil {
+		return nil, fmt.Errorf("internal/server/alerting: fetch samples of %s/%s error %w", rule.Metric.Type, name, err)
+	}
+
+	return samples, nil
+}
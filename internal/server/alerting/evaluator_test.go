@@ -0,0 +1,279 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+type fakeGauges struct {
+	gauges []models.Gauge
+	err    error
+}
+
+func (r *fakeGauges) All(ctx context.Context) ([]models.Gauge, error) {
+	return r.gauges, r.err
+}
+
+type fakeCounters struct {
+	counters []models.Counter
+}
+
+func (r *fakeCounters) All(ctx context.Context) ([]models.Counter, error) {
+	return r.counters, nil
+}
+
+// fakeHistory returns samples of the series within the range
+type fakeHistory struct {
+	samples map[string][]models.Sample
+	err     error
+}
+
+func (h *fakeHistory) Samples(ctx context.Context, mType, mName string, resolution time.Duration, from, to time.Time) ([]models.Sample, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+
+	res := make([]models.Sample, 0)
+	for _, s := range h.samples[mType+"/"+mName] {
+		if !s.Timestamp.Before(from) && !s.Timestamp.After(to) {
+			res = append(res, s)
+		}
+	}
+
+	return res, nil
+}
+
+// fakeNotifier records the alerts it was given
+type fakeNotifier struct {
+	calls [][]Alert
+	err   error
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, alerts []Alert) error {
+	n.calls = append(n.calls, alerts)
+	return n.err
+}
+
+func newTestLogger(t *testing.T) *logging.ZapLogger {
+	lg, err := logging.NewZapLogger(&config.Config{LogLevel: -1})
+	require.NoError(t, err)
+
+	return lg
+}
+
+func TestEvaluator_Evaluate(t *testing.T) {
+	now := time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+	ago := func(sec int) time.Time { return now.Add(-time.Duration(sec) * time.Second) }
+
+	lg := newTestLogger(t)
+
+	gauges := []models.Gauge{{Name: "Alloc", Value: 120}, {Name: "HeapAlloc", Value: 80}, {Name: "HeapIdle", Value: 200}}
+	counters := []models.Counter{{Name: "PollCount", Value: 100}}
+	history := map[string][]models.Sample{
+		"gauge/Alloc":       {models.NewSample(ago(30), 120)},
+		"gauge/HeapIdle":    {models.NewSample(ago(300), 200)},
+		"counter/PollCount": {models.NewSample(ago(50), 0), models.NewSample(ago(10), 100)},
+	}
+
+	rule := func(name, mType, pattern string, cond Condition) Rule {
+		return Rule{Name: name, Metric: Selector{Type: mType, Name: pattern}, Condition: cond, Severity: SeverityWarning}
+	}
+
+	type want struct {
+		mName string
+		value float64
+	}
+
+	tests := []struct {
+		name       string
+		rules      []Rule
+		historyErr error
+		gaugesErr  error
+		want       []want
+		wantErr    bool
+	}{
+		{
+			name:  "threshold of the exact series",
+			rules: []Rule{rule("high", models.GaugeType, "Alloc", Condition{Kind: ConditionThreshold, Op: ">", Value: 100})},
+			want:  []want{{"Alloc", 120}},
+		},
+		{
+			name:  "threshold of the pattern",
+			rules: []Rule{rule("heap", models.GaugeType, "Heap*", Condition{Kind: ConditionThreshold, Op: ">=", Value: 80})},
+			want:  []want{{"HeapAlloc", 80}, {"HeapIdle", 200}},
+		},
+		{
+			name:  "threshold does not hold",
+			rules: []Rule{rule("low", models.GaugeType, "*", Condition{Kind: ConditionThreshold, Op: "<", Value: 10})},
+			want:  []want{},
+		},
+		{
+			name:  "absence of the series without recent samples",
+			rules: []Rule{rule("stale", models.GaugeType, "*", Condition{Kind: ConditionAbsence, Window: Duration(time.Minute)})},
+			want:  []want{{"HeapAlloc", 80}, {"HeapIdle", 200}},
+		},
+		{
+			name:  "absence of the series never reported",
+			rules: []Rule{rule("missing", models.GaugeType, "Sys", Condition{Kind: ConditionAbsence, Window: Duration(time.Minute)})},
+			want:  []want{{"Sys", 0}},
+		},
+		{
+			name:  "rate of the counter",
+			rules: []Rule{rule("polls", models.CounterType, "PollCount", Condition{Kind: ConditionRate, Op: ">", Value: 2, Window: Duration(time.Minute)})},
+			want:  []want{{"PollCount", 2.5}},
+		},
+		{
+			name:  "rate requires two samples",
+			rules: []Rule{rule("alloc_rate", models.GaugeType, "Alloc", Condition{Kind: ConditionRate, Op: ">=", Value: 0, Window: Duration(time.Minute)})},
+			want:  []want{},
+		},
+		{
+			name: "failed rule is skipped",
+			rules: []Rule{
+				rule("high", models.GaugeType, "Alloc", Condition{Kind: ConditionThreshold, Op: ">", Value: 100}),
+				rule("stale", models.GaugeType, "*", Condition{Kind: ConditionAbsence, Window: Duration(time.Minute)}),
+			},
+			historyErr: errors.New("storage error"),
+			want:       []want{{"Alloc", 120}},
+		},
+		{
+			name:      "repository error",
+			rules:     []Rule{rule("high", models.GaugeType, "Alloc", Condition{Kind: ConditionThreshold, Op: ">", Value: 100})},
+			gaugesErr: errors.New("storage error"),
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := NewRules(&config.Config{})
+			require.NoError(t, err)
+			for _, r := range tt.rules {
+				_, err := rules.Put(r)
+				require.NoError(t, err)
+			}
+
+			alerts, err := NewAlerts(&config.Config{})
+			require.NoError(t, err)
+
+			e := NewEvaluator(
+				fxtest.NewLifecycle(t),
+				&config.Config{},
+				lg,
+				rules,
+				alerts,
+				&fakeGauges{gauges: gauges, err: tt.gaugesErr},
+				&fakeCounters{counters: counters},
+				&fakeHistory{samples: history, err: tt.historyErr},
+				&fakeNotifier{err: errors.New("notifier error")},
+			)
+			e.now = func() time.Time { return now }
+
+			transitions, err := e.Evaluate(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			got := make([]want, 0, len(transitions))
+			for _, alert := range transitions {
+				assert.Equal(t, StateFiring, alert.State)
+				got = append(got, want{alert.MName, alert.Value})
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEvaluator_deletedRule(t *testing.T) {
+	now := time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	rules, err := NewRules(&config.Config{})
+	require.NoError(t, err)
+	_, err = rules.Put(Rule{
+		Name:      "high",
+		Metric:    Selector{Type: models.GaugeType, Name: "Alloc"},
+		Condition: Condition{Kind: ConditionThreshold, Op: ">", Value: 100},
+		Severity:  SeverityCritical,
+	})
+	require.NoError(t, err)
+
+	alerts, err := NewAlerts(&config.Config{})
+	require.NoError(t, err)
+
+	notifier := &fakeNotifier{}
+	e := NewEvaluator(
+		fxtest.NewLifecycle(t),
+		&config.Config{},
+		newTestLogger(t),
+		rules,
+		alerts,
+		&fakeGauges{gauges: []models.Gauge{{Name: "Alloc", Value: 120}}},
+		&fakeCounters{},
+		&fakeHistory{},
+		notifier,
+	)
+	e.now = func() time.Time { return now }
+
+	_, err = e.Evaluate(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, alerts.Active(), 1)
+	require.Len(t, notifier.calls, 1)
+	assert.Equal(t, alerts.List(), notifier.calls[0])
+
+	require.NoError(t, rules.Delete("high"))
+
+	transitions, err := e.Evaluate(context.Background())
+	require.NoError(t, err)
+	require.Len(t, transitions, 1)
+	assert.Equal(t, StateResolved, transitions[0].State)
+	assert.Empty(t, alerts.Active())
+	require.Len(t, notifier.calls, 2)
+	assert.Equal(t, transitions, notifier.calls[1])
+}
+
+func TestNewEvaluator(t *testing.T) {
+	rules, err := NewRules(&config.Config{})
+	require.NoError(t, err)
+	_, err = rules.Put(Rule{
+		Name:      "high",
+		Metric:    Selector{Type: models.GaugeType, Name: "Alloc"},
+		Condition: Condition{Kind: ConditionThreshold, Op: ">", Value: 100},
+		Severity:  SeverityCritical,
+	})
+	require.NoError(t, err)
+
+	alerts, err := NewAlerts(&config.Config{})
+	require.NoError(t, err)
+
+	app := fxtest.New(
+		t,
+		fx.Supply(
+			&config.Config{AlertEvaluationInterval: 10 * time.Millisecond},
+			newTestLogger(t),
+			rules,
+			alerts,
+			fx.Annotate(&fakeGauges{gauges: []models.Gauge{{Name: "Alloc", Value: 120}}}, fx.As(new(GaugeRepository))),
+			fx.Annotate(&fakeCounters{}, fx.As(new(CounterRepository))),
+			fx.Annotate(&fakeHistory{}, fx.As(new(HistorySource))),
+			fx.Annotate(&fakeNotifier{}, fx.As(new(Notifier))),
+		),
+		fx.Provide(NewEvaluator),
+		fx.Invoke(func(*Evaluator) {}),
+	)
+
+	app.RequireStart()
+	assert.Eventually(t, func() bool { return len(alerts.Active()) == 1 }, time.Second, 10*time.Millisecond)
+	app.RequireStop()
+}
@@ -0,0 +1,102 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// FileConfig of the file channel, every notification is appended as a JSON line
+type FileConfig struct {
+	Path string `json:"path"`
+}
+
+type fileChannel struct {
+	mu   sync.Mutex
+	path string
+}
+
+func newFileChannel(cfg FileConfig) (*fileChannel, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("path is empty")
+	}
+
+	return &fileChannel{path: cfg.Path}, nil
+}
+
+func (ch *fileChannel) Send(ctx context.Context, n Notification) error {
+	b, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("file: encode notification error %w", err)
+	}
+
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(ch.path), 0o755); err != nil {
+		return fmt.Errorf("file: create dir of %s error %w", ch.path, err)
+	}
+
+	f, err := os.OpenFile(ch.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("file: open %s error %w", ch.path, err)
+	}
+
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("file: write %s error %w", ch.path, err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("file: close %s error %w", ch.path, err)
+	}
+
+	return nil
+}
+
+// ExecConfig of the exec channel, the command reads the notification JSON from stdin.
+// ALERT_GROUP and ALERT_STATUS environment variables are set for the command.
+type ExecConfig struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+}
+
+type execChannel struct {
+	command string
+	args    []string
+}
+
+func newExecChannel(cfg ExecConfig) (*execChannel, error) {
+	if cfg.Command == "" {
+		return nil, errors.New("command is empty")
+	}
+
+	return &execChannel{command: cfg.Command, args: cfg.Args}, nil
+}
+
+func (ch *execChannel) Send(ctx context.Context, n Notification) error {
+	b, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("exec: encode notification error %w", err)
+	}
+
+	var output bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, ch.command, ch.args...)
+	cmd.Stdin = bytes.NewReader(b)
+	cmd.Stdout, cmd.Stderr = &output, &output
+	cmd.Env = append(os.Environ(), "ALERT_GROUP="+n.Group, "ALERT_STATUS="+n.Status)
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("exec: run %s error %w: %s", ch.command, err, strings.TrimSpace(output.String()))
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package alerting
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidNotifications returned when the notifications file can't be used
+var ErrInvalidNotifications = errors.New("alerting: invalid notifications")
+
+const (
+	defaultGroupInterval  = time.Minute
+	defaultRepeatInterval = 4 * time.Hour
+	defaultMaxAttempts    = 10
+	defaultMaxBackoff     = 5 * time.Minute
+)
+
+// notificationsFile is the format of the notifications file
+type notificationsFile struct {
+	Channels []ChannelConfig `json:"channels"`
+	Routes   []Route         `json:"routes"`
+	Retry    Retry           `json:"retry"`
+}
+
+// Route sends alerts matching the severities and labels to the channels. Alerts are grouped by group_by
+// fields: rule, type, name, severity or label names, all alerts of the route are in one group when empty.
+// Routes are matched in order, the first matching route wins unless continue is set.
+type Route struct {
+	Severities     []string          `json:"severities,omitempty"` // any severity when empty
+	Labels         map[string]string `json:"labels,omitempty"`     // path.Match patterns of the label values
+	Channels       []string          `json:"channels"`
+	GroupBy        []string          `json:"group_by,omitempty"`
+	GroupWait      Duration          `json:"group_wait,omitempty"`      // delay of the first notification of the group to collect more alerts
+	GroupInterval  Duration          `json:"group_interval,omitempty"`  // min delay between notifications about changes of the group
+	RepeatInterval Duration          `json:"repeat_interval,omitempty"` // delay of the repeated notification when nothing changed
+	Continue       bool              `json:"continue,omitempty"`
+}
+
+// Retry of the failed deliveries, the backoff grows exponentially up to the max backoff
+type Retry struct {
+	MaxAttempts int      `json:"max_attempts,omitempty"`
+	MaxBackoff  Duration `json:"max_backoff,omitempty"`
+}
+
+// validate checks the file and fills the defaults
+func (f *notificationsFile) validate() error {
+	names := make(map[string]struct{}, len(f.Channels))
+	for _, ch := range f.Channels {
+		if _, ok := names[ch.Name]; ok {
+			return fmt.Errorf("%w: duplicate channel %s", ErrInvalidNotifications, ch.Name)
+		}
+		names[ch.Name] = struct{}{}
+	}
+
+	for i := range f.Routes {
+		r := &f.Routes[i]
+
+		if len(r.Channels) == 0 {
+			return fmt.Errorf("%w: route %d has no channels", ErrInvalidNotifications, i)
+		}
+
+		for _, name := range r.Channels {
+			if _, ok := names[name]; !ok {
+				return fmt.Errorf("%w: route %d unknown channel %s", ErrInvalidNotifications, i, name)
+			}
+		}
+
+		for _, pattern := range r.Labels {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("%w: route %d invalid label pattern %q", ErrInvalidNotifications, i, pattern)
+			}
+		}
+
+		if r.GroupWait < 0 || r.GroupInterval < 0 || r.RepeatInterval < 0 {
+			return fmt.Errorf("%w: route %d negative interval", ErrInvalidNotifications, i)
+		}
+
+		if r.GroupInterval == 0 {
+			r.GroupInterval = Duration(defaultGroupInterval)
+		}
+
+		if r.RepeatInterval == 0 {
+			r.RepeatInterval = Duration(defaultRepeatInterval)
+		}
+	}
+
+	if f.Retry.MaxAttempts < 0 || f.Retry.MaxBackoff < 0 {
+		return fmt.Errorf("%w: negative retry settings", ErrInvalidNotifications)
+	}
+
+	if f.Retry.MaxAttempts == 0 {
+		f.Retry.MaxAttempts = defaultMaxAttempts
+	}
+
+	if f.Retry.MaxBackoff == 0 {
+		f.Retry.MaxBackoff = Duration(defaultMaxBackoff)
+	}
+
+	return nil
+}
+
+// matches reports whether the alert is sent by the route
+func (r Route) matches(alert Alert) bool {
+	if len(r.Severities) > 0 && !slices.Contains(r.Severities, alert.Severity) {
+		return false
+	}
+
+	for name, pattern := range r.Labels {
+		value, ok := alert.Labels[name]
+		if !ok {
+			return false
+		}
+
+		if matched, _ := path.Match(pattern, value); !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// group returns the key and the labels of the group of the alert, the key includes the index of the route
+func (r Route) group(i int, alert Alert) (string, map[string]string) {
+	parts := make([]string, 0, len(r.GroupBy)+1)
+	parts = append(parts, strconv.Itoa(i))
+
+	var labels map[string]string
+	if len(r.GroupBy) > 0 {
+		labels = make(map[string]string, len(r.GroupBy))
+	}
+
+	for _, field := range r.GroupBy {
+		var value string
+		switch field {
+		case "rule":
+			value = alert.Rule
+		case "type":
+			value = alert.MType
+		case "name":
+			value = alert.MName
+		case "severity":
+			value = alert.Severity
+		default:
+			value = alert.Labels[field]
+		}
+
+		labels[field] = value
+		parts = append(parts, field+"="+value)
+	}
+
+	return strings.Join(parts, "/"), labels
+}
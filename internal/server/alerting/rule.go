@@ -0,0 +1,170 @@
+// Package alerting evaluates alerting rules over the stored metrics, tracks the state of the alerts
+// and dispatches notifications about them to the channels.
+package alerting
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/models"
+)
+
+// ErrInvalidRule returned when the rule can't be stored
+var ErrInvalidRule = errors.New("alerting: invalid rule")
+
+// Kinds of the rule conditions
+const (
+	ConditionThreshold = "threshold" // the current value compared with the value
+	ConditionAbsence   = "absence"   // no samples within the window
+	ConditionRate      = "rate"      // per second change over the window compared with the value
+)
+
+// Severities of the rules
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Rule fires alerts for every series matching the selector while the condition holds for the duration
+type Rule struct {
+	Name        string            `json:"name"`
+	Metric      Selector          `json:"metric"`
+	Condition   Condition         `json:"condition"`
+	For         Duration          `json:"for"`
+	Severity    string            `json:"severity"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"` // text/template of the alert fields, e.g. {{ .Value }}
+}
+
+// Selector of the series, the name is path.Match pattern
+type Selector struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// Condition of the rule, window is used by absence and rate conditions
+type Condition struct {
+	Kind   string   `json:"kind"`
+	Op     string   `json:"op,omitempty"`
+	Value  float64  `json:"value,omitempty"`
+	Window Duration `json:"window,omitempty"`
+}
+
+// Duration is encoded as a string, e.g. "5m"
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var val string
+	if err := json.Unmarshal(b, &val); err != nil {
+		return fmt.Errorf("alerting: duration must be a string error %w", err)
+	}
+
+	parsed, err := time.ParseDuration(val)
+	if err != nil {
+		return fmt.Errorf("alerting: parse duration error %w", err)
+	}
+
+	*d = Duration(parsed)
+	return nil
+}
+
+var ops = map[string]func(a, b float64) bool{
+	">":  func(a, b float64) bool { return a > b },
+	">=": func(a, b float64) bool { return a >= b },
+	"<":  func(a, b float64) bool { return a < b },
+	"<=": func(a, b float64) bool { return a <= b },
+	"==": func(a, b float64) bool { return a == b },
+	"!=": func(a, b float64) bool { return a != b },
+}
+
+// compare applies the operator of the condition
+func (c Condition) compare(value float64) bool {
+	return ops[c.Op](value, c.Value)
+}
+
+func (r Rule) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("%w: name is empty", ErrInvalidRule)
+	}
+
+	if r.Metric.Type != models.GaugeType && r.Metric.Type != models.CounterType {
+		return fmt.Errorf("%w: rule %s unexpected metric type(%s)", ErrInvalidRule, r.Name, r.Metric.Type)
+	}
+
+	if _, err := path.Match(r.Metric.Name, ""); err != nil || r.Metric.Name == "" {
+		return fmt.Errorf("%w: rule %s invalid metric name pattern %q", ErrInvalidRule, r.Name, r.Metric.Name)
+	}
+
+	switch r.Condition.Kind {
+	case ConditionThreshold, ConditionRate:
+		if _, ok := ops[r.Condition.Op]; !ok {
+			return fmt.Errorf("%w: rule %s unexpected op(%s)", ErrInvalidRule, r.Name, r.Condition.Op)
+		}
+	case ConditionAbsence:
+	default:
+		return fmt.Errorf("%w: rule %s unexpected condition(%s)", ErrInvalidRule, r.Name, r.Condition.Kind)
+	}
+
+	if r.Condition.Kind != ConditionThreshold && r.Condition.Window <= 0 {
+		return fmt.Errorf("%w: rule %s %s condition requires positive window", ErrInvalidRule, r.Name, r.Condition.Kind)
+	}
+
+	if r.For < 0 {
+		return fmt.Errorf("%w: rule %s negative for", ErrInvalidRule, r.Name)
+	}
+
+	switch r.Severity {
+	case SeverityInfo, SeverityWarning, SeverityCritical:
+	default:
+		return fmt.Errorf("%w: rule %s unexpected severity(%s)", ErrInvalidRule, r.Name, r.Severity)
+	}
+
+	for key, text := range r.Annotations {
+		if _, err := template.New(key).Parse(text); err != nil {
+			return fmt.Errorf("%w: rule %s annotation %s: %s", ErrInvalidRule, r.Name, key, err)
+		}
+	}
+
+	return nil
+}
+
+// exact reports whether the selector matches a single series
+func (s Selector) exact() bool {
+	return !strings.ContainsAny(s.Name, `*?[\`)
+}
+
+// annotations renders annotations of the rule for the alert, invalid templates are kept as is
+func (r Rule) annotations(alert Alert) map[string]string {
+	if len(r.Annotations) == 0 {
+		return nil
+	}
+
+	res := make(map[string]string, len(r.Annotations))
+	for key, text := range r.Annotations {
+		res[key] = text
+
+		tmpl, err := template.New(key).Parse(text)
+		if err != nil {
+			continue
+		}
+
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, alert); err != nil {
+			continue
+		}
+		res[key] = b.String()
+	}
+
+	return res
+}
@@ -0,0 +1,156 @@
+package alerting
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/models"
+)
+
+func validRule() Rule {
+	return Rule{
+		Name:      "high_alloc",
+		Metric:    Selector{Type: models.GaugeType, Name: "Alloc"},
+		Condition: Condition{Kind: ConditionThreshold, Op: ">", Value: 100},
+		For:       Duration(time.Minute),
+		Severity:  SeverityWarning,
+	}
+}
+
+func TestRule_validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(r *Rule)
+		wantErr bool
+	}{
+		{
+			name:   "threshold rule",
+			modify: func(r *Rule) {},
+		},
+		{
+			name: "absence rule of the pattern",
+			modify: func(r *Rule) {
+				r.Metric.Name = "Heap*"
+				r.Condition = Condition{Kind: ConditionAbsence, Window: Duration(time.Minute)}
+			},
+		},
+		{
+			name: "rate rule",
+			modify: func(r *Rule) {
+				r.Metric.Type = models.CounterType
+				r.Condition = Condition{Kind: ConditionRate, Op: ">=", Value: 10, Window: Duration(time.Minute)}
+			},
+		},
+		{
+			name:    "empty name",
+			modify:  func(r *Rule) { r.Name = "" },
+			wantErr: true,
+		},
+		{
+			name:    "unexpected metric type",
+			modify:  func(r *Rule) { r.Metric.Type = "histogram" },
+			wantErr: true,
+		},
+		{
+			name:    "invalid name pattern",
+			modify:  func(r *Rule) { r.Metric.Name = "Heap[" },
+			wantErr: true,
+		},
+		{
+			name:    "empty name pattern",
+			modify:  func(r *Rule) { r.Metric.Name = "" },
+			wantErr: true,
+		},
+		{
+			name:    "unexpected condition",
+			modify:  func(r *Rule) { r.Condition.Kind = "anomaly" },
+			wantErr: true,
+		},
+		{
+			name:    "unexpected op",
+			modify:  func(r *Rule) { r.Condition.Op = "=>" },
+			wantErr: true,
+		},
+		{
+			name:    "rate without window",
+			modify:  func(r *Rule) { r.Condition.Kind = ConditionRate },
+			wantErr: true,
+		},
+		{
+			name:    "absence without window",
+			modify:  func(r *Rule) { r.Condition = Condition{Kind: ConditionAbsence} },
+			wantErr: true,
+		},
+		{
+			name:    "negative for",
+			modify:  func(r *Rule) { r.For = Duration(-time.Second) },
+			wantErr: true,
+		},
+		{
+			name:    "unexpected severity",
+			modify:  func(r *Rule) { r.Severity = "fatal" },
+			wantErr: true,
+		},
+		{
+			name:    "invalid annotation",
+			modify:  func(r *Rule) { r.Annotations = map[string]string{"summary": "{{ .Value"} },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := validRule()
+			tt.modify(&r)
+
+			err := r.validate()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidRule)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestRule_JSON(t *testing.T) {
+	body := `{"name":"high_alloc","metric":{"type":"gauge","name":"Alloc"},"condition":{"kind":"threshold","op":">","value":100},"for":"1m0s","severity":"warning"}`
+
+	var r Rule
+	require.NoError(t, json.Unmarshal([]byte(body), &r))
+	assert.Equal(t, validRule(), r)
+
+	b, err := json.Marshal(r)
+	require.NoError(t, err)
+	assert.JSONEq(t, body, string(b))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"for":60}`), &r))
+	assert.Error(t, json.Unmarshal([]byte(`{"for":"minute"}`), &r))
+}
+
+func TestRule_annotations(t *testing.T) {
+	r := validRule()
+	r.Annotations = map[string]string{
+		"summary": "{{ .MName }} is {{ .Value }}",
+		"missing": "{{ .Missing }}",
+	}
+
+	got := r.annotations(Alert{MName: "Alloc", Value: 120})
+	assert.Equal(t, map[string]string{
+		"summary": "Alloc is 120",
+		"missing": "{{ .Missing }}",
+	}, got)
+
+	assert.Nil(t, validRule().annotations(Alert{}))
+}
+
+func TestSelector_exact(t *testing.T) {
+	assert.True(t, Selector{Name: "Alloc"}.exact())
+	assert.False(t, Selector{Name: "Heap*"}.exact())
+	assert.False(t, Selector{Name: "Heap?"}.exact())
+	assert.False(t, Selector{Name: "Heap[AI]"}.exact())
+}
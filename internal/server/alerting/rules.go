@@ -0,0 +1,127 @@
+package alerting
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+)
+
+// ErrRuleNotFound returned when there is no rule with the name
+var ErrRuleNotFound = errors.New("alerting: rule not found")
+
+// rulesFile is the format of the rules file
+type rulesFile struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Rules stores alerting rules loaded from the file, changes made over the api are written back to the file.
+// Rules are kept in memory only when the path is not configured.
+type Rules struct {
+	mu    sync.RWMutex
+	path  string
+	rules []Rule
+}
+
+func NewRules(cfg *config.Config) (*Rules, error) {
+	r := &Rules{path: cfg.AlertRulesPath}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// List returns all rules in the order they were added
+func (r *Rules) List() []Rule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append(make([]Rule, 0, len(r.rules)), r.rules...)
+}
+
+// Put creates or replaces the rule
+func (r *Rules) Put(rule Rule) (Rule, error) {
+	if err := rule.validate(); err != nil {
+		return Rule{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rules := slices.Clone(r.rules)
+	if i := r.index(rule.Name); i >= 0 {
+		rules[i] = rule
+	} else {
+		rules = append(rules, rule)
+	}
+
+	if err := r.save(rules); err != nil {
+		return Rule{}, err
+	}
+
+	r.rules = rules
+	return rule, nil
+}
+
+// Delete removes the rule, alerts of the rule are resolved on the next evaluation
+func (r *Rules) Delete(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.index(name)
+	if i < 0 {
+		return ErrRuleNotFound
+	}
+
+	rules := slices.Delete(slices.Clone(r.rules), i, i+1)
+	if err := r.save(rules); err != nil {
+		return err
+	}
+
+	r.rules = rules
+	return nil
+}
+
+func (r *Rules) index(name string) int {
+	return slices.IndexFunc(r.rules, func(rule Rule) bool {
+		return rule.Name == name
+	})
+}
+
+func (r *Rules) load() error {
+	if r.path == "" {
+		return nil
+	}
+
+	var file rulesFile
+	if err := readJSON(r.path, &file); err != nil {
+		return err
+	}
+
+	seen := make(map[string]struct{}, len(file.Rules))
+	for _, rule := range file.Rules {
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("internal/server/alerting: load %s error %w", r.path, err)
+		}
+
+		if _, ok := seen[rule.Name]; ok {
+			return fmt.Errorf("internal/server/alerting: load %s error %w: duplicate rule %s", r.path, ErrInvalidRule, rule.Name)
+		}
+		seen[rule.Name] = struct{}{}
+	}
+
+	r.rules = file.Rules
+	return nil
+}
+
+func (r *Rules) save(rules []Rule) error {
+	if r.path == "" {
+		return nil
+	}
+
+	return writeJSON(r.path, rulesFile{Rules: rules})
+}
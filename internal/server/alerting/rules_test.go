@@ -0,0 +1,106 @@
+package alerting
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+)
+
+func TestRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+
+	rules, err := NewRules(&config.Config{AlertRulesPath: path})
+	require.NoError(t, err)
+	assert.Empty(t, rules.List())
+
+	first, second := validRule(), validRule()
+	second.Name = "low_alloc"
+	second.Condition.Op = "<"
+
+	_, err = rules.Put(first)
+	require.NoError(t, err)
+	_, err = rules.Put(second)
+	require.NoError(t, err)
+
+	first.Severity = SeverityCritical
+	_, err = rules.Put(first)
+	require.NoError(t, err)
+	assert.Equal(t, []Rule{first, second}, rules.List())
+
+	invalid := validRule()
+	invalid.Severity = ""
+	_, err = rules.Put(invalid)
+	assert.ErrorIs(t, err, ErrInvalidRule)
+
+	assert.ErrorIs(t, rules.Delete("unknown"), ErrRuleNotFound)
+	require.NoError(t, rules.Delete(second.Name))
+
+	reloaded, err := NewRules(&config.Config{AlertRulesPath: path})
+	require.NoError(t, err)
+	assert.Equal(t, []Rule{first}, reloaded.List())
+}
+
+func TestNewRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "rules file",
+			content: `{"rules":[
+				{"name":"a","metric":{"type":"gauge","name":"Alloc"},"condition":{"kind":"threshold","op":">","value":1},"severity":"info"},
+				{"name":"b","metric":{"type":"counter","name":"PollCount"},"condition":{"kind":"absence","window":"1m"},"for":"5m","severity":"critical"}
+			]}`,
+			want: 2,
+		},
+		{
+			name:    "invalid rule",
+			content: `{"rules":[{"name":"a","metric":{"type":"gauge","name":"Alloc"},"condition":{"kind":"threshold"},"severity":"info"}]}`,
+			wantErr: true,
+		},
+		{
+			name: "duplicate rule",
+			content: `{"rules":[
+				{"name":"a","metric":{"type":"gauge","name":"Alloc"},"condition":{"kind":"threshold","op":">","value":1},"severity":"info"},
+				{"name":"a","metric":{"type":"gauge","name":"Alloc"},"condition":{"kind":"threshold","op":"<","value":1},"severity":"info"}
+			]}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			content: `{"rules":`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rules.json")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+
+			rules, err := NewRules(&config.Config{AlertRulesPath: path})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Len(t, rules.List(), tt.want)
+		})
+	}
+}
+
+func TestRules_inMemory(t *testing.T) {
+	rules, err := NewRules(&config.Config{})
+	require.NoError(t, err)
+
+	_, err = rules.Put(validRule())
+	require.NoError(t, err)
+	assert.Len(t, rules.List(), 1)
+}
@@ -0,0 +1,156 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	defaultSMTPSubject = `[{{ .Status }}] {{ len .Alerts }} alert(s) of {{ .Group }}`
+	defaultSMTPBody    = `{{ range .Alerts }}{{ .State }} {{ .Severity }} {{ .Rule }}: {{ .MType }}/{{ .MName }} = {{ .Value }}
+{{ range $key, $value := .Annotations }}  {{ $key }}: {{ $value }}
+{{ end }}{{ end }}`
+)
+
+// SMTPConfig of the email channel, subject and body are text/template of the notification.
+// STARTTLS is used when the server supports it, credentials are optional.
+type SMTPConfig struct {
+	Address  string   `json:"address"` // host:port
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	Subject  string   `json:"subject,omitempty"`
+	Body     string   `json:"body,omitempty"`
+}
+
+type smtpChannel struct {
+	address string
+	host    string
+	from    string
+	to      []string
+	auth    smtp.Auth
+	subject *template.Template
+	body    *template.Template
+}
+
+func newSMTPChannel(cfg SMTPConfig) (*smtpChannel, error) {
+	host, _, err := net.SplitHostPort(cfg.Address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %q", cfg.Address)
+	}
+
+	if cfg.From == "" || len(cfg.To) == 0 {
+		return nil, errors.New("from and to are required")
+	}
+
+	subject, err := parseTemplate("subject", cfg.Subject, defaultSMTPSubject)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := parseTemplate("body", cfg.Body, defaultSMTPBody)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := &smtpChannel{address: cfg.Address, host: host, from: cfg.From, to: cfg.To, subject: subject, body: body}
+	if cfg.Username != "" {
+		ch.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, host)
+	}
+
+	return ch, nil
+}
+
+func (ch *smtpChannel) Send(ctx context.Context, n Notification) error {
+	msg, err := ch.message(n)
+	if err != nil {
+		return err
+	}
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", ch.address)
+	if err != nil {
+		return fmt.Errorf("smtp: dial error %w", err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, ch.host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("smtp: handshake error %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: ch.host}); err != nil {
+			return fmt.Errorf("smtp: starttls error %w", err)
+		}
+	}
+
+	if ch.auth != nil {
+		if err := c.Auth(ch.auth); err != nil {
+			return fmt.Errorf("smtp: auth error %w", err)
+		}
+	}
+
+	if err := c.Mail(ch.from); err != nil {
+		return fmt.Errorf("smtp: mail error %w", err)
+	}
+
+	for _, to := range ch.to {
+		if err := c.Rcpt(to); err != nil {
+			return fmt.Errorf("smtp: rcpt %s error %w", to, err)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("smtp: data error %w", err)
+	}
+
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("smtp: write message error %w", err)
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp: send message error %w", err)
+	}
+
+	return c.Quit()
+}
+
+func (ch *smtpChannel) message(n Notification) ([]byte, error) {
+	subject, err := render(ch.subject, n)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := render(ch.body, n)
+	if err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", ch.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(ch.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", strings.TrimSpace(string(subject))))
+	fmt.Fprintf(&msg, "Date: %s\r\n", n.CreatedAt.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(string(body), "\n", "\r\n"))
+
+	return msg.Bytes(), nil
+}
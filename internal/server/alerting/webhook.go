@@ -0,0 +1,79 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"text/template"
+)
+
+// defaultWebhookBody sends the notification as is
+const defaultWebhookBody = `{{ json . }}`
+
+// WebhookConfig of the webhook channel, the body is text/template of the notification which must render to JSON
+type WebhookConfig struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+type webhookChannel struct {
+	url     string
+	headers map[string]string
+	body    *template.Template
+	client  *http.Client
+}
+
+func newWebhookChannel(cfg WebhookConfig) (*webhookChannel, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid url %q", cfg.URL)
+	}
+
+	body, err := parseTemplate("body", cfg.Body, defaultWebhookBody)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webhookChannel{url: cfg.URL, headers: cfg.Headers, body: body, client: &http.Client{}}, nil
+}
+
+func (ch *webhookChannel) Send(ctx context.Context, n Notification) error {
+	body, err := render(ch.body, n)
+	if err != nil {
+		return err
+	}
+
+	if !json.Valid(body) {
+		return errors.New("webhook: rendered body is not a valid JSON")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ch.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook: build request error %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range ch.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := ch.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: send request error %w", err)
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook: unexpected status %d", resp.StatusCode)
+	}
+
+	return nil
+}
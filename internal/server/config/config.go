@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"github.com/caarlos0/env"
 	"go.uber.org/zap"
@@ -32,6 +33,30 @@ type Config struct {
 	ConfigPath      string    `json:"config_path" env:"CONFIG" envDefault:""`
 	TrustedSubnet   string    `json:"trusted_subnet" env:"TRUSTED_SUBNET" envDefault:""`
 	GRPCPort        string    `json:"grpc_port" env:"GRPC_PORT" envDefault:"3200"`
+	TLSCert         string    `json:"tls_cert" env:"TLS_CERT"`           // Path to the server certificate, enables HTTPS
+	TLSKey          string    `json:"tls_key" env:"TLS_KEY"`             // Path to the server certificate private key
+	TLSClientCA     string    `json:"tls_client_ca" env:"TLS_CLIENT_CA"` // Path to the CA used to verify client certificates
+
+	IdempotencyWindow time.Duration `json:"idempotency_window" env:"IDEMPOTENCY_WINDOW" envDefault:"5m"` // How long applied idempotency keys are remembered
+
+	AgentProfilesPath string `json:"agent_profiles_path" env:"AGENT_PROFILES_PATH"` // File to persist agent config profiles, profiles are kept in memory when empty
+
+	HistorySize        int           `json:"history_size" env:"HISTORY_SIZE" envDefault:"1000"`               // Samples kept per series by memory and file storages
+	Retention          string        `json:"retention" env:"RETENTION" envDefault:"raw:48h,1m:720h,1h:8760h"` // Comma separated <resolution>:<ttl> tiers of the samples history
+	CompactionInterval time.Duration `json:"compaction_interval" env:"COMPACTION_INTERVAL" envDefault:"1m"`   // How often rollups are built and expired samples are deleted
+
+	AlertRulesPath          string        `json:"alert_rules_path" env:"ALERT_RULES_PATH"`                                                      // File with alerting rules, rules are kept in memory when empty
+	AlertStatePath          string        `json:"alert_state_path" env:"ALERT_STATE_PATH" envDefault:"data/alerts.json"`                        // File to persist the state of the alerts, the state is kept in memory when empty
+	AlertEvaluationInterval time.Duration `json:"alert_evaluation_interval" env:"ALERT_EVALUATION_INTERVAL" envDefault:"15s"`                   // How often alerting rules are evaluated, 0 - disabled
+	NotificationsPath       string        `json:"notifications_path" env:"NOTIFICATIONS_PATH"`                                                  // File with notification channels and routes, notifications are disabled when empty
+	NotificationOutboxPath  string        `json:"notification_outbox_path" env:"NOTIFICATION_OUTBOX_PATH" envDefault:"data/notifications.json"` // File to persist undelivered notifications, the outbox is kept in memory when empty
+
+	MaxBatchSize int   `json:"max_batch_size" env:"MAX_BATCH_SIZE"` // Max metrics in a single batch, 0 - unlimited
+	MaxBodyBytes int64 `json:"max_body_bytes" env:"MAX_BODY_BYTES"` // Max uncompressed request body size, 0 - unlimited, gzip bodies are capped at 32 MiB then
+
+	NATSURL     string `json:"nats_url" env:"NATS_URL"`                                       // Comma separated nats servers, the consumer is disabled when empty
+	NATSSubject string `json:"nats_subject" env:"NATS_SUBJECT" envDefault:"metrics.updates"`  // Subject agents publish metric batches to
+	NATSQueue   string `json:"nats_queue" env:"NATS_QUEUE" envDefault:"mem_stats_monitoring"` // Stream and durable consumer balancing batches between servers
 }
 
 func (c *Config) LLevel() zapcore.Level {
@@ -62,7 +87,16 @@ func NewConfig(fc FileConfigurer) (*Config, error) {
 	return c, nil
 }
 
-const defaultStoreInterval = 300
+const (
+	defaultStoreInterval      = 300
+	defaultIdempotencyWindow  = 5 * time.Minute
+	defaultHistorySize        = 1000
+	defaultRetention          = "raw:48h,1m:720h,1h:8760h"
+	defaultCompactionInterval = time.Minute
+	defaultAlertStatePath     = "data/alerts.json"
+	defaultAlertEvaluation    = 15 * time.Second
+	defaultNotificationOutbox = "data/notifications.json"
+)
 
 func (c *Config) parseConfigFile(fc FileConfigurer) error {
 	if c.ConfigPath == "" {
@@ -120,11 +154,88 @@ func (c *Config) parseFlags() error {
 		flag.StringVar(&c.GRPCPort, "grpc-port", "3200", "grpc port")
 	}
 
+	if flag.Lookup("tls-cert") == nil {
+		flag.StringVar(&c.TLSCert, "tls-cert", "", "path to the server tls certificate")
+	}
+
+	if flag.Lookup("tls-key") == nil {
+		flag.StringVar(&c.TLSKey, "tls-key", "", "path to the server tls private key")
+	}
+
+	if flag.Lookup("tls-client-ca") == nil {
+		flag.StringVar(&c.TLSClientCA, "tls-client-ca", "", "path to the ca certificate to verify agents")
+	}
+
+	if flag.Lookup("idempotency-window") == nil {
+		flag.DurationVar(&c.IdempotencyWindow, "idempotency-window", defaultIdempotencyWindow, "how long to remember idempotency keys of applied counter updates")
+	}
+
+	if flag.Lookup("agent-profiles") == nil {
+		flag.StringVar(&c.AgentProfilesPath, "agent-profiles", "", "file to persist agent config profiles")
+	}
+
+	if flag.Lookup("history-size") == nil {
+		flag.IntVar(&c.HistorySize, "history-size", defaultHistorySize, "samples kept per series by memory and file storages")
+	}
+
+	if flag.Lookup("retention") == nil {
+		flag.StringVar(&c.Retention, "retention", defaultRetention, "comma separated <resolution>:<ttl> retention tiers of the samples history")
+	}
+
+	if flag.Lookup("compaction-interval") == nil {
+		flag.DurationVar(&c.CompactionInterval, "compaction-interval", defaultCompactionInterval, "how often rollups are built and expired samples are deleted")
+	}
+
+	if flag.Lookup("alert-rules") == nil {
+		flag.StringVar(&c.AlertRulesPath, "alert-rules", "", "file with alerting rules")
+	}
+
+	if flag.Lookup("alert-state") == nil {
+		flag.StringVar(&c.AlertStatePath, "alert-state", defaultAlertStatePath, "file to persist the state of the alerts")
+	}
+
+	if flag.Lookup("alert-evaluation-interval") == nil {
+		flag.DurationVar(&c.AlertEvaluationInterval, "alert-evaluation-interval", defaultAlertEvaluation, "how often alerting rules are evaluated, 0 - disabled")
+	}
+
+	if flag.Lookup("notifications") == nil {
+		flag.StringVar(&c.NotificationsPath, "notifications", "", "file with notification channels and routes")
+	}
+
+	if flag.Lookup("notification-outbox") == nil {
+		flag.StringVar(&c.NotificationOutboxPath, "notification-outbox", defaultNotificationOutbox, "file to persist undelivered notifications")
+	}
+
+	if flag.Lookup("max-batch-size") == nil {
+		flag.IntVar(&c.MaxBatchSize, "max-batch-size", 0, "max metrics in a single batch, 0 - unlimited")
+	}
+
+	if flag.Lookup("max-body-bytes") == nil {
+		flag.Int64Var(&c.MaxBodyBytes, "max-body-bytes", 0, "max uncompressed request body size, 0 - unlimited, gzip bodies are capped at 32 MiB then")
+	}
+
+	if flag.Lookup("nats-url") == nil {
+		flag.StringVar(&c.NATSURL, "nats-url", "", "comma separated nats servers to consume metric batches from")
+	}
+
+	if flag.Lookup("nats-subject") == nil {
+		flag.StringVar(&c.NATSSubject, "nats-subject", "metrics.updates", "nats subject agents publish metric batches to")
+	}
+
+	if flag.Lookup("nats-queue") == nil {
+		flag.StringVar(&c.NATSQueue, "nats-queue", "mem_stats_monitoring", "nats jetstream stream and durable consumer balancing metric batches between servers")
+	}
+
 	flag.Parse()
 
 	return nil
 }
 
+// IsTLSEnabled checks if the server certificate is configured
+func (c *Config) IsTLSEnabled() bool {
+	return c.TLSCert != "" && c.TLSKey != ""
+}
+
 // IsDBDSNPresent checks if a database connection string is configured
 func (c *Config) IsDBDSNPresent() bool {
 	return c.DatabaseDSN != ""
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"time"
 )
 
 type FileConfig struct {
@@ -14,6 +15,27 @@ type FileConfig struct {
 	DatabaseDSN     string `json:"database_dsn"`
 	PrivateKey      string `json:"crypto_key"`
 	TrustedSubnet   string `json:"trusted_subnet"`
+	TLSCert         string `json:"tls_cert"`
+	TLSKey          string `json:"tls_key"`
+	TLSClientCA     string `json:"tls_client_ca"`
+
+	AgentProfilesPath  string `json:"agent_profiles_path"`
+	HistorySize        int    `json:"history_size"`
+	Retention          string `json:"retention"`
+	CompactionInterval string `json:"compaction_interval"`
+
+	AlertRulesPath          string `json:"alert_rules_path"`
+	AlertStatePath          string `json:"alert_state_path"`
+	AlertEvaluationInterval string `json:"alert_evaluation_interval"`
+	NotificationsPath       string `json:"notifications_path"`
+	NotificationOutboxPath  string `json:"notification_outbox_path"`
+
+	MaxBatchSize int   `json:"max_batch_size"`
+	MaxBodyBytes int64 `json:"max_body_bytes"`
+
+	NATSURL     string `json:"nats_url"`
+	NATSSubject string `json:"nats_subject"`
+	NATSQueue   string `json:"nats_queue"`
 }
 
 func NewFileConfig() *FileConfig {
@@ -55,5 +77,81 @@ func (f *FileConfig) Configure(c *Config, source io.Reader) error {
 		c.TrustedSubnet = ts
 	}
 
+	if c.TLSCert == "" && f.TLSCert != "" {
+		c.TLSCert = f.TLSCert
+	}
+
+	if c.TLSKey == "" && f.TLSKey != "" {
+		c.TLSKey = f.TLSKey
+	}
+
+	if c.TLSClientCA == "" && f.TLSClientCA != "" {
+		c.TLSClientCA = f.TLSClientCA
+	}
+
+	if c.AgentProfilesPath == "" && f.AgentProfilesPath != "" {
+		c.AgentProfilesPath = f.AgentProfilesPath
+	}
+
+	if c.HistorySize == 0 && f.HistorySize != 0 {
+		c.HistorySize = f.HistorySize
+	}
+
+	if c.Retention == "" && f.Retention != "" {
+		c.Retention = f.Retention
+	}
+
+	if c.CompactionInterval == 0 && f.CompactionInterval != "" {
+		interval, err := time.ParseDuration(f.CompactionInterval)
+		if err != nil {
+			return fmt.Errorf("config: failed to parse compaction interval: %w", err)
+		}
+		c.CompactionInterval = interval
+	}
+
+	if c.AlertRulesPath == "" && f.AlertRulesPath != "" {
+		c.AlertRulesPath = f.AlertRulesPath
+	}
+
+	if c.AlertStatePath == "" && f.AlertStatePath != "" {
+		c.AlertStatePath = f.AlertStatePath
+	}
+
+	if c.AlertEvaluationInterval == 0 && f.AlertEvaluationInterval != "" {
+		interval, err := time.ParseDuration(f.AlertEvaluationInterval)
+		if err != nil {
+			return fmt.Errorf("config: failed to parse alert evaluation interval: %w", err)
+		}
+		c.AlertEvaluationInterval = interval
+	}
+
+	if c.NotificationsPath == "" && f.NotificationsPath != "" {
+		c.NotificationsPath = f.NotificationsPath
+	}
+
+	if c.NotificationOutboxPath == "" && f.NotificationOutboxPath != "" {
+		c.NotificationOutboxPath = f.NotificationOutboxPath
+	}
+
+	if c.MaxBatchSize == 0 && f.MaxBatchSize != 0 {
+		c.MaxBatchSize = f.MaxBatchSize
+	}
+
+	if c.MaxBodyBytes == 0 && f.MaxBodyBytes != 0 {
+		c.MaxBodyBytes = f.MaxBodyBytes
+	}
+
+	if c.NATSURL == "" && f.NATSURL != "" {
+		c.NATSURL = f.NATSURL
+	}
+
+	if c.NATSSubject == "" && f.NATSSubject != "" {
+		c.NATSSubject = f.NATSSubject
+	}
+
+	if c.NATSQueue == "" && f.NATSQueue != "" {
+		c.NATSQueue = f.NATSQueue
+	}
+
 	return nil
 }
@@ -88,6 +88,59 @@ func TestFileConfig_Configure(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "with retention",
+			fields: fields{
+				source: bytes.NewBufferString(`{
+					"retention": "raw:24h,5m:720h",
+					"compaction_interval": "30s"
+				}`),
+			},
+			args: args{
+				c: &Config{},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid compaction interval",
+			fields: fields{
+				source: bytes.NewBufferString(`{
+					"compaction_interval": "often"
+				}`),
+			},
+			args: args{
+				c: &Config{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "with alerting",
+			fields: fields{
+				source: bytes.NewBufferString(`{
+					"alert_rules_path": "rules.json",
+					"alert_state_path": "alerts.json",
+					"alert_evaluation_interval": "30s",
+					"notifications_path": "notifications.json",
+					"notification_outbox_path": "outbox.json"
+				}`),
+			},
+			args: args{
+				c: &Config{},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid alert evaluation interval",
+			fields: fields{
+				source: bytes.NewBufferString(`{
+					"alert_evaluation_interval": "often"
+				}`),
+			},
+			args: args{
+				c: &Config{},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
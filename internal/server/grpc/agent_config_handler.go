@@ -0,0 +1,58 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/service"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"github.com/vysogota0399/mem_stats_monitoring/pkg/gen/services/metrics"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type IAgentConfigService interface {
+	Resolve(req service.AgentConfigRequest) (service.AgentProfile, error)
+}
+
+var _ IAgentConfigService = (*service.AgentProfiles)(nil)
+
+type AgentConfigHandler struct {
+	service IAgentConfigService
+	lg      *logging.ZapLogger
+}
+
+func NewAgentConfigHandler(srvc IAgentConfigService, lg *logging.ZapLogger) *AgentConfigHandler {
+	return &AgentConfigHandler{service: srvc, lg: lg}
+}
+
+// AgentConfig returns the config profile assigned to the agent, the agent reports the version it is running
+func (h *AgentConfigHandler) AgentConfig(ctx context.Context, params *metrics.AgentConfigParams) (*metrics.AgentConfigResponse, error) {
+	ctx = h.lg.WithContextFields(ctx, zap.String("handler", "agent_config_handler"))
+
+	if params.GetAgentId() == "" && params.GetHost() == "" {
+		return nil, status.Error(codes.InvalidArgument, "agent id or host is required")
+	}
+
+	profile, err := h.service.Resolve(service.AgentConfigRequest{
+		AgentID:        params.GetAgentId(),
+		Host:           params.GetHost(),
+		RunningVersion: params.GetRunningVersion(),
+		ApplyError:     params.GetApplyError(),
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrProfileNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+
+		h.lg.ErrorCtx(ctx, "resolve agent profile error", zap.Error(err))
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &metrics.AgentConfigResponse{
+		Profile: profile.Name,
+		Version: profile.Version,
+		Config:  profile.Config,
+	}, nil
+}
@@ -0,0 +1,80 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/service"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"github.com/vysogota0399/mem_stats_monitoring/pkg/gen/services/metrics"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAgentConfigHandler_AgentConfig(t *testing.T) {
+	lg, err := logging.NewZapLogger(&config.Config{LogLevel: -1})
+	require.NoError(t, err)
+
+	cfg := &config.Config{
+		GRPCPort: "3200",
+	}
+
+	profiles, err := service.NewAgentProfiles(cfg)
+	require.NoError(t, err)
+
+	_, err = profiles.Put(service.AgentProfile{Name: "db", Match: []string{"db-*"}, Config: json.RawMessage(`{"poll_interval":5}`)})
+	require.NoError(t, err)
+
+	th := NewTestHandler(t, func(h *TestHandler) {
+		h.AgentConfigHandler = *NewAgentConfigHandler(profiles, lg)
+	})
+
+	RunTestServer(t, cfg, lg, th)
+	client := NewTestClient(t, cfg)
+
+	tests := []struct {
+		name     string
+		params   *metrics.AgentConfigParams
+		want     *metrics.AgentConfigResponse
+		wantCode codes.Code
+	}{
+		{
+			name:   "when profile is assigned",
+			params: &metrics.AgentConfigParams{AgentId: "agent", Host: "db-1"},
+			want: &metrics.AgentConfigResponse{
+				Profile: "db",
+				Version: 1,
+				Config:  []byte(`{"poll_interval":5}`),
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "when no profile is assigned",
+			params:   &metrics.AgentConfigParams{AgentId: "web-1"},
+			wantCode: codes.NotFound,
+		},
+		{
+			name:     "when agent is not identified",
+			params:   &metrics.AgentConfigParams{},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.AgentConfig(context.Background(), tt.params)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+
+			if tt.want != nil {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want.GetProfile(), resp.GetProfile())
+				assert.Equal(t, tt.want.GetVersion(), resp.GetVersion())
+				assert.JSONEq(t, string(tt.want.GetConfig()), string(resp.GetConfig()))
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/capabilities"
+	"github.com/vysogota0399/mem_stats_monitoring/pkg/gen/services/metrics"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type CapabilitiesHandler struct {
+	caps capabilities.Capabilities
+}
+
+func NewCapabilitiesHandler(caps capabilities.Capabilities) *CapabilitiesHandler {
+	return &CapabilitiesHandler{caps: caps}
+}
+
+// Capabilities advertises api versions, features and limits of the server
+func (h *CapabilitiesHandler) Capabilities(ctx context.Context, params *emptypb.Empty) (*metrics.CapabilitiesResponse, error) {
+	return &metrics.CapabilitiesResponse{
+		ApiVersions:  h.caps.APIVersions,
+		Features:     h.caps.Features,
+		Encodings:    h.caps.Encodings,
+		Compression:  h.caps.Compression,
+		Encryption:   h.caps.Encryption,
+		Signatures:   h.caps.Signatures,
+		MaxBatchSize: int64(h.caps.MaxBatchSize),
+		MaxBodyBytes: h.caps.MaxBodyBytes,
+		GrpcPort:     h.caps.GRPCPort,
+	}, nil
+}
@@ -0,0 +1,40 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/service"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestCapabilitiesHandler_Capabilities(t *testing.T) {
+	lg, err := logging.NewZapLogger(&config.Config{LogLevel: -1})
+	require.NoError(t, err)
+
+	cfg := &config.Config{
+		GRPCPort:     "3200",
+		MaxBatchSize: 100,
+		MaxBodyBytes: 1 << 20,
+	}
+
+	th := NewTestHandler(t, func(h *TestHandler) {
+		h.CapabilitiesHandler = *NewCapabilitiesHandler(service.NewCapabilities(cfg))
+	})
+
+	RunTestServer(t, cfg, lg, th)
+	client := NewTestClient(t, cfg)
+
+	resp, err := client.Capabilities(context.Background(), &emptypb.Empty{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"v1"}, resp.GetApiVersions())
+	assert.Contains(t, resp.GetFeatures(), "batch")
+	assert.Equal(t, int64(100), resp.GetMaxBatchSize())
+	assert.Equal(t, int64(1<<20), resp.GetMaxBodyBytes())
+	assert.Equal(t, "3200", resp.GetGrpcPort())
+}
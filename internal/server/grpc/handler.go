@@ -3,7 +3,9 @@ package grpc
 import (
 	"context"
 
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/idempotency"
 	"github.com/vysogota0399/mem_stats_monitoring/pkg/gen/services/metrics"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -14,6 +16,9 @@ type Handler struct {
 	ShowHandler
 	UpdateBatchHandler
 	UpdateHandler
+	AgentConfigHandler
+	CapabilitiesHandler
+	HistoryHandler
 }
 
 func NewHandler(
@@ -22,13 +27,19 @@ func NewHandler(
 	showHandler *ShowHandler,
 	updateBatchHandler *UpdateBatchHandler,
 	updateHandler *UpdateHandler,
+	agentConfigHandler *AgentConfigHandler,
+	capabilitiesHandler *CapabilitiesHandler,
+	historyHandler *HistoryHandler,
 ) *Handler {
 	return &Handler{
-		PingHandler:        *pingHandler,
-		IndexHandler:       *indexHandler,
-		ShowHandler:        *showHandler,
-		UpdateBatchHandler: *updateBatchHandler,
-		UpdateHandler:      *updateHandler,
+		PingHandler:         *pingHandler,
+		IndexHandler:        *indexHandler,
+		ShowHandler:         *showHandler,
+		UpdateBatchHandler:  *updateBatchHandler,
+		UpdateHandler:       *updateHandler,
+		AgentConfigHandler:  *agentConfigHandler,
+		CapabilitiesHandler: *capabilitiesHandler,
+		HistoryHandler:      *historyHandler,
 	}
 }
 
@@ -51,3 +62,29 @@ func (h *Handler) UpdateBatch(ctx context.Context, params *metrics.UpdateMetrics
 func (h *Handler) Update(ctx context.Context, params *metrics.UpdateMetricParams) (*emptypb.Empty, error) {
 	return h.UpdateHandler.Update(ctx, params)
 }
+
+func (h *Handler) AgentConfig(ctx context.Context, params *metrics.AgentConfigParams) (*metrics.AgentConfigResponse, error) {
+	return h.AgentConfigHandler.AgentConfig(ctx, params)
+}
+
+func (h *Handler) Capabilities(ctx context.Context, params *emptypb.Empty) (*metrics.CapabilitiesResponse, error) {
+	return h.CapabilitiesHandler.Capabilities(ctx, params)
+}
+
+func (h *Handler) History(ctx context.Context, params *metrics.HistoryParams) (*metrics.HistoryResponse, error) {
+	return h.HistoryHandler.History(ctx, params)
+}
+
+// withIdempotencyKey moves idempotency key from the incoming metadata to the context
+func withIdempotencyKey(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	if vals := md.Get(idempotency.MetadataKey); len(vals) > 0 {
+		return idempotency.WithKey(ctx, vals[0])
+	}
+
+	return ctx
+}
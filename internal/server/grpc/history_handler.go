@@ -0,0 +1,86 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/service"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"github.com/vysogota0399/mem_stats_monitoring/pkg/gen/entities"
+	"github.com/vysogota0399/mem_stats_monitoring/pkg/gen/services/metrics"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type IHistoryService interface {
+	Call(context.Context, service.HistoryParams) (service.HistoryResult, error)
+}
+
+var _ IHistoryService = (*service.HistoryService)(nil)
+
+type HistoryHandler struct {
+	lg      *logging.ZapLogger
+	service IHistoryService
+}
+
+func NewHistoryHandler(lg *logging.ZapLogger, service IHistoryService) *HistoryHandler {
+	return &HistoryHandler{lg: lg, service: service}
+}
+
+// History returns samples of the series over the range, unset from, to and step have the http api defaults.
+// Resolution of the response is the retention tier the samples were read from, zero means raw samples.
+func (h *HistoryHandler) History(ctx context.Context, params *metrics.HistoryParams) (*metrics.HistoryResponse, error) {
+	ctx = h.lg.WithContextFields(ctx, zap.String("handler", "history_handler"))
+
+	var mType string
+	switch params.MType {
+	case entities.MetricTypes_COUNTER:
+		mType = models.CounterType
+	case entities.MetricTypes_GAUGE:
+		mType = models.GaugeType
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unexpected type(%s)", params.MType)
+	}
+
+	query := service.HistoryParams{
+		MName: params.Name,
+		MType: mType,
+		Step:  params.Step.AsDuration(),
+	}
+	if params.From != nil {
+		query.From = params.From.AsTime()
+	}
+	if params.To != nil {
+		query.To = params.To.AsTime()
+	}
+
+	res, err := h.service.Call(ctx, query)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidHistoryParams) || errors.Is(err, service.ErrTooManyPoints) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		h.lg.ErrorCtx(ctx, "history query error", zap.Error(err))
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &metrics.HistoryResponse{
+		Samples:    make([]*metrics.Sample, 0, len(res.Samples)),
+		Resolution: durationpb.New(res.Resolution),
+	}
+	for _, s := range res.Samples {
+		resp.Samples = append(resp.Samples, &metrics.Sample{
+			Timestamp: timestamppb.New(s.Timestamp),
+			Value:     s.Value,
+			Min:       s.Min,
+			Max:       s.Max,
+			Count:     s.Count,
+		})
+	}
+
+	return resp, nil
+}
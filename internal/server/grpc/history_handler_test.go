@@ -0,0 +1,111 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/service"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/storages"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"github.com/vysogota0399/mem_stats_monitoring/pkg/gen/entities"
+	"github.com/vysogota0399/mem_stats_monitoring/pkg/gen/services/metrics"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestHistoryHandler_History(t *testing.T) {
+	lg, err := logging.NewZapLogger(&config.Config{LogLevel: -1})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	retention, err := storages.ParseRetention("raw:48h,1m:720h")
+	require.NoError(t, err)
+
+	strg := storages.NewMemory(lg)
+	require.NoError(t, strg.IncrementCounter(ctx, "poll_count", 3))
+	require.NoError(t, strg.IncrementCounter(ctx, "poll_count", 4))
+	require.NoError(t, strg.Compact(ctx, retention, time.Now().Add(time.Minute)))
+
+	cfg := &config.Config{GRPCPort: "3200"}
+	th := NewTestHandler(t, func(h *TestHandler) {
+		h.HistoryHandler = *NewHistoryHandler(lg, service.NewHistoryService(strg, retention))
+	})
+
+	RunTestServer(t, cfg, lg, th)
+	client := NewTestClient(t, cfg)
+
+	tests := []struct {
+		name           string
+		params         *metrics.HistoryParams
+		wantValues     []float64
+		wantResolution time.Duration
+		wantCode       codes.Code
+	}{
+		{
+			name:       "raw samples of the last hour",
+			params:     &metrics.HistoryParams{Name: "poll_count", MType: entities.MetricTypes_COUNTER},
+			wantValues: []float64{3, 7},
+		},
+		{
+			name: "samples resampled by the step",
+			params: &metrics.HistoryParams{
+				Name:  "poll_count",
+				MType: entities.MetricTypes_COUNTER,
+				From:  timestamppb.New(time.Now().Add(-time.Minute)),
+				To:    timestamppb.New(time.Now().Add(time.Minute)),
+				Step:  durationpb.New(time.Hour),
+			},
+			wantValues: []float64{7},
+		},
+		{
+			name: "rollups of the range older than raw samples",
+			params: &metrics.HistoryParams{
+				Name:  "poll_count",
+				MType: entities.MetricTypes_COUNTER,
+				From:  timestamppb.New(time.Now().Add(-72 * time.Hour)),
+			},
+			wantValues:     []float64{7},
+			wantResolution: time.Minute,
+		},
+		{
+			name:     "unexpected type",
+			params:   &metrics.HistoryParams{Name: "poll_count", MType: entities.MetricTypes(42)},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "from is after to",
+			params: &metrics.HistoryParams{
+				Name:  "poll_count",
+				MType: entities.MetricTypes_COUNTER,
+				From:  timestamppb.New(time.Now()),
+				To:    timestamppb.New(time.Now().Add(-time.Hour)),
+			},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.History(ctx, tt.params)
+			if tt.wantCode != codes.OK {
+				assert.Equal(t, tt.wantCode, status.Code(err))
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantResolution, resp.GetResolution().AsDuration())
+
+			values := make([]float64, 0, len(resp.GetSamples()))
+			for _, s := range resp.GetSamples() {
+				values = append(values, s.GetValue())
+			}
+			assert.Equal(t, tt.wantValues, values)
+		})
+	}
+}
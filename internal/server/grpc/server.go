@@ -3,13 +3,14 @@ package grpc
 import (
 	"context"
 	"fmt"
-	"net"
+	"sync/atomic"
 
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
 	"google.golang.org/grpc"
 
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
 	lg "github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/systemd"
 	"github.com/vysogota0399/mem_stats_monitoring/pkg/gen/services/metrics"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
@@ -19,6 +20,7 @@ type Server struct {
 	cfg        *config.Config
 	lg         *lg.ZapLogger
 	grpcServer *grpc.Server
+	serving    atomic.Bool
 }
 
 var _ metrics.MetricsServiceServer = (*Handler)(nil)
@@ -28,6 +30,7 @@ func NewServer(
 	cfg *config.Config,
 	lg *lg.ZapLogger,
 	handler metrics.MetricsServiceServer,
+	sockets *systemd.Sockets,
 ) *Server {
 	srv := &Server{
 		cfg: cfg,
@@ -37,19 +40,27 @@ func NewServer(
 	lc.Append(
 		fx.Hook{
 			OnStart: func(ctx context.Context) error {
-				ln, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+				ln, err := sockets.Listen(ctx, "grpc", ":"+cfg.GRPCPort)
 				if err != nil {
 					return fmt.Errorf("server: failed to create listener on port %s error %w", cfg.GRPCPort, err)
 				}
 
-				srv.grpcServer = grpc.NewServer(
+				opts := []grpc.ServerOption{
 					grpc.ChainUnaryInterceptor(
 						logging.UnaryServerInterceptor(InterceptorLogger(zap.NewExample())),
 					),
-				)
+				}
+				if cfg.MaxBodyBytes > 0 {
+					opts = append(opts, grpc.MaxRecvMsgSize(int(cfg.MaxBodyBytes)))
+				}
+
+				srv.grpcServer = grpc.NewServer(opts...)
 
 				metrics.RegisterMetricsServiceServer(srv.grpcServer, handler)
+				srv.serving.Store(true)
 				go func() {
+					defer srv.serving.Store(false)
+
 					if err := srv.grpcServer.Serve(ln); err != nil {
 						srv.lg.ErrorCtx(ctx, "server: serve failer error", zap.Error(err))
 					}
@@ -66,3 +77,8 @@ func NewServer(
 
 	return srv
 }
+
+// Serving reports whether the server accepts connections
+func (s *Server) Serving() bool {
+	return s.serving.Load()
+}
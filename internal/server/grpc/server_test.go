@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/systemd"
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxtest"
 )
@@ -43,7 +44,7 @@ func TestNewServer(t *testing.T) {
 				fx.Populate(&l, &s),
 			)
 
-			got := NewServer(l, tt.args.cfg, lg, &Handler{})
+			got := NewServer(l, tt.args.cfg, lg, &Handler{}, &systemd.Sockets{})
 			assert.NotNil(t, got)
 
 			err := app.Start(context.Background())
@@ -6,6 +6,7 @@ import (
 
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/systemd"
 	"github.com/vysogota0399/mem_stats_monitoring/pkg/gen/services/metrics"
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxtest"
@@ -24,7 +25,7 @@ func RunTestServer(t testing.TB, config *config.Config, lg *logging.ZapLogger, h
 		fx.Populate(&l, &s),
 	)
 
-	NewServer(l, config, lg, h)
+	NewServer(l, config, lg, h, &systemd.Sockets{})
 
 	ctx := context.Background()
 	if err := app.Start(ctx); err != nil {
@@ -52,6 +53,9 @@ func NewTestHandler(t testing.TB, opts ...TestHandlerOpt) *TestHandler {
 		&ShowHandler{},
 		&UpdateBatchHandler{},
 		&UpdateHandler{},
+		&AgentConfigHandler{},
+		&CapabilitiesHandler{},
+		&HistoryHandler{},
 	)
 	h := &TestHandler{Handler: base}
 	for _, f := range opts {
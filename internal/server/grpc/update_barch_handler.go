@@ -2,6 +2,7 @@ package grpc
 
 import (
 	"context"
+	"errors"
 
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/models"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/service"
@@ -30,17 +31,27 @@ func NewUpdateBatchHandler(lg *logging.ZapLogger, service IUpdateMetricsService)
 
 func (h *UpdateBatchHandler) UpdateBatch(ctx context.Context, params *metrics.UpdateMetricsBatchParams) (*emptypb.Empty, error) {
 	ctx = h.lg.WithContextFields(ctx, zap.String("handler", "update_batch_handler"))
+	ctx = withIdempotencyKey(ctx)
 
-	_, err := h.service.Call(ctx, h.prepareParams(params))
+	_, err := h.service.Call(ctx, BatchServiceParams(params))
 	if err != nil {
 		h.lg.ErrorCtx(ctx, "update metrics batch error", zap.Error(err))
+		if errors.Is(err, service.ErrRequestInProgress) {
+			return &emptypb.Empty{}, status.Error(codes.Aborted, err.Error())
+		}
+
+		if errors.Is(err, service.ErrBatchTooLarge) {
+			return &emptypb.Empty{}, status.Error(codes.ResourceExhausted, err.Error())
+		}
+
 		return &emptypb.Empty{}, status.Error(codes.InvalidArgument, err.Error())
 	}
 
 	return &emptypb.Empty{}, nil
 }
 
-func (h *UpdateBatchHandler) prepareParams(params *metrics.UpdateMetricsBatchParams) service.UpdateMetricsServiceParams {
+// BatchServiceParams converts the protobuf batch to the update metrics service params
+func BatchServiceParams(params *metrics.UpdateMetricsBatchParams) service.UpdateMetricsServiceParams {
 	elements := make([]service.UpdateMetricsServiceEl, len(params.Item))
 
 	for i, item := range params.Item {
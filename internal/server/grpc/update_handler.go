@@ -2,6 +2,7 @@ package grpc
 
 import (
 	"context"
+	"errors"
 
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/models"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/service"
@@ -30,6 +31,7 @@ func NewUpdateHandler(lg *logging.ZapLogger, service IUpdateMetricService) *Upda
 
 func (h *UpdateHandler) Update(ctx context.Context, params *metrics.UpdateMetricParams) (*emptypb.Empty, error) {
 	ctx = h.lg.WithContextFields(ctx, zap.String("handler", "update_handler"))
+	ctx = withIdempotencyKey(ctx)
 
 	var serviceParams service.UpdateMetricServiceParams
 
@@ -51,6 +53,10 @@ func (h *UpdateHandler) Update(ctx context.Context, params *metrics.UpdateMetric
 	_, err := h.service.Call(ctx, serviceParams)
 	if err != nil {
 		h.lg.ErrorCtx(ctx, "error updating metric", zap.Error(err))
+		if errors.Is(err, service.ErrRequestInProgress) {
+			return &emptypb.Empty{}, status.Error(codes.Aborted, err.Error())
+		}
+
 		return &emptypb.Empty{}, status.Error(codes.InvalidArgument, err.Error())
 	}
 
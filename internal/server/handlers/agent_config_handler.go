@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/service"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"go.uber.org/zap"
+)
+
+type IAgentConfigService interface {
+	Resolve(req service.AgentConfigRequest) (service.AgentProfile, error)
+}
+
+var _ IAgentConfigService = (*service.AgentProfiles)(nil)
+
+// AgentConfigHandler returns the config profile assigned to the agent, the agent reports the version it is running
+type AgentConfigHandler struct {
+	service IAgentConfigService
+	lg      *logging.ZapLogger
+}
+
+func NewAgentConfigHandler(srvc IAgentConfigService, lg *logging.ZapLogger) *AgentConfigHandler {
+	return &AgentConfigHandler{
+		service: srvc,
+		lg:      lg,
+	}
+}
+
+func (h *AgentConfigHandler) Registrate() (server.Route, error) {
+	return server.Route{
+		Path:    "/agent/config",
+		Method:  "POST",
+		Handler: h.handler(),
+	}, nil
+}
+
+type agentConfigParams struct {
+	AgentID        string `json:"agent_id"`
+	Host           string `json:"host"`
+	RunningVersion int64  `json:"running_version"` // 0 when the agent runs its local config
+	ApplyError     string `json:"apply_error"`     // why the agent failed to apply the last fetched version
+}
+
+type agentConfigResponse struct {
+	Profile string          `json:"profile"`
+	Version int64           `json:"version"`
+	Config  json.RawMessage `json:"config"`
+}
+
+func (h *AgentConfigHandler) handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := utils.InitHandlerCtx(c, h.lg, "agent_config_handler")
+
+		var params agentConfigParams
+		if err := c.ShouldBindJSON(&params); err != nil || (params.AgentID == "" && params.Host == "") {
+			h.lg.DebugCtx(ctx, "invalid params", zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{})
+			return
+		}
+
+		profile, err := h.service.Resolve(service.AgentConfigRequest{
+			AgentID:        params.AgentID,
+			Host:           params.Host,
+			RunningVersion: params.RunningVersion,
+			ApplyError:     params.ApplyError,
+		})
+		if err != nil {
+			if errors.Is(err, service.ErrProfileNotFound) {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{})
+				return
+			}
+
+			h.lg.ErrorCtx(ctx, "resolve agent profile error", zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		c.JSON(http.StatusOK, agentConfigResponse{Profile: profile.Name, Version: profile.Version, Config: profile.Config})
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/service"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+)
+
+func TestAgentConfigHandler(t *testing.T) {
+	lg, err := logging.NewZapLogger(&config.Config{LogLevel: -1})
+	require.NoError(t, err)
+
+	profiles, err := service.NewAgentProfiles(&config.Config{})
+	require.NoError(t, err)
+
+	r := gin.Default()
+	r.PUT("/agent/profiles/:name", NewPutAgentProfileHandler(profiles, lg).handler())
+	r.DELETE("/agent/profiles/:name", NewDeleteAgentProfileHandler(profiles, lg).handler())
+	r.GET("/agent/profiles", NewListAgentProfilesHandler(profiles, lg).handler())
+	r.POST("/agent/config", NewAgentConfigHandler(profiles, lg).handler())
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	do := func(method, path, body string) (int, string) {
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+
+		resp, err := srv.Client().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return resp.StatusCode, string(b)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "when no profile is assigned",
+			method:     http.MethodPost,
+			path:       "/agent/config",
+			body:       `{"agent_id":"db-1"}`,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "when profile is invalid",
+			method:     http.MethodPut,
+			path:       "/agent/profiles/db",
+			body:       `{"match":[],"config":{}}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "when profile is stored",
+			method:     http.MethodPut,
+			path:       "/agent/profiles/db",
+			body:       `{"match":["db-*"],"config":{"poll_interval":5}}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "when agent is not identified",
+			method:     http.MethodPost,
+			path:       "/agent/config",
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "when profile is assigned",
+			method:     http.MethodPost,
+			path:       "/agent/config",
+			body:       `{"agent_id":"db-1","host":"host-1"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"profile":"db","version":1,"config":{"poll_interval":5}}`,
+		},
+		{
+			name:       "when unknown profile is deleted",
+			method:     http.MethodDelete,
+			path:       "/agent/profiles/web",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "when profile is deleted",
+			method:     http.MethodDelete,
+			path:       "/agent/profiles/db",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "when profile of the agent was deleted",
+			method:     http.MethodPost,
+			path:       "/agent/config",
+			body:       `{"agent_id":"db-1","running_version":1}`,
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := do(tt.method, tt.path, tt.body)
+			assert.Equal(t, tt.wantStatus, status)
+
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, body)
+			}
+		})
+	}
+
+	t.Run("list shows agents", func(t *testing.T) {
+		status, body := do(http.MethodGet, "/agent/profiles", "")
+		require.Equal(t, http.StatusOK, status)
+
+		var resp listAgentProfilesResponse
+		require.NoError(t, json.Unmarshal([]byte(body), &resp))
+		assert.Empty(t, resp.Profiles)
+		require.Len(t, resp.Agents, 1)
+		assert.Equal(t, "db-1", resp.Agents[0].ID)
+		assert.Equal(t, int64(1), resp.Agents[0].RunningVersion)
+	})
+}
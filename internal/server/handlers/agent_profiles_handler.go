@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/service"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"go.uber.org/zap"
+)
+
+type IAgentProfilesService interface {
+	List() []service.AgentProfile
+	Put(profile service.AgentProfile) (service.AgentProfile, error)
+	Delete(name string) error
+	Agents() []service.AgentState
+}
+
+var _ IAgentProfilesService = (*service.AgentProfiles)(nil)
+
+// ListAgentProfilesHandler shows profiles in the matching order and the config agents reported to run
+type ListAgentProfilesHandler struct {
+	service IAgentProfilesService
+	lg      *logging.ZapLogger
+}
+
+func NewListAgentProfilesHandler(srvc IAgentProfilesService, lg *logging.ZapLogger) *ListAgentProfilesHandler {
+	return &ListAgentProfilesHandler{service: srvc, lg: lg}
+}
+
+func (h *ListAgentProfilesHandler) Registrate() (server.Route, error) {
+	return server.Route{
+		Path:    "/agent/profiles",
+		Method:  "GET",
+		Handler: h.handler(),
+	}, nil
+}
+
+type listAgentProfilesResponse struct {
+	Profiles []service.AgentProfile `json:"profiles"`
+	Agents   []service.AgentState   `json:"agents"`
+}
+
+func (h *ListAgentProfilesHandler) handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, listAgentProfilesResponse{Profiles: h.service.List(), Agents: h.service.Agents()})
+	}
+}
+
+// PutAgentProfileHandler creates or replaces the profile
+type PutAgentProfileHandler struct {
+	service IAgentProfilesService
+	lg      *logging.ZapLogger
+}
+
+func NewPutAgentProfileHandler(srvc IAgentProfilesService, lg *logging.ZapLogger) *PutAgentProfileHandler {
+	return &PutAgentProfileHandler{service: srvc, lg: lg}
+}
+
+func (h *PutAgentProfileHandler) Registrate() (server.Route, error) {
+	return server.Route{
+		Path:    "/agent/profiles/:name",
+		Method:  "PUT",
+		Handler: h.handler(),
+	}, nil
+}
+
+type putAgentProfileParams struct {
+	Match  []string        `json:"match"`  // path.Match patterns of the agent id or host
+	Config json.RawMessage `json:"config"` // agent settings, validated by the agent
+}
+
+func (h *PutAgentProfileHandler) handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := utils.InitHandlerCtx(c, h.lg, "put_agent_profile_handler")
+
+		var params putAgentProfileParams
+		if err := c.ShouldBindJSON(&params); err != nil {
+			h.lg.DebugCtx(ctx, "invalid params", zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{})
+			return
+		}
+
+		profile, err := h.service.Put(service.AgentProfile{Name: c.Param("name"), Match: params.Match, Config: params.Config})
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidProfile) {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+
+			h.lg.ErrorCtx(ctx, "put agent profile error", zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		c.JSON(http.StatusOK, profile)
+	}
+}
+
+// DeleteAgentProfileHandler removes the profile
+type DeleteAgentProfileHandler struct {
+	service IAgentProfilesService
+	lg      *logging.ZapLogger
+}
+
+func NewDeleteAgentProfileHandler(srvc IAgentProfilesService, lg *logging.ZapLogger) *DeleteAgentProfileHandler {
+	return &DeleteAgentProfileHandler{service: srvc, lg: lg}
+}
+
+func (h *DeleteAgentProfileHandler) Registrate() (server.Route, error) {
+	return server.Route{
+		Path:    "/agent/profiles/:name",
+		Method:  "DELETE",
+		Handler: h.handler(),
+	}, nil
+}
+
+func (h *DeleteAgentProfileHandler) handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := utils.InitHandlerCtx(c, h.lg, "delete_agent_profile_handler")
+
+		if err := h.service.Delete(c.Param("name")); err != nil {
+			if errors.Is(err, service.ErrProfileNotFound) {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{})
+				return
+			}
+
+			h.lg.ErrorCtx(ctx, "delete agent profile error", zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		c.Status(http.StatusOK)
+	}
+}
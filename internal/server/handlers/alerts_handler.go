@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/alerting"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"go.uber.org/zap"
+)
+
+type IAlertRulesService interface {
+	List() []alerting.Rule
+	Put(rule alerting.Rule) (alerting.Rule, error)
+	Delete(name string) error
+}
+
+var _ IAlertRulesService = (*alerting.Rules)(nil)
+
+type IAlertsService interface {
+	Active() []alerting.Alert
+}
+
+var _ IAlertsService = (*alerting.Alerts)(nil)
+
+// ListAlertRulesHandler shows alerting rules
+type ListAlertRulesHandler struct {
+	service IAlertRulesService
+}
+
+func NewListAlertRulesHandler(srvc IAlertRulesService) *ListAlertRulesHandler {
+	return &ListAlertRulesHandler{service: srvc}
+}
+
+func (h *ListAlertRulesHandler) Registrate() (server.Route, error) {
+	return server.Route{
+		Path:    "/alerts/rules",
+		Method:  "GET",
+		Handler: h.handler(),
+	}, nil
+}
+
+type listAlertRulesResponse struct {
+	Rules []alerting.Rule `json:"rules"`
+}
+
+func (h *ListAlertRulesHandler) handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, listAlertRulesResponse{Rules: h.service.List()})
+	}
+}
+
+// PutAlertRuleHandler creates or replaces the rule, the name is taken from the path
+type PutAlertRuleHandler struct {
+	service IAlertRulesService
+	lg      *logging.ZapLogger
+}
+
+func NewPutAlertRuleHandler(srvc IAlertRulesService, lg *logging.ZapLogger) *PutAlertRuleHandler {
+	return &PutAlertRuleHandler{service: srvc, lg: lg}
+}
+
+func (h *PutAlertRuleHandler) Registrate() (server.Route, error) {
+	return server.Route{
+		Path:    "/alerts/rules/:name",
+		Method:  "PUT",
+		Handler: h.handler(),
+	}, nil
+}
+
+func (h *PutAlertRuleHandler) handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := utils.InitHandlerCtx(c, h.lg, "put_alert_rule_handler")
+
+		var rule alerting.Rule
+		if err := c.ShouldBindJSON(&rule); err != nil {
+			h.lg.DebugCtx(ctx, "invalid params", zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		rule.Name = c.Param("name")
+
+		rule, err := h.service.Put(rule)
+		if err != nil {
+			if errors.Is(err, alerting.ErrInvalidRule) {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+
+			h.lg.ErrorCtx(ctx, "put alert rule error", zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		c.JSON(http.StatusOK, rule)
+	}
+}
+
+// DeleteAlertRuleHandler removes the rule
+type DeleteAlertRuleHandler struct {
+	service IAlertRulesService
+	lg      *logging.ZapLogger
+}
+
+func NewDeleteAlertRuleHandler(srvc IAlertRulesService, lg *logging.ZapLogger) *DeleteAlertRuleHandler {
+	return &DeleteAlertRuleHandler{service: srvc, lg: lg}
+}
+
+func (h *DeleteAlertRuleHandler) Registrate() (server.Route, error) {
+	return server.Route{
+		Path:    "/alerts/rules/:name",
+		Method:  "DELETE",
+		Handler: h.handler(),
+	}, nil
+}
+
+func (h *DeleteAlertRuleHandler) handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := utils.InitHandlerCtx(c, h.lg, "delete_alert_rule_handler")
+
+		if err := h.service.Delete(c.Param("name")); err != nil {
+			if errors.Is(err, alerting.ErrRuleNotFound) {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{})
+				return
+			}
+
+			h.lg.ErrorCtx(ctx, "delete alert rule error", zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		c.Status(http.StatusOK)
+	}
+}
+
+// ListAlertsHandler shows pending and firing alerts
+type ListAlertsHandler struct {
+	service IAlertsService
+}
+
+func NewListAlertsHandler(srvc IAlertsService) *ListAlertsHandler {
+	return &ListAlertsHandler{service: srvc}
+}
+
+func (h *ListAlertsHandler) Registrate() (server.Route, error) {
+	return server.Route{
+		Path:    "/alerts",
+		Method:  "GET",
+		Handler: h.handler(),
+	}, nil
+}
+
+type listAlertsResponse struct {
+	Alerts []alerting.Alert `json:"alerts"`
+}
+
+func (h *ListAlertsHandler) handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, listAlertsResponse{Alerts: h.service.Active()})
+	}
+}
+
+// AlertsPageHandler renders the page of pending and firing alerts
+type AlertsPageHandler struct {
+	service IAlertsService
+}
+
+func NewAlertsPageHandler(srvc IAlertsService) *AlertsPageHandler {
+	return &AlertsPageHandler{service: srvc}
+}
+
+func (h *AlertsPageHandler) Registrate() (server.Route, error) {
+	tmp := template.New("alerts")
+	if _, err := tmp.ParseFS(rootHandlerTemplates, "templates/alerts/alerts.tmpl"); err != nil {
+		return server.Route{}, fmt.Errorf("alerts_handler: parse fs error %w", err)
+	}
+
+	return server.Route{
+		Path:          "/alerts/ui",
+		Method:        "GET",
+		Handler:       h.handler(),
+		HTMLTemplates: []*template.Template{tmp},
+	}, nil
+}
+
+func (h *AlertsPageHandler) handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.HTML(http.StatusOK, "alerts.tmpl", gin.H{
+			"alerts": h.service.Active(),
+		})
+	}
+}
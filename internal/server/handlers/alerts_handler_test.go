@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/alerting"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+)
+
+type fakeAlerts struct {
+	alerts []alerting.Alert
+}
+
+func (a *fakeAlerts) Active() []alerting.Alert {
+	return a.alerts
+}
+
+func TestAlertRulesHandlers(t *testing.T) {
+	lg, err := logging.NewZapLogger(&config.Config{LogLevel: -1})
+	require.NoError(t, err)
+
+	rules, err := alerting.NewRules(&config.Config{})
+	require.NoError(t, err)
+
+	r := gin.Default()
+	r.PUT("/alerts/rules/:name", NewPutAlertRuleHandler(rules, lg).handler())
+	r.DELETE("/alerts/rules/:name", NewDeleteAlertRuleHandler(rules, lg).handler())
+	r.GET("/alerts/rules", NewListAlertRulesHandler(rules).handler())
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	do := func(method, path, body string) (int, string) {
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+
+		resp, err := srv.Client().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return resp.StatusCode, string(b)
+	}
+
+	rule := `{"name":"high_alloc","metric":{"type":"gauge","name":"Alloc"},"condition":{"kind":"threshold","op":">","value":100},"for":"1m0s","severity":"warning"}`
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "when there are no rules",
+			method:     http.MethodGet,
+			path:       "/alerts/rules",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"rules":[]}`,
+		},
+		{
+			name:       "when rule is invalid",
+			method:     http.MethodPut,
+			path:       "/alerts/rules/high_alloc",
+			body:       `{"metric":{"type":"gauge","name":"Alloc"},"condition":{"kind":"threshold"},"severity":"warning"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "when body is malformed",
+			method:     http.MethodPut,
+			path:       "/alerts/rules/high_alloc",
+			body:       `{"for":60}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "when rule is stored",
+			method:     http.MethodPut,
+			path:       "/alerts/rules/high_alloc",
+			body:       `{"name":"ignored","metric":{"type":"gauge","name":"Alloc"},"condition":{"kind":"threshold","op":">","value":100},"for":"1m","severity":"warning"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   rule,
+		},
+		{
+			name:       "when rules are listed",
+			method:     http.MethodGet,
+			path:       "/alerts/rules",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"rules":[` + rule + `]}`,
+		},
+		{
+			name:       "when rule is deleted",
+			method:     http.MethodDelete,
+			path:       "/alerts/rules/high_alloc",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "when rule is not found",
+			method:     http.MethodDelete,
+			path:       "/alerts/rules/high_alloc",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := do(tt.method, tt.path, tt.body)
+			assert.Equal(t, tt.wantStatus, status)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, body)
+			}
+		})
+	}
+}
+
+func TestListAlertsHandler(t *testing.T) {
+	alerts := &fakeAlerts{alerts: []alerting.Alert{
+		{Rule: "high_alloc", MType: "gauge", MName: "Alloc", Severity: alerting.SeverityWarning, State: alerting.StateFiring, Value: 120},
+	}}
+
+	r := gin.Default()
+	r.GET("/alerts", NewListAlertsHandler(alerts).handler())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/alerts", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"alerts":[{"rule":"high_alloc","type":"gauge","name":"Alloc","severity":"warning","state":"firing","value":120,"active_at":"0001-01-01T00:00:00Z"}]}`, w.Body.String())
+}
+
+func TestAlertsPageHandler(t *testing.T) {
+	alerts := &fakeAlerts{alerts: []alerting.Alert{
+		{Rule: "high_alloc", MType: "gauge", MName: "Alloc", State: alerting.StateFiring, Annotations: map[string]string{"summary": "<b>Alloc</b>"}},
+	}}
+
+	h := NewAlertsPageHandler(alerts)
+	route, err := h.Registrate()
+	require.NoError(t, err)
+	assert.Equal(t, "/alerts/ui", route.Path)
+	require.Len(t, route.HTMLTemplates, 1)
+
+	r := gin.Default()
+	r.SetHTMLTemplate(route.HTMLTemplates[0])
+	r.GET(route.Path, route.Handler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/alerts/ui", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "gauge/Alloc")
+	assert.Contains(t, w.Body.String(), "summary: &lt;b&gt;Alloc&lt;/b&gt;")
+
+	alerts.alerts = nil
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/alerts/ui", nil))
+	assert.Contains(t, w.Body.String(), "No active alerts")
+}
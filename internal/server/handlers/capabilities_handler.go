@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/capabilities"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+)
+
+// CapabilitiesHandler advertises api versions, features, body codings and limits of the server
+type CapabilitiesHandler struct {
+	caps capabilities.Capabilities
+	lg   *logging.ZapLogger
+}
+
+func NewCapabilitiesHandler(caps capabilities.Capabilities, lg *logging.ZapLogger) *CapabilitiesHandler {
+	return &CapabilitiesHandler{caps: caps, lg: lg}
+}
+
+func (h *CapabilitiesHandler) Registrate() (server.Route, error) {
+	return server.Route{
+		Path:    "/capabilities",
+		Method:  "GET",
+		Handler: h.handler(),
+	}, nil
+}
+
+func (h *CapabilitiesHandler) handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, h.caps)
+	}
+}
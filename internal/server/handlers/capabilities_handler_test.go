@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/service"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+)
+
+func TestCapabilitiesHandler(t *testing.T) {
+	lg, err := logging.NewZapLogger(&config.Config{LogLevel: -1})
+	require.NoError(t, err)
+
+	r := gin.Default()
+	r.GET("/capabilities", NewCapabilitiesHandler(service.NewCapabilities(&config.Config{MaxBatchSize: 100, Key: "secret"}), lg).handler())
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/capabilities", http.NoBody))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{
+		"api_versions":["v1"],
+		"features":["batch","idempotency","agent_config"],
+		"encodings":["json","protobuf"],
+		"compression":["gzip"],
+		"encryption":[],
+		"signatures":["hmac-sha256"],
+		"max_batch_size":100,
+		"max_body_bytes":0
+	}`, rec.Body.String())
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/service"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"go.uber.org/zap"
+)
+
+type IHistoryService interface {
+	Call(context.Context, service.HistoryParams) (service.HistoryResult, error)
+}
+
+var _ IHistoryService = (*service.HistoryService)(nil)
+
+// HistoryHandler returns samples of the series over the range. Query params: from and to as RFC 3339 or unix
+// seconds, step as duration (1m) or seconds. Raw samples of the last hour are returned by default, older ranges
+// are served from rollups of the retention tier reported in the resolution field.
+type HistoryHandler struct {
+	service IHistoryService
+	lg      *logging.ZapLogger
+}
+
+func NewHistoryHandler(srvc IHistoryService, lg *logging.ZapLogger) *HistoryHandler {
+	return &HistoryHandler{service: srvc, lg: lg}
+}
+
+func (h *HistoryHandler) Registrate() (server.Route, error) {
+	return server.Route{
+		Path:    "/history/:type/:name",
+		Method:  "GET",
+		Handler: h.handler(),
+	}, nil
+}
+
+type historyResponse struct {
+	ID         string          `json:"id"`
+	MType      string          `json:"type"`
+	Resolution string          `json:"resolution"`
+	Samples    []models.Sample `json:"samples"`
+}
+
+func (h *HistoryHandler) handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := utils.InitHandlerCtx(c, h.lg, "history_handler")
+
+		params, err := historyParams(c)
+		if err != nil {
+			h.lg.DebugCtx(ctx, "invalid params", zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		res, err := h.service.Call(ctx, params)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidHistoryParams) || errors.Is(err, service.ErrTooManyPoints) {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+
+			h.lg.ErrorCtx(ctx, "history query error", zap.Error(err))
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		resolution := "raw"
+		if res.Resolution > 0 {
+			resolution = res.Resolution.String()
+		}
+
+		c.JSON(http.StatusOK, historyResponse{
+			ID:         params.MName,
+			MType:      params.MType,
+			Resolution: resolution,
+			Samples:    res.Samples,
+		})
+	}
+}
+
+func historyParams(c *gin.Context) (service.HistoryParams, error) {
+	params := service.HistoryParams{
+		MName: c.Param("name"),
+		MType: c.Param("type"),
+	}
+
+	var err error
+	if params.From, err = parseTime(c.Query("from")); err != nil {
+		return params, fmt.Errorf("from: %w", err)
+	}
+
+	if params.To, err = parseTime(c.Query("to")); err != nil {
+		return params, fmt.Errorf("to: %w", err)
+	}
+
+	if params.Step, err = parseStep(c.Query("step")); err != nil {
+		return params, fmt.Errorf("step: %w", err)
+	}
+
+	return params, nil
+}
+
+// parseTime parses RFC 3339 time or unix seconds, empty value is zero time
+func parseTime(val string) (time.Time, error) {
+	if val == "" {
+		return time.Time{}, nil
+	}
+
+	if sec, err := strconv.ParseInt(val, 10, 64); err == nil {
+		return time.Unix(sec, 0), nil
+	}
+
+	return time.Parse(time.RFC3339, val)
+}
+
+// parseStep parses duration or seconds, empty value is zero step
+func parseStep(val string) (time.Duration, error) {
+	if val == "" {
+		return 0, nil
+	}
+
+	if sec, err := strconv.ParseInt(val, 10, 64); err == nil {
+		return time.Duration(sec) * time.Second, nil
+	}
+
+	return time.ParseDuration(val)
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/service"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/storages"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+)
+
+func TestHistoryHandler(t *testing.T) {
+	lg, err := logging.NewZapLogger(&config.Config{LogLevel: -1})
+	require.NoError(t, err)
+
+	retention, err := storages.ParseRetention("raw:48h,1m:720h")
+	require.NoError(t, err)
+
+	strg := storages.NewMemory(lg)
+	ctx := context.Background()
+	require.NoError(t, strg.CreateOrUpdate(ctx, models.GaugeType, "alloc", 1.5))
+	require.NoError(t, strg.CreateOrUpdate(ctx, models.GaugeType, "alloc", 2.5))
+	require.NoError(t, strg.Compact(ctx, retention, time.Now().Add(time.Minute)))
+
+	r := gin.Default()
+	r.GET("/history/:type/:name", NewHistoryHandler(service.NewHistoryService(strg, retention), lg).handler())
+
+	tests := []struct {
+		name           string
+		path           string
+		wantStatus     int
+		wantResolution string
+		wantValues     []float64
+	}{
+		{
+			name:           "raw samples of the last hour",
+			path:           "/history/gauge/alloc",
+			wantStatus:     http.StatusOK,
+			wantResolution: "raw",
+			wantValues:     []float64{1.5, 2.5},
+		},
+		{
+			name:           "samples averaged by the step",
+			path:           "/history/gauge/alloc?from=0&step=87600h",
+			wantStatus:     http.StatusOK,
+			wantResolution: "1m0s",
+			wantValues:     []float64{2},
+		},
+		{
+			name:           "unknown series",
+			path:           "/history/gauge/unknown?from=2025-10-18T12:00:00Z&step=86400",
+			wantStatus:     http.StatusOK,
+			wantResolution: "1m0s",
+			wantValues:     []float64{},
+		},
+		{
+			name:       "invalid from",
+			path:       "/history/gauge/alloc?from=yesterday",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid step",
+			path:       "/history/gauge/alloc?step=often",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unexpected type",
+			path:       "/history/histogram/alloc",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "too many points",
+			path:       "/history/gauge/alloc?from=0&step=1",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, http.NoBody))
+
+			require.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp historyResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, "gauge", resp.MType)
+			assert.Equal(t, tt.wantResolution, resp.Resolution)
+
+			values := make([]float64, 0, len(resp.Samples))
+			for _, s := range resp.Samples {
+				values = append(values, s.Value)
+			}
+			assert.Equal(t, tt.wantValues, values)
+		})
+	}
+}
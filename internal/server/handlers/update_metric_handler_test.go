@@ -43,6 +43,7 @@ func TestNewUpdateMetricHandler(t *testing.T) {
 			service := service.NewUpdateMetricService(
 				tt.args.service,
 				repositories.NewMockIGaugeRepository(ctrl),
+				service.NewIdempotencyKeys(&config.Config{}),
 			)
 			h := NewUpdateMetricHandler(service, lg)
 			assert.NotNil(t, h)
@@ -68,6 +69,7 @@ func TestUpdateMetricHandler_Registrate(t *testing.T) {
 				service: service.NewUpdateMetricService(
 					repositories.NewMockICounterRepository(nil),
 					repositories.NewMockIGaugeRepository(nil),
+					service.NewIdempotencyKeys(&config.Config{}),
 				),
 				lg: nil,
 			},
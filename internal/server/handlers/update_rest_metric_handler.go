@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -10,6 +11,7 @@ import (
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/models"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/service"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/utils"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/idempotency"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
 	"go.uber.org/zap"
 )
@@ -52,6 +54,7 @@ func (h *UpdateRestMetricHandler) Registrate() (server.Route, error) {
 func (h *UpdateRestMetricHandler) handler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx := utils.InitHandlerCtx(c, h.lg, "update_rest_metrics_handler")
+		ctx = idempotency.WithKey(ctx, c.GetHeader(idempotency.Header))
 
 		var metric Metrics
 		if err := c.ShouldBindJSON(&metric); err != nil {
@@ -76,6 +79,11 @@ func (h *UpdateRestMetricHandler) handler() gin.HandlerFunc {
 		result, err := h.service.Call(ctx, params)
 		if err != nil {
 			h.lg.ErrorCtx(ctx, "update record failed", zap.Error(err))
+			if errors.Is(err, service.ErrRequestInProgress) {
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{})
+				return
+			}
+
 			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{})
 			return
 		}
@@ -2,12 +2,14 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/service"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/utils"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/idempotency"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
 	"go.uber.org/zap"
 )
@@ -43,6 +45,7 @@ func (h *UpdatesRestMetricsHandler) handler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var params service.UpdateMetricsServiceParams
 		ctx := utils.InitHandlerCtx(c, h.lg, "updates_rest_metrics_handler")
+		ctx = idempotency.WithKey(ctx, c.GetHeader(idempotency.Header))
 
 		if err := c.ShouldBindJSON(&params); err != nil {
 			h.lg.DebugCtx(ctx, "invalid params", zap.Error(err))
@@ -61,6 +64,21 @@ func (h *UpdatesRestMetricsHandler) handler() gin.HandlerFunc {
 			params,
 		); err != nil {
 			h.lg.ErrorCtx(ctx, "server error", zap.Error(err))
+			if errors.Is(err, service.ErrRequestInProgress) {
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{})
+				return
+			}
+
+			if errors.Is(err, service.ErrBatchTooLarge) {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{})
+				return
+			}
+
+			if errors.Is(err, service.ErrInvalidMetric) {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{})
+				return
+			}
+
 			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{})
 			return
 		}
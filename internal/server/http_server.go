@@ -4,13 +4,18 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
+	"fmt"
 	"net"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/crypto"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/systemd"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 )
@@ -22,18 +27,21 @@ type IServer interface {
 }
 
 type HTTPServer struct {
-	Router *Router
-	srv    IServer
-	cfg    *config.Config
-	lg     *logging.ZapLogger
+	Router  *Router
+	srv     IServer
+	cfg     *config.Config
+	lg      *logging.ZapLogger
+	sockets *systemd.Sockets
+	serving atomic.Bool
 }
 
-func NewHTTPServer(lc fx.Lifecycle, cfg *config.Config, r *Router, lg *logging.ZapLogger) *HTTPServer {
+func NewHTTPServer(lc fx.Lifecycle, cfg *config.Config, r *Router, lg *logging.ZapLogger, sockets *systemd.Sockets) *HTTPServer {
 	s := &HTTPServer{
-		srv:    &http.Server{Addr: cfg.Address, Handler: r.router, ReadHeaderTimeout: time.Minute},
-		Router: r,
-		cfg:    cfg,
-		lg:     lg,
+		srv:     &http.Server{Addr: cfg.Address, Handler: r.router, ReadHeaderTimeout: time.Minute},
+		Router:  r,
+		cfg:     cfg,
+		lg:      lg,
+		sockets: sockets,
 	}
 
 	lc.Append(
@@ -51,13 +59,29 @@ func NewHTTPServer(lc fx.Lifecycle, cfg *config.Config, r *Router, lg *logging.Z
 	return s
 }
 
+// Start serves on the "http" socket passed by systemd, or listens on the configured address
 func (s *HTTPServer) Start(ctx context.Context) error {
-	ln, err := net.Listen("tcp", s.cfg.Address)
+	ln, err := s.sockets.Listen(ctx, "http", s.cfg.Address)
 	if err != nil {
 		return err
 	}
 
+	if s.cfg.IsTLSEnabled() {
+		tlsCfg, err := crypto.NewServerTLSConfig(s.cfg.TLSCert, s.cfg.TLSKey, s.cfg.TLSClientCA)
+		if err != nil {
+			if closeErr := ln.Close(); closeErr != nil {
+				s.lg.ErrorCtx(ctx, "http_server: close listener error", zap.Error(closeErr))
+			}
+			return fmt.Errorf("http_server: build tls config error %w", err)
+		}
+
+		ln = tls.NewListener(ln, tlsCfg)
+	}
+
+	s.serving.Store(true)
 	go func() {
+		defer s.serving.Store(false)
+
 		if err := s.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			s.lg.ErrorCtx(ctx, "http_server: serve failer error", zap.Error(err))
 		}
@@ -66,6 +90,11 @@ func (s *HTTPServer) Start(ctx context.Context) error {
 	return nil
 }
 
+// Serving reports whether the server accepts connections
+func (s *HTTPServer) Serving() bool {
+	return s.serving.Load()
+}
+
 func (s *HTTPServer) Shutdown(ctx context.Context) error {
 	return s.srv.Shutdown(ctx)
 }
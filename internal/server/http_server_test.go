@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/systemd"
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxtest"
 )
@@ -47,7 +48,7 @@ func TestNewHTTPServer(t *testing.T) {
 				fx.Populate(&l, &s),
 			)
 
-			got := NewHTTPServer(l, tt.args.cfg, NewRouter([]Handler{&MockHandler{}}, l, lg, tt.args.cfg, nil), lg)
+			got := NewHTTPServer(l, tt.args.cfg, NewRouter([]Handler{&MockHandler{}}, l, lg, tt.args.cfg, nil), lg, &systemd.Sockets{})
 			assert.NotNil(t, got)
 
 			err := app.Start(context.Background())
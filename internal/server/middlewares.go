@@ -2,6 +2,7 @@ package server
 
 import (
 	"bytes"
+	stdgzip "compress/gzip"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
@@ -9,12 +10,15 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-contrib/gzip"
 	"github.com/gin-gonic/gin"
 	uuid "github.com/satori/go.uuid"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/capabilities"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/crypto"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
 	"go.uber.org/zap"
@@ -112,6 +116,77 @@ func decrypterMiddleware(lg *logging.ZapLogger, decrypter Decrypter) gin.Handler
 	}
 }
 
+// defaultMaxDecompressedBytes caps gzip request bodies when max body bytes is not configured
+const defaultMaxDecompressedBytes int64 = 32 << 20
+
+// decompressMiddleware decompresses gzip request bodies, other content codings are rejected with 415
+// so the agent can fall back to uncompressed requests. Bodies decompressed beyond limit are rejected with 413.
+func decompressMiddleware(lg *logging.ZapLogger, limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch strings.ToLower(strings.TrimSpace(c.Request.Header.Get("Content-Encoding"))) {
+		case "", "identity":
+			return
+		case capabilities.CompressionGzip:
+		default:
+			c.AbortWithStatus(http.StatusUnsupportedMediaType)
+			return
+		}
+
+		r, err := stdgzip.NewReader(c.Request.Body)
+		if err != nil {
+			lg.DebugCtx(c, "invalid gzip body", zap.Error(err))
+			c.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+		defer func() {
+			if err := r.Close(); err != nil {
+				lg.ErrorCtx(c, "close gzip reader error", zap.Error(err))
+			}
+		}()
+
+		body, err := io.ReadAll(io.LimitReader(r, limit+1))
+		if err != nil {
+			lg.DebugCtx(c, "invalid gzip body", zap.Error(err))
+			c.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+
+		if int64(len(body)) > limit {
+			c.AbortWithStatus(http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Request.ContentLength = int64(len(body))
+		c.Request.Header.Del("Content-Encoding")
+		c.Request.Header.Del("Content-Length")
+
+		c.Next()
+	}
+}
+
+// bodyLimitMiddleware rejects requests with the uncompressed body larger than limit with 413
+func bodyLimitMiddleware(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body == nil {
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, limit+1))
+		if err != nil {
+			c.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+
+		if int64(len(body)) > limit {
+			c.AbortWithStatus(http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+}
+
 func headerMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if c.Request.Header.Get("Content-Type") == "application/json" {
@@ -141,6 +216,10 @@ func httpLoggerMiddleware(lg *logging.ZapLogger) gin.HandlerFunc {
 			zap.Reflect("headers", c.Request.Header),
 		)
 
+		if identity, ok := ClientIdentity(c); ok {
+			ctx = lg.WithContextFields(ctx, zap.Stringer("client", identity))
+		}
+
 		bodyBuff := &bytes.Buffer{}
 		if _, err := io.Copy(bodyBuff, c.Request.Body); err != nil {
 			lg.ErrorCtx(ctx, "read body error", zap.Error(err))
@@ -194,12 +273,50 @@ func aclMiddleware(lg *logging.ZapLogger, ipnet *net.IPNet) gin.HandlerFunc {
 	}
 }
 
+// ClientIdentity returns identity of the verified agent certificate, it is present when mutual tls is enabled
+func ClientIdentity(c *gin.Context) (crypto.PeerIdentity, bool) {
+	val, ok := c.Get(utils.ClientIdentityKey)
+	if !ok {
+		return crypto.PeerIdentity{}, false
+	}
+
+	identity, ok := val.(crypto.PeerIdentity)
+	return identity, ok
+}
+
+func clientIdentityMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if identity, ok := crypto.PeerIdentityFromState(c.Request.TLS); ok {
+			c.Set(utils.ClientIdentityKey, identity)
+		}
+
+		c.Next()
+	}
+}
+
 func middlewares(cfg *config.Config, lg *logging.ZapLogger, decrypter Decrypter) ([]gin.HandlerFunc, error) {
 	mws := []gin.HandlerFunc{
 		gin.Recovery(),
 		headerMiddleware(),
+	}
+
+	if cfg.TLSClientCA != "" {
+		mws = append(mws, clientIdentityMiddleware())
+	}
+
+	decompressLimit := defaultMaxDecompressedBytes
+	if cfg.MaxBodyBytes > 0 {
+		decompressLimit = cfg.MaxBodyBytes
+	}
+
+	mws = append(mws,
 		httpLoggerMiddleware(lg),
 		gzip.Gzip(gzip.DefaultCompression),
+		decompressMiddleware(lg, decompressLimit),
+	)
+
+	if cfg.MaxBodyBytes > 0 {
+		mws = append(mws, bodyLimitMiddleware(cfg.MaxBodyBytes))
 	}
 
 	if cfg.PrivateKey != nil {
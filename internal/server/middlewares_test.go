@@ -2,11 +2,16 @@ package server
 
 import (
 	"bytes"
+	stdgzip "compress/gzip"
 	"context"
 	"crypto/hmac"
 	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/base64"
 	"errors"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -272,6 +277,132 @@ func Test_decrypterMiddleware(t *testing.T) {
 	}
 }
 
+func Test_decompressMiddleware(t *testing.T) {
+	gzipped := &bytes.Buffer{}
+	w := stdgzip.NewWriter(gzipped)
+	_, err := w.Write([]byte(`{"id":"Alloc"}`))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	// a few kilobytes decompressed into a megabyte
+	bomb := &bytes.Buffer{}
+	w = stdgzip.NewWriter(bomb)
+	_, err = w.Write(make([]byte, 1<<20))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	tests := []struct {
+		name           string
+		encoding       string
+		body           []byte
+		wantStatusCode int
+		wantBody       string
+	}{
+		{
+			name:           "when body is not compressed",
+			body:           []byte(`{"id":"Alloc"}`),
+			wantStatusCode: http.StatusOK,
+			wantBody:       `{"id":"Alloc"}`,
+		},
+		{
+			name:           "when body is gzipped",
+			encoding:       "gzip",
+			body:           gzipped.Bytes(),
+			wantStatusCode: http.StatusOK,
+			wantBody:       `{"id":"Alloc"}`,
+		},
+		{
+			name:           "when gzip body is invalid",
+			encoding:       "gzip",
+			body:           []byte(`{"id":"Alloc"}`),
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "when decompressed body exceeds the limit",
+			encoding:       "gzip",
+			body:           bomb.Bytes(),
+			wantStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:           "when encoding is not supported",
+			encoding:       "br",
+			body:           []byte(`{"id":"Alloc"}`),
+			wantStatusCode: http.StatusUnsupportedMediaType,
+		},
+	}
+
+	lg, err := logging.NewZapLogger(&config.Config{LogLevel: -1})
+	if err != nil {
+		t.Fatalf("failed to create zap logger: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+
+			var got []byte
+			r.Use(decompressMiddleware(lg, 1024))
+			r.POST("/", func(c *gin.Context) {
+				got, _ = io.ReadAll(c.Request.Body)
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.body))
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatusCode, rec.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, string(got))
+			}
+		})
+	}
+}
+
+func Test_bodyLimitMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		wantStatusCode int
+	}{
+		{
+			name:           "when body fits the limit",
+			body:           "12345",
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "when body exceeds the limit",
+			body:           "123456",
+			wantStatusCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+
+			var got []byte
+			r.Use(bodyLimitMiddleware(5))
+			r.POST("/", func(c *gin.Context) {
+				got, _ = io.ReadAll(c.Request.Body)
+				c.Status(http.StatusOK)
+			})
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(tt.body)))
+
+			assert.Equal(t, tt.wantStatusCode, rec.Code)
+			if tt.wantStatusCode == http.StatusOK {
+				assert.Equal(t, tt.body, string(got))
+			}
+		})
+	}
+}
+
 func Test_middlewares(t *testing.T) {
 	type args struct {
 		cfg *config.Config
@@ -290,6 +421,7 @@ func Test_middlewares(t *testing.T) {
 				headerMiddleware(),
 				httpLoggerMiddleware(nil),
 				gzip.Gzip(gzip.DefaultCompression),
+				decompressMiddleware(nil, defaultMaxDecompressedBytes),
 			},
 		},
 		{
@@ -300,6 +432,7 @@ func Test_middlewares(t *testing.T) {
 				headerMiddleware(),
 				httpLoggerMiddleware(nil),
 				gzip.Gzip(gzip.DefaultCompression),
+				decompressMiddleware(nil, defaultMaxDecompressedBytes),
 				decrypterMiddleware(nil, nil),
 			},
 		},
@@ -311,6 +444,7 @@ func Test_middlewares(t *testing.T) {
 				headerMiddleware(),
 				httpLoggerMiddleware(nil),
 				gzip.Gzip(gzip.DefaultCompression),
+				decompressMiddleware(nil, defaultMaxDecompressedBytes),
 				signerMiddleware(nil, []byte("")),
 			},
 		},
@@ -322,9 +456,34 @@ func Test_middlewares(t *testing.T) {
 				headerMiddleware(),
 				httpLoggerMiddleware(nil),
 				gzip.Gzip(gzip.DefaultCompression),
+				decompressMiddleware(nil, defaultMaxDecompressedBytes),
 				aclMiddleware(nil, nil),
 			},
 		},
+		{
+			name: "when tls client ca present",
+			args: args{cfg: &config.Config{TLSClientCA: "ca.crt"}},
+			want: []gin.HandlerFunc{
+				gin.Recovery(),
+				headerMiddleware(),
+				clientIdentityMiddleware(),
+				httpLoggerMiddleware(nil),
+				gzip.Gzip(gzip.DefaultCompression),
+				decompressMiddleware(nil, defaultMaxDecompressedBytes),
+			},
+		},
+		{
+			name: "when max body bytes present",
+			args: args{cfg: &config.Config{MaxBodyBytes: 1024}},
+			want: []gin.HandlerFunc{
+				gin.Recovery(),
+				headerMiddleware(),
+				httpLoggerMiddleware(nil),
+				gzip.Gzip(gzip.DefaultCompression),
+				decompressMiddleware(nil, defaultMaxDecompressedBytes),
+				bodyLimitMiddleware(1024),
+			},
+		},
 		{
 			name: "when trusted subnet invalid",
 			args: args{cfg: &config.Config{TrustedSubnet: "192.168.0/24"}},
@@ -333,6 +492,7 @@ func Test_middlewares(t *testing.T) {
 				headerMiddleware(),
 				httpLoggerMiddleware(nil),
 				gzip.Gzip(gzip.DefaultCompression),
+				decompressMiddleware(nil, defaultMaxDecompressedBytes),
 				aclMiddleware(nil, nil),
 			},
 			wantErr: true,
@@ -409,3 +569,53 @@ func Test_aclMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func Test_clientIdentityMiddleware(t *testing.T) {
+	cert := &x509.Certificate{
+		Subject:  pkix.Name{CommonName: "agent-1"},
+		DNSNames: []string{"agent-1.local"},
+	}
+
+	tests := []struct {
+		name     string
+		state    *tls.ConnectionState
+		want     crypto.PeerIdentity
+		wantSeen bool
+	}{
+		{
+			name: "plain http request",
+		},
+		{
+			name:  "tls without client certificate",
+			state: &tls.ConnectionState{},
+		},
+		{
+			name:     "verified client certificate",
+			state:    &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}},
+			want:     crypto.PeerIdentity{Subject: "CN=agent-1", SAN: []string{"agent-1.local"}},
+			wantSeen: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, r := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			c.Request.TLS = tt.state
+
+			var (
+				got  crypto.PeerIdentity
+				seen bool
+			)
+			r.Use(clientIdentityMiddleware())
+			r.GET("/", func(c *gin.Context) {
+				got, seen = ClientIdentity(c)
+				c.Status(http.StatusOK)
+			})
+			r.HandleContext(c)
+
+			assert.Equal(t, tt.wantSeen, seen)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Sample is the metric value accepted at the moment, counters are sampled with the accumulated value.
+// Rollups aggregate samples of the resolution bucket: Value is the average for gauges and the last value
+// for counters, Count is the number of raw samples in the bucket.
+type Sample struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+	Min       float64   `json:"min"`
+	Max       float64   `json:"max"`
+	Count     int64     `json:"count"`
+}
+
+// NewSample returns raw sample of the value
+func NewSample(ts time.Time, value float64) Sample {
+	return Sample{Timestamp: ts, Value: value, Min: value, Max: value, Count: 1}
+}
+
+// Aggregate merges ordered samples into a single sample at the time
+func Aggregate(mType string, ts time.Time, samples []Sample) Sample {
+	res := Sample{Timestamp: ts}
+	if len(samples) == 0 {
+		return res
+	}
+
+	var sum float64
+	res.Min, res.Max = samples[0].Min, samples[0].Max
+	for _, s := range samples {
+		sum += s.Value * float64(s.Count)
+		res.Count += s.Count
+		res.Min = min(res.Min, s.Min)
+		res.Max = max(res.Max, s.Max)
+	}
+
+	res.Value = sum / float64(res.Count)
+	if mType == CounterType {
+		res.Value = samples[len(samples)-1].Value
+	}
+
+	return res
+}
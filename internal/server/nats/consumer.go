@@ -0,0 +1,232 @@
+// Package nats consumes metric batches which agents publish to a nats JetStream stream.
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/grpc"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/service"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/idempotency"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"github.com/vysogota0399/mem_stats_monitoring/pkg/gen/services/metrics"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+type IUpdateMetricsService interface {
+	Call(context.Context, service.UpdateMetricsServiceParams) (service.UpdateMetricsServiceResult, error)
+}
+
+var _ IUpdateMetricsService = (*service.UpdateMetricsService)(nil)
+
+const (
+	// maxDeliveries limits attempts to apply the batch, nats stops delivering it after the last one
+	maxDeliveries = 10
+	// ackWait is the delay before the batch which was not acknowledged is delivered again, e.g. the server crashed
+	ackWait = 30 * time.Second
+)
+
+// redeliveryBackoff are delays before the batch which failed to apply is delivered again, the last one is repeated.
+// The backoff is applied with nak delays rather than the consumer BackOff, since nats offsets nak delays by it.
+var redeliveryBackoff = []time.Duration{time.Second, 5 * time.Second, 30 * time.Second, time.Minute, 5 * time.Minute}
+
+// Consumer stores the metrics subject in the work queue stream named after the nats queue and consumes it
+// with the durable consumer of the same name, so every batch is applied by a single server of the group.
+// Batches are acknowledged once applied, failed ones are redelivered. The consumer is disabled when nats url is not configured.
+type Consumer struct {
+	cfg      *config.Config
+	lg       *logging.ZapLogger
+	service  IUpdateMetricsService
+	conn     *nats.Conn
+	consumer jetstream.ConsumeContext
+	ctx      context.Context
+}
+
+func NewConsumer(lc fx.Lifecycle, cfg *config.Config, lg *logging.ZapLogger, service IUpdateMetricsService) *Consumer {
+	c := &Consumer{
+		cfg:     cfg,
+		lg:      lg,
+		service: service,
+	}
+
+	lc.Append(
+		fx.Hook{
+			OnStart: func(ctx context.Context) error {
+				if cfg.NATSURL == "" {
+					return nil
+				}
+
+				return c.start(ctx)
+			},
+			OnStop: func(ctx context.Context) error {
+				return c.stop(ctx)
+			},
+		},
+	)
+
+	return c
+}
+
+func (c *Consumer) start(ctx context.Context) error {
+	c.ctx = c.lg.WithContextFields(context.Background(),
+		zap.String("name", "nats_consumer"),
+		zap.String("subject", c.cfg.NATSSubject),
+	)
+
+	conn, err := nats.Connect(
+		c.cfg.NATSURL,
+		nats.Name("mem_stats_monitoring-server"),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			c.lg.WarnCtx(c.ctx, "nats disconnected", zap.Error(err))
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			c.lg.InfoCtx(c.ctx, "nats reconnected", zap.String("url", conn.ConnectedUrlRedacted()))
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("internal/server/nats/consumer: connect error %w", err)
+	}
+
+	consumer, err := c.subscribe(ctx, conn)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	c.conn = conn
+	c.consumer = consumer
+	c.lg.InfoCtx(ctx, "nats consumer started", zap.String("subject", c.cfg.NATSSubject), zap.String("queue", c.cfg.NATSQueue))
+
+	return nil
+}
+
+// subscribe creates the stream and the durable consumer unless they exist and starts consuming
+func (c *Consumer) subscribe(ctx context.Context, conn *nats.Conn) (jetstream.ConsumeContext, error) {
+	js, err := jetstream.New(conn)
+	if err != nil {
+		return nil, fmt.Errorf("internal/server/nats/consumer: jetstream error %w", err)
+	}
+
+	stream, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:      c.cfg.NATSQueue,
+		Subjects:  []string{c.cfg.NATSSubject},
+		Retention: jetstream.WorkQueuePolicy,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("internal/server/nats/consumer: create stream error %w", err)
+	}
+
+	consumer, err := stream.CreateOrUpdateConsumer(ctx, jetstream.ConsumerConfig{
+		Durable:    c.cfg.NATSQueue,
+		AckPolicy:  jetstream.AckExplicitPolicy,
+		AckWait:    ackWait,
+		MaxDeliver: maxDeliveries,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("internal/server/nats/consumer: create consumer error %w", err)
+	}
+
+	cc, err := consumer.Consume(c.handle, jetstream.ConsumeErrHandler(func(_ jetstream.ConsumeContext, err error) {
+		c.lg.WarnCtx(c.ctx, "nats consume error", zap.Error(err))
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("internal/server/nats/consumer: subscribe error %w", err)
+	}
+
+	return cc, nil
+}
+
+// stop waits until received messages are handled and closes the connection
+func (c *Consumer) stop(ctx context.Context) error {
+	if c.conn == nil {
+		return nil
+	}
+
+	c.consumer.Drain()
+	select {
+	case <-c.consumer.Closed():
+	case <-ctx.Done():
+		c.conn.Close()
+		return fmt.Errorf("internal/server/nats/consumer: drain error %w", ctx.Err())
+	}
+
+	closed := make(chan struct{})
+	c.conn.SetClosedHandler(func(*nats.Conn) { close(closed) })
+
+	if err := c.conn.Drain(); err != nil {
+		c.conn.Close()
+		return fmt.Errorf("internal/server/nats/consumer: drain error %w", err)
+	}
+
+	select {
+	case <-closed:
+		return nil
+	case <-ctx.Done():
+		c.conn.Close()
+		return fmt.Errorf("internal/server/nats/consumer: drain error %w", ctx.Err())
+	}
+}
+
+// handle applies the batch and acknowledges it. The batch which failed to apply is redelivered with backoff
+// up to maxDeliveries times, the one which can't be decoded or is rejected by the service is terminated,
+// since it never succeeds.
+func (c *Consumer) handle(msg jetstream.Msg) {
+	ctx := c.ctx
+
+	params := &metrics.UpdateMetricsBatchParams{}
+	if err := proto.Unmarshal(msg.Data(), params); err != nil {
+		c.lg.ErrorCtx(ctx, "failed to decode metrics batch", zap.Error(err))
+		c.settle(ctx, msg.Term())
+		return
+	}
+
+	if key := msg.Headers().Get(idempotency.Header); key != "" {
+		ctx = idempotency.WithKey(ctx, key)
+	}
+
+	if _, err := c.service.Call(ctx, grpc.BatchServiceParams(params)); err != nil {
+		c.lg.ErrorCtx(ctx, "update metrics batch error", zap.Error(err), zap.Int("size", len(params.Item)))
+		c.retry(ctx, msg, err)
+		return
+	}
+
+	c.settle(ctx, msg.Ack())
+}
+
+// retry redelivers the batch after the transient error, permanent errors and the last attempt terminate it
+func (c *Consumer) retry(ctx context.Context, msg jetstream.Msg, err error) {
+	if errors.Is(err, service.ErrBatchTooLarge) || errors.Is(err, service.ErrInvalidMetric) {
+		c.settle(ctx, msg.Term())
+		return
+	}
+
+	var delivered uint64
+	if meta, metaErr := msg.Metadata(); metaErr == nil {
+		delivered = meta.NumDelivered
+	}
+
+	if delivered >= maxDeliveries {
+		c.lg.ErrorCtx(ctx, "metrics batch dropped after max deliveries", zap.Uint64("delivered", delivered))
+		c.settle(ctx, msg.Term())
+		return
+	}
+
+	attempt := min(max(int(delivered), 1), len(redeliveryBackoff))
+	c.settle(ctx, msg.NakWithDelay(redeliveryBackoff[attempt-1]))
+}
+
+// settle logs the error of the message acknowledgement, the message is redelivered after ack wait then
+func (c *Consumer) settle(ctx context.Context, err error) {
+	if err != nil {
+		c.lg.ErrorCtx(ctx, "failed to acknowledge metrics batch", zap.Error(err))
+	}
+}
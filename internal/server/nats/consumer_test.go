@@ -0,0 +1,302 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	natstest "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/service"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/idempotency"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"github.com/vysogota0399/mem_stats_monitoring/pkg/gen/entities"
+	"github.com/vysogota0399/mem_stats_monitoring/pkg/gen/services/metrics"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"google.golang.org/protobuf/proto"
+)
+
+type call struct {
+	params service.UpdateMetricsServiceParams
+	key    string
+}
+
+// fakeService records batches, the first failures calls return err or the storage error when err is nil
+type fakeService struct {
+	mu       sync.Mutex
+	calls    []call
+	failures int
+	err      error
+}
+
+func (s *fakeService) Call(ctx context.Context, params service.UpdateMetricsServiceParams) (service.UpdateMetricsServiceResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls = append(s.calls, call{params: params, key: idempotency.KeyFromContext(ctx)})
+	if len(s.calls) <= s.failures {
+		if s.err != nil {
+			return service.UpdateMetricsServiceResult{}, s.err
+		}
+
+		return service.UpdateMetricsServiceResult{}, errors.New("storage error")
+	}
+
+	return service.UpdateMetricsServiceResult{}, nil
+}
+
+func (s *fakeService) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.calls)
+}
+
+// runServer starts embedded nats server with jetstream on a random port, it is shut down after consumers are stopped
+func runServer(t *testing.T) *server.Server {
+	t.Helper()
+
+	opts := natstest.DefaultTestOptions
+	opts.Port = -1
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+
+	srv := natstest.RunServer(&opts)
+	t.Cleanup(srv.Shutdown)
+
+	return srv
+}
+
+func startConsumer(t *testing.T, cfg *config.Config, svc IUpdateMetricsService) *Consumer {
+	t.Helper()
+
+	c, stop := runConsumer(t, cfg, svc)
+	t.Cleanup(stop)
+
+	return c
+}
+
+// runConsumer starts the consumer, it is stopped with the returned func
+func runConsumer(t *testing.T, cfg *config.Config, svc IUpdateMetricsService) (*Consumer, func()) {
+	t.Helper()
+
+	lg, err := logging.NewZapLogger(&config.Config{LogLevel: -1})
+	require.NoError(t, err)
+
+	var c *Consumer
+	app := fxtest.New(t,
+		fx.Supply(cfg, lg),
+		fx.Provide(
+			func() IUpdateMetricsService { return svc },
+			NewConsumer,
+		),
+		fx.Populate(&c),
+	)
+	app.RequireStart()
+
+	return c, app.RequireStop
+}
+
+func publish(t *testing.T, url string, params *metrics.UpdateMetricsBatchParams, key string) {
+	t.Helper()
+
+	conn, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	data, err := proto.Marshal(params)
+	require.NoError(t, err)
+
+	msg := nats.NewMsg("metrics.updates")
+	msg.Data = data
+	if key != "" {
+		msg.Header.Set(idempotency.Header, key)
+	}
+
+	js, err := jetstream.New(conn)
+	require.NoError(t, err)
+
+	_, err = js.PublishMsg(context.Background(), msg)
+	require.NoError(t, err)
+}
+
+func TestConsumer(t *testing.T) {
+	batch := &metrics.UpdateMetricsBatchParams{
+		Item: []*metrics.Item{
+			{Metric: &metrics.Item_Gauge{Gauge: &entities.Gauge{Name: "alloc", Value: 1.5}}},
+			{Metric: &metrics.Item_Counter{Counter: &entities.Counter{Name: "poll_count", Value: 3}}},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		key       string
+		failures  int
+		wantCalls int
+		wantKey   string
+	}{
+		{
+			name:      "batch with idempotency key",
+			key:       "report-1",
+			wantCalls: 2,
+			wantKey:   "report-1",
+		},
+		{
+			name:      "batch without idempotency key",
+			wantCalls: 2,
+		},
+		{
+			name:      "failed batch is redelivered",
+			key:       "report-1",
+			failures:  1,
+			wantCalls: 3,
+			wantKey:   "report-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := runServer(t)
+
+			svc := &fakeService{failures: tt.failures}
+			startConsumer(t, &config.Config{NATSURL: srv.ClientURL(), NATSSubject: "metrics.updates", NATSQueue: "servers"}, svc)
+
+			publish(t, srv.ClientURL(), batch, tt.key)
+			publish(t, srv.ClientURL(), batch, tt.key)
+			require.Eventually(t, func() bool { return svc.len() == tt.wantCalls }, 3*redeliveryBackoff[0], 10*time.Millisecond)
+
+			svc.mu.Lock()
+			defer svc.mu.Unlock()
+
+			for _, c := range svc.calls {
+				assert.Equal(t, tt.wantKey, c.key)
+				assert.Equal(t, service.UpdateMetricsServiceParams{
+					{ID: "alloc", MType: models.GaugeType, Value: 1.5},
+					{ID: "poll_count", MType: models.CounterType, Delta: 3},
+				}, c.params)
+			}
+		})
+	}
+}
+
+func TestConsumer_queueGroup(t *testing.T) {
+	srv := runServer(t)
+
+	cfg := &config.Config{NATSURL: srv.ClientURL(), NATSSubject: "metrics.updates", NATSQueue: "servers"}
+	first, second := &fakeService{}, &fakeService{}
+	startConsumer(t, cfg, first)
+	startConsumer(t, cfg, second)
+
+	const total = 20
+	for i := range total {
+		item := &metrics.Item{Metric: &metrics.Item_Counter{Counter: &entities.Counter{Name: "poll_count", Value: 1}}}
+		publish(t, srv.ClientURL(), &metrics.UpdateMetricsBatchParams{Item: []*metrics.Item{item}}, fmt.Sprintf("report-%d", i))
+	}
+
+	require.Eventually(t, func() bool { return first.len()+second.len() == total }, time.Second, 10*time.Millisecond)
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, total, first.len()+second.len(), "every batch is applied by a single server of the group")
+	assert.NotZero(t, first.len(), "batches are balanced between servers")
+	assert.NotZero(t, second.len(), "batches are balanced between servers")
+}
+
+func TestConsumer_permanentError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{
+			name: "batch too large",
+			err:  fmt.Errorf("%w: 2 metrics, max 1", service.ErrBatchTooLarge),
+		},
+		{
+			name: "invalid metric",
+			err:  service.ErrInvalidMetric,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := runServer(t)
+
+			svc := &fakeService{failures: 1, err: tt.err}
+			startConsumer(t, &config.Config{NATSURL: srv.ClientURL(), NATSSubject: "metrics.updates", NATSQueue: "servers"}, svc)
+
+			publish(t, srv.ClientURL(), &metrics.UpdateMetricsBatchParams{}, "report-1")
+			require.Eventually(t, func() bool { return svc.len() == 1 }, time.Second, 10*time.Millisecond)
+
+			time.Sleep(2 * redeliveryBackoff[0])
+			assert.Equal(t, 1, svc.len(), "rejected batch is not redelivered")
+		})
+	}
+}
+
+func TestConsumer_maxDeliveries(t *testing.T) {
+	backoff := redeliveryBackoff
+	redeliveryBackoff = []time.Duration{10 * time.Millisecond}
+	t.Cleanup(func() { redeliveryBackoff = backoff })
+
+	srv := runServer(t)
+
+	svc := &fakeService{failures: 2 * maxDeliveries}
+	startConsumer(t, &config.Config{NATSURL: srv.ClientURL(), NATSSubject: "metrics.updates", NATSQueue: "servers"}, svc)
+
+	publish(t, srv.ClientURL(), &metrics.UpdateMetricsBatchParams{}, "report-1")
+	require.Eventually(t, func() bool { return svc.len() == maxDeliveries }, 2*time.Second, 10*time.Millisecond)
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, maxDeliveries, svc.len(), "batch is terminated after the last delivery")
+}
+
+func TestConsumer_serverUnavailable(t *testing.T) {
+	srv := runServer(t)
+
+	// the stream is created by the first server start, batches published while servers are down are kept
+	cfg := &config.Config{NATSURL: srv.ClientURL(), NATSSubject: "metrics.updates", NATSQueue: "servers"}
+	_, stop := runConsumer(t, cfg, &fakeService{})
+	stop()
+
+	publish(t, srv.ClientURL(), &metrics.UpdateMetricsBatchParams{}, "report-1")
+
+	svc := &fakeService{}
+	startConsumer(t, cfg, svc)
+	require.Eventually(t, func() bool { return svc.len() == 1 }, time.Second, 10*time.Millisecond)
+}
+
+func TestConsumer_disabled(t *testing.T) {
+	c := startConsumer(t, &config.Config{}, &fakeService{})
+	assert.Nil(t, c.conn)
+}
+
+func TestConsumer_invalidMessage(t *testing.T) {
+	srv := runServer(t)
+
+	svc := &fakeService{}
+	startConsumer(t, &config.Config{NATSURL: srv.ClientURL(), NATSSubject: "metrics.updates", NATSQueue: "servers"}, svc)
+
+	conn, err := nats.Connect(srv.ClientURL())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	js, err := jetstream.New(conn)
+	require.NoError(t, err)
+
+	_, err = js.Publish(context.Background(), "metrics.updates", []byte("not a protobuf message"))
+	require.NoError(t, err)
+	publish(t, srv.ClientURL(), &metrics.UpdateMetricsBatchParams{}, "")
+
+	require.Eventually(t, func() bool { return svc.len() == 1 }, time.Second, 10*time.Millisecond)
+	time.Sleep(2 * redeliveryBackoff[0])
+	assert.Equal(t, 1, svc.len(), "invalid message is not redelivered")
+}
@@ -40,6 +40,8 @@ func NewRouter(handlers []Handler, lc fx.Lifecycle, lg *logging.ZapLogger, cfg *
 
 				r.router.Use(mws...)
 
+				// gin holds a single template set, so templates of all routes are merged into it
+				html := template.New("")
 				for _, handler := range handlers {
 					route, err := handler.Registrate()
 					if err != nil {
@@ -51,15 +53,25 @@ func NewRouter(handlers []Handler, lc fx.Lifecycle, lg *logging.ZapLogger, cfg *
 						continue
 					}
 
-					if len(route.HTMLTemplates) > 0 {
-						for _, tmp := range route.HTMLTemplates {
-							r.router.SetHTMLTemplate(tmp)
+					for _, tmp := range route.HTMLTemplates {
+						for _, t := range tmp.Templates() {
+							if t.Tree == nil {
+								continue
+							}
+
+							if _, err := html.AddParseTree(t.Name(), t.Tree); err != nil {
+								return fmt.Errorf("router: add template %s error %w", t.Name(), err)
+							}
 						}
 					}
 
 					r.router.Handle(route.Method, route.Path, route.Handler)
 				}
 
+				if len(html.Templates()) > 0 {
+					r.router.SetHTMLTemplate(html)
+				}
+
 				return nil
 			},
 			OnStop: func(ctx context.Context) error {
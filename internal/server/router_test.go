@@ -2,11 +2,16 @@ package server
 
 import (
 	"context"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxtest"
 )
@@ -22,6 +27,48 @@ func (h *MockHandler) Registrate() (Route, error) {
 	}, nil
 }
 
+// pageHandler renders its own template
+type pageHandler struct {
+	path string
+	name string
+}
+
+func (h *pageHandler) Registrate() (Route, error) {
+	tmp := template.Must(template.New("").New(h.name).Parse(h.name + ": {{ .value }}"))
+
+	return Route{
+		Path:          h.path,
+		Method:        "GET",
+		Handler:       func(c *gin.Context) { c.HTML(http.StatusOK, h.name, gin.H{"value": h.path}) },
+		HTMLTemplates: []*template.Template{tmp},
+	}, nil
+}
+
+func TestNewRouter_templates(t *testing.T) {
+	lg, err := logging.NewZapLogger(&config.Config{LogLevel: -1})
+	require.NoError(t, err)
+
+	lc := fxtest.NewLifecycle(t)
+
+	r := NewRouter(
+		[]Handler{&pageHandler{path: "/a", name: "a.tmpl"}, &pageHandler{path: "/b", name: "b.tmpl"}},
+		lc,
+		lg,
+		&config.Config{},
+		nil,
+	)
+	lc.RequireStart()
+	defer lc.RequireStop()
+
+	for _, name := range []string{"a", "b"} {
+		w := httptest.NewRecorder()
+		r.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+name, nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, name+".tmpl: /"+name, w.Body.String())
+	}
+}
+
 func TestNewRouter(t *testing.T) {
 	type args struct {
 		handlers []Handler
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+)
+
+var (
+	// ErrProfileNotFound returned when there is no profile with the name or matching the agent
+	ErrProfileNotFound = errors.New("agent profiles: profile not found")
+	// ErrInvalidProfile returned when the profile can't be stored
+	ErrInvalidProfile = errors.New("agent profiles: invalid profile")
+)
+
+// AgentProfile is the agent config assigned to agents by id or host pattern.
+// Config is opaque for the server, agents validate it before applying.
+type AgentProfile struct {
+	Name      string          `json:"name"`
+	Match     []string        `json:"match"`   // path.Match patterns of the agent id or host
+	Version   int64           `json:"version"` // changes on every update of any profile, so agents notice reassignment too
+	Config    json.RawMessage `json:"config"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// AgentState is the last config request of the agent
+type AgentState struct {
+	ID             string    `json:"id"`
+	Host           string    `json:"host"`
+	Profile        string    `json:"profile,omitempty"`
+	RunningVersion int64     `json:"running_version"` // 0 when the agent runs its local config
+	ApplyError     string    `json:"apply_error,omitempty"`
+	SeenAt         time.Time `json:"seen_at"`
+}
+
+// AgentConfigRequest is sent by the agent to fetch its profile and report the running config
+type AgentConfigRequest struct {
+	AgentID        string
+	Host           string
+	RunningVersion int64
+	ApplyError     string
+}
+
+// agentProfilesFile is the persisted state of the profiles
+type agentProfilesFile struct {
+	Version  int64          `json:"version"`
+	Profiles []AgentProfile `json:"profiles"`
+}
+
+// AgentProfiles stores agent config profiles, profiles are checked in order and the first matching one is used.
+// Profiles are persisted to the file when the path is configured.
+type AgentProfiles struct {
+	mu       sync.Mutex
+	path     string
+	version  int64
+	profiles []AgentProfile
+	agents   map[string]AgentState
+	now      func() time.Time
+}
+
+func NewAgentProfiles(cfg *config.Config) (*AgentProfiles, error) {
+	p := &AgentProfiles{
+		path:   cfg.AgentProfilesPath,
+		agents: make(map[string]AgentState),
+		now:    time.Now,
+	}
+
+	if err := p.load(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// List returns all profiles in the matching order
+func (p *AgentProfiles) List() []AgentProfile {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return slices.Clone(p.profiles)
+}
+
+// Put creates or replaces the profile, new profiles are checked after the existing ones
+func (p *AgentProfiles) Put(profile AgentProfile) (AgentProfile, error) {
+	if err := validateProfile(profile); err != nil {
+		return AgentProfile{}, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	profiles := slices.Clone(p.profiles)
+	profile.Version = p.version + 1
+	profile.UpdatedAt = p.now()
+
+	if i := p.index(profile.Name); i >= 0 {
+		profiles[i] = profile
+	} else {
+		profiles = append(profiles, profile)
+	}
+
+	if err := p.save(profile.Version, profiles); err != nil {
+		return AgentProfile{}, err
+	}
+
+	p.version, p.profiles = profile.Version, profiles
+	return profile, nil
+}
+
+// Delete removes the profile, agents assigned to it fall back to the next matching profile or the local config
+func (p *AgentProfiles) Delete(name string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i := p.index(name)
+	if i < 0 {
+		return ErrProfileNotFound
+	}
+
+	profiles := slices.Delete(slices.Clone(p.profiles), i, i+1)
+	if err := p.save(p.version+1, profiles); err != nil {
+		return err
+	}
+
+	p.version++
+	p.profiles = profiles
+	return nil
+}
+
+// Resolve returns the profile of the agent and records the config the agent is running.
+// The version of the returned profile is the current version of the profiles,
+// so the agent applies the profile again after any change.
+func (p *AgentProfiles) Resolve(req AgentConfigRequest) (AgentProfile, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state := AgentState{
+		ID:             req.AgentID,
+		Host:           req.Host,
+		RunningVersion: req.RunningVersion,
+		ApplyError:     req.ApplyError,
+		SeenAt:         p.now(),
+	}
+
+	var (
+		found AgentProfile
+		ok    bool
+	)
+	for _, profile := range p.profiles {
+		if matchAgent(profile.Match, req.AgentID, req.Host) {
+			found, ok = profile, true
+			break
+		}
+	}
+
+	if ok {
+		state.Profile = found.Name
+	}
+	p.agents[agentKey(req)] = state
+
+	if !ok {
+		return AgentProfile{}, ErrProfileNotFound
+	}
+
+	found.Version = p.version
+	return found, nil
+}
+
+// Agents returns the last state reported by the agents sorted by id
+func (p *AgentProfiles) Agents() []AgentState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	res := make([]AgentState, 0, len(p.agents))
+	for _, state := range p.agents {
+		res = append(res, state)
+	}
+
+	slices.SortFunc(res, func(a, b AgentState) int {
+		return strings.Compare(a.ID+"/"+a.Host, b.ID+"/"+b.Host)
+	})
+
+	return res
+}
+
+func (p *AgentProfiles) index(name string) int {
+	return slices.IndexFunc(p.profiles, func(profile AgentProfile) bool {
+		return profile.Name == name
+	})
+}
+
+func (p *AgentProfiles) load() error {
+	if p.path == "" {
+		return nil
+	}
+
+	b, err := os.ReadFile(p.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("internal/server/service/agent_profiles: read %s error %w", p.path, err)
+	}
+
+	var state agentProfilesFile
+	if err := json.Unmarshal(b, &state); err != nil {
+		return fmt.Errorf("internal/server/service/agent_profiles: decode %s error %w", p.path, err)
+	}
+
+	for _, profile := range state.Profiles {
+		if err := validateProfile(profile); err != nil {
+			return fmt.Errorf("internal/server/service/agent_profiles: load %s error %w", p.path, err)
+		}
+	}
+
+	p.version, p.profiles = state.Version, state.Profiles
+	return nil
+}
+
+// save replaces the file atomically, so the profiles are not lost when the server stops while writing
+func (p *AgentProfiles) save(version int64, profiles []AgentProfile) error {
+	if p.path == "" {
+		return nil
+	}
+
+	b, err := json.MarshalIndent(agentProfilesFile{Version: version, Profiles: profiles}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("internal/server/service/agent_profiles: encode profiles error %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p.path), filepath.Base(p.path)+".*")
+	if err != nil {
+		return fmt.Errorf("internal/server/service/agent_profiles: create temp file error %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("internal/server/service/agent_profiles: write profiles error %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("internal/server/service/agent_profiles: close temp file error %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), p.path); err != nil {
+		return fmt.Errorf("internal/server/service/agent_profiles: replace %s error %w", p.path, err)
+	}
+
+	return nil
+}
+
+func validateProfile(profile AgentProfile) error {
+	if profile.Name == "" {
+		return fmt.Errorf("%w: name is empty", ErrInvalidProfile)
+	}
+
+	if len(profile.Match) == 0 {
+		return fmt.Errorf("%w: profile %s matches no agents", ErrInvalidProfile, profile.Name)
+	}
+
+	for _, pattern := range profile.Match {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%w: profile %s pattern %q: %s", ErrInvalidProfile, profile.Name, pattern, err)
+		}
+	}
+
+	var settings map[string]json.RawMessage
+	if err := json.Unmarshal(profile.Config, &settings); err != nil || settings == nil {
+		return fmt.Errorf("%w: profile %s config must be json object", ErrInvalidProfile, profile.Name)
+	}
+
+	return nil
+}
+
+func matchAgent(patterns []string, agentID, host string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, agentID); ok && agentID != "" {
+			return true
+		}
+
+		if ok, _ := path.Match(pattern, host); ok && host != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func agentKey(req AgentConfigRequest) string {
+	if req.AgentID != "" {
+		return req.AgentID
+	}
+
+	return req.Host
+}
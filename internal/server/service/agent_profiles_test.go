@@ -0,0 +1,142 @@
+package service
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+)
+
+func TestAgentProfiles_Resolve(t *testing.T) {
+	p, err := NewAgentProfiles(&config.Config{})
+	require.NoError(t, err)
+
+	_, err = p.Put(AgentProfile{Name: "db", Match: []string{"db-*"}, Config: json.RawMessage(`{"poll_interval":5}`)})
+	require.NoError(t, err)
+	_, err = p.Put(AgentProfile{Name: "default", Match: []string{"*"}, Config: json.RawMessage(`{}`)})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		req         AgentConfigRequest
+		wantProfile string
+		wantErr     error
+	}{
+		{
+			name:        "when agent id matches",
+			req:         AgentConfigRequest{AgentID: "db-1", Host: "host-1"},
+			wantProfile: "db",
+		},
+		{
+			name:        "when host matches",
+			req:         AgentConfigRequest{AgentID: "agent", Host: "db-2"},
+			wantProfile: "db",
+		},
+		{
+			name:        "when the first profile does not match",
+			req:         AgentConfigRequest{AgentID: "web-1"},
+			wantProfile: "default",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, err := p.Resolve(tt.req)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantProfile, profile.Name)
+			assert.Equal(t, int64(2), profile.Version)
+		})
+	}
+
+	t.Run("when no profile matches", func(t *testing.T) {
+		require.NoError(t, p.Delete("default"))
+
+		_, err := p.Resolve(AgentConfigRequest{AgentID: "web-1", RunningVersion: 2, ApplyError: "invalid"})
+		assert.ErrorIs(t, err, ErrProfileNotFound)
+
+		agents := p.Agents()
+		require.Len(t, agents, 3)
+		assert.Equal(t, "web-1", agents[2].ID)
+		assert.Empty(t, agents[2].Profile)
+		assert.Equal(t, int64(2), agents[2].RunningVersion)
+		assert.Equal(t, "invalid", agents[2].ApplyError)
+	})
+}
+
+func TestAgentProfiles_Put(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile AgentProfile
+		wantErr error
+	}{
+		{
+			name:    "when profile is valid",
+			profile: AgentProfile{Name: "default", Match: []string{"*"}, Config: json.RawMessage(`{"batch_report":true}`)},
+		},
+		{
+			name:    "when name is empty",
+			profile: AgentProfile{Match: []string{"*"}, Config: json.RawMessage(`{}`)},
+			wantErr: ErrInvalidProfile,
+		},
+		{
+			name:    "when match is empty",
+			profile: AgentProfile{Name: "default", Config: json.RawMessage(`{}`)},
+			wantErr: ErrInvalidProfile,
+		},
+		{
+			name:    "when pattern is invalid",
+			profile: AgentProfile{Name: "default", Match: []string{"["}, Config: json.RawMessage(`{}`)},
+			wantErr: ErrInvalidProfile,
+		},
+		{
+			name:    "when config is not an object",
+			profile: AgentProfile{Name: "default", Match: []string{"*"}, Config: json.RawMessage(`[]`)},
+			wantErr: ErrInvalidProfile,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewAgentProfiles(&config.Config{})
+			require.NoError(t, err)
+
+			_, err = p.Put(tt.profile)
+			assert.ErrorIs(t, err, tt.wantErr)
+
+			if tt.wantErr != nil {
+				assert.Empty(t, p.List())
+			} else {
+				assert.Len(t, p.List(), 1)
+			}
+		})
+	}
+}
+
+func TestAgentProfiles_persistence(t *testing.T) {
+	cfg := &config.Config{AgentProfilesPath: filepath.Join(t.TempDir(), "profiles.json")}
+
+	p, err := NewAgentProfiles(cfg)
+	require.NoError(t, err)
+
+	_, err = p.Put(AgentProfile{Name: "db", Match: []string{"db-*"}, Config: json.RawMessage(`{"poll_interval":5}`)})
+	require.NoError(t, err)
+	_, err = p.Put(AgentProfile{Name: "db", Match: []string{"db-*", "pg-*"}, Config: json.RawMessage(`{"poll_interval":10}`)})
+	require.NoError(t, err)
+
+	restored, err := NewAgentProfiles(cfg)
+	require.NoError(t, err)
+
+	profiles := restored.List()
+	require.Len(t, profiles, 1)
+	assert.Equal(t, []string{"db-*", "pg-*"}, profiles[0].Match)
+	assert.JSONEq(t, `{"poll_interval":10}`, string(profiles[0].Config))
+
+	profile, err := restored.Resolve(AgentConfigRequest{Host: "pg-1"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), profile.Version)
+
+	assert.ErrorIs(t, restored.Delete("web"), ErrProfileNotFound)
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/capabilities"
+)
+
+// NewCapabilities returns capabilities of the server advertised to the agents
+func NewCapabilities(cfg *config.Config) capabilities.Capabilities {
+	caps := capabilities.Capabilities{
+		APIVersions: []string{capabilities.APIVersion},
+		Features: []string{
+			capabilities.FeatureBatch,
+			capabilities.FeatureIdempotency,
+			capabilities.FeatureAgentConfig,
+		},
+		Encodings:    []string{capabilities.EncodingJSON, capabilities.EncodingProtobuf},
+		Compression:  []string{capabilities.CompressionGzip},
+		Encryption:   []string{},
+		Signatures:   []string{},
+		MaxBatchSize: cfg.MaxBatchSize,
+		MaxBodyBytes: cfg.MaxBodyBytes,
+		GRPCPort:     cfg.GRPCPort,
+	}
+
+	if cfg.PrivateKey != nil {
+		caps.Encryption = append(caps.Encryption, capabilities.EncryptionRSA)
+	}
+
+	if cfg.Key != "" {
+		caps.Signatures = append(caps.Signatures, capabilities.SignatureHMACSHA256)
+	}
+
+	return caps
+}
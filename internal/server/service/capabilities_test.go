@@ -0,0 +1,47 @@
+package service
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/capabilities"
+)
+
+func TestNewCapabilities(t *testing.T) {
+	tests := []struct {
+		name           string
+		cfg            *config.Config
+		wantEncryption []string
+		wantSignatures []string
+	}{
+		{
+			name:           "when body encryption and signatures are disabled",
+			cfg:            &config.Config{MaxBatchSize: 100, MaxBodyBytes: 1 << 20, GRPCPort: "3200"},
+			wantEncryption: []string{},
+			wantSignatures: []string{},
+		},
+		{
+			name:           "when body encryption and signatures are enabled",
+			cfg:            &config.Config{MaxBatchSize: 100, MaxBodyBytes: 1 << 20, GRPCPort: "3200", PrivateKey: &bytes.Buffer{}, Key: "secret"},
+			wantEncryption: []string{capabilities.EncryptionRSA},
+			wantSignatures: []string{capabilities.SignatureHMACSHA256},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewCapabilities(tt.cfg)
+
+			assert.True(t, got.Supports(capabilities.APIVersion))
+			assert.True(t, got.Has(capabilities.FeatureBatch))
+			assert.True(t, got.Accepts(capabilities.CompressionGzip))
+			assert.Equal(t, tt.wantEncryption, got.Encryption)
+			assert.Equal(t, tt.wantSignatures, got.Signatures)
+			assert.Equal(t, 100, got.MaxBatchSize)
+			assert.Equal(t, int64(1<<20), got.MaxBodyBytes)
+			assert.Equal(t, "3200", got.GRPCPort)
+		})
+	}
+}
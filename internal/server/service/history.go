@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/storages"
+)
+
+// HistorySource returns samples of the series of the resolution, zero resolution means raw samples
+type HistorySource interface {
+	Samples(ctx context.Context, mType, mName string, resolution time.Duration, from, to time.Time) ([]models.Sample, error)
+}
+
+var _ HistorySource = (*storages.Memory)(nil)
+var _ HistorySource = (*storages.PG)(nil)
+
+var (
+	// ErrInvalidHistoryParams returned when the range, the step or the metric type of the query is invalid
+	ErrInvalidHistoryParams = errors.New("history: invalid params")
+	// ErrTooManyPoints returned when the step is too small for the range
+	ErrTooManyPoints = errors.New("history: too many points")
+)
+
+const (
+	// maxHistoryPoints limits the number of resampled points in a single query
+	maxHistoryPoints = 11000
+	// defaultHistoryRange is used when the range start is not set
+	defaultHistoryRange = time.Hour
+)
+
+// HistoryParams describes the series and the range to query. Zero To means now, zero From means
+// an hour before To, zero Step returns raw samples.
+type HistoryParams struct {
+	MName string
+	MType string
+	From  time.Time
+	To    time.Time
+	Step  time.Duration
+}
+
+// HistoryResult samples of the resolution the query was served from, zero resolution means raw samples
+type HistoryResult struct {
+	Resolution time.Duration
+	Samples    []models.Sample
+}
+
+// HistoryService queries samples of the series over the range and resamples them by the step
+type HistoryService struct {
+	source    HistorySource
+	retention storages.Retention
+	now       func() time.Time
+}
+
+func NewHistoryService(source HistorySource, retention storages.Retention) *HistoryService {
+	return &HistoryService{source: source, retention: retention, now: time.Now}
+}
+
+// Call returns samples of the series within [From, To] of the resolution picked by the range and the step.
+// With the step samples are grouped into buckets starting from From, gauges are averaged and counters take
+// the last accumulated value of the bucket. Buckets without samples are skipped.
+func (s *HistoryService) Call(ctx context.Context, params HistoryParams) (HistoryResult, error) {
+	if params.MType != models.GaugeType && params.MType != models.CounterType {
+		return HistoryResult{}, fmt.Errorf("%w: unexpected type(%s)", ErrInvalidHistoryParams, params.MType)
+	}
+
+	if params.To.IsZero() {
+		params.To = s.now()
+	}
+
+	if params.From.IsZero() {
+		params.From = params.To.Add(-defaultHistoryRange)
+	}
+
+	if params.From.After(params.To) {
+		return HistoryResult{}, fmt.Errorf("%w: from %s is after to %s", ErrInvalidHistoryParams, params.From, params.To)
+	}
+
+	if params.Step < 0 {
+		return HistoryResult{}, fmt.Errorf("%w: negative step %s", ErrInvalidHistoryParams, params.Step)
+	}
+
+	if params.Step > 0 && params.To.Sub(params.From)/params.Step >= maxHistoryPoints {
+		return HistoryResult{}, fmt.Errorf("%w: step %s is too small for the range, max %d points", ErrTooManyPoints, params.Step, maxHistoryPoints)
+	}
+
+	res := HistoryResult{Resolution: s.resolution(params.From, params.To, params.Step)}
+
+	samples, err := s.source.Samples(ctx, params.MType, params.MName, res.Resolution, params.From, params.To)
+	if err != nil {
+		return HistoryResult{}, fmt.Errorf("internal/server/service/history.go: get samples error %w", err)
+	}
+
+	res.Samples = samples
+	if params.Step > 0 {
+		res.Samples = resample(samples, params.MType, params.From, params.Step)
+	}
+
+	return res, nil
+}
+
+// resolution picks the finest tier still keeping samples since from. With the step the coarsest of such tiers
+// not exceeding the step is preferred, if its rollups are already built up to to. The coarsest tier is used
+// when none of them keeps samples since from.
+func (s *HistoryService) resolution(from, to time.Time, step time.Duration) time.Duration {
+	if len(s.retention) == 0 {
+		return 0
+	}
+
+	now := s.now()
+	picked := -1
+	for i, tier := range s.retention {
+		if now.Sub(from) > tier.TTL {
+			continue
+		}
+
+		if picked == -1 {
+			picked = i
+			continue
+		}
+
+		if step > 0 && tier.Resolution <= step && !to.After(now.Truncate(tier.Resolution)) {
+			picked = i
+		}
+	}
+
+	if picked == -1 {
+		picked = len(s.retention) - 1
+	}
+
+	return s.retention[picked].Resolution
+}
+
+// resample groups ordered samples into step buckets starting from the range start
+func resample(samples []models.Sample, mType string, from time.Time, step time.Duration) []models.Sample {
+	res := make([]models.Sample, 0)
+
+	var (
+		bucket int64 = -1
+		group  []models.Sample
+	)
+
+	flush := func() {
+		if len(group) == 0 {
+			return
+		}
+
+		res = append(res, models.Aggregate(mType, from.Add(time.Duration(bucket)*step), group))
+		group = group[:0]
+	}
+
+	for _, s := range samples {
+		if b := int64(s.Timestamp.Sub(from) / step); b != bucket {
+			flush()
+			bucket = b
+		}
+
+		group = append(group, s)
+	}
+	flush()
+
+	return res
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/storages"
+)
+
+// fakeHistorySource returns the samples and records the queried resolution and range
+type fakeHistorySource struct {
+	samples    []models.Sample
+	err        error
+	resolution time.Duration
+	from, to   time.Time
+}
+
+func (s *fakeHistorySource) Samples(ctx context.Context, mType, mName string, resolution time.Duration, from, to time.Time) ([]models.Sample, error) {
+	s.resolution, s.from, s.to = resolution, from, to
+	return s.samples, s.err
+}
+
+var testRetention = storages.Retention{
+	{TTL: 48 * time.Hour},
+	{Resolution: time.Minute, TTL: 720 * time.Hour},
+	{Resolution: time.Hour, TTL: 8760 * time.Hour},
+}
+
+func TestHistoryService_Call(t *testing.T) {
+	now := time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+	at := func(sec int) time.Time { return now.Add(time.Duration(sec) * time.Second) }
+
+	samples := []models.Sample{
+		models.NewSample(at(0), 1),
+		models.NewSample(at(5), 3),
+		models.NewSample(at(10), 5),
+		models.NewSample(at(35), 10),
+	}
+
+	tests := []struct {
+		name     string
+		params   HistoryParams
+		err      error
+		want     []models.Sample
+		wantFrom time.Time
+		wantTo   time.Time
+		wantErr  error
+	}{
+		{
+			name:     "raw samples of the last hour by default",
+			params:   HistoryParams{MName: "alloc", MType: models.GaugeType},
+			want:     samples,
+			wantFrom: now.Add(-time.Hour),
+			wantTo:   now,
+		},
+		{
+			name:   "gauges are averaged by the step",
+			params: HistoryParams{MName: "alloc", MType: models.GaugeType, From: at(0), To: at(60), Step: 10 * time.Second},
+			want: []models.Sample{
+				{Timestamp: at(0), Value: 2, Min: 1, Max: 3, Count: 2},
+				models.NewSample(at(10), 5),
+				models.NewSample(at(30), 10),
+			},
+			wantFrom: at(0),
+			wantTo:   at(60),
+		},
+		{
+			name:   "counters take the last value of the step",
+			params: HistoryParams{MName: "poll_count", MType: models.CounterType, From: at(0), To: at(60), Step: 10 * time.Second},
+			want: []models.Sample{
+				{Timestamp: at(0), Value: 3, Min: 1, Max: 3, Count: 2},
+				models.NewSample(at(10), 5),
+				models.NewSample(at(30), 10),
+			},
+			wantFrom: at(0),
+			wantTo:   at(60),
+		},
+		{
+			name:    "unexpected type",
+			params:  HistoryParams{MName: "alloc", MType: "histogram"},
+			wantErr: ErrInvalidHistoryParams,
+		},
+		{
+			name:    "from is after to",
+			params:  HistoryParams{MName: "alloc", MType: models.GaugeType, From: at(60), To: at(0)},
+			wantErr: ErrInvalidHistoryParams,
+		},
+		{
+			name:    "negative step",
+			params:  HistoryParams{MName: "alloc", MType: models.GaugeType, Step: -time.Second},
+			wantErr: ErrInvalidHistoryParams,
+		},
+		{
+			name:    "step is too small for the range",
+			params:  HistoryParams{MName: "alloc", MType: models.GaugeType, From: now.Add(-24 * time.Hour), To: now, Step: time.Second},
+			wantErr: ErrTooManyPoints,
+		},
+		{
+			name:    "source error",
+			params:  HistoryParams{MName: "alloc", MType: models.GaugeType},
+			err:     errors.New("storage error"),
+			wantErr: errors.New("storage error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &fakeHistorySource{samples: samples, err: tt.err}
+			s := NewHistoryService(source, testRetention)
+			s.now = func() time.Time { return now }
+
+			got, err := s.Call(context.Background(), tt.params)
+			if tt.wantErr != nil {
+				require.Error(t, err)
+				if tt.err == nil {
+					assert.ErrorIs(t, err, tt.wantErr)
+				}
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.Samples)
+			assert.Equal(t, source.resolution, got.Resolution)
+			assert.Equal(t, tt.wantFrom, source.from)
+			assert.Equal(t, tt.wantTo, source.to)
+		})
+	}
+}
+
+func TestHistoryService_resolution(t *testing.T) {
+	now := time.Date(2025, 10, 18, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		retention storages.Retention
+		from      time.Time
+		to        time.Time
+		step      time.Duration
+		want      time.Duration
+	}{
+		{
+			name:      "raw samples of the recent range",
+			retention: testRetention,
+			from:      now.Add(-time.Hour),
+			want:      0,
+		},
+		{
+			name:      "minute rollups when raw samples are expired",
+			retention: testRetention,
+			from:      now.Add(-72 * time.Hour),
+			want:      time.Minute,
+		},
+		{
+			name:      "hour rollups of the old range",
+			retention: testRetention,
+			from:      now.Add(-60 * 24 * time.Hour),
+			want:      time.Hour,
+		},
+		{
+			name:      "the coarsest tier when the range is older than every ttl",
+			retention: testRetention,
+			from:      now.Add(-2 * 8760 * time.Hour),
+			want:      time.Hour,
+		},
+		{
+			name:      "the coarsest tier not exceeding the step",
+			retention: testRetention,
+			from:      now.Add(-24 * time.Hour),
+			to:        now.Add(-time.Hour),
+			step:      5 * time.Minute,
+			want:      time.Minute,
+		},
+		{
+			name:      "step larger than every resolution",
+			retention: testRetention,
+			from:      now.Add(-24 * time.Hour),
+			to:        now.Add(-2 * time.Hour),
+			step:      24 * time.Hour,
+			want:      time.Hour,
+		},
+		{
+			name:      "step smaller than rollups",
+			retention: testRetention,
+			from:      now.Add(-24 * time.Hour),
+			to:        now.Add(-time.Hour),
+			step:      10 * time.Second,
+			want:      0,
+		},
+		{
+			name:      "rollups are not built up to the range end yet",
+			retention: testRetention,
+			from:      now.Add(-24 * time.Hour),
+			to:        now.Add(-time.Minute / 2),
+			step:      time.Hour,
+			want:      time.Minute,
+		},
+		{
+			name: "without retention",
+			from: now.Add(-72 * time.Hour),
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewHistoryService(&fakeHistorySource{}, tt.retention)
+			s.now = func() time.Time { return now }
+
+			to := tt.to
+			if to.IsZero() {
+				to = now
+			}
+
+			assert.Equal(t, tt.want, s.resolution(tt.from, to, tt.step))
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+)
+
+var (
+	// ErrDuplicateRequest returned when the request with the same idempotency key was already applied within the window
+	ErrDuplicateRequest = errors.New("idempotency: request already applied")
+	// ErrRequestInProgress returned when the request with the same idempotency key is being applied right now
+	ErrRequestInProgress = errors.New("idempotency: request in progress")
+)
+
+// IdempotencyStore tracks idempotency keys of the applied counter updates
+type IdempotencyStore interface {
+	Begin(key string) error
+	Commit(key string)
+	Abort(key string)
+}
+
+var _ IdempotencyStore = (*IdempotencyKeys)(nil)
+
+type idempotencyKey struct {
+	applied   bool
+	expiresAt time.Time
+}
+
+// IdempotencyKeys in-memory storage of idempotency keys. Applied keys are kept for the configured window,
+// zero window disables deduplication.
+type IdempotencyKeys struct {
+	mu        sync.Mutex
+	window    time.Duration
+	keys      map[string]idempotencyKey
+	nextPrune time.Time
+	now       func() time.Time
+}
+
+func NewIdempotencyKeys(cfg *config.Config) *IdempotencyKeys {
+	return &IdempotencyKeys{
+		window: cfg.IdempotencyWindow,
+		keys:   make(map[string]idempotencyKey),
+		now:    time.Now,
+	}
+}
+
+// Begin marks the key as being applied. It returns ErrDuplicateRequest when the key was already applied
+// and ErrRequestInProgress when the key is being applied by a concurrent request.
+func (k *IdempotencyKeys) Begin(key string) error {
+	if key == "" || k.window <= 0 {
+		return nil
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := k.now()
+	k.prune(now)
+
+	if state, ok := k.keys[key]; ok && now.Before(state.expiresAt) {
+		if state.applied {
+			return ErrDuplicateRequest
+		}
+
+		return ErrRequestInProgress
+	}
+
+	k.keys[key] = idempotencyKey{expiresAt: now.Add(k.window)}
+	return nil
+}
+
+// Commit marks the key as applied, the same key is rejected until the window expires
+func (k *IdempotencyKeys) Commit(key string) {
+	if key == "" || k.window <= 0 {
+		return
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.keys[key] = idempotencyKey{applied: true, expiresAt: k.now().Add(k.window)}
+}
+
+// Abort forgets the key, so the retried request is applied
+func (k *IdempotencyKeys) Abort(key string) {
+	if key == "" || k.window <= 0 {
+		return
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	delete(k.keys, key)
+}
+
+// prune removes expired keys not more often than once per window
+func (k *IdempotencyKeys) prune(now time.Time) {
+	if now.Before(k.nextPrune) {
+		return
+	}
+
+	for key, state := range k.keys {
+		if !now.Before(state.expiresAt) {
+			delete(k.keys, key)
+		}
+	}
+
+	k.nextPrune = now.Add(k.window)
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+)
+
+func TestIdempotencyKeys(t *testing.T) {
+	now := time.Now()
+	keys := NewIdempotencyKeys(&config.Config{IdempotencyWindow: time.Minute})
+	keys.now = func() time.Time { return now }
+
+	assert.NoError(t, keys.Begin("a"))
+	assert.ErrorIs(t, keys.Begin("a"), ErrRequestInProgress)
+
+	keys.Abort("a")
+	assert.NoError(t, keys.Begin("a"))
+
+	keys.Commit("a")
+	assert.ErrorIs(t, keys.Begin("a"), ErrDuplicateRequest)
+	assert.NoError(t, keys.Begin("b"))
+
+	// key is forgotten when the window expires
+	now = now.Add(time.Minute)
+	assert.NoError(t, keys.Begin("a"))
+	assert.NotContains(t, keys.keys, "b")
+}
+
+func TestIdempotencyKeys_disabled(t *testing.T) {
+	tests := []struct {
+		name   string
+		window time.Duration
+		key    string
+	}{
+		{name: "when window is zero", window: 0, key: "a"},
+		{name: "when key is empty", window: time.Minute, key: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := NewIdempotencyKeys(&config.Config{IdempotencyWindow: tt.window})
+
+			assert.NoError(t, keys.Begin(tt.key))
+			keys.Commit(tt.key)
+			assert.NoError(t, keys.Begin(tt.key))
+		})
+	}
+}
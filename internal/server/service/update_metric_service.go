@@ -2,10 +2,12 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/models"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/repositories"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/idempotency"
 )
 
 type CreateCntrRep interface {
@@ -29,12 +31,14 @@ var _ CreateGaugeRep = (*repositories.GaugeRepository)(nil)
 type UpdateMetricService struct {
 	counterRep CreateCntrRep
 	gaugeRep   CreateGaugeRep
+	keys       IdempotencyStore
 }
 
-func NewUpdateMetricService(counterRep CreateCntrRep, gaugeRep CreateGaugeRep) *UpdateMetricService {
+func NewUpdateMetricService(counterRep CreateCntrRep, gaugeRep CreateGaugeRep, keys IdempotencyStore) *UpdateMetricService {
 	return &UpdateMetricService{
 		counterRep: counterRep,
 		gaugeRep:   gaugeRep,
+		keys:       keys,
 	}
 }
 
@@ -72,9 +76,21 @@ func (s UpdateMetricService) createCounter(ctx context.Context, params UpdateMet
 		Value: params.Delta,
 	}
 
+	// повторная доставка уже примененного инкремента не должна увеличивать счетчик
+	key := idempotency.KeyFromContext(ctx)
+	if err := s.keys.Begin(key); err != nil {
+		if errors.Is(err, ErrDuplicateRequest) {
+			return UpdateMetricServiceResult{ID: params.MName, MType: params.MType, Delta: params.Delta}, nil
+		}
+
+		return UpdateMetricServiceResult{}, fmt.Errorf("internal/server/service/update_metric_service.go: begin key %s error %w", key, err)
+	}
+
 	if err := s.counterRep.Create(ctx, &cntr); err != nil {
+		s.keys.Abort(key)
 		return UpdateMetricServiceResult{}, fmt.Errorf("internal/server/service/update_metric_service.go: create counter %+v error %w", cntr, err)
 	}
+	s.keys.Commit(key)
 
 	return UpdateMetricServiceResult{
 		ID:    params.MName,
@@ -4,11 +4,14 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/mocks/server/repositories"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/idempotency"
 )
 
 func TestUpdateMetricService_Call(t *testing.T) {
@@ -107,7 +110,7 @@ func TestUpdateMetricService_Call(t *testing.T) {
 			tt.args.gaugeRep = repositories.NewMockIGaugeRepository(cntr)
 			tt.prepare(&tt.args)
 
-			service := NewUpdateMetricService(tt.args.counterRep, tt.args.gaugeRep)
+			service := NewUpdateMetricService(tt.args.counterRep, tt.args.gaugeRep, NewIdempotencyKeys(&config.Config{}))
 			result, err := service.Call(context.Background(), tt.args.params)
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -118,3 +121,32 @@ func TestUpdateMetricService_Call(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateMetricService_Call_idempotency(t *testing.T) {
+	cntr := gomock.NewController(t)
+	defer cntr.Finish()
+
+	counterRep := repositories.NewMockICounterRepository(cntr)
+	counterRep.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(ctx context.Context, c *models.Counter) error {
+		c.Value = 20
+		return nil
+	})
+
+	service := NewUpdateMetricService(
+		counterRep,
+		repositories.NewMockIGaugeRepository(cntr),
+		NewIdempotencyKeys(&config.Config{IdempotencyWindow: time.Minute}),
+	)
+
+	params := UpdateMetricServiceParams{MName: "test", MType: models.CounterType, Delta: 10}
+	ctx := idempotency.WithKey(context.Background(), "report-1")
+
+	result, err := service.Call(ctx, params)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20), result.Delta)
+
+	// retried request is acknowledged without applying the increment again
+	result, err = service.Call(ctx, params)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), result.Delta)
+}
@@ -2,10 +2,13 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/models"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/repositories"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/idempotency"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
 	"go.uber.org/zap"
 )
@@ -23,22 +26,33 @@ type IUpdateMetricsService interface {
 	Call(context.Context, UpdateMetricsServiceParams) (UpdateMetricsServiceResult, error)
 }
 
+var (
+	// ErrBatchTooLarge returned when the batch has more metrics than the server accepts
+	ErrBatchTooLarge = errors.New("update metrics: batch is too large")
+	// ErrInvalidMetric returned when the batch has a metric without name or with unknown type
+	ErrInvalidMetric = errors.New("update metrics: invalid metric")
+)
+
 var _ IUpdateMetricsService = (*UpdateMetricsService)(nil)
 var _ CntrRep = (*repositories.CounterRepository)(nil)
 var _ GGRep = (*repositories.GaugeRepository)(nil)
 
 // UpdateMetricsService это сервис, который отвечает за логику обновления/создания сразу нескольких метрик.
 type UpdateMetricsService struct {
-	counterRep CntrRep
-	gaugeRep   GGRep
-	lg         *logging.ZapLogger
+	counterRep   CntrRep
+	gaugeRep     GGRep
+	keys         IdempotencyStore
+	maxBatchSize int
+	lg           *logging.ZapLogger
 }
 
-func NewUpdateMetricsService(counterRep CntrRep, gaugeRep GGRep, lg *logging.ZapLogger) *UpdateMetricsService {
+func NewUpdateMetricsService(counterRep CntrRep, gaugeRep GGRep, keys IdempotencyStore, cfg *config.Config, lg *logging.ZapLogger) *UpdateMetricsService {
 	return &UpdateMetricsService{
-		counterRep: counterRep,
-		gaugeRep:   gaugeRep,
-		lg:         lg,
+		counterRep:   counterRep,
+		gaugeRep:     gaugeRep,
+		keys:         keys,
+		maxBatchSize: cfg.MaxBatchSize,
+		lg:           lg,
 	}
 }
 
@@ -60,11 +74,21 @@ type UpdateMetricsServiceResult struct {
 func (s *UpdateMetricsService) Call(ctx context.Context, params UpdateMetricsServiceParams) (UpdateMetricsServiceResult, error) {
 	svcCtx := s.lg.WithContextFields(ctx, zap.Any("actor", "update_metrics_service"))
 
+	if s.maxBatchSize > 0 && len(params) > s.maxBatchSize {
+		return UpdateMetricsServiceResult{}, fmt.Errorf("%w: %d metrics, max %d", ErrBatchTooLarge, len(params), s.maxBatchSize)
+	}
+
+	for _, el := range params {
+		if el.ID == "" || el.MType != models.CounterType && el.MType != models.GaugeType {
+			return UpdateMetricsServiceResult{}, fmt.Errorf("%w: name %q, type %q", ErrInvalidMetric, el.ID, el.MType)
+		}
+	}
+
 	cntrs, ggs := s.group(params)
 
 	if len(cntrs) > 0 {
-		if err := s.counterRep.SaveCollection(svcCtx, cntrs); err != nil {
-			return UpdateMetricsServiceResult{}, fmt.Errorf("update_metrics_service.go: save counters error %w", err)
+		if err := s.saveCounters(svcCtx, cntrs); err != nil {
+			return UpdateMetricsServiceResult{}, err
 		}
 	}
 
@@ -77,6 +101,27 @@ func (s *UpdateMetricsService) Call(ctx context.Context, params UpdateMetricsSer
 	return UpdateMetricsServiceResult{}, nil
 }
 
+// saveCounters saves counters once per idempotency key, retried increments are skipped
+func (s *UpdateMetricsService) saveCounters(ctx context.Context, cntrs []models.Counter) error {
+	key := idempotency.KeyFromContext(ctx)
+	if err := s.keys.Begin(key); err != nil {
+		if errors.Is(err, ErrDuplicateRequest) {
+			s.lg.InfoCtx(ctx, "counters already applied, skip", zap.String("idempotency_key", key))
+			return nil
+		}
+
+		return fmt.Errorf("update_metrics_service.go: begin key %s error %w", key, err)
+	}
+
+	if err := s.counterRep.SaveCollection(ctx, cntrs); err != nil {
+		s.keys.Abort(key)
+		return fmt.Errorf("update_metrics_service.go: save counters error %w", err)
+	}
+	s.keys.Commit(key)
+
+	return nil
+}
+
 func (s *UpdateMetricsService) group(total UpdateMetricsServiceParams) (cntrs []models.Counter, gs []models.Gauge) {
 	cntrs = make([]models.Counter, 0, len(total))
 	gs = make([]models.Gauge, 0, len(total))
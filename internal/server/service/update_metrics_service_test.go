@@ -4,12 +4,14 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	repoMock "github.com/vysogota0399/mem_stats_monitoring/internal/mocks/server/repositories"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/idempotency"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
 )
 
@@ -18,6 +20,8 @@ func TestUpdateMetricsService_Call(t *testing.T) {
 		params     UpdateMetricsServiceParams
 		counterRep *repoMock.MockICounterRepository
 		gaugeRep   *repoMock.MockIGaugeRepository
+		keys       *IdempotencyKeys
+		key        string
 	}
 	tests := []struct {
 		name    string
@@ -81,6 +85,65 @@ func TestUpdateMetricsService_Call(t *testing.T) {
 			wantErr: false,
 			want:    UpdateMetricsServiceResult{},
 		},
+		{
+			name: "when counters already applied with the same key",
+			args: args{
+				key: "report-1",
+				params: UpdateMetricsServiceParams{
+					{
+						ID:    "test1",
+						MType: models.CounterType,
+						Delta: 10,
+					},
+					{
+						ID:    "test2",
+						MType: models.GaugeType,
+						Value: 10.5,
+					},
+				},
+			},
+			prepare: func(args *args) {
+				assert.NoError(t, args.keys.Begin("report-1"))
+				args.keys.Commit("report-1")
+				args.gaugeRep.EXPECT().SaveCollection(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantErr: false,
+			want:    UpdateMetricsServiceResult{},
+		},
+		{
+			name: "when counters with the same key are being applied",
+			args: args{
+				key: "report-1",
+				params: UpdateMetricsServiceParams{
+					{
+						ID:    "test1",
+						MType: models.CounterType,
+						Delta: 10,
+					},
+				},
+			},
+			prepare: func(args *args) {
+				assert.NoError(t, args.keys.Begin("report-1"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "when save counters with key error, key is released",
+			args: args{
+				key: "report-1",
+				params: UpdateMetricsServiceParams{
+					{
+						ID:    "test1",
+						MType: models.CounterType,
+						Delta: 10,
+					},
+				},
+			},
+			prepare: func(args *args) {
+				args.counterRep.EXPECT().SaveCollection(gomock.Any(), gomock.Any()).Return(errors.New("save counters error"))
+			},
+			wantErr: true,
+		},
 	}
 
 	cntr := gomock.NewController(t)
@@ -93,16 +156,20 @@ func TestUpdateMetricsService_Call(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.args.counterRep = repoMock.NewMockICounterRepository(cntr)
 			tt.args.gaugeRep = repoMock.NewMockIGaugeRepository(cntr)
+			tt.args.keys = NewIdempotencyKeys(&config.Config{IdempotencyWindow: time.Minute})
 			tt.prepare(&tt.args)
 
 			rep := NewUpdateMetricsService(
 				tt.args.counterRep,
 				tt.args.gaugeRep,
+				tt.args.keys,
+				&config.Config{},
 				lg,
 			)
-			got, err := rep.Call(context.Background(), tt.args.params)
+			got, err := rep.Call(idempotency.WithKey(context.Background(), tt.args.key), tt.args.params)
 			if tt.wantErr {
 				assert.Error(t, err)
+				assert.NotErrorIs(t, tt.args.keys.Begin(tt.args.key), ErrDuplicateRequest)
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.want, got)
@@ -118,3 +185,62 @@ func int64Ptr(v int64) *int64 {
 func float64Ptr(v float64) *float64 {
 	return &v
 }
+
+func TestUpdateMetricsService_Call_batchTooLarge(t *testing.T) {
+	cntr := gomock.NewController(t)
+	defer cntr.Finish()
+
+	lg, err := logging.NewZapLogger(&config.Config{})
+	assert.NoError(t, err)
+
+	svc := NewUpdateMetricsService(
+		repoMock.NewMockICounterRepository(cntr),
+		repoMock.NewMockIGaugeRepository(cntr),
+		NewIdempotencyKeys(&config.Config{}),
+		&config.Config{MaxBatchSize: 1},
+		lg,
+	)
+
+	_, err = svc.Call(context.Background(), UpdateMetricsServiceParams{
+		{ID: "test1", MType: models.GaugeType, Value: 1},
+		{ID: "test2", MType: models.GaugeType, Value: 2},
+	})
+	assert.ErrorIs(t, err, ErrBatchTooLarge)
+}
+
+func TestUpdateMetricsService_Call_invalidMetric(t *testing.T) {
+	cntr := gomock.NewController(t)
+	defer cntr.Finish()
+
+	lg, err := logging.NewZapLogger(&config.Config{})
+	assert.NoError(t, err)
+
+	svc := NewUpdateMetricsService(
+		repoMock.NewMockICounterRepository(cntr),
+		repoMock.NewMockIGaugeRepository(cntr),
+		NewIdempotencyKeys(&config.Config{}),
+		&config.Config{},
+		lg,
+	)
+
+	tests := []struct {
+		name   string
+		params UpdateMetricsServiceParams
+	}{
+		{
+			name:   "empty name",
+			params: UpdateMetricsServiceParams{{MType: models.GaugeType, Value: 1}},
+		},
+		{
+			name:   "unknown type",
+			params: UpdateMetricsServiceParams{{ID: "test", MType: "unknown"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.Call(context.Background(), tt.params)
+			assert.ErrorIs(t, err, ErrInvalidMetric)
+		})
+	}
+}
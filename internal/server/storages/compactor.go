@@ -0,0 +1,105 @@
+package storages
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Compactor builds rollups and deletes expired samples of the history in the background.
+// The compactor is disabled when the interval is not positive.
+type Compactor struct {
+	history   History
+	retention Retention
+	interval  time.Duration
+	lg        *logging.ZapLogger
+	now       func() time.Time
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+func NewCompactor(lc fx.Lifecycle, cfg *config.Config, lg *logging.ZapLogger, history History, retention Retention) *Compactor {
+	c := &Compactor{
+		history:   history,
+		retention: retention,
+		interval:  cfg.CompactionInterval,
+		lg:        lg,
+		now:       time.Now,
+	}
+
+	lc.Append(
+		fx.Hook{
+			OnStart: func(ctx context.Context) error {
+				if c.interval <= 0 {
+					return nil
+				}
+
+				c.start()
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				return c.stop(ctx)
+			},
+		},
+	)
+
+	return c
+}
+
+func (c *Compactor) start() {
+	ctx, cancel := context.WithCancel(
+		c.lg.WithContextFields(context.Background(), zap.String("actor", "history_compactor")),
+	)
+	c.cancel = cancel
+	c.done = make(chan struct{})
+
+	go func() {
+		defer close(c.done)
+
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.Compact(ctx); err != nil {
+					c.lg.ErrorCtx(ctx, "history compaction error", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+// stop waits for the running compaction
+func (c *Compactor) stop(ctx context.Context) error {
+	if c.cancel == nil {
+		return nil
+	}
+
+	c.cancel()
+
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("storages: stop compactor error %w", ctx.Err())
+	}
+}
+
+// Compact builds rollups of the completed buckets and deletes samples outside of the retention
+func (c *Compactor) Compact(ctx context.Context) error {
+	started := c.now()
+	if err := c.history.Compact(ctx, c.retention, started); err != nil {
+		return fmt.Errorf("storages: compact history error %w", err)
+	}
+
+	c.lg.DebugCtx(ctx, "history compacted", zap.Duration("duration", c.now().Sub(started)))
+	return nil
+}
@@ -0,0 +1,107 @@
+package storages
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+// fakeHistory records compactions
+type fakeHistory struct {
+	mu    sync.Mutex
+	calls []Retention
+	err   error
+}
+
+func (h *fakeHistory) Samples(ctx context.Context, mType, mName string, resolution time.Duration, from, to time.Time) ([]models.Sample, error) {
+	return nil, nil
+}
+
+func (h *fakeHistory) Compact(ctx context.Context, retention Retention, now time.Time) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.calls = append(h.calls, retention)
+	return h.err
+}
+
+func (h *fakeHistory) len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.calls)
+}
+
+func TestCompactor(t *testing.T) {
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: -1})
+	require.NoError(t, err)
+
+	retention := Retention{{TTL: time.Hour}, {Resolution: time.Minute, TTL: 24 * time.Hour}}
+
+	tests := []struct {
+		name      string
+		interval  time.Duration
+		err       error
+		wantCalls bool
+	}{
+		{
+			name:      "compacts history periodically",
+			interval:  10 * time.Millisecond,
+			wantCalls: true,
+		},
+		{
+			name:      "keeps compacting after errors",
+			interval:  10 * time.Millisecond,
+			err:       errors.New("storage error"),
+			wantCalls: true,
+		},
+		{
+			name:     "disabled",
+			interval: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history := &fakeHistory{err: tt.err}
+
+			app := fxtest.New(t,
+				fx.Supply(&config.Config{CompactionInterval: tt.interval}, lg, retention),
+				fx.Provide(
+					func() History { return history },
+					NewCompactor,
+				),
+				fx.Invoke(func(*Compactor) {}),
+			)
+			app.RequireStart()
+
+			if tt.wantCalls {
+				require.Eventually(t, func() bool { return history.len() >= 2 }, time.Second, 5*time.Millisecond)
+			} else {
+				time.Sleep(30 * time.Millisecond)
+			}
+
+			app.RequireStop()
+			calls := history.len()
+
+			time.Sleep(30 * time.Millisecond)
+			assert.Equal(t, calls, history.len(), "compactor is stopped with the app")
+
+			if tt.wantCalls {
+				assert.Equal(t, retention, history.calls[0])
+			} else {
+				assert.Zero(t, calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package storages
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/models"
+)
+
+// History keeps timestamped samples of the accepted metric values and their rollups
+type History interface {
+	// Samples returns samples of the resolution within [from, to], zero resolution means raw samples
+	Samples(ctx context.Context, mType, mName string, resolution time.Duration, from, to time.Time) ([]models.Sample, error)
+	// Compact builds rollups of the completed buckets and deletes samples outside of the retention
+	Compact(ctx context.Context, retention Retention, now time.Time) error
+}
+
+var _ History = (*Memory)(nil)
+var _ History = (*PG)(nil)
+
+// NewHistory returns samples history of the storage
+func NewHistory(strg Storage) (History, error) {
+	h, ok := strg.(History)
+	if !ok {
+		return nil, fmt.Errorf("storages: %T doesn't keep samples history", strg)
+	}
+
+	return h, nil
+}
+
+type skipHistory string
+
+const skipHistoryKey skipHistory = "skipHistory"
+
+// WithoutHistory returns context of the updates which are not recorded into the samples history,
+// e.g. values restored at startup which were accepted before
+func WithoutHistory(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipHistoryKey, struct{}{})
+}
+
+func historySkipped(ctx context.Context) bool {
+	return ctx.Value(skipHistoryKey) != nil
+}
+
+// defaultHistorySize is the number of samples kept per series in memory
+const defaultHistorySize = 1000
+
+type seriesKey struct {
+	mType string
+	mName string
+}
+
+// MemoryHistory keeps the latest raw samples of every series in ring buffers, the oldest samples are overwritten.
+// Rollups are kept ordered by the bucket start.
+type MemoryHistory struct {
+	mu      sync.RWMutex
+	series  map[seriesKey]*ring
+	rollups map[time.Duration]*memoryRollups
+	size    int
+	now     func() time.Time
+}
+
+// memoryRollups of the resolution, buckets before compactedTo are built
+type memoryRollups struct {
+	series      map[seriesKey][]models.Sample
+	compactedTo time.Time
+}
+
+func NewMemoryHistory(size int) *MemoryHistory {
+	if size <= 0 {
+		size = defaultHistorySize
+	}
+
+	return &MemoryHistory{
+		series:  make(map[seriesKey]*ring),
+		rollups: make(map[time.Duration]*memoryRollups),
+		size:    size,
+		now:     time.Now,
+	}
+}
+
+// Append adds the sample of the series with the current time
+func (h *MemoryHistory) Append(mType, mName string, value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := seriesKey{mType: mType, mName: mName}
+	r, ok := h.series[key]
+	if !ok {
+		r = newRing(h.size)
+		h.series[key] = r
+	}
+
+	r.push(models.NewSample(h.now(), value))
+}
+
+// Samples returns samples of the series within [from, to] in the order they were appended, rollups are returned
+// starting from the bucket containing from
+func (h *MemoryHistory) Samples(ctx context.Context, mType, mName string, resolution time.Duration, from, to time.Time) ([]models.Sample, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.samples(seriesKey{mType: mType, mName: mName}, resolution, from, to), nil
+}
+
+func (h *MemoryHistory) samples(key seriesKey, resolution time.Duration, from, to time.Time) []models.Sample {
+	res := make([]models.Sample, 0)
+
+	if resolution == 0 {
+		if r, ok := h.series[key]; ok {
+			res = r.between(from, to)
+		}
+		return res
+	}
+
+	rollups, ok := h.rollups[resolution]
+	if !ok {
+		return res
+	}
+
+	from = bucket(from, resolution)
+	for _, s := range rollups.series[key] {
+		if s.Timestamp.Before(from) || s.Timestamp.After(to) {
+			continue
+		}
+
+		res = append(res, s)
+	}
+
+	return res
+}
+
+// keys returns series having samples of the resolution
+func (h *MemoryHistory) keys(resolution time.Duration) []seriesKey {
+	res := make([]seriesKey, 0)
+	if resolution == 0 {
+		for key := range h.series {
+			res = append(res, key)
+		}
+		return res
+	}
+
+	if rollups, ok := h.rollups[resolution]; ok {
+		for key := range rollups.series {
+			res = append(res, key)
+		}
+	}
+
+	return res
+}
+
+// Compact builds rollups of every tier from the previous one and deletes samples older than the tier ttl
+func (h *MemoryHistory) Compact(ctx context.Context, retention Retention, now time.Time) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i := 1; i < len(retention); i++ {
+		h.rollup(retention[i-1].Resolution, retention[i].Resolution, now)
+	}
+
+	for _, tier := range retention {
+		h.expire(tier, now.Add(-tier.TTL))
+	}
+
+	return nil
+}
+
+// rollup aggregates source samples of the completed buckets which are not built yet
+func (h *MemoryHistory) rollup(source, resolution time.Duration, now time.Time) {
+	rollups, ok := h.rollups[resolution]
+	if !ok {
+		rollups = &memoryRollups{series: make(map[seriesKey][]models.Sample)}
+		h.rollups[resolution] = rollups
+	}
+
+	end := bucket(now, resolution)
+	if !end.After(rollups.compactedTo) {
+		return
+	}
+
+	for _, key := range h.keys(source) {
+		var (
+			current time.Time
+			group   []models.Sample
+		)
+
+		flush := func() {
+			if len(group) == 0 {
+				return
+			}
+
+			rollups.series[key] = append(rollups.series[key], models.Aggregate(key.mType, current, group))
+			group = group[:0]
+		}
+
+		for _, s := range h.samples(key, source, rollups.compactedTo, end) {
+			if !s.Timestamp.Before(end) {
+				break
+			}
+
+			if b := bucket(s.Timestamp, resolution); !b.Equal(current) {
+				flush()
+				current = b
+			}
+
+			group = append(group, s)
+		}
+		flush()
+	}
+
+	rollups.compactedTo = end
+}
+
+// expire deletes samples of the tier created before the cutoff
+func (h *MemoryHistory) expire(tier RetentionTier, cutoff time.Time) {
+	if tier.Resolution == 0 {
+		for key, r := range h.series {
+			if r.trim(cutoff) == 0 {
+				delete(h.series, key)
+			}
+		}
+		return
+	}
+
+	rollups, ok := h.rollups[tier.Resolution]
+	if !ok {
+		return
+	}
+
+	for key, samples := range rollups.series {
+		i := 0
+		for i < len(samples) && samples[i].Timestamp.Before(cutoff) {
+			i++
+		}
+
+		if i == len(samples) {
+			delete(rollups.series, key)
+			continue
+		}
+
+		rollups.series[key] = samples[i:]
+	}
+}
+
+// ring is a fixed size buffer of samples
+type ring struct {
+	buf   []models.Sample
+	start int // index of the oldest sample
+	count int
+}
+
+func newRing(size int) *ring {
+	return &ring{buf: make([]models.Sample, size)}
+}
+
+func (r *ring) push(s models.Sample) {
+	r.buf[(r.start+r.count)%len(r.buf)] = s
+	if r.count == len(r.buf) {
+		r.start = (r.start + 1) % len(r.buf)
+		return
+	}
+
+	r.count++
+}
+
+// trim drops samples created before the cutoff and returns the number of samples left
+func (r *ring) trim(cutoff time.Time) int {
+	for r.count > 0 && r.buf[r.start].Timestamp.Before(cutoff) {
+		r.buf[r.start] = models.Sample{}
+		r.start = (r.start + 1) % len(r.buf)
+		r.count--
+	}
+
+	return r.count
+}
+
+// between returns samples within [from, to] starting from the oldest one
+func (r *ring) between(from, to time.Time) []models.Sample {
+	res := make([]models.Sample, 0)
+	for i := range r.count {
+		s := r.buf[(r.start+i)%len(r.buf)]
+		if s.Timestamp.Before(from) || s.Timestamp.After(to) {
+			continue
+		}
+
+		res = append(res, s)
+	}
+
+	return res
+}
+
+// sampleValue converts the stored metric value to the sample value
+func sampleValue(val any) (float64, error) {
+	switch v := val.(type) {
+	case float64:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	default:
+		return 0, fmt.Errorf("storages: unexpected metric value type %T", val)
+	}
+}
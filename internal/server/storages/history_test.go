@@ -0,0 +1,243 @@
+package storages
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/models"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+)
+
+// clock returns the next second on every call starting from the start
+func clock(start time.Time) func() time.Time {
+	now := start
+	return func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+}
+
+func TestMemoryHistory_Samples(t *testing.T) {
+	start := time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+	at := func(sec int) time.Time { return start.Add(time.Duration(sec) * time.Second) }
+
+	tests := []struct {
+		name   string
+		size   int
+		values []float64
+		from   time.Time
+		to     time.Time
+		want   []models.Sample
+	}{
+		{
+			name:   "returns samples in the order they were appended",
+			size:   5,
+			values: []float64{1, 2, 3},
+			from:   start,
+			to:     at(10),
+			want: []models.Sample{
+				models.NewSample(at(1), 1),
+				models.NewSample(at(2), 2),
+				models.NewSample(at(3), 3),
+			},
+		},
+		{
+			name:   "overwrites the oldest samples when full",
+			size:   3,
+			values: []float64{1, 2, 3, 4, 5},
+			from:   start,
+			to:     at(10),
+			want: []models.Sample{
+				models.NewSample(at(3), 3),
+				models.NewSample(at(4), 4),
+				models.NewSample(at(5), 5),
+			},
+		},
+		{
+			name:   "filters samples by the range inclusively",
+			size:   5,
+			values: []float64{1, 2, 3, 4, 5},
+			from:   at(2),
+			to:     at(4),
+			want: []models.Sample{
+				models.NewSample(at(2), 2),
+				models.NewSample(at(3), 3),
+				models.NewSample(at(4), 4),
+			},
+		},
+		{
+			name: "returns empty samples of unknown series",
+			size: 5,
+			from: start,
+			to:   at(10),
+			want: []models.Sample{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewMemoryHistory(tt.size)
+			h.now = clock(start)
+
+			for _, v := range tt.values {
+				h.Append(models.GaugeType, "alloc", v)
+			}
+			h.Append(models.CounterType, "alloc", 100)
+
+			got, err := h.Samples(context.Background(), models.GaugeType, "alloc", 0, tt.from, tt.to)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMemory_Samples(t *testing.T) {
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: -1})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	start := time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	t.Run("records accepted values", func(t *testing.T) {
+		m := NewMemory(lg)
+		m.history.now = clock(start)
+
+		require.NoError(t, m.CreateOrUpdate(ctx, models.GaugeType, "alloc", 1.5))
+		require.NoError(t, m.CreateOrUpdate(ctx, models.GaugeType, "alloc", 2.5))
+		require.NoError(t, m.IncrementCounter(ctx, "poll_count", 3))
+		require.NoError(t, m.IncrementCounter(ctx, "poll_count", 4))
+
+		gauges, err := m.Samples(ctx, models.GaugeType, "alloc", 0, start, start.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, []models.Sample{
+			models.NewSample(start.Add(time.Second), 1.5),
+			models.NewSample(start.Add(2*time.Second), 2.5),
+		}, gauges)
+
+		counters, err := m.Samples(ctx, models.CounterType, "poll_count", 0, start, start.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, []models.Sample{
+			models.NewSample(start.Add(3*time.Second), 3),
+			models.NewSample(start.Add(4*time.Second), 7),
+		}, counters)
+	})
+
+	t.Run("keeps the configured number of samples", func(t *testing.T) {
+		m := NewMemory(lg).WithHistorySize(2)
+
+		for _, v := range []float64{1, 2, 3} {
+			require.NoError(t, m.CreateOrUpdate(ctx, models.GaugeType, "alloc", v))
+		}
+
+		got, err := m.Samples(ctx, models.GaugeType, "alloc", 0, time.Time{}, time.Now())
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+		assert.Equal(t, 2.0, got[0].Value)
+		assert.Equal(t, 3.0, got[1].Value)
+	})
+
+	t.Run("without history", func(t *testing.T) {
+		m := &Memory{storage: make(map[string]map[string]any), lg: lg}
+
+		require.NoError(t, m.CreateOrUpdate(ctx, models.GaugeType, "alloc", 1.5))
+
+		got, err := m.Samples(ctx, models.GaugeType, "alloc", 0, time.Time{}, time.Now())
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+}
+
+func TestMemoryHistory_Compact(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+	retention := Retention{
+		{TTL: 2 * time.Hour},
+		{Resolution: time.Minute, TTL: 24 * time.Hour},
+		{Resolution: time.Hour, TTL: 48 * time.Hour},
+	}
+
+	// appends a gauge and a counter sample every 20 seconds during the first two hours
+	h := NewMemoryHistory(1000)
+	now := start
+	h.now = func() time.Time { return now }
+	for i := range 360 {
+		now = start.Add(time.Duration(i) * 20 * time.Second)
+		h.Append(models.GaugeType, "alloc", float64(i%3))
+		h.Append(models.CounterType, "poll_count", float64(i+1))
+	}
+
+	require.NoError(t, h.Compact(ctx, retention, start.Add(2*time.Hour+30*time.Second)))
+
+	t.Run("builds minute rollups from raw samples", func(t *testing.T) {
+		got, err := h.Samples(ctx, models.GaugeType, "alloc", time.Minute, start, start.Add(2*time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, []models.Sample{
+			{Timestamp: start, Value: 1, Min: 0, Max: 2, Count: 3},
+			{Timestamp: start.Add(time.Minute), Value: 1, Min: 0, Max: 2, Count: 3},
+			{Timestamp: start.Add(2 * time.Minute), Value: 1, Min: 0, Max: 2, Count: 3},
+		}, got)
+
+		counters, err := h.Samples(ctx, models.CounterType, "poll_count", time.Minute, start, start.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, []models.Sample{
+			{Timestamp: start, Value: 3, Min: 1, Max: 3, Count: 3},
+			{Timestamp: start.Add(time.Minute), Value: 6, Min: 4, Max: 6, Count: 3},
+		}, counters)
+	})
+
+	t.Run("builds hour rollups from minute rollups", func(t *testing.T) {
+		got, err := h.Samples(ctx, models.CounterType, "poll_count", time.Hour, start.Add(30*time.Minute), start.Add(3*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, []models.Sample{
+			{Timestamp: start, Value: 180, Min: 1, Max: 180, Count: 180},
+			{Timestamp: start.Add(time.Hour), Value: 360, Min: 181, Max: 360, Count: 180},
+		}, got)
+	})
+
+	t.Run("doesn't build incomplete buckets twice", func(t *testing.T) {
+		require.NoError(t, h.Compact(ctx, retention, start.Add(2*time.Hour+50*time.Second)))
+
+		got, err := h.Samples(ctx, models.GaugeType, "alloc", time.Minute, start, start.Add(3*time.Hour))
+		require.NoError(t, err)
+		assert.Len(t, got, 120)
+	})
+
+	t.Run("deletes expired samples", func(t *testing.T) {
+		require.NoError(t, h.Compact(ctx, retention, start.Add(25*time.Hour)))
+
+		raw, err := h.Samples(ctx, models.GaugeType, "alloc", 0, start, start.Add(48*time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, raw)
+
+		minutes, err := h.Samples(ctx, models.GaugeType, "alloc", time.Minute, start, start.Add(48*time.Hour))
+		require.NoError(t, err)
+		assert.Len(t, minutes, 60, "only the second hour is kept")
+		assert.Equal(t, start.Add(time.Hour), minutes[0].Timestamp)
+
+		hours, err := h.Samples(ctx, models.GaugeType, "alloc", time.Hour, start, start.Add(48*time.Hour))
+		require.NoError(t, err)
+		assert.Len(t, hours, 2)
+	})
+}
+
+func TestNewHistory(t *testing.T) {
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: -1})
+	require.NoError(t, err)
+
+	memory := NewMemory(lg)
+	h, err := NewHistory(memory)
+	require.NoError(t, err)
+	assert.Same(t, memory, h)
+
+	_, err = NewHistory(withoutHistory{Storage: memory})
+	assert.Error(t, err)
+}
+
+// withoutHistory hides samples history of the storage
+type withoutHistory struct {
+	Storage
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/models"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
@@ -15,6 +16,7 @@ type Memory struct {
 	storage map[string]map[string]any
 	mutex   sync.RWMutex
 	lg      *logging.ZapLogger
+	history *MemoryHistory // samples are not kept when nil
 }
 
 func NewMemory(lg *logging.ZapLogger) *Memory {
@@ -22,9 +24,16 @@ func NewMemory(lg *logging.ZapLogger) *Memory {
 		storage: make(map[string]map[string]any),
 		mutex:   sync.RWMutex{},
 		lg:      lg,
+		history: NewMemoryHistory(defaultHistorySize),
 	}
 }
 
+// WithHistorySize sets the number of samples kept per series
+func (m *Memory) WithHistorySize(size int) *Memory {
+	m.history = NewMemoryHistory(size)
+	return m
+}
+
 // CreateOrUpdate implements Storage. CreateOrUpdate is used to create or update a metric.
 // It should not lock the mutex, because it will be called from Tx.
 func (m *Memory) CreateOrUpdate(ctx context.Context, mType, mName string, val any) error {
@@ -41,9 +50,37 @@ func (m *Memory) CreateOrUpdate(ctx context.Context, mType, mName string, val an
 	}
 
 	mTypeStorage[mName] = val
+
+	if m.history != nil && !historySkipped(ctx) {
+		v, err := sampleValue(val)
+		if err != nil {
+			return fmt.Errorf("memory: create or update %s %s error %w", mType, mName, err)
+		}
+
+		m.history.Append(mType, mName, v)
+	}
+
 	return nil
 }
 
+// Samples implements History
+func (m *Memory) Samples(ctx context.Context, mType, mName string, resolution time.Duration, from, to time.Time) ([]models.Sample, error) {
+	if m.history == nil {
+		return []models.Sample{}, nil
+	}
+
+	return m.history.Samples(ctx, mType, mName, resolution, from, to)
+}
+
+// Compact implements History
+func (m *Memory) Compact(ctx context.Context, retention Retention, now time.Time) error {
+	if m.history == nil {
+		return nil
+	}
+
+	return m.history.Compact(ctx, retention, now)
+}
+
 func (m *Memory) GetGauge(ctx context.Context, record *models.Gauge) error {
 	m.lg.DebugCtx(ctx, "get gauge", zap.Any("record", record))
 	defer m.lg.DebugCtx(ctx, "get gauge done")
@@ -92,6 +92,9 @@ func (r *MetricsRestorer) Call(ctx context.Context) error {
 			atomic.AddInt64(&sucCntr, 1)
 			r.lg.DebugCtx(ctx, "save new record to storage", zap.Any("record", record))
 
+			// restored values were recorded into the history when they were accepted
+			ctx = WithoutHistory(ctx)
+
 			switch record.MType {
 			case models.CounterType:
 				return r.strg.CreateOrUpdate(ctx, record.MType, record.MName, int64(record.MValue.(float64)))
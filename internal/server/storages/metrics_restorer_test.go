@@ -6,11 +6,14 @@ import (
 	"errors"
 	"io"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/mocks/server/storages"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/models"
 	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
 )
 
@@ -165,3 +168,32 @@ func TestMetricsRestorer_Call(t *testing.T) {
 		})
 	}
 }
+
+func TestMetricsRestorer_Call_withoutHistory(t *testing.T) {
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: -1})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	strg := NewMemory(lg)
+
+	restorer := &MetricsRestorer{
+		source: &ReadCloserTarget{Buffer: bytes.NewBufferString(
+			"{\"type\":\"gauge\",\"name\":\"alloc\",\"value\":1.5}\n{\"type\":\"counter\",\"name\":\"poll_count\",\"value\":3}\n",
+		)},
+		lg:   lg,
+		cfg:  &config.Config{},
+		strg: strg,
+	}
+	require.NoError(t, restorer.Call(ctx))
+
+	gauge := &models.Gauge{Name: "alloc"}
+	require.NoError(t, strg.GetGauge(ctx, gauge))
+	assert.Equal(t, 1.5, gauge.Value)
+
+	// restored values were recorded before the restart, they are not new samples
+	for _, s := range []struct{ mType, mName string }{{models.GaugeType, "alloc"}, {models.CounterType, "poll_count"}} {
+		got, err := strg.Samples(ctx, s.mType, s.mName, 0, time.Time{}, time.Now())
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	}
+}
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS samples(
+  id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+  type VARCHAR NOT NULL,
+  name VARCHAR NOT NULL,
+  value double precision NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS samples_type_name_created_at_idx ON samples (type, name, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS samples;
+-- +goose StatementEnd
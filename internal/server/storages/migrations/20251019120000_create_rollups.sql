@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS rollups(
+  type VARCHAR NOT NULL,
+  name VARCHAR NOT NULL,
+  resolution BIGINT NOT NULL,
+  bucket TIMESTAMP WITH TIME ZONE NOT NULL,
+  value double precision NOT NULL,
+  min double precision NOT NULL,
+  max double precision NOT NULL,
+  count BIGINT NOT NULL,
+  PRIMARY KEY (type, name, resolution, bucket)
+);
+
+CREATE INDEX IF NOT EXISTS rollups_resolution_bucket_idx ON rollups (resolution, bucket);
+CREATE INDEX IF NOT EXISTS samples_created_at_idx ON samples (created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS samples_created_at_idx;
+DROP TABLE IF EXISTS rollups;
+-- +goose StatementEnd
@@ -50,7 +50,7 @@ func (s SourceFileFactory) Source(cfg *config.Config) (io.ReadCloser, error) {
 
 func NewPersistance(lc fx.Lifecycle, cfg *config.Config, dumper Dumper, lg *logging.ZapLogger, srsb SourceBuilder) (*Persistance, error) {
 	strg := &Persistance{
-		Memory: NewMemory(lg),
+		Memory: NewMemory(lg).WithHistorySize(cfg.HistorySize),
 		lg:     lg,
 	}
 
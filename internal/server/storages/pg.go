@@ -168,14 +168,221 @@ func (pg *PG) CreateOrUpdate(ctx context.Context, mType, mName string, val any)
 		ON CONFLICT (name) DO UPDATE SET value = $2
 	`, table)
 
+	sample, err := sampleValue(val)
+	if err != nil {
+		return fmt.Errorf("pg: create or update failed error %w", err)
+	}
+
 	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
 
-	_, err := pg.db.ExecContext(dbCtx, query, mName, val)
+	tx, err := pg.db.BeginTx(dbCtx, nil)
 	if err != nil {
+		return fmt.Errorf("pg: begin tx failed error %w", err)
+	}
+	defer func() {
+		if rlberr := tx.Rollback(); rlberr != nil {
+			return
+		}
+	}()
+
+	if _, err := tx.ExecContext(dbCtx, query, mName, val); err != nil {
 		return fmt.Errorf("pg: create or update failed error %w", err)
 	}
 
+	if !historySkipped(ctx) {
+		if err := pg.insertSample(dbCtx, tx, mType, mName, sample); err != nil {
+			return fmt.Errorf("pg: create or update failed error %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("pg: commit tx failed error %w", err)
+	}
+
+	return nil
+}
+
+// insertSample appends the sample of the series within the transaction of the value update
+func (pg *PG) insertSample(ctx context.Context, tx *sql.Tx, mType, mName string, value float64) error {
+	query := `
+		INSERT INTO samples (type, name, value)
+		VALUES ($1, $2, $3)
+	`
+
+	if _, err := tx.ExecContext(ctx, query, mType, mName, value); err != nil {
+		return fmt.Errorf("pg: insert sample failed error %w", err)
+	}
+
+	return nil
+}
+
+// Samples implements History
+func (pg *PG) Samples(ctx context.Context, mType, mName string, resolution time.Duration, from, to time.Time) ([]models.Sample, error) {
+	if resolution > 0 {
+		return pg.rollups(ctx, mType, mName, resolution, from, to)
+	}
+
+	rows, err := pg.db.QueryContext(ctx, `
+		SELECT created_at, value
+		FROM samples
+		WHERE type = $1 AND name = $2 AND created_at BETWEEN $3 AND $4
+		ORDER BY created_at, id
+	`, mType, mName, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("pg: get samples failed error %w", err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			pg.lg.ErrorCtx(ctx, "db: failed to close rows", zap.Error(closeErr))
+		}
+	}()
+
+	samples := make([]models.Sample, 0)
+	for rows.Next() {
+		var (
+			ts    time.Time
+			value float64
+		)
+		if err := rows.Scan(&ts, &value); err != nil {
+			return nil, fmt.Errorf("pg: get samples failed error %w", err)
+		}
+		samples = append(samples, models.NewSample(ts, value))
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("pg: close rows err %w", err)
+	}
+
+	return samples, nil
+}
+
+// rollups returns rollups of the resolution starting from the bucket containing from
+func (pg *PG) rollups(ctx context.Context, mType, mName string, resolution time.Duration, from, to time.Time) ([]models.Sample, error) {
+	rows, err := pg.db.QueryContext(ctx, `
+		SELECT bucket, value, min, max, count
+		FROM rollups
+		WHERE type = $1 AND name = $2 AND resolution = $3 AND bucket BETWEEN $4 AND $5
+		ORDER BY bucket
+	`, mType, mName, int64(resolution.Seconds()), bucket(from, resolution), to)
+	if err != nil {
+		return nil, fmt.Errorf("pg: get rollups failed error %w", err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			pg.lg.ErrorCtx(ctx, "db: failed to close rows", zap.Error(closeErr))
+		}
+	}()
+
+	samples := make([]models.Sample, 0)
+	for rows.Next() {
+		s := models.Sample{}
+		if err := rows.Scan(&s.Timestamp, &s.Value, &s.Min, &s.Max, &s.Count); err != nil {
+			return nil, fmt.Errorf("pg: get rollups failed error %w", err)
+		}
+		samples = append(samples, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("pg: close rows err %w", err)
+	}
+
+	return samples, nil
+}
+
+// Compact implements History. Rollups are built starting from the last built bucket, so the job
+// catches up after downtime and rebuilding the last bucket is idempotent.
+func (pg *PG) Compact(ctx context.Context, retention Retention, now time.Time) error {
+	for i := 1; i < len(retention); i++ {
+		if err := pg.rollup(ctx, retention[i-1].Resolution, retention[i].Resolution, now); err != nil {
+			return fmt.Errorf("pg: compact failed error %w", err)
+		}
+	}
+
+	for _, tier := range retention {
+		if err := pg.expire(ctx, tier, now.Add(-tier.TTL)); err != nil {
+			return fmt.Errorf("pg: compact failed error %w", err)
+		}
+	}
+
+	return nil
+}
+
+// rollupOrigin aligns buckets with time.Truncate which operates on the time since the zero time
+const rollupOrigin = "0001-01-01 00:00:00+00"
+
+func (pg *PG) rollup(ctx context.Context, source, resolution time.Duration, now time.Time) error {
+	seconds := int64(resolution.Seconds())
+
+	var last sql.NullTime
+	if err := pg.db.QueryRowContext(ctx, `SELECT max(bucket) FROM rollups WHERE resolution = $1`, seconds).Scan(&last); err != nil {
+		return fmt.Errorf("pg: get last rollup failed error %w", err)
+	}
+
+	var start time.Time
+	if last.Valid {
+		start = last.Time
+	}
+
+	query := `
+		INSERT INTO rollups (type, name, resolution, bucket, value, min, max, count)
+		SELECT
+			type,
+			name,
+			$1::bigint,
+			date_bin(make_interval(secs => $1::double precision), created_at, $4::timestamptz) AS b,
+			CASE WHEN type = $5 THEN (array_agg(value ORDER BY created_at DESC, id DESC))[1] ELSE avg(value) END,
+			min(value),
+			max(value),
+			count(*)
+		FROM samples
+		WHERE created_at >= $2 AND created_at < $3
+		GROUP BY type, name, b
+		ON CONFLICT (type, name, resolution, bucket)
+		DO UPDATE SET value = EXCLUDED.value, min = EXCLUDED.min, max = EXCLUDED.max, count = EXCLUDED.count
+	`
+	args := []any{seconds, start, bucket(now, resolution), rollupOrigin, models.CounterType}
+
+	if source > 0 {
+		query = `
+			INSERT INTO rollups (type, name, resolution, bucket, value, min, max, count)
+			SELECT
+				type,
+				name,
+				$1::bigint,
+				date_bin(make_interval(secs => $1::double precision), bucket, $4::timestamptz) AS b,
+				CASE WHEN type = $5 THEN (array_agg(value ORDER BY bucket DESC))[1] ELSE sum(value * count) / sum(count) END,
+				min(min),
+				max(max),
+				sum(count)
+			FROM rollups
+			WHERE resolution = $6 AND bucket >= $2 AND bucket < $3
+			GROUP BY type, name, b
+			ON CONFLICT (type, name, resolution, bucket)
+			DO UPDATE SET value = EXCLUDED.value, min = EXCLUDED.min, max = EXCLUDED.max, count = EXCLUDED.count
+		`
+		args = append(args, int64(source.Seconds()))
+	}
+
+	if _, err := pg.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("pg: build rollups of %s failed error %w", resolution, err)
+	}
+
+	return nil
+}
+
+func (pg *PG) expire(ctx context.Context, tier RetentionTier, cutoff time.Time) error {
+	if tier.Resolution == 0 {
+		if _, err := pg.db.ExecContext(ctx, `DELETE FROM samples WHERE created_at < $1`, cutoff); err != nil {
+			return fmt.Errorf("pg: delete expired samples failed error %w", err)
+		}
+		return nil
+	}
+
+	if _, err := pg.db.ExecContext(ctx, `DELETE FROM rollups WHERE resolution = $1 AND bucket < $2`, int64(tier.Resolution.Seconds()), cutoff); err != nil {
+		return fmt.Errorf("pg: delete expired rollups of %s failed error %w", tier.Resolution, err)
+	}
+
 	return nil
 }
 
@@ -398,6 +605,10 @@ func (pg *PG) IncrementCounter(ctx context.Context, name string, delta int64) er
 				return fmt.Errorf("pg: increment counter failed error %w", execErr)
 			}
 
+			if err := pg.insertSample(ctx, tx, models.CounterType, name, float64(delta)); err != nil {
+				return fmt.Errorf("pg: increment counter failed error %w", err)
+			}
+
 			if txErr := tx.Commit(); txErr != nil {
 				return fmt.Errorf("pg: commit tx failed error %w", txErr)
 			}
@@ -423,6 +634,10 @@ func (pg *PG) IncrementCounter(ctx context.Context, name string, delta int64) er
 		return fmt.Errorf("pg: increment counter %s with delta %d failed error %w", name, delta, execErr)
 	}
 
+	if err := pg.insertSample(ctx, tx, models.CounterType, name, float64(value+delta)); err != nil {
+		return fmt.Errorf("pg: increment counter %s with delta %d failed error %w", name, delta, err)
+	}
+
 	if txErr := tx.Commit(); txErr != nil {
 		return fmt.Errorf("pg: commit tx failed error %w", txErr)
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/stretchr/testify/assert"
@@ -704,3 +705,85 @@ func TestPG_IncrementCounter(t *testing.T) {
 		})
 	}
 }
+
+func TestPG_Samples(t *testing.T) {
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: -1})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	pg := initPG(t, lg)
+
+	from := time.Now().Add(-time.Minute)
+	assert.NoError(t, pg.CreateOrUpdate(ctx, models.GaugeType, "alloc", 1.5))
+	assert.NoError(t, pg.CreateOrUpdate(ctx, models.GaugeType, "alloc", 2.5))
+	assert.NoError(t, pg.IncrementCounter(ctx, "poll_count", 3))
+	assert.NoError(t, pg.IncrementCounter(ctx, "poll_count", 4))
+	to := time.Now().Add(time.Minute)
+
+	values := func(samples []models.Sample) []float64 {
+		res := make([]float64, 0, len(samples))
+		for _, s := range samples {
+			res = append(res, s.Value)
+		}
+		return res
+	}
+
+	gauges, err := pg.Samples(ctx, models.GaugeType, "alloc", 0, from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1.5, 2.5}, values(gauges))
+
+	counters, err := pg.Samples(ctx, models.CounterType, "poll_count", 0, from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{3, 7}, values(counters))
+
+	empty, err := pg.Samples(ctx, models.GaugeType, "alloc", 0, to, to.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestPG_Compact(t *testing.T) {
+	lg, err := logging.MustZapLogger(&config.Config{LogLevel: -1})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	pg := initPG(t, lg)
+
+	start := time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+	for i := range 360 {
+		ts := start.Add(time.Duration(i) * 20 * time.Second)
+		_, err := pg.db.ExecContext(ctx, `
+			INSERT INTO samples (type, name, value, created_at)
+			VALUES ($1, $2, $3, $5), ($4, $2, $6, $5)
+		`, models.GaugeType, "alloc", float64(i%3), models.CounterType, ts, float64(i+1))
+		assert.NoError(t, err)
+	}
+
+	retention := Retention{
+		{TTL: 2 * time.Hour},
+		{Resolution: time.Minute, TTL: 24 * time.Hour},
+		{Resolution: time.Hour, TTL: 48 * time.Hour},
+	}
+	assert.NoError(t, pg.Compact(ctx, retention, start.Add(2*time.Hour+30*time.Second)))
+
+	minutes, err := pg.Samples(ctx, models.GaugeType, "alloc", time.Minute, start, start.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Len(t, minutes, 2)
+	assert.Equal(t, []float64{1, 1}, []float64{minutes[0].Value, minutes[1].Value})
+	assert.Equal(t, int64(3), minutes[0].Count)
+
+	hours, err := pg.Samples(ctx, models.CounterType, "poll_count", time.Hour, start, start.Add(3*time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, hours, 2)
+	assert.Equal(t, []float64{180, 360}, []float64{hours[0].Value, hours[1].Value})
+	assert.Equal(t, int64(180), hours[1].Count)
+
+	assert.NoError(t, pg.Compact(ctx, retention, start.Add(25*time.Hour)))
+
+	raw, err := pg.Samples(ctx, models.GaugeType, "alloc", 0, start, start.Add(48*time.Hour))
+	assert.NoError(t, err)
+	assert.Empty(t, raw)
+
+	minutes, err = pg.Samples(ctx, models.GaugeType, "alloc", time.Minute, start, start.Add(48*time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, minutes, 60)
+}
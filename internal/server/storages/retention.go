@@ -0,0 +1,98 @@
+package storages
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+)
+
+// rawResolution is the resolution of the accepted samples
+const rawResolution = "raw"
+
+// RetentionTier keeps samples of the resolution for the ttl, zero resolution means raw samples
+type RetentionTier struct {
+	Resolution time.Duration
+	TTL        time.Duration
+}
+
+// Retention tiers ordered by the resolution, the first tier keeps raw samples and every next tier
+// is rolled up from the previous one
+type Retention []RetentionTier
+
+func NewRetention(cfg *config.Config) (Retention, error) {
+	return ParseRetention(cfg.Retention)
+}
+
+// ParseRetention parses comma separated tiers "<resolution>:<ttl>", e.g. "raw:48h,1m:720h,1h:8760h"
+func ParseRetention(val string) (Retention, error) {
+	res := make(Retention, 0)
+	for i, part := range strings.Split(val, ",") {
+		resolution, ttl, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			return nil, fmt.Errorf("storages: invalid retention tier %q, expected <resolution>:<ttl>", part)
+		}
+
+		tier := RetentionTier{}
+		if resolution != rawResolution {
+			d, err := time.ParseDuration(resolution)
+			if err != nil {
+				return nil, fmt.Errorf("storages: invalid retention tier %q resolution error %w", part, err)
+			}
+			tier.Resolution = d
+		}
+
+		d, err := time.ParseDuration(ttl)
+		if err != nil {
+			return nil, fmt.Errorf("storages: invalid retention tier %q ttl error %w", part, err)
+		}
+		tier.TTL = d
+
+		if err := tier.validate(i, res); err != nil {
+			return nil, fmt.Errorf("storages: invalid retention tier %q: %w", part, err)
+		}
+
+		res = append(res, tier)
+	}
+
+	return res, nil
+}
+
+func (t RetentionTier) validate(i int, prev Retention) error {
+	if t.TTL <= 0 {
+		return errors.New("ttl must be positive")
+	}
+
+	if i == 0 {
+		if t.Resolution != 0 {
+			return fmt.Errorf("the first tier must keep %s samples", rawResolution)
+		}
+		return nil
+	}
+
+	if t.Resolution%time.Second != 0 {
+		return errors.New("resolution must be a multiple of a second")
+	}
+
+	p := prev[i-1]
+	if t.Resolution <= p.Resolution {
+		return fmt.Errorf("resolution must be greater than %s", p.Resolution)
+	}
+
+	if p.Resolution > 0 && t.Resolution%p.Resolution != 0 {
+		return fmt.Errorf("resolution must be a multiple of %s", p.Resolution)
+	}
+
+	if t.TTL < p.TTL {
+		return fmt.Errorf("ttl must not be less than %s", p.TTL)
+	}
+
+	return nil
+}
+
+// bucket returns the start of the resolution bucket of the time
+func bucket(ts time.Time, resolution time.Duration) time.Time {
+	return ts.Truncate(resolution)
+}
@@ -0,0 +1,96 @@
+package storages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetention(t *testing.T) {
+	tests := []struct {
+		name    string
+		val     string
+		want    Retention
+		wantErr bool
+	}{
+		{
+			name: "default tiers",
+			val:  "raw:48h,1m:720h,1h:8760h",
+			want: Retention{
+				{TTL: 48 * time.Hour},
+				{Resolution: time.Minute, TTL: 720 * time.Hour},
+				{Resolution: time.Hour, TTL: 8760 * time.Hour},
+			},
+		},
+		{
+			name: "raw samples only",
+			val:  "raw:1h",
+			want: Retention{{TTL: time.Hour}},
+		},
+		{
+			name: "with spaces",
+			val:  "raw:1h, 5m:24h",
+			want: Retention{{TTL: time.Hour}, {Resolution: 5 * time.Minute, TTL: 24 * time.Hour}},
+		},
+		{
+			name:    "without ttl",
+			val:     "raw",
+			wantErr: true,
+		},
+		{
+			name:    "invalid ttl",
+			val:     "raw:forever",
+			wantErr: true,
+		},
+		{
+			name:    "invalid resolution",
+			val:     "raw:1h,minute:24h",
+			wantErr: true,
+		},
+		{
+			name:    "first tier is not raw",
+			val:     "1m:24h",
+			wantErr: true,
+		},
+		{
+			name:    "resolution is not increasing",
+			val:     "raw:1h,1h:24h,1m:48h",
+			wantErr: true,
+		},
+		{
+			name:    "resolution is not a multiple of the previous one",
+			val:     "raw:1h,2m:24h,3m:48h",
+			wantErr: true,
+		},
+		{
+			name:    "resolution is less than a second",
+			val:     "raw:1h,500ms:24h",
+			wantErr: true,
+		},
+		{
+			name:    "ttl is decreasing",
+			val:     "raw:48h,1m:24h",
+			wantErr: true,
+		},
+		{
+			name:    "zero ttl",
+			val:     "raw:0s",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRetention(tt.val)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -40,5 +40,5 @@ func NewStorage(
 		return NewPersistance(lc, cfg, dumper, lg, srsb)
 	}
 
-	return NewMemory(lg), nil
+	return NewMemory(lg).WithHistorySize(cfg.HistorySize), nil
 }
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/systemd"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Servable is the server which reports whether it accepts connections
+type Servable interface {
+	Serving() bool
+}
+
+// NotifySystemd tells systemd that the server is ready once the servers are started and pings the watchdog
+// while all of them serve. It must be invoked after the servers are constructed so their hooks run first.
+func NotifySystemd(lc fx.Lifecycle, cfg *config.Config, n *systemd.Notifier, lg *logging.ZapLogger, servers ...Servable) {
+	if !n.Enabled() {
+		return
+	}
+
+	keepAliveCtx, cancel := context.WithCancel(context.Background())
+
+	lc.Append(
+		fx.Hook{
+			OnStart: func(ctx context.Context) error {
+				status := fmt.Sprintf("serving http on %s, grpc on :%s", cfg.Address, cfg.GRPCPort)
+				if err := n.Ready(status); err != nil {
+					lg.ErrorCtx(ctx, "systemd: ready notification failed", zap.Error(err))
+				}
+
+				go n.KeepAlive(keepAliveCtx, func() bool {
+					for _, s := range servers {
+						if !s.Serving() {
+							return false
+						}
+					}
+
+					return true
+				})
+
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				cancel()
+
+				if err := n.Stopping(); err != nil {
+					lg.ErrorCtx(ctx, "systemd: stopping notification failed", zap.Error(err))
+				}
+
+				return nil
+			},
+		},
+	)
+}
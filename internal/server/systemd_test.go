@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/systemd"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+type fakeServable struct {
+	serving atomic.Bool
+}
+
+func (s *fakeServable) Serving() bool {
+	return s.serving.Load()
+}
+
+func TestNotifySystemd(t *testing.T) {
+	lg, err := logging.NewZapLogger(&config.Config{LogLevel: -1})
+	require.NoError(t, err)
+
+	sock := systemd.NewFakeNotifySocket(t, 40*time.Millisecond)
+
+	var l fx.Lifecycle
+	app := fxtest.New(t, fx.Populate(&l))
+
+	srv := &fakeServable{}
+	srv.serving.Store(true)
+	NotifySystemd(l, &config.Config{Address: ":8080", GRPCPort: "3200"}, systemd.NewNotifier(lg), lg, srv)
+
+	require.NoError(t, app.Start(context.Background()))
+
+	states, ok := sock.Next(time.Second)
+	require.True(t, ok)
+	assert.Equal(t, []string{systemd.StateReady, "STATUS=serving http on :8080, grpc on :3200"}, states)
+
+	_, ok = sock.WaitFor(systemd.StateWatchdog, time.Second)
+	assert.True(t, ok, "watchdog is pinged while servers serve")
+
+	srv.serving.Store(false)
+	sock.Next(30 * time.Millisecond)
+	_, ok = sock.Next(100 * time.Millisecond)
+	assert.False(t, ok, "watchdog is not pinged when server stopped serving")
+
+	require.NoError(t, app.Stop(context.Background()))
+
+	states, ok = sock.Next(time.Second)
+	require.True(t, ok)
+	assert.Equal(t, []string{systemd.StateStopping}, states)
+}
+
+func TestHTTPServer_Serving(t *testing.T) {
+	lg, err := logging.NewZapLogger(&config.Config{LogLevel: -1})
+	require.NoError(t, err)
+
+	var l fx.Lifecycle
+	app := fxtest.New(t, fx.Populate(&l))
+
+	cfg := &config.Config{Address: "127.0.0.1:0"}
+	s := NewHTTPServer(l, cfg, NewRouter([]Handler{&MockHandler{}}, l, lg, cfg, nil), lg, &systemd.Sockets{})
+	assert.False(t, s.Serving())
+
+	require.NoError(t, app.Start(context.Background()))
+	assert.True(t, s.Serving())
+
+	require.NoError(t, app.Stop(context.Background()))
+	assert.Eventually(t, func() bool { return !s.Serving() }, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,142 @@
+// Package capabilities describes what the server supports. The server advertises capabilities
+// over http and grpc, the agent adapts reporting to them instead of assuming the server setup.
+package capabilities
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrIncompatible is returned when the server can't accept reports of the agent
+var ErrIncompatible = errors.New("capabilities: server is incompatible with the agent")
+
+// RetryInterval is how long the agent waits before negotiating again after the failed negotiation
+const RetryInterval = 30 * time.Second
+
+// APIVersion is the version of the metrics api implemented by the agent and the server
+const APIVersion = "v1"
+
+// Features of the server
+const (
+	FeatureBatch       = "batch"        // /updates/ endpoint and UpdateBatch rpc
+	FeatureIdempotency = "idempotency"  // retried reports with the same idempotency key are applied once
+	FeatureAgentConfig = "agent_config" // config profiles of the agents
+)
+
+// Request body encodings, compression, encryption and signature schemes
+const (
+	EncodingJSON        = "json"
+	EncodingProtobuf    = "protobuf"
+	CompressionGzip     = "gzip"
+	EncryptionRSA       = "rsa"
+	SignatureHMACSHA256 = "hmac-sha256"
+)
+
+// Capabilities are advertised by the server, empty lists mean the capability is not supported
+// and zero limits mean there is no limit
+type Capabilities struct {
+	APIVersions  []string `json:"api_versions"`
+	Features     []string `json:"features"`
+	Encodings    []string `json:"encodings"`
+	Compression  []string `json:"compression"`
+	Encryption   []string `json:"encryption"`
+	Signatures   []string `json:"signatures"`
+	MaxBatchSize int      `json:"max_batch_size"` // metrics in a single batch
+	MaxBodyBytes int64    `json:"max_body_bytes"` // uncompressed request body
+	GRPCPort     string   `json:"grpc_port,omitempty"`
+}
+
+// Legacy are capabilities of the servers which don't advertise them. Such servers decrypt and verify
+// bodies when they are configured to, but don't decompress them.
+func Legacy() Capabilities {
+	return Capabilities{
+		APIVersions: []string{APIVersion},
+		Features:    []string{FeatureBatch},
+		Encodings:   []string{EncodingJSON, EncodingProtobuf},
+		Encryption:  []string{EncryptionRSA},
+		Signatures:  []string{SignatureHMACSHA256},
+	}
+}
+
+// Compatible checks that the server accepts reports of the agent. The agent which encrypts bodies
+// never downgrades to plain ones.
+func (c Capabilities) Compatible(encrypted bool) error {
+	if !c.Supports(APIVersion) {
+		return fmt.Errorf("%w: api version %s is not supported, server supports %v", ErrIncompatible, APIVersion, c.APIVersions)
+	}
+
+	if encrypted && !c.Decrypts(EncryptionRSA) {
+		return fmt.Errorf("%w: server does not decrypt %s bodies", ErrIncompatible, EncryptionRSA)
+	}
+
+	return nil
+}
+
+// Supports checks that the server implements the api version of the agent
+func (c Capabilities) Supports(version string) bool {
+	return slices.Contains(c.APIVersions, version)
+}
+
+// Has checks that the server has the feature
+func (c Capabilities) Has(feature string) bool {
+	return slices.Contains(c.Features, feature)
+}
+
+// Accepts checks that the server accepts request bodies compressed by the scheme
+func (c Capabilities) Accepts(compression string) bool {
+	return slices.Contains(c.Compression, compression)
+}
+
+// Decrypts checks that the server decrypts request bodies encrypted by the scheme
+func (c Capabilities) Decrypts(encryption string) bool {
+	return slices.Contains(c.Encryption, encryption)
+}
+
+// Limit returns the lower of the local and the server limits, zero means there is no limit
+func Limit(local, server int) int {
+	if local <= 0 || (server > 0 && server < local) {
+		return server
+	}
+
+	return local
+}
+
+// Session tracks the server capabilities were negotiated with. They are negotiated again when the agent
+// fails over to another server or RetryInterval after the failed negotiation. Nothing is renegotiated until
+// the first negotiation.
+type Session struct {
+	mu        sync.Mutex
+	addr      string // server of the last negotiation
+	failed    bool
+	attemptAt time.Time
+}
+
+// Due reports whether capabilities must be negotiated before sending to addr, the attempt is claimed,
+// so concurrent senders negotiate once
+func (s *Session) Due(addr string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.attemptAt.IsZero() {
+		return false
+	}
+
+	if addr == s.addr && (!s.failed || now.Sub(s.attemptAt) < RetryInterval) {
+		return false
+	}
+
+	s.addr, s.failed, s.attemptAt = addr, false, now
+
+	return true
+}
+
+// Negotiated records result of the negotiation with addr
+func (s *Session) Negotiated(addr string, now time.Time, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.addr, s.failed, s.attemptAt = addr, err != nil, now
+}
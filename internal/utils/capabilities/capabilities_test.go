@@ -0,0 +1,104 @@
+package capabilities
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimit(t *testing.T) {
+	tests := []struct {
+		name   string
+		local  int
+		server int
+		want   int
+	}{
+		{name: "when both are unlimited", want: 0},
+		{name: "when only local is limited", local: 10, want: 10},
+		{name: "when only server is limited", server: 20, want: 20},
+		{name: "when server limit is lower", local: 10, server: 5, want: 5},
+		{name: "when local limit is lower", local: 10, server: 20, want: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Limit(tt.local, tt.server))
+		})
+	}
+}
+
+func TestCapabilities_Compatible(t *testing.T) {
+	tests := []struct {
+		name      string
+		caps      Capabilities
+		encrypted bool
+		wantErr   bool
+	}{
+		{
+			name: "when server is legacy",
+			caps: Legacy(),
+		},
+		{
+			name:      "when legacy server gets encrypted bodies",
+			caps:      Legacy(),
+			encrypted: true,
+		},
+		{
+			name:    "when api version is not supported",
+			caps:    Capabilities{APIVersions: []string{"v2"}},
+			wantErr: true,
+		},
+		{
+			name:      "when server does not decrypt bodies",
+			caps:      Capabilities{APIVersions: []string{APIVersion}},
+			encrypted: true,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.caps.Compatible(tt.encrypted)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrIncompatible)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestSession_Due(t *testing.T) {
+	negotiatedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		negotiated bool
+		err        error
+		addr       string
+		now        time.Duration // since the negotiation
+		want       bool
+	}{
+		{name: "when not negotiated yet", addr: "a", want: false},
+		{name: "when negotiated with the server", negotiated: true, addr: "a", now: time.Hour, want: false},
+		{name: "when failed over to another server", negotiated: true, addr: "b", want: true},
+		{name: "when negotiation failed recently", negotiated: true, err: errors.New("timeout"), addr: "a", now: time.Second, want: false},
+		{name: "when negotiation failed long ago", negotiated: true, err: errors.New("timeout"), addr: "a", now: RetryInterval, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s Session
+			if tt.negotiated {
+				s.Negotiated("a", negotiatedAt, tt.err)
+			}
+
+			now := negotiatedAt.Add(tt.now)
+			assert.Equal(t, tt.want, s.Due(tt.addr, now))
+			assert.False(t, s.Due(tt.addr, now), "attempt is claimed")
+		})
+	}
+}
@@ -9,12 +9,16 @@ import (
 	"encoding/pem"
 	"fmt"
 	"io"
+	"sync"
 
 	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
 )
 
 type Decryptor struct {
 	privateKey io.Reader
+	once       sync.Once
+	rsaKey     *rsa.PrivateKey
+	keyErr     error
 }
 
 func NewDecryptor(cfg *config.Config) *Decryptor {
@@ -22,35 +26,53 @@ func NewDecryptor(cfg *config.Config) *Decryptor {
 }
 
 func (d *Decryptor) Decrypt(ciphertext string) (string, error) {
-	pkdata, err := io.ReadAll(d.privateKey)
+	rsaKey, err := d.key()
 	if err != nil {
-		return "", fmt.Errorf("decryptor: failed to read private key: %w", err)
+		return "", err
 	}
 
-	block, _ := pem.Decode(pkdata)
-	if block == nil {
-		return "", fmt.Errorf("decryptor: failed to decode private key")
+	decoded, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("decryptor: failed to decode ciphertext: %w", err)
 	}
 
-	privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	decrypted, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, rsaKey, decoded, nil)
 	if err != nil {
-		return "", fmt.Errorf("decryptor: failed to parse private key: %w", err)
+		return "", fmt.Errorf("decryptor: failed to decrypt ciphertext: %w", err)
 	}
 
-	rsaKey, ok := privateKey.(*rsa.PrivateKey)
-	if !ok {
-		return "", fmt.Errorf("decryptor: private key is not a RSA key")
-	}
+	return string(decrypted), nil
+}
 
-	decoded, err := base64.StdEncoding.DecodeString(ciphertext)
+// key reads and parses the private key once, so the decryptor can serve many requests
+func (d *Decryptor) key() (*rsa.PrivateKey, error) {
+	d.once.Do(func() {
+		d.rsaKey, d.keyErr = parsePrivateKey(d.privateKey)
+	})
+
+	return d.rsaKey, d.keyErr
+}
+
+func parsePrivateKey(source io.Reader) (*rsa.PrivateKey, error) {
+	pkdata, err := io.ReadAll(source)
 	if err != nil {
-		return "", fmt.Errorf("decryptor: failed to decode ciphertext: %w", err)
+		return nil, fmt.Errorf("decryptor: failed to read private key: %w", err)
 	}
 
-	decrypted, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, rsaKey, decoded, nil)
+	block, _ := pem.Decode(pkdata)
+	if block == nil {
+		return nil, fmt.Errorf("decryptor: failed to decode private key")
+	}
+
+	privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
 	if err != nil {
-		return "", fmt.Errorf("decryptor: failed to decrypt ciphertext: %w", err)
+		return nil, fmt.Errorf("decryptor: failed to parse private key: %w", err)
 	}
 
-	return string(decrypted), nil
+	rsaKey, ok := privateKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("decryptor: private key is not a RSA key")
+	}
+
+	return rsaKey, nil
 }
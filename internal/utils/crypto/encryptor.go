@@ -9,10 +9,14 @@ import (
 	"encoding/pem"
 	"fmt"
 	"io"
+	"sync"
 )
 
 type Encryptor struct {
-	certData io.Reader
+	certData  io.Reader
+	once      sync.Once
+	publicKey *rsa.PublicKey
+	keyErr    error
 }
 
 func NewEncryptor(cert io.Reader) *Encryptor {
@@ -20,29 +24,58 @@ func NewEncryptor(cert io.Reader) *Encryptor {
 }
 
 func (e *Encryptor) Encrypt(message []byte) (string, error) {
-	certBytes, err := io.ReadAll(e.certData)
+	publicKey, err := e.key()
 	if err != nil {
-		return "", fmt.Errorf("encryptor: failed to read public key: %w", err)
+		return "", err
+	}
+
+	encrypted, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, message, nil)
+	if err != nil {
+		return "", fmt.Errorf("encryptor: failed to encrypt message: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(encrypted), nil
+}
+
+// MaxMessageSize returns the max length of the message which can be encrypted with the public key
+func (e *Encryptor) MaxMessageSize() (int, error) {
+	publicKey, err := e.key()
+	if err != nil {
+		return 0, err
+	}
+
+	// RSA-OAEP reserves two hash lengths and two bytes of the modulus, see rsa.EncryptOAEP
+	return publicKey.Size() - 2*sha256.Size - 2, nil
+}
+
+// key reads and parses the public key once, so the encryptor can be used for many messages
+func (e *Encryptor) key() (*rsa.PublicKey, error) {
+	e.once.Do(func() {
+		e.publicKey, e.keyErr = parsePublicKey(e.certData)
+	})
+
+	return e.publicKey, e.keyErr
+}
+
+func parsePublicKey(certData io.Reader) (*rsa.PublicKey, error) {
+	certBytes, err := io.ReadAll(certData)
+	if err != nil {
+		return nil, fmt.Errorf("encryptor: failed to read public key: %w", err)
 	}
 
 	block, _ := pem.Decode(certBytes)
 	if block == nil {
-		return "", fmt.Errorf("encryptor: failed to decode public key")
+		return nil, fmt.Errorf("encryptor: failed to decode public key")
 	}
 	parsedCert, err := x509.ParseCertificate(block.Bytes)
 	if err != nil {
-		return "", fmt.Errorf("encryptor: failed to parse public key: %w", err)
+		return nil, fmt.Errorf("encryptor: failed to parse public key: %w", err)
 	}
 
 	publicKey, ok := parsedCert.PublicKey.(*rsa.PublicKey)
 	if !ok {
-		return "", fmt.Errorf("encryptor: failed to cast public key to rsa.PublicKey")
+		return nil, fmt.Errorf("encryptor: failed to cast public key to rsa.PublicKey")
 	}
 
-	encrypted, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, message, nil)
-	if err != nil {
-		return "", fmt.Errorf("encryptor: failed to encrypt message: %w", err)
-	}
-
-	return base64.StdEncoding.EncodeToString(encrypted), nil
+	return publicKey, nil
 }
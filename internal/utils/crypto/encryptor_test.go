@@ -23,7 +23,48 @@ func TestEncrypt(t *testing.T) {
 			name: "successful encryption",
 			args: args{
 				message: []byte(`Hello, World!`),
-				cert: bytes.NewReader([]byte(`-----BEGIN CERTIFICATE-----
+				cert:    bytes.NewReader([]byte(testCert)),
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encryptor := NewEncryptor(tt.args.cert)
+
+			message, err := encryptor.Encrypt(tt.args.message)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			fmt.Println(message)
+			assert.NotEqual(t, "", message)
+		})
+	}
+}
+
+func TestEncryptor_MaxMessageSize(t *testing.T) {
+	encryptor := NewEncryptor(bytes.NewReader([]byte(testCert)))
+
+	size, err := encryptor.MaxMessageSize()
+	assert.NoError(t, err)
+	// 4096 bit key: 512 bytes modulus - 2 * sha256 size - 2
+	assert.Equal(t, 446, size)
+
+	// public key is parsed once, so encryptor can be reused
+	for range 2 {
+		message, err := encryptor.Encrypt(make([]byte, size))
+		assert.NoError(t, err)
+		assert.NotEmpty(t, message)
+	}
+
+	_, err = encryptor.Encrypt(make([]byte, size+1))
+	assert.Error(t, err)
+}
+
+const testCert = `-----BEGIN CERTIFICATE-----
 MIIEfDCCAmSgAwIBAgIEZ/kQFzANBgkqhkiG9w0BAQsFADAAMB4XDTI1MDQxMTEy
 NTAzMVoXDTI2MDQxMTEyNTAzMVowADCCAiIwDQYJKoZIhvcNAQEBBQADggIPADCC
 AgoCggIBALCMsAGE+KVp/Cb8q6+adz1zmfIOAYG0IYuLs8Mp+/5867F9cDNzAsi5
@@ -49,24 +90,4 @@ kUKrzPIkDfRQYxHh0kna2Up4vPawLP3ZStAVzjskUUHmjZz0SX8lORbv1fCmSJct
 Wc774xRQem2kUm1sVcuy3LV2xpxSNSksrcsM10Rs4RdOiuysnHq/bLySX6tVe4ib
 IOLlRE1QeXDJuz9kAbWJSk0+ZkpGornCXCqI8TLOJ1XpcQG93A8DIM6n+jx8v/n/
 -----END CERTIFICATE-----
-				`)),
-			},
-			wantErr: false,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			encryptor := NewEncryptor(tt.args.cert)
-
-			message, err := encryptor.Encrypt(tt.args.message)
-			if tt.wantErr {
-				assert.Error(t, err)
-			} else {
-				assert.NoError(t, err)
-			}
-
-			fmt.Println(message)
-			assert.NotEqual(t, "", message)
-		})
-	}
-}
+`
@@ -0,0 +1,119 @@
+package crypto
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// NewServerTLSConfig builds tls config for the server from PEM files.
+// When clientCAFile is set, clients must present a certificate signed by one of the CA certificates.
+func NewServerTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("tls: failed to load server key pair: %w", err)
+	}
+
+	cfg := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+
+	if clientCAFile == "" {
+		return cfg, nil
+	}
+
+	pool, err := loadCertPool(clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.ClientCAs = pool
+	cfg.ClientAuth = tls.RequireAndVerifyClientCert
+
+	return cfg, nil
+}
+
+// NewClientTLSConfig builds tls config for the agent from PEM files.
+// caFile is used to verify the server certificate, the system pool is used when it is empty.
+// certFile and keyFile is the client certificate presented to the server, they are optional.
+func NewClientTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caFile != "" {
+		pool, err := loadCertPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg.RootCAs = pool
+	}
+
+	if certFile == "" && keyFile == "" {
+		return cfg, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("tls: failed to load client key pair: %w", err)
+	}
+
+	cfg.Certificates = []tls.Certificate{cert}
+
+	return cfg, nil
+}
+
+// PeerIdentity describes the verified client certificate
+type PeerIdentity struct {
+	Subject string   `json:"subject"`
+	SAN     []string `json:"san"`
+}
+
+// String returns identity representation for logs
+func (p PeerIdentity) String() string {
+	if len(p.SAN) == 0 {
+		return p.Subject
+	}
+
+	return fmt.Sprintf("%s [%s]", p.Subject, strings.Join(p.SAN, ","))
+}
+
+// PeerIdentityFromState returns identity of the verified client certificate from the connection state
+func PeerIdentityFromState(state *tls.ConnectionState) (PeerIdentity, bool) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return PeerIdentity{}, false
+	}
+
+	return NewPeerIdentity(state.VerifiedChains[0][0]), true
+}
+
+// NewPeerIdentity collects subject and subject alternative names of the certificate
+func NewPeerIdentity(cert *x509.Certificate) PeerIdentity {
+	san := make([]string, 0, len(cert.DNSNames)+len(cert.IPAddresses)+len(cert.EmailAddresses)+len(cert.URIs))
+	san = append(san, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		san = append(san, ip.String())
+	}
+	san = append(san, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		san = append(san, uri.String())
+	}
+
+	return PeerIdentity{Subject: cert.Subject.String(), SAN: san}
+}
+
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("tls: failed to read ca file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("tls: no certificates found in %s", caFile)
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,177 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testPKI struct {
+	caFile, serverCert, serverKey, clientCert, clientKey string
+}
+
+func newTestPKI(t *testing.T) testPKI {
+	t.Helper()
+	dir := t.TempDir()
+
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	issue := func(name string, serial int64, tmpl *x509.Certificate) (string, string) {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+
+		tmpl.SerialNumber = big.NewInt(serial)
+		tmpl.NotBefore = time.Now().Add(-time.Hour)
+		tmpl.NotAfter = time.Now().Add(time.Hour)
+		tmpl.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+		require.NoError(t, err)
+
+		certFile := filepath.Join(dir, name+".crt")
+		keyFile := filepath.Join(dir, name+".key")
+		writePem(t, certFile, "CERTIFICATE", der)
+		writePem(t, keyFile, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key))
+
+		return certFile, keyFile
+	}
+
+	pki := testPKI{caFile: filepath.Join(dir, "ca.crt")}
+	writePem(t, pki.caFile, "CERTIFICATE", caDER)
+
+	pki.serverCert, pki.serverKey = issue("server", 2, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "server"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	pki.clientCert, pki.clientKey = issue("agent", 3, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "agent-1", Organization: []string{"monitoring"}},
+		DNSNames:    []string{"agent-1.local"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+
+	return pki
+}
+
+func writePem(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+}
+
+func TestMutualTLS(t *testing.T) {
+	pki := newTestPKI(t)
+
+	serverCfg, err := NewServerTLSConfig(pki.serverCert, pki.serverKey, pki.caFile)
+	require.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, serverCfg.ClientAuth)
+
+	var identity PeerIdentity
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := PeerIdentityFromState(r.TLS)
+		assert.True(t, ok)
+		identity = id
+	}))
+	srv.TLS = serverCfg
+	srv.StartTLS()
+	defer srv.Close()
+
+	tests := []struct {
+		name        string
+		cert, key   string
+		ca          string
+		wantErr     bool
+		wantSubject string
+	}{
+		{
+			name:        "client presents certificate signed by ca",
+			cert:        pki.clientCert,
+			key:         pki.clientKey,
+			ca:          pki.caFile,
+			wantSubject: "CN=agent-1,O=monitoring",
+		},
+		{
+			name:    "client without certificate is rejected",
+			ca:      pki.caFile,
+			wantErr: true,
+		},
+		{
+			name:    "server is not trusted without ca",
+			cert:    pki.clientCert,
+			key:     pki.clientKey,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientCfg, err := NewClientTLSConfig(tt.cert, tt.key, tt.ca)
+			require.NoError(t, err)
+
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientCfg}}
+			resp, err := client.Get(srv.URL)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NoError(t, resp.Body.Close())
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, tt.wantSubject, identity.Subject)
+			assert.Equal(t, []string{"agent-1.local"}, identity.SAN)
+		})
+	}
+}
+
+func TestNewServerTLSConfig_errors(t *testing.T) {
+	pki := newTestPKI(t)
+
+	_, err := NewServerTLSConfig("missing.crt", pki.serverKey, "")
+	assert.Error(t, err)
+
+	_, err = NewServerTLSConfig(pki.serverCert, pki.serverKey, "missing.crt")
+	assert.Error(t, err)
+
+	_, err = NewServerTLSConfig(pki.serverCert, pki.serverKey, pki.serverKey)
+	assert.Error(t, err)
+
+	cfg, err := NewServerTLSConfig(pki.serverCert, pki.serverKey, "")
+	assert.NoError(t, err)
+	assert.Equal(t, tls.NoClientCert, cfg.ClientAuth)
+}
+
+func TestPeerIdentity_String(t *testing.T) {
+	assert.Equal(t, "CN=agent", PeerIdentity{Subject: "CN=agent"}.String())
+	assert.Equal(t, "CN=agent [a.local,10.0.0.1]", PeerIdentity{Subject: "CN=agent", SAN: []string{"a.local", "10.0.0.1"}}.String())
+
+	_, ok := PeerIdentityFromState(nil)
+	assert.False(t, ok)
+}
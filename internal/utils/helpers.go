@@ -2,6 +2,7 @@ package utils
 
 import (
 	"context"
+	"fmt"
 	"math"
 
 	"github.com/gin-gonic/gin"
@@ -9,10 +10,19 @@ import (
 	"go.uber.org/zap"
 )
 
+// ClientIdentityKey is the gin context key of the verified client identity
+const ClientIdentityKey = "client_identity"
+
 func InitHandlerCtx(c *gin.Context, lg *logging.ZapLogger, handler string) context.Context {
 	ctx := lg.WithContextFields(context.Background(),
 		zap.String("name", handler),
 	)
+	if identity, ok := c.Get(ClientIdentityKey); ok {
+		if s, ok := identity.(fmt.Stringer); ok {
+			ctx = lg.WithContextFields(ctx, zap.Stringer("client", s))
+		}
+	}
+
 	rid, ok := c.Get("request_id")
 	if !ok {
 		return ctx
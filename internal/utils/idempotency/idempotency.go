@@ -0,0 +1,36 @@
+// Package idempotency passes the report idempotency key from the agent to the server.
+// The server uses the key to drop retried counter increments which were already applied.
+package idempotency
+
+import "context"
+
+const (
+	// Header is the http header with the idempotency key
+	Header = "Idempotency-Key"
+	// MetadataKey is the grpc metadata key with the idempotency key
+	MetadataKey = "idempotency-key"
+)
+
+type ctxKey struct{}
+
+// WithKey returns context with the idempotency key
+func WithKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, key)
+}
+
+// KeyFromContext returns the idempotency key from the context or empty string when it is not set
+func KeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(ctxKey{}).(string)
+	return key
+}
+
+// MetricKey derives idempotency key of the metric sent in a separate request from the key of the report
+// with total metrics. Every path sending metrics one by one uses it, so a resent metric keeps its key
+// whether it is sent in a batch or alone. The report key is kept as is for a single metric.
+func MetricKey(key, name string, total int) string {
+	if key == "" || total <= 1 {
+		return key
+	}
+
+	return key + "/" + name
+}
@@ -0,0 +1,121 @@
+// Package systemd integrates the services with systemd: readiness and status notifications, watchdog
+// pings and socket activation. Everything falls back to no-op when the process is not started by systemd.
+package systemd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"go.uber.org/zap"
+)
+
+// Notification states, see sd_notify(3)
+const (
+	StateReady    = "READY=1"
+	StateStopping = "STOPPING=1"
+	StateWatchdog = "WATCHDOG=1"
+	statePrefix   = "STATUS="
+)
+
+// Notifier sends notifications to the service manager over the NOTIFY_SOCKET datagram socket
+type Notifier struct {
+	addr     string
+	watchdog time.Duration
+	lg       *logging.ZapLogger
+}
+
+// NewNotifier reads the notify socket and the watchdog timeout systemd passed to the service
+func NewNotifier(lg *logging.ZapLogger) *Notifier {
+	n := &Notifier{addr: os.Getenv("NOTIFY_SOCKET"), lg: lg}
+
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return n
+	}
+
+	// the watchdog may be set up for another process of the service
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return n
+	}
+
+	n.watchdog = time.Duration(usec) * time.Microsecond
+
+	return n
+}
+
+// Enabled reports whether the process is started by systemd with Type=notify
+func (n *Notifier) Enabled() bool {
+	return n.addr != ""
+}
+
+// WatchdogInterval returns the watchdog timeout of the service, zero when the watchdog is disabled
+func (n *Notifier) WatchdogInterval() time.Duration {
+	return n.watchdog
+}
+
+// Notify sends the states to the service manager in a single datagram
+func (n *Notifier) Notify(states ...string) error {
+	if !n.Enabled() {
+		return nil
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: n.addr, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("internal/utils/systemd: dial notify socket error %w", err)
+	}
+	defer conn.Close() //nolint:errcheck // datagram is already sent or failed
+
+	if _, err := conn.Write([]byte(strings.Join(states, "\n"))); err != nil {
+		return fmt.Errorf("internal/utils/systemd: send notification error %w", err)
+	}
+
+	return nil
+}
+
+// Ready tells that the service started up, status describes the running service
+func (n *Notifier) Ready(status string) error {
+	return n.Notify(StateReady, statePrefix+status)
+}
+
+// Status updates the status line of the service shown by systemctl status
+func (n *Notifier) Status(status string) error {
+	return n.Notify(statePrefix + status)
+}
+
+// Stopping tells that the service is shutting down
+func (n *Notifier) Stopping() error {
+	return n.Notify(StateStopping)
+}
+
+// KeepAlive pings the watchdog twice per timeout until ctx is done. Pings are skipped while healthy
+// reports false, so systemd restarts the hung service when the timeout expires.
+func (n *Notifier) KeepAlive(ctx context.Context, healthy func() bool) {
+	if !n.Enabled() || n.watchdog <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(n.watchdog / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !healthy() {
+				n.lg.WarnCtx(ctx, "service is unhealthy, watchdog is not pinged")
+				continue
+			}
+
+			if err := n.Notify(StateWatchdog); err != nil {
+				n.lg.ErrorCtx(ctx, "watchdog ping failed", zap.Error(err))
+			}
+		}
+	}
+}
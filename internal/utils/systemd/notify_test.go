@@ -0,0 +1,80 @@
+package systemd
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+)
+
+func TestNotifier_Notify(t *testing.T) {
+	lg, err := logging.NewZapLogger(&config.Config{LogLevel: -1})
+	require.NoError(t, err)
+
+	sock := NewFakeNotifySocket(t, 0)
+	n := NewNotifier(lg)
+	require.True(t, n.Enabled())
+	assert.Zero(t, n.WatchdogInterval())
+
+	require.NoError(t, n.Ready("serving"))
+	states, ok := sock.Next(time.Second)
+	require.True(t, ok)
+	assert.Equal(t, []string{StateReady, "STATUS=serving"}, states)
+
+	require.NoError(t, n.Status("reporting"))
+	states, ok = sock.Next(time.Second)
+	require.True(t, ok)
+	assert.Equal(t, []string{"STATUS=reporting"}, states)
+
+	require.NoError(t, n.Stopping())
+	states, ok = sock.Next(time.Second)
+	require.True(t, ok)
+	assert.Equal(t, []string{StateStopping}, states)
+}
+
+func TestNotifier_Notify_disabled(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+
+	n := NewNotifier(nil)
+	assert.False(t, n.Enabled())
+	assert.NoError(t, n.Ready("serving"))
+}
+
+func TestNotifier_KeepAlive(t *testing.T) {
+	lg, err := logging.NewZapLogger(&config.Config{LogLevel: -1})
+	require.NoError(t, err)
+
+	sock := NewFakeNotifySocket(t, 40*time.Millisecond)
+	n := NewNotifier(lg)
+	require.Equal(t, 40*time.Millisecond, n.WatchdogInterval())
+
+	var healthy atomic.Bool
+	healthy.Store(true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go n.KeepAlive(ctx, healthy.Load)
+
+	_, ok := sock.WaitFor(StateWatchdog, time.Second)
+	assert.True(t, ok, "healthy service pings the watchdog")
+
+	healthy.Store(false)
+	// drain the ping which could be sent before the service became unhealthy
+	sock.Next(30 * time.Millisecond)
+
+	_, ok = sock.Next(100 * time.Millisecond)
+	assert.False(t, ok, "unhealthy service does not ping the watchdog")
+}
+
+func TestNewNotifier_watchdogOfAnotherProcess(t *testing.T) {
+	NewFakeNotifySocket(t, time.Second)
+	t.Setenv("WATCHDOG_PID", "1")
+
+	assert.Zero(t, NewNotifier(nil).WatchdogInterval())
+}
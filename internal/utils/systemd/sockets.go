@@ -0,0 +1,143 @@
+package systemd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+	"go.uber.org/zap"
+)
+
+// listenFdsStart is the first file descriptor passed by systemd, see sd_listen_fds(3)
+const listenFdsStart = 3
+
+// Sockets are listeners passed to the service by systemd socket activation
+type Sockets struct {
+	mu        sync.Mutex
+	listeners []socket
+	lg        *logging.ZapLogger
+}
+
+type socket struct {
+	name string // FileDescriptorName of the socket unit
+	ln   net.Listener
+}
+
+// NewSockets takes the listeners passed by systemd, the environment is cleared so child processes
+// don't inherit them
+func NewSockets(lg *logging.ZapLogger) (*Sockets, error) {
+	s, err := newSockets(lg, os.Getenv, listenFdsStart)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+		if err := os.Unsetenv(key); err != nil {
+			return nil, fmt.Errorf("internal/utils/systemd: unset %s error %w", key, err)
+		}
+	}
+
+	return s, nil
+}
+
+func newSockets(lg *logging.ZapLogger, getenv func(string) string, start int) (*Sockets, error) {
+	s := &Sockets{lg: lg}
+
+	if getenv("LISTEN_PID") != strconv.Itoa(os.Getpid()) {
+		return s, nil
+	}
+
+	count, err := strconv.Atoi(getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return s, nil
+	}
+
+	var names []string
+	if v := getenv("LISTEN_FDNAMES"); v != "" {
+		names = strings.Split(v, ":")
+	}
+
+	for i := range count {
+		fd := start + i
+		syscall.CloseOnExec(fd)
+
+		name := ""
+		if i < len(names) {
+			name = names[i]
+		}
+
+		f := os.NewFile(uintptr(fd), name)
+		ln, err := net.FileListener(f)
+		if closeErr := f.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("internal/utils/systemd: socket %d %s error %w", fd, name, err)
+		}
+
+		s.listeners = append(s.listeners, socket{name: name, ln: ln})
+	}
+
+	return s, nil
+}
+
+// Listen returns the socket passed by systemd with the name, or the one bound to the port of addr when
+// the socket units are not named. The tcp listener on addr is created when systemd passed no socket.
+func (s *Sockets) Listen(ctx context.Context, name, addr string) (net.Listener, error) {
+	if ln := s.take(name, addr); ln != nil {
+		s.lg.InfoCtx(ctx, "socket passed by systemd is used", zap.String("name", name), zap.String("address", ln.Addr().String()))
+		return ln, nil
+	}
+
+	return net.Listen("tcp", addr)
+}
+
+func (s *Sockets) take(name, addr string) net.Listener {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(func(sk socket) bool { return sk.name == name })
+	if i < 0 {
+		i = s.index(func(sk socket) bool { return samePort(sk.ln.Addr(), addr) })
+	}
+
+	if i < 0 {
+		return nil
+	}
+
+	ln := s.listeners[i].ln
+	s.listeners = append(s.listeners[:i], s.listeners[i+1:]...)
+
+	return ln
+}
+
+func (s *Sockets) index(match func(socket) bool) int {
+	for i, sk := range s.listeners {
+		if match(sk) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func samePort(la net.Addr, addr string) bool {
+	tcp, ok := la.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+
+	p, err := strconv.Atoi(port)
+	return err == nil && p == tcp.Port
+}
@@ -0,0 +1,95 @@
+package systemd
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/server/config"
+	"github.com/vysogota0399/mem_stats_monitoring/internal/utils/logging"
+)
+
+// passSocket emulates systemd: the listener is duplicated to a descriptor passed with LISTEN_* variables,
+// the descriptor is owned by Sockets
+func passSocket(t *testing.T, name string, ln *net.TCPListener) (func(string) string, int) {
+	t.Helper()
+
+	f, err := ln.File()
+	require.NoError(t, err)
+
+	fd, err := syscall.Dup(int(f.Fd()))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	env := map[string]string{
+		"LISTEN_PID":     strconv.Itoa(os.Getpid()),
+		"LISTEN_FDS":     "1",
+		"LISTEN_FDNAMES": name,
+	}
+
+	return func(key string) string { return env[key] }, fd
+}
+
+func listenTCP(t *testing.T) *net.TCPListener {
+	t.Helper()
+
+	ln, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	return ln
+}
+
+func TestSockets_Listen(t *testing.T) {
+	lg, err := logging.NewZapLogger(&config.Config{LogLevel: -1})
+	require.NoError(t, err)
+
+	t.Run("when socket is named", func(t *testing.T) {
+		grpc := listenTCP(t)
+		getenv, start := passSocket(t, "grpc", grpc)
+
+		s, err := newSockets(lg, getenv, start)
+		require.NoError(t, err)
+
+		ln, err := s.Listen(context.Background(), "http", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer ln.Close()
+		assert.NotEqual(t, grpc.Addr().String(), ln.Addr().String())
+
+		ln, err = s.Listen(context.Background(), "grpc", ":0")
+		require.NoError(t, err)
+		defer ln.Close()
+		assert.Equal(t, grpc.Addr().String(), ln.Addr().String())
+	})
+
+	t.Run("when sockets are not named they are matched by port", func(t *testing.T) {
+		http := listenTCP(t)
+		getenv, start := passSocket(t, "", http)
+
+		s, err := newSockets(lg, getenv, start)
+		require.NoError(t, err)
+
+		port := strconv.Itoa(http.Addr().(*net.TCPAddr).Port)
+		ln, err := s.Listen(context.Background(), "http", ":"+port)
+		require.NoError(t, err)
+		defer ln.Close()
+		assert.Equal(t, http.Addr().String(), ln.Addr().String())
+	})
+
+	t.Run("when sockets are passed to another process", func(t *testing.T) {
+		s, err := newSockets(lg, func(key string) string {
+			return map[string]string{"LISTEN_PID": "1", "LISTEN_FDS": "1"}[key]
+		}, listenFdsStart)
+		require.NoError(t, err)
+
+		ln, err := s.Listen(context.Background(), "http", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer ln.Close()
+		assert.NotEmpty(t, ln.Addr().String())
+	})
+}
@@ -0,0 +1,84 @@
+package systemd
+
+import (
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// FakeNotifySocket receives notifications instead of systemd, NOTIFY_SOCKET of the test points to it
+type FakeNotifySocket struct {
+	t    testing.TB
+	conn *net.UnixConn
+}
+
+// NewFakeNotifySocket listens on a socket in the test temp dir and sets NOTIFY_SOCKET, the watchdog
+// is enabled when watchdog is positive
+func NewFakeNotifySocket(t testing.TB, watchdog time.Duration) *FakeNotifySocket {
+	t.Helper()
+
+	addr := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("listen notify socket error %s", err.Error())
+	}
+
+	t.Cleanup(func() {
+		if err := conn.Close(); err != nil {
+			t.Errorf("close notify socket error %s", err.Error())
+		}
+	})
+
+	t.Setenv("NOTIFY_SOCKET", addr)
+	t.Setenv("WATCHDOG_PID", "")
+	t.Setenv("WATCHDOG_USEC", "")
+	if watchdog > 0 {
+		t.Setenv("WATCHDOG_USEC", strconv.FormatInt(watchdog.Microseconds(), 10))
+	}
+
+	return &FakeNotifySocket{t: t, conn: conn}
+}
+
+// Next returns states of the next notification, false when nothing is received within timeout
+func (s *FakeNotifySocket) Next(timeout time.Duration) ([]string, bool) {
+	s.t.Helper()
+
+	if err := s.conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		s.t.Fatalf("set read deadline error %s", err.Error())
+	}
+
+	buf := make([]byte, 4096)
+	n, err := s.conn.Read(buf)
+	if err != nil {
+		return nil, false
+	}
+
+	return strings.Split(string(buf[:n]), "\n"), true
+}
+
+// WaitFor skips notifications until the one with the state, false when it is not received within timeout
+func (s *FakeNotifySocket) WaitFor(state string, timeout time.Duration) ([]string, bool) {
+	s.t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		left := time.Until(deadline)
+		if left <= 0 {
+			return nil, false
+		}
+
+		states, ok := s.Next(left)
+		if !ok {
+			return nil, false
+		}
+
+		for _, st := range states {
+			if st == state {
+				return states, true
+			}
+		}
+	}
+}
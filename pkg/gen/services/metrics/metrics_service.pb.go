@@ -1,6 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.36.5
+// 	protoc-gen-go v1.36.6
 // 	protoc        v5.29.3
 // source: pkg/proto/services/metrics/metrics_service.proto
 
@@ -23,85 +23,54 @@ const (
 
 var File_pkg_proto_services_metrics_metrics_service_proto protoreflect.FileDescriptor
 
-var file_pkg_proto_services_metrics_metrics_service_proto_rawDesc = string([]byte{
-	0x0a, 0x30, 0x70, 0x6b, 0x67, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x73, 0x65, 0x72, 0x76,
-	0x69, 0x63, 0x65, 0x73, 0x2f, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2f, 0x6d, 0x65, 0x74,
-	0x72, 0x69, 0x63, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x12, 0x10, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x73, 0x2e, 0x6d, 0x65, 0x74,
-	0x72, 0x69, 0x63, 0x73, 0x1a, 0x35, 0x70, 0x6b, 0x67, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f,
-	0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x73, 0x2f, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73,
-	0x2f, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x5f, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x5f, 0x70,
-	0x61, 0x72, 0x61, 0x6d, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x3c, 0x70, 0x6b, 0x67,
-	0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x73, 0x2f,
-	0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2f, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x5f, 0x6d,
-	0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x5f, 0x62, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x70, 0x61, 0x72,
-	0x61, 0x6d, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x33, 0x70, 0x6b, 0x67, 0x2f, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x73, 0x2f, 0x6d, 0x65,
-	0x74, 0x72, 0x69, 0x63, 0x73, 0x2f, 0x73, 0x68, 0x6f, 0x77, 0x5f, 0x6d, 0x65, 0x74, 0x72, 0x69,
-	0x63, 0x5f, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x35,
-	0x70, 0x6b, 0x67, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63,
-	0x65, 0x73, 0x2f, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2f, 0x73, 0x68, 0x6f, 0x77, 0x5f,
-	0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x5f, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x2f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x73, 0x2f, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63,
-	0x73, 0x2f, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x5f, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
-	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1b, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x65, 0x6d, 0x70, 0x74, 0x79, 0x2e, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x32, 0xf7, 0x02, 0x0a, 0x0e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x53,
-	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x46, 0x0a, 0x06, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65,
-	0x12, 0x24, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x73, 0x2e, 0x6d, 0x65, 0x74, 0x72,
-	0x69, 0x63, 0x73, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63,
-	0x50, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x51,
-	0x0a, 0x0b, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x42, 0x61, 0x74, 0x63, 0x68, 0x12, 0x2a, 0x2e,
-	0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x73, 0x2e, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73,
-	0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x42, 0x61,
-	0x74, 0x63, 0x68, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
-	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74,
-	0x79, 0x12, 0x50, 0x0a, 0x04, 0x53, 0x68, 0x6f, 0x77, 0x12, 0x22, 0x2e, 0x73, 0x65, 0x72, 0x76,
-	0x69, 0x63, 0x65, 0x73, 0x2e, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x53, 0x68, 0x6f,
-	0x77, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x1a, 0x24, 0x2e,
-	0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x73, 0x2e, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73,
-	0x2e, 0x53, 0x68, 0x6f, 0x77, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x52, 0x65, 0x73, 0x70, 0x6f,
-	0x6e, 0x73, 0x65, 0x12, 0x40, 0x0a, 0x05, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x16, 0x2e, 0x67,
-	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45,
-	0x6d, 0x70, 0x74, 0x79, 0x1a, 0x1f, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x73, 0x2e,
-	0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x52, 0x65, 0x73,
-	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x36, 0x0a, 0x04, 0x50, 0x69, 0x6e, 0x67, 0x12, 0x16, 0x2e,
-	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
-	0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x42, 0x47, 0x5a,
-	0x45, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x76, 0x79, 0x73, 0x6f,
-	0x67, 0x6f, 0x74, 0x61, 0x30, 0x33, 0x39, 0x39, 0x2f, 0x6d, 0x65, 0x6d, 0x5f, 0x73, 0x74, 0x61,
-	0x74, 0x73, 0x5f, 0x6d, 0x6f, 0x6e, 0x69, 0x74, 0x6f, 0x72, 0x69, 0x6e, 0x67, 0x2f, 0x70, 0x6b,
-	0x67, 0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x73, 0x2f, 0x6d,
-	0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
-})
+const file_pkg_proto_services_metrics_metrics_service_proto_rawDesc = "" +
+	"\n" +
+	"0pkg/proto/services/metrics/metrics_service.proto\x12\x10services.metrics\x1a5pkg/proto/services/metrics/update_metric_params.proto\x1a<pkg/proto/services/metrics/update_metrics_batch_params.proto\x1a3pkg/proto/services/metrics/show_metric_params.proto\x1a5pkg/proto/services/metrics/show_metric_response.proto\x1a/pkg/proto/services/metrics/index_response.proto\x1a4pkg/proto/services/metrics/agent_config_params.proto\x1a6pkg/proto/services/metrics/agent_config_response.proto\x1a6pkg/proto/services/metrics/capabilities_response.proto\x1a/pkg/proto/services/metrics/history_params.proto\x1a1pkg/proto/services/metrics/history_response.proto\x1a\x1bgoogle/protobuf/empty.proto2\xf1\x04\n" +
+	"\x0eMetricsService\x12F\n" +
+	"\x06Update\x12$.services.metrics.UpdateMetricParams\x1a\x16.google.protobuf.Empty\x12Q\n" +
+	"\vUpdateBatch\x12*.services.metrics.UpdateMetricsBatchParams\x1a\x16.google.protobuf.Empty\x12P\n" +
+	"\x04Show\x12\".services.metrics.ShowMetricParams\x1a$.services.metrics.ShowMetricResponse\x12@\n" +
+	"\x05Index\x12\x16.google.protobuf.Empty\x1a\x1f.services.metrics.IndexResponse\x126\n" +
+	"\x04Ping\x12\x16.google.protobuf.Empty\x1a\x16.google.protobuf.Empty\x12Y\n" +
+	"\vAgentConfig\x12#.services.metrics.AgentConfigParams\x1a%.services.metrics.AgentConfigResponse\x12N\n" +
+	"\fCapabilities\x12\x16.google.protobuf.Empty\x1a&.services.metrics.CapabilitiesResponse\x12M\n" +
+	"\aHistory\x12\x1f.services.metrics.HistoryParams\x1a!.services.metrics.HistoryResponseBGZEgithub.com/vysogota0399/mem_stats_monitoring/pkg/gen/services/metricsb\x06proto3"
 
 var file_pkg_proto_services_metrics_metrics_service_proto_goTypes = []any{
 	(*UpdateMetricParams)(nil),       // 0: services.metrics.UpdateMetricParams
 	(*UpdateMetricsBatchParams)(nil), // 1: services.metrics.UpdateMetricsBatchParams
 	(*ShowMetricParams)(nil),         // 2: services.metrics.ShowMetricParams
 	(*emptypb.Empty)(nil),            // 3: google.protobuf.Empty
-	(*ShowMetricResponse)(nil),       // 4: services.metrics.ShowMetricResponse
-	(*IndexResponse)(nil),            // 5: services.metrics.IndexResponse
+	(*AgentConfigParams)(nil),        // 4: services.metrics.AgentConfigParams
+	(*HistoryParams)(nil),            // 5: services.metrics.HistoryParams
+	(*ShowMetricResponse)(nil),       // 6: services.metrics.ShowMetricResponse
+	(*IndexResponse)(nil),            // 7: services.metrics.IndexResponse
+	(*AgentConfigResponse)(nil),      // 8: services.metrics.AgentConfigResponse
+	(*CapabilitiesResponse)(nil),     // 9: services.metrics.CapabilitiesResponse
+	(*HistoryResponse)(nil),          // 10: services.metrics.HistoryResponse
 }
 var file_pkg_proto_services_metrics_metrics_service_proto_depIdxs = []int32{
-	0, // 0: services.metrics.MetricsService.Update:input_type -> services.metrics.UpdateMetricParams
-	1, // 1: services.metrics.MetricsService.UpdateBatch:input_type -> services.metrics.UpdateMetricsBatchParams
-	2, // 2: services.metrics.MetricsService.Show:input_type -> services.metrics.ShowMetricParams
-	3, // 3: services.metrics.MetricsService.Index:input_type -> google.protobuf.Empty
-	3, // 4: services.metrics.MetricsService.Ping:input_type -> google.protobuf.Empty
-	3, // 5: services.metrics.MetricsService.Update:output_type -> google.protobuf.Empty
-	3, // 6: services.metrics.MetricsService.UpdateBatch:output_type -> google.protobuf.Empty
-	4, // 7: services.metrics.MetricsService.Show:output_type -> services.metrics.ShowMetricResponse
-	5, // 8: services.metrics.MetricsService.Index:output_type -> services.metrics.IndexResponse
-	3, // 9: services.metrics.MetricsService.Ping:output_type -> google.protobuf.Empty
-	5, // [5:10] is the sub-list for method output_type
-	0, // [0:5] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	0,  // 0: services.metrics.MetricsService.Update:input_type -> services.metrics.UpdateMetricParams
+	1,  // 1: services.metrics.MetricsService.UpdateBatch:input_type -> services.metrics.UpdateMetricsBatchParams
+	2,  // 2: services.metrics.MetricsService.Show:input_type -> services.metrics.ShowMetricParams
+	3,  // 3: services.metrics.MetricsService.Index:input_type -> google.protobuf.Empty
+	3,  // 4: services.metrics.MetricsService.Ping:input_type -> google.protobuf.Empty
+	4,  // 5: services.metrics.MetricsService.AgentConfig:input_type -> services.metrics.AgentConfigParams
+	3,  // 6: services.metrics.MetricsService.Capabilities:input_type -> google.protobuf.Empty
+	5,  // 7: services.metrics.MetricsService.History:input_type -> services.metrics.HistoryParams
+	3,  // 8: services.metrics.MetricsService.Update:output_type -> google.protobuf.Empty
+	3,  // 9: services.metrics.MetricsService.UpdateBatch:output_type -> google.protobuf.Empty
+	6,  // 10: services.metrics.MetricsService.Show:output_type -> services.metrics.ShowMetricResponse
+	7,  // 11: services.metrics.MetricsService.Index:output_type -> services.metrics.IndexResponse
+	3,  // 12: services.metrics.MetricsService.Ping:output_type -> google.protobuf.Empty
+	8,  // 13: services.metrics.MetricsService.AgentConfig:output_type -> services.metrics.AgentConfigResponse
+	9,  // 14: services.metrics.MetricsService.Capabilities:output_type -> services.metrics.CapabilitiesResponse
+	10, // 15: services.metrics.MetricsService.History:output_type -> services.metrics.HistoryResponse
+	8,  // [8:16] is the sub-list for method output_type
+	0,  // [0:8] is the sub-list for method input_type
+	0,  // [0:0] is the sub-list for extension type_name
+	0,  // [0:0] is the sub-list for extension extendee
+	0,  // [0:0] is the sub-list for field type_name
 }
 
 func init() { file_pkg_proto_services_metrics_metrics_service_proto_init() }
@@ -114,6 +83,11 @@ func file_pkg_proto_services_metrics_metrics_service_proto_init() {
 	file_pkg_proto_services_metrics_show_metric_params_proto_init()
 	file_pkg_proto_services_metrics_show_metric_response_proto_init()
 	file_pkg_proto_services_metrics_index_response_proto_init()
+	file_pkg_proto_services_metrics_agent_config_params_proto_init()
+	file_pkg_proto_services_metrics_agent_config_response_proto_init()
+	file_pkg_proto_services_metrics_capabilities_response_proto_init()
+	file_pkg_proto_services_metrics_history_params_proto_init()
+	file_pkg_proto_services_metrics_history_response_proto_init()
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
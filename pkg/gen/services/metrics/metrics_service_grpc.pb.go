@@ -20,11 +20,14 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	MetricsService_Update_FullMethodName      = "/services.metrics.MetricsService/Update"
-	MetricsService_UpdateBatch_FullMethodName = "/services.metrics.MetricsService/UpdateBatch"
-	MetricsService_Show_FullMethodName        = "/services.metrics.MetricsService/Show"
-	MetricsService_Index_FullMethodName       = "/services.metrics.MetricsService/Index"
-	MetricsService_Ping_FullMethodName        = "/services.metrics.MetricsService/Ping"
+	MetricsService_Update_FullMethodName       = "/services.metrics.MetricsService/Update"
+	MetricsService_UpdateBatch_FullMethodName  = "/services.metrics.MetricsService/UpdateBatch"
+	MetricsService_Show_FullMethodName         = "/services.metrics.MetricsService/Show"
+	MetricsService_Index_FullMethodName        = "/services.metrics.MetricsService/Index"
+	MetricsService_Ping_FullMethodName         = "/services.metrics.MetricsService/Ping"
+	MetricsService_AgentConfig_FullMethodName  = "/services.metrics.MetricsService/AgentConfig"
+	MetricsService_Capabilities_FullMethodName = "/services.metrics.MetricsService/Capabilities"
+	MetricsService_History_FullMethodName      = "/services.metrics.MetricsService/History"
 )
 
 // MetricsServiceClient is the client API for MetricsService service.
@@ -36,6 +39,9 @@ type MetricsServiceClient interface {
 	Show(ctx context.Context, in *ShowMetricParams, opts ...grpc.CallOption) (*ShowMetricResponse, error)
 	Index(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*IndexResponse, error)
 	Ping(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	AgentConfig(ctx context.Context, in *AgentConfigParams, opts ...grpc.CallOption) (*AgentConfigResponse, error)
+	Capabilities(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*CapabilitiesResponse, error)
+	History(ctx context.Context, in *HistoryParams, opts ...grpc.CallOption) (*HistoryResponse, error)
 }
 
 type metricsServiceClient struct {
@@ -96,6 +102,36 @@ func (c *metricsServiceClient) Ping(ctx context.Context, in *emptypb.Empty, opts
 	return out, nil
 }
 
+func (c *metricsServiceClient) AgentConfig(ctx context.Context, in *AgentConfigParams, opts ...grpc.CallOption) (*AgentConfigResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AgentConfigResponse)
+	err := c.cc.Invoke(ctx, MetricsService_AgentConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricsServiceClient) Capabilities(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*CapabilitiesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CapabilitiesResponse)
+	err := c.cc.Invoke(ctx, MetricsService_Capabilities_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricsServiceClient) History(ctx context.Context, in *HistoryParams, opts ...grpc.CallOption) (*HistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HistoryResponse)
+	err := c.cc.Invoke(ctx, MetricsService_History_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // MetricsServiceServer is the server API for MetricsService service.
 // All implementations must embed UnimplementedMetricsServiceServer
 // for forward compatibility.
@@ -105,6 +141,9 @@ type MetricsServiceServer interface {
 	Show(context.Context, *ShowMetricParams) (*ShowMetricResponse, error)
 	Index(context.Context, *emptypb.Empty) (*IndexResponse, error)
 	Ping(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
+	AgentConfig(context.Context, *AgentConfigParams) (*AgentConfigResponse, error)
+	Capabilities(context.Context, *emptypb.Empty) (*CapabilitiesResponse, error)
+	History(context.Context, *HistoryParams) (*HistoryResponse, error)
 	mustEmbedUnimplementedMetricsServiceServer()
 }
 
@@ -130,6 +169,15 @@ func (UnimplementedMetricsServiceServer) Index(context.Context, *emptypb.Empty)
 func (UnimplementedMetricsServiceServer) Ping(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
 }
+func (UnimplementedMetricsServiceServer) AgentConfig(context.Context, *AgentConfigParams) (*AgentConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AgentConfig not implemented")
+}
+func (UnimplementedMetricsServiceServer) Capabilities(context.Context, *emptypb.Empty) (*CapabilitiesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Capabilities not implemented")
+}
+func (UnimplementedMetricsServiceServer) History(context.Context, *HistoryParams) (*HistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method History not implemented")
+}
 func (UnimplementedMetricsServiceServer) mustEmbedUnimplementedMetricsServiceServer() {}
 func (UnimplementedMetricsServiceServer) testEmbeddedByValue()                        {}
 
@@ -241,6 +289,60 @@ func _MetricsService_Ping_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MetricsService_AgentConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AgentConfigParams)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServiceServer).AgentConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetricsService_AgentConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServiceServer).AgentConfig(ctx, req.(*AgentConfigParams))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetricsService_Capabilities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServiceServer).Capabilities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetricsService_Capabilities_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServiceServer).Capabilities(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetricsService_History_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HistoryParams)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServiceServer).History(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetricsService_History_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServiceServer).History(ctx, req.(*HistoryParams))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // MetricsService_ServiceDesc is the grpc.ServiceDesc for MetricsService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -268,6 +370,18 @@ var MetricsService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Ping",
 			Handler:    _MetricsService_Ping_Handler,
 		},
+		{
+			MethodName: "AgentConfig",
+			Handler:    _MetricsService_AgentConfig_Handler,
+		},
+		{
+			MethodName: "Capabilities",
+			Handler:    _MetricsService_Capabilities_Handler,
+		},
+		{
+			MethodName: "History",
+			Handler:    _MetricsService_History_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "pkg/proto/services/metrics/metrics_service.proto",
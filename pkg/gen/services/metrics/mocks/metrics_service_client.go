@@ -37,6 +37,66 @@ func (m *MockMetricsServiceClient) EXPECT() *MockMetricsServiceClientMockRecorde
 	return m.recorder
 }
 
+// AgentConfig mocks base method.
+func (m *MockMetricsServiceClient) AgentConfig(arg0 context.Context, arg1 *metrics.AgentConfigParams, arg2 ...grpc.CallOption) (*metrics.AgentConfigResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "AgentConfig", varargs...)
+	ret0, _ := ret[0].(*metrics.AgentConfigResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AgentConfig indicates an expected call of AgentConfig.
+func (mr *MockMetricsServiceClientMockRecorder) AgentConfig(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AgentConfig", reflect.TypeOf((*MockMetricsServiceClient)(nil).AgentConfig), varargs...)
+}
+
+// Capabilities mocks base method.
+func (m *MockMetricsServiceClient) Capabilities(arg0 context.Context, arg1 *emptypb.Empty, arg2 ...grpc.CallOption) (*metrics.CapabilitiesResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Capabilities", varargs...)
+	ret0, _ := ret[0].(*metrics.CapabilitiesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Capabilities indicates an expected call of Capabilities.
+func (mr *MockMetricsServiceClientMockRecorder) Capabilities(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Capabilities", reflect.TypeOf((*MockMetricsServiceClient)(nil).Capabilities), varargs...)
+}
+
+// History mocks base method.
+func (m *MockMetricsServiceClient) History(arg0 context.Context, arg1 *metrics.HistoryParams, arg2 ...grpc.CallOption) (*metrics.HistoryResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "History", varargs...)
+	ret0, _ := ret[0].(*metrics.HistoryResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// History indicates an expected call of History.
+func (mr *MockMetricsServiceClientMockRecorder) History(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "History", reflect.TypeOf((*MockMetricsServiceClient)(nil).History), varargs...)
+}
+
 // Index mocks base method.
 func (m *MockMetricsServiceClient) Index(arg0 context.Context, arg1 *emptypb.Empty, arg2 ...grpc.CallOption) (*metrics.IndexResponse, error) {
 	m.ctrl.T.Helper()